@@ -0,0 +1,234 @@
+// Package cache stores the results of tasks, keyed by a hash of their inputs.
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joerdav/xc/glob"
+	"github.com/joerdav/xc/i18n"
+)
+
+const (
+	entryFile = "entry.json"
+	// filesDir is the directory of an entry that holds its cached files.
+	filesDir = "files"
+)
+
+// Entry is the freshness metadata of a cached task result.
+type Entry struct {
+	// Key is the hash of the inputs of the task.
+	Key  string `json:"key"`
+	Task string `json:"task"`
+	// Created is when the result was stored.
+	Created time.Time `json:"created"`
+	// Used is when the result was last used to skip the task.
+	Used time.Time `json:"used,omitempty"`
+	// Files are the cached files, as slash separated paths relative to the directory of the task.
+	Files []string `json:"files,omitempty"`
+	// Size is the size in bytes of the entry on disk, it is calculated when entries are listed.
+	Size int64 `json:"-"`
+}
+
+// Store is a cache of task results in a directory, each entry is stored in a directory named after its key.
+type Store struct {
+	dir string
+}
+
+// New returns a Store in dir, the directory is created when the first entry is stored.
+func New(dir string) Store {
+	return Store{dir: dir}
+}
+
+// Dir returns the directory of the Store.
+func (s Store) Dir() string {
+	return s.dir
+}
+
+// Path returns the directory of the entry with key, which can hold cached files alongside the metadata.
+func (s Store) Path(key string) string {
+	return filepath.Join(s.dir, key)
+}
+
+// Put stores the metadata of an entry, replacing any entry with the same key.
+func (s Store) Put(e Entry) error {
+	if e.Key == "" {
+		return errors.New(i18n.T("cache entry has no key"))
+	}
+	if err := os.MkdirAll(s.Path(e.Key), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.Path(e.Key), entryFile), b, 0o644)
+}
+
+// Get returns the entry with key, ok is false if there is no such entry.
+func (s Store) Get(key string) (e Entry, ok bool, err error) {
+	b, err := os.ReadFile(filepath.Join(s.Path(key), entryFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return e, false, nil
+	}
+	if err != nil {
+		return e, false, err
+	}
+	if err = json.Unmarshal(b, &e); err != nil {
+		return e, false, err
+	}
+	return e, true, nil
+}
+
+// Save copies the Files of the entry from dir into the store, then stores its metadata.
+// Every file must be below dir and match the outputs patterns of the task, see CheckFiles.
+func (s Store) Save(e Entry, dir string, outputs []string) error {
+	if e.Key == "" {
+		return errors.New(i18n.T("cache entry has no key"))
+	}
+	if err := e.CheckFiles(outputs); err != nil {
+		return err
+	}
+	files := filepath.Join(s.Path(e.Key), filesDir)
+	if err := os.RemoveAll(files); err != nil {
+		return err
+	}
+	for _, f := range e.Files {
+		if err := copyFile(filepath.Join(dir, filepath.FromSlash(f)), filepath.Join(files, filepath.FromSlash(f))); err != nil {
+			return err
+		}
+	}
+	return s.Put(e)
+}
+
+// Restore copies the Files of the entry from the store into dir, replacing the files that are there.
+// The entry may have been downloaded from a remote, so it is rejected unless every file is below dir
+// and matches the outputs patterns of the task, see CheckFiles.
+func (s Store) Restore(e Entry, dir string, outputs []string) error {
+	if err := e.CheckFiles(outputs); err != nil {
+		return err
+	}
+	files := filepath.Join(s.Path(e.Key), filesDir)
+	for _, f := range e.Files {
+		if err := copyFile(filepath.Join(files, filepath.FromSlash(f)), filepath.Join(dir, filepath.FromSlash(f))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckFiles returns an error if any of the Files of the entry is not a local path,
+// see filepath.IsLocal, or doesn't match the outputs patterns of the task.
+func (e Entry) CheckFiles(outputs []string) error {
+	m := glob.NewMatcher(outputs)
+	for _, f := range e.Files {
+		if !filepath.IsLocal(filepath.FromSlash(f)) || !m.Match(f) {
+			return i18n.Errorf("cache entry %s has the file %q, which is not an output of task %s", e.Key, f, e.Task)
+		}
+	}
+	return nil
+}
+
+// Touch records that the entry with key was used at t.
+func (s Store) Touch(key string, t time.Time) error {
+	e, ok, err := s.Get(key)
+	if err != nil || !ok {
+		return err
+	}
+	e.Used = t
+	return s.Put(e)
+}
+
+// copyFile copies the file at src to dst, keeping its permissions, and creates the directory of dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	// The file is removed first, so that a read only file can be replaced.
+	if err := os.Remove(dst); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return writeFile(dst, in, info.Mode().Perm())
+}
+
+// Entries returns all entries in the store, oldest first.
+// Directories without valid metadata, and hidden directories that hold entries being unpacked, are ignored.
+func (s Store) Entries() ([]Entry, error) {
+	des, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, de := range des {
+		if !de.IsDir() || strings.HasPrefix(de.Name(), ".") {
+			continue
+		}
+		e, ok, err := s.Get(de.Name())
+		if err != nil || !ok {
+			continue
+		}
+		if e.Size, err = dirSize(s.Path(de.Name())); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Created.Before(entries[j].Created) })
+	return entries, nil
+}
+
+// Prune removes the entries that were created before t, and returns them.
+func (s Store) Prune(t time.Time) ([]Entry, error) {
+	entries, err := s.Entries()
+	if err != nil {
+		return nil, err
+	}
+	var removed []Entry
+	for _, e := range entries {
+		if !e.Created.Before(t) {
+			continue
+		}
+		if err := os.RemoveAll(s.Path(e.Key)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, e)
+	}
+	return removed, nil
+}
+
+// Clear removes every entry in the store.
+func (s Store) Clear() error {
+	return os.RemoveAll(s.dir)
+}
+
+func dirSize(dir string) (size int64, err error) {
+	err = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return
+}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	t.Run("given no directory, should have no entries", func(t *testing.T) {
+		s := New(filepath.Join(t.TempDir(), "missing"))
+		entries, err := s.Entries()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Fatalf("expected no entries got %v", entries)
+		}
+	})
+	t.Run("given stored entries, should list oldest first with sizes", func(t *testing.T) {
+		s := New(t.TempDir())
+		if err := s.Put(Entry{Key: "b", Task: "test", Created: now}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Put(Entry{Key: "a", Task: "build", Created: now.Add(-time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(s.Path("a"), "output"), make([]byte, 100), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		entries, err := s.Entries()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 2 || entries[0].Key != "a" || entries[1].Key != "b" {
+			t.Fatalf("want entries a, b got %v", entries)
+		}
+		if entries[0].Size <= 100 {
+			t.Fatalf("size want>100 got=%d", entries[0].Size)
+		}
+	})
+	t.Run("given an entry, should get it by key", func(t *testing.T) {
+		s := New(t.TempDir())
+		if err := s.Put(Entry{Key: "a", Task: "build", Created: now}); err != nil {
+			t.Fatal(err)
+		}
+		e, ok, err := s.Get("a")
+		if err != nil || !ok {
+			t.Fatalf("expected entry got ok=%v err=%v", ok, err)
+		}
+		if e.Task != "build" || !e.Created.Equal(now) {
+			t.Fatalf("unexpected entry %+v", e)
+		}
+		if _, ok, _ := s.Get("missing"); ok {
+			t.Fatal("expected no entry for missing key")
+		}
+	})
+	t.Run("given old entries, prune should remove only those", func(t *testing.T) {
+		s := New(t.TempDir())
+		_ = s.Put(Entry{Key: "old", Created: now.Add(-48 * time.Hour)})
+		_ = s.Put(Entry{Key: "new", Created: now})
+		removed, err := s.Prune(now.Add(-24 * time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(removed) != 1 || removed[0].Key != "old" {
+			t.Fatalf("want old removed got %v", removed)
+		}
+		entries, _ := s.Entries()
+		if len(entries) != 1 || entries[0].Key != "new" {
+			t.Fatalf("want new kept got %v", entries)
+		}
+	})
+	t.Run("given entries, clear should remove all", func(t *testing.T) {
+		s := New(t.TempDir())
+		_ = s.Put(Entry{Key: "a", Created: now})
+		if err := s.Clear(); err != nil {
+			t.Fatal(err)
+		}
+		entries, _ := s.Entries()
+		if len(entries) != 0 {
+			t.Fatalf("expected no entries got %v", entries)
+		}
+	})
+	t.Run("given an entry with files, should restore them", func(t *testing.T) {
+		s, dir := New(t.TempDir()), t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, "bin"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "bin", "app"), []byte("app"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		e := Entry{Key: "a", Task: "build", Created: now, Files: []string{"bin/app"}}
+		if err := s.Save(e, dir, []string{"bin/*"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.RemoveAll(filepath.Join(dir, "bin")); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Restore(e, dir, []string{"bin/*"}); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, "bin", "app"))
+		if err != nil || string(b) != "app" {
+			t.Fatalf("want restored file got %q err=%v", b, err)
+		}
+	})
+	t.Run("given an entry with files that aren't outputs, should reject it", func(t *testing.T) {
+		tests := []struct {
+			name string
+			file string
+		}{
+			{name: "parent directory", file: "../app"},
+			{name: "nested parent directory", file: "bin/../../app"},
+			{name: "absolute path", file: "/etc/passwd"},
+			{name: "not matching outputs", file: "src/main.go"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				s, dir := New(t.TempDir()), t.TempDir()
+				e := Entry{Key: "a", Task: "build", Created: now, Files: []string{tt.file}}
+				if err := s.Save(e, dir, []string{"bin/*"}); err == nil {
+					t.Fatal("expected save error got nil")
+				}
+				if err := s.Put(e); err != nil {
+					t.Fatal(err)
+				}
+				if err := s.Restore(e, filepath.Join(dir, "task"), []string{"bin/*"}); err == nil {
+					t.Fatal("expected restore error got nil")
+				}
+				if _, err := os.Stat(filepath.Join(dir, "app")); err == nil {
+					t.Fatal("expected no file outside of the task directory")
+				}
+			})
+		}
+	})
+	t.Run("given an entry without key, put should error", func(t *testing.T) {
+		if err := New(t.TempDir()).Put(Entry{}); err == nil {
+			t.Fatal("expected error got nil")
+		}
+	})
+}
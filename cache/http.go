@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/joerdav/xc/i18n"
+)
+
+// httpRemote stores objects with GET and PUT requests to base/name.
+type httpRemote struct {
+	base   string
+	client *http.Client
+	// authorization adds credentials to a request with the given body, it is nil if none are needed.
+	authorization func(req *http.Request, body []byte) error
+}
+
+func (r *httpRemote) do(ctx context.Context, method, name string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, r.base+"/"+name, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if r.authorization != nil {
+		if err := r.authorization(req, body); err != nil {
+			return nil, err
+		}
+	}
+	client := r.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+func (r *httpRemote) Get(ctx context.Context, name string) ([]byte, bool, error) {
+	resp, err := r.do(ctx, http.MethodGet, name, nil)
+	if err != nil {
+		return nil, false, i18n.Errorf("failed to download %s from the cache remote: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, i18n.Errorf("failed to download %s from the cache remote: %s", name, resp.Status)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, i18n.Errorf("failed to download %s from the cache remote: %w", name, err)
+	}
+	return b, true, nil
+}
+
+func (r *httpRemote) Put(ctx context.Context, name string, b []byte) error {
+	resp, err := r.do(ctx, http.MethodPut, name, b)
+	if err != nil {
+		return i18n.Errorf("failed to upload %s to the cache remote: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return i18n.Errorf("failed to upload %s to the cache remote: %s", name, resp.Status)
+	}
+	return nil
+}
+
+// gcsAuthorization returns a func that adds an OAuth access token to requests to Google Cloud Storage,
+// from GOOGLE_OAUTH_ACCESS_TOKEN or else gcloud, which is only asked once.
+func gcsAuthorization() func(req *http.Request, body []byte) error {
+	var (
+		mu    sync.Mutex
+		token = os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	)
+	return func(req *http.Request, _ []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if token == "" {
+			out, err := exec.CommandContext(req.Context(), "gcloud", "auth", "print-access-token").Output()
+			if err != nil {
+				return i18n.Errorf("no credentials for the gs cache remote, set GOOGLE_OAUTH_ACCESS_TOKEN or log in with gcloud: %w", err)
+			}
+			token = strings.TrimSpace(string(out))
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+// joinPath joins the non-empty parts of an object path with slashes.
+func joinPath(parts ...string) string {
+	var result []string
+	for _, p := range parts {
+		if p != "" {
+			result = append(result, p)
+		}
+	}
+	return strings.Join(result, "/")
+}
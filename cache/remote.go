@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/joerdav/xc/i18n"
+)
+
+// Remote stores objects on a server, so that cache entries can be shared between machines.
+type Remote interface {
+	// Get returns the object with name, ok is false if there is no such object.
+	Get(ctx context.Context, name string) (b []byte, ok bool, err error)
+	// Put stores an object, replacing any object with the same name.
+	Put(ctx context.Context, name string, b []byte) error
+}
+
+const (
+	// ModeReadOnly downloads entries from the remote, but never uploads them.
+	ModeReadOnly = "read-only"
+	// ModeReadWrite downloads entries from the remote, and uploads new entries to it.
+	ModeReadWrite = "read-write"
+)
+
+// RemoteConfig configures the remote that a cache is shared through.
+type RemoteConfig struct {
+	// URL is where entries are stored, it is one of:
+	// s3://bucket/prefix, gs://bucket/prefix or an http(s) URL that accepts GET and PUT requests.
+	URL string `yaml:"url"`
+	// Mode is ModeReadOnly or ModeReadWrite, ModeReadOnly if it is not set.
+	Mode string `yaml:"mode"`
+	// Region is the region of an S3 bucket, AWS_REGION is used if it is not set.
+	Region string `yaml:"region"`
+	// Endpoint replaces the AWS endpoint of an S3 bucket, for S3 compatible servers such as MinIO.
+	Endpoint string `yaml:"endpoint"`
+}
+
+// NewRemote returns the Remote for the config.
+// Credentials are read from the environment: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN for S3,
+// GOOGLE_OAUTH_ACCESS_TOKEN for GCS, falling back to gcloud, and XC_CACHE_TOKEN as a bearer token for HTTP.
+func NewRemote(c RemoteConfig) (Remote, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, i18n.Errorf("invalid cache remote %q: %w", c.URL, err)
+	}
+	prefix := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case "s3":
+		return newS3Remote(u.Host, prefix, c.Region, c.Endpoint), nil
+	case "gs":
+		return &httpRemote{
+			base:          "https://storage.googleapis.com/" + joinPath(u.Host, prefix),
+			authorization: gcsAuthorization(),
+		}, nil
+	case "http", "https":
+		r := &httpRemote{base: strings.TrimSuffix(c.URL, "/")}
+		if token := os.Getenv("XC_CACHE_TOKEN"); token != "" {
+			r.authorization = func(req *http.Request, _ []byte) error {
+				req.Header.Set("Authorization", "Bearer "+token)
+				return nil
+			}
+		}
+		return r, nil
+	}
+	return nil, i18n.Errorf("invalid cache remote %q, should start with s3://, gs://, http:// or https://", c.URL)
+}
+
+// Shared is a cache that downloads entries that are missing locally from a Remote,
+// and uploads the entries it stores unless it is read only.
+type Shared struct {
+	Local Store
+	// Remote is nil if the cache isn't shared.
+	Remote Remote
+	// ReadOnly stops entries from being uploaded.
+	ReadOnly bool
+}
+
+// archiveName returns the name of the object that holds the archive of the entry with key.
+func archiveName(key string) string {
+	return key + ".tar.gz"
+}
+
+// digestName returns the name of the object that holds the SHA-256 of the archive of the entry with key.
+// It is uploaded after the archive, so an archive without a digest is incomplete.
+func digestName(key string) string {
+	return archiveName(key) + ".sha256"
+}
+
+// Get returns the entry with key from the local store, or downloads it from the remote if it is missing.
+// A downloaded archive that doesn't match its digest is an error, and isn't stored.
+func (s Shared) Get(ctx context.Context, key string) (e Entry, ok bool, err error) {
+	if e, ok, err = s.Local.Get(key); ok || err != nil || s.Remote == nil {
+		return e, ok, err
+	}
+	digest, ok, err := s.Remote.Get(ctx, digestName(key))
+	if err != nil || !ok {
+		return e, false, err
+	}
+	archive, ok, err := s.Remote.Get(ctx, archiveName(key))
+	if err != nil || !ok {
+		return e, false, err
+	}
+	if sum := sha256.Sum256(archive); hex.EncodeToString(sum[:]) != strings.TrimSpace(string(digest)) {
+		return e, false, i18n.Errorf("cache entry %s failed verification, its digest doesn't match", key)
+	}
+	if err := s.Local.Unpack(key, bytes.NewReader(archive)); err != nil {
+		return e, false, err
+	}
+	return s.Local.Get(key)
+}
+
+// Put stores the metadata of an entry locally, then uploads the entry and its cached files unless the cache is read only.
+func (s Shared) Put(ctx context.Context, e Entry) error {
+	if err := s.Local.Put(e); err != nil {
+		return err
+	}
+	if s.ReadOnly || s.Remote == nil {
+		return nil
+	}
+	return s.Upload(ctx, e.Key)
+}
+
+// Save stores an entry and copies its Files from dir locally, see Store.Save, then uploads it unless the cache is read only.
+func (s Shared) Save(ctx context.Context, e Entry, dir string, outputs []string) error {
+	if err := s.Local.Save(e, dir, outputs); err != nil {
+		return err
+	}
+	if s.ReadOnly || s.Remote == nil {
+		return nil
+	}
+	return s.Upload(ctx, e.Key)
+}
+
+// Upload uploads the local entry with key to the remote.
+func (s Shared) Upload(ctx context.Context, key string) error {
+	if s.ReadOnly {
+		return errors.New(i18n.T("cache remote is read only"))
+	}
+	var archive bytes.Buffer
+	if err := s.Local.Pack(key, &archive); err != nil {
+		return err
+	}
+	if err := s.Remote.Put(ctx, archiveName(key), archive.Bytes()); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(archive.Bytes())
+	return s.Remote.Put(ctx, digestName(key), []byte(hex.EncodeToString(sum[:])))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/run"
+)
+
+func bundleCommand(_ context.Context, p project, args []string) error {
+	fs := flag.NewFlagSet("bundle", flag.ContinueOnError)
+	output := fs.String("o", "", "the file to write the script to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	// Allow the flags to follow the task, as in `xc bundle deploy -o deploy.sh`.
+	if fs.NArg() == 0 {
+		return errors.New(i18n.T("usage: xc bundle <task> [-o <file>]"))
+	}
+	task := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New(i18n.T("usage: xc bundle <task> [-o <file>]"))
+	}
+	runner, err := run.NewRunner(p.tasks, p.dir)
+	if err != nil {
+		return parseError{i18n.Errorf("xc parse error: %w", err)}
+	}
+	if *output == "" {
+		if err := runner.Bundle(os.Stdout, task, ""); err != nil {
+			return i18n.Errorf("xc bundle: %w", err)
+		}
+		return nil
+	}
+	out, err := filepath.Abs(*output)
+	if err != nil {
+		return i18n.Errorf("xc bundle: %w", err)
+	}
+	// The script changes to the project directory relative to its own location, so it can be run from anywhere.
+	root, err := filepath.Rel(filepath.Dir(out), p.dir)
+	if err != nil {
+		return i18n.Errorf("xc bundle: %w", err)
+	}
+	var script bytes.Buffer
+	if err := runner.Bundle(&script, task, root); err != nil {
+		return i18n.Errorf("xc bundle: %w", err)
+	}
+	//nolint:gosec // the script is meant to be executable
+	if err := os.WriteFile(out, script.Bytes(), 0o755); err != nil {
+		return i18n.Errorf("xc bundle: %w", err)
+	}
+	i18n.Printf("xc: bundled %s to %s\n", task, *output)
+	return nil
+}
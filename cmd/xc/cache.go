@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joerdav/xc/cache"
+	"github.com/joerdav/xc/config"
+	"github.com/joerdav/xc/dirs"
+	"github.com/joerdav/xc/i18n"
+)
+
+func resultCache(d dirs.Dirs) cache.Store {
+	return cache.New(filepath.Join(d.Cache, "results"))
+}
+
+// cacheRemote returns the remote of the result cache from the config,
+// XC_CACHE_MODE takes precedence over the configured mode, so that CI can upload entries that everyone else only downloads.
+func cacheRemote(c config.Cache) (cache.RemoteConfig, error) {
+	r := c.Remote
+	if m := os.Getenv("XC_CACHE_MODE"); m != "" {
+		r.Mode = m
+	}
+	if r.Mode == "" {
+		r.Mode = cache.ModeReadOnly
+	}
+	if r.Mode != cache.ModeReadOnly && r.Mode != cache.ModeReadWrite {
+		return r, parseError{i18n.Errorf("xc config error: cache remote mode %q should be (read-only, read-write)", r.Mode)}
+	}
+	if r.URL == "" {
+		return r, nil
+	}
+	if _, err := cache.NewRemote(r); err != nil {
+		return r, parseError{i18n.Errorf("xc config error: %w", err)}
+	}
+	return r, nil
+}
+
+// sharedCache returns the result cache shared through the configured remote, ok is false if there is no remote.
+func sharedCache(p project) (s cache.Shared, ok bool, err error) {
+	if p.cacheRemote.URL == "" {
+		return s, false, nil
+	}
+	remote, err := cache.NewRemote(p.cacheRemote)
+	if err != nil {
+		return s, false, err
+	}
+	return cache.Shared{Local: resultCache(p.paths), Remote: remote, ReadOnly: p.cacheRemote.Mode != cache.ModeReadWrite}, true, nil
+}
+
+// taskCache returns the cache that the results of tasks are stored in, which is shared if a remote is configured.
+func taskCache(p project) (cache.Shared, error) {
+	shared, ok, err := sharedCache(p)
+	if err != nil || ok {
+		return shared, err
+	}
+	return cache.Shared{Local: resultCache(p.paths)}, nil
+}
+
+const cacheUsage = "usage: xc cache status|prune <age>|clear|push"
+
+func cacheCommand(ctx context.Context, p project, args []string) error {
+	if len(args) == 0 {
+		return errors.New(i18n.T(cacheUsage))
+	}
+	store := resultCache(p.paths)
+	switch args[0] {
+	case "status":
+		if err := cacheStatus(store); err != nil {
+			return err
+		}
+		if p.cacheRemote.URL != "" {
+			i18n.Printf("remote: %s (%s)\n", p.cacheRemote.URL, p.cacheRemote.Mode)
+		}
+		return nil
+	case "push":
+		return cachePush(ctx, p)
+	case "prune":
+		if len(args) != 2 {
+			return errors.New(i18n.T(cacheUsage))
+		}
+		age, err := parseAge(args[1])
+		if err != nil {
+			return i18n.Errorf("xc cache: %w", err)
+		}
+		removed, err := store.Prune(time.Now().Add(-age))
+		if err != nil {
+			return i18n.Errorf("xc cache: %w", err)
+		}
+		i18n.Printf("removed %d cache entries\n", len(removed))
+		return nil
+	case "clear":
+		if err := store.Clear(); err != nil {
+			return i18n.Errorf("xc cache: %w", err)
+		}
+		fmt.Println(i18n.T("cache cleared"))
+		return nil
+	}
+	return errors.New(i18n.T(cacheUsage))
+}
+
+// cachePush uploads every local entry of the result cache to the remote.
+func cachePush(ctx context.Context, p project) error {
+	shared, ok, err := sharedCache(p)
+	if err != nil {
+		return i18n.Errorf("xc cache: %w", err)
+	}
+	if !ok {
+		return errors.New(i18n.T("xc cache: no cache remote configured"))
+	}
+	entries, err := shared.Local.Entries()
+	if err != nil {
+		return i18n.Errorf("xc cache: %w", err)
+	}
+	for _, e := range entries {
+		if err := shared.Upload(ctx, e.Key); err != nil {
+			return i18n.Errorf("xc cache: %w", err)
+		}
+	}
+	i18n.Printf("uploaded %d cache entries\n", len(entries))
+	return nil
+}
+
+func cacheStatus(store cache.Store) error {
+	entries, err := store.Entries()
+	if err != nil {
+		return i18n.Errorf("xc cache: %w", err)
+	}
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+	fmt.Println(i18n.T("cache:"), store.Dir())
+	i18n.Printf("%d entries, %s\n", len(entries), formatSize(total))
+	maxLen := 0
+	for _, e := range entries {
+		if len(e.Task) > maxLen {
+			maxLen = len(e.Task)
+		}
+	}
+	now := time.Now()
+	for _, e := range entries {
+		key := e.Key
+		if len(key) > 12 {
+			key = key[:12]
+		}
+		used := "-"
+		if !e.Used.IsZero() {
+			used = formatAge(now.Sub(e.Used))
+		}
+		fmt.Printf("    %-*s  %s  %s  %s  %s\n", maxLen, e.Task, key,
+			i18n.Sprintf("created %s ago", formatAge(now.Sub(e.Created))),
+			i18n.Sprintf("used %s", used),
+			formatSize(e.Size))
+	}
+	return nil
+}
+
+// parseAge parses a duration as accepted by time.ParseDuration, with an additional "d" unit for days.
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, i18n.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, i18n.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
+
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return d.Round(time.Second).String()
+	case d < 24*time.Hour:
+		return d.Round(time.Minute).String()
+	default:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+}
+
+// parseSize parses a size in bytes, with an optional B, KB, MB or GB unit, e.g. 500MB.
+// Units are powers of 1024, matching formatSize.
+func parseSize(s string) (int64, error) {
+	n := strings.ToUpper(strings.TrimSpace(s))
+	mult := int64(1)
+	for i, unit := range []string{"KB", "MB", "GB"} {
+		if v, ok := strings.CutSuffix(n, unit); ok {
+			n, mult = v, int64(1)<<(10*(i+1))
+			break
+		}
+	}
+	n = strings.TrimSpace(strings.TrimSuffix(n, "B"))
+	v, err := strconv.ParseInt(n, 10, 64)
+	if err != nil || v < 0 {
+		return 0, i18n.Errorf("invalid size %q", s)
+	}
+	return v * mult, nil
+}
+
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/shlex"
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/run"
+)
+
+// chain holds the tasks to run after the invoked task, from the --then and --on-failure flags.
+type chain struct {
+	then, onFailure []string
+}
+
+// splitChain removes the --then and --on-failure flags from the arguments of a task.
+// Arguments after "--" are left untouched, so they can still be passed to the task.
+func splitChain(args []string) (rest []string, c chain, err error) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(a, "-"), "=")
+		var dst *[]string
+		switch name {
+		case "-then":
+			dst = &c.then
+		case "-on-failure":
+			dst = &c.onFailure
+		default:
+			rest = append(rest, a)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, c, i18n.Errorf("%s requires a task", a)
+			}
+			i++
+			value = args[i]
+		}
+		*dst = append(*dst, value)
+	}
+	return rest, c, nil
+}
+
+// validate checks that every task in the chain exists, so that a typo is reported before anything runs.
+func (c chain) validate(tasks models.Tasks) error {
+	for _, t := range append(append([]string{}, c.then...), c.onFailure...) {
+		ta, err := shlex.Split(t)
+		if err != nil {
+			return err
+		}
+		if len(ta) == 0 {
+			return run.TaskNotFoundError{Name: t}
+		}
+		if _, ok := tasks.Get(ta[0]); !ok {
+			return run.TaskNotFoundError{Name: ta[0]}
+		}
+	}
+	return nil
+}
+
+// runChain runs the first task, followed by each --then task if it succeeded.
+// If any of them fail the --on-failure tasks are run, and the original error is returned.
+func runChain(ctx context.Context, runner *run.Runner, first func(context.Context) error, c chain) error {
+	err := first(ctx)
+	for _, t := range c.then {
+		if err != nil {
+			break
+		}
+		err = runTaskArgs(ctx, runner, t)
+	}
+	if err == nil || len(c.onFailure) == 0 {
+		return err
+	}
+	// The --on-failure tasks must run even if the failure was caused by cancellation.
+	ctx = context.Background()
+	errs := []error{err}
+	for _, t := range c.onFailure {
+		errs = append(errs, runTaskArgs(ctx, runner, t))
+	}
+	return errors.Join(errs...)
+}
+
+// runTaskArgs runs a task given as a name followed by its inputs, e.g. "deploy production".
+func runTaskArgs(ctx context.Context, runner *run.Runner, s string) error {
+	ta, err := shlex.Split(s)
+	if err != nil {
+		return err
+	}
+	if len(ta) == 0 {
+		return run.TaskNotFoundError{Name: s}
+	}
+	return runner.Run(ctx, ta[0], ta[1:])
+}
+
+// multipleTasks returns the names of the tasks of `xc lint test build`, which runs several tasks,
+// or nil if the arguments after the first task are its inputs: if it takes inputs, or if any of them isn't a task.
+func multipleTasks(tasks models.Tasks, name string, args []string) []string {
+	task, ok := tasks.Get(name)
+	if !ok || len(args) == 0 || len(task.Inputs) > 0 {
+		return nil
+	}
+	for _, a := range args {
+		if _, ok := tasks.Get(a); !ok {
+			return nil
+		}
+	}
+	return append([]string{name}, args...)
+}
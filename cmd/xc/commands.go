@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+
+	"github.com/joerdav/xc/cache"
+	"github.com/joerdav/xc/dirs"
+	"github.com/joerdav/xc/history"
+	"github.com/joerdav/xc/logs"
+	"github.com/joerdav/xc/models"
+)
+
+// project is the parsed task file that xc was invoked against.
+type project struct {
+	tasks models.Tasks
+	dir   string
+	// file is the path of the task file.
+	file  string
+	cfg   *flagConfig
+	paths dirs.Dirs
+	// retention configures which runs of the project are kept in the history.
+	retention history.Retention
+	// logPolicy configures which files in the logs directory are kept.
+	logPolicy logs.Policy
+	// cacheRemote configures the remote the task result cache is shared through.
+	cacheRemote cache.RemoteConfig
+}
+
+// command is a builtin xc command such as `xc telemetry`.
+// A task with the same name as a command takes precedence over the command.
+type command struct {
+	// needsTasks is true if the command can only run with a valid task file.
+	needsTasks bool
+	run        func(ctx context.Context, p project, args []string) error
+}
+
+var commands = map[string]command{
+	"telemetry": {run: telemetryCommand},
+	"cache":     {run: cacheCommand},
+	"copy":      {needsTasks: true, run: copyCommand},
+	"diff":      {needsTasks: true, run: diffCommand},
+	"deps":      {needsTasks: true, run: depsCommand},
+	"search":    {needsTasks: true, run: searchCommand},
+	"rerun":     {needsTasks: true, run: rerunCommand},
+	"history":   {run: historyCommand},
+	"new":       {run: newCommand},
+	"manifest":  {needsTasks: true, run: manifestCommand},
+	"graph":     {needsTasks: true, run: graphCommand},
+	"bundle":    {needsTasks: true, run: bundleCommand},
+	"validate":  {needsTasks: true, run: validateCommand},
+	"schedule":  {needsTasks: true, run: scheduleCommand},
+}
+
+// lookupCommand returns the command for the given arguments,
+// if the first argument is a command name and isn't shadowed by a task.
+func lookupCommand(args []string, tasks models.Tasks) (command, bool) {
+	if len(args) == 0 {
+		return command{}, false
+	}
+	c, ok := commands[args[0]]
+	if !ok {
+		return command{}, false
+	}
+	if _, isTask := tasks.Get(args[0]); isTask {
+		return command{}, false
+	}
+	return c, true
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/run"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func copyCommand(_ context.Context, p project, args []string) error {
+	fs := flag.NewFlagSet("copy", flag.ContinueOnError)
+	script := fs.Bool("script", false, "copy the resolved script rather than the xc invocation")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 {
+		return errors.New(i18n.T("usage: xc copy [-script] <task> [inputs...]"))
+	}
+	task, ok := p.tasks.Get(fs.Arg(0))
+	if !ok {
+		return run.TaskNotFoundError{Name: fs.Arg(0)}
+	}
+	text, err := invocation(task, fs.Args()[1:])
+	if *script {
+		text, err = resolvedScript(p, task, fs.Args()[1:])
+	}
+	if err != nil {
+		return err
+	}
+	if err := copyToClipboard(text, os.Stderr); err != nil {
+		return i18n.Errorf("xc copy: %w", err)
+	}
+	i18n.Printf("copied %s to clipboard\n", task.Name)
+	return nil
+}
+
+func invocation(task models.Task, inputs []string) (string, error) {
+	parts := []string{"xc", task.Name}
+	parts = append(parts, inputs...)
+	for i, p := range parts {
+		q, err := syntax.Quote(p, syntax.LangBash)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = q
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// resolvedScript returns a script that can be run without xc,
+// changing to the task directory and exporting the task environment and inputs before the task script.
+// The inputs are resolved from args the same way as when the task runs.
+func resolvedScript(p project, task models.Task, args []string) (string, error) {
+	if strings.HasPrefix(strings.TrimSpace(task.Script), "#!") {
+		return task.Script, nil
+	}
+	runner, err := run.NewRunner(p.tasks, p.dir, runnerOptions()...)
+	if err != nil {
+		return "", err
+	}
+	inputs, err := runner.Inputs(task, args, os.Environ())
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	taskDir := p.dir
+	if task.Dir != "" {
+		taskDir = filepath.Join(p.dir, filepath.FromSlash(task.Dir))
+		if filepath.IsAbs(task.Dir) {
+			taskDir = task.Dir
+		}
+	}
+	if abs, err := filepath.Abs(taskDir); err == nil {
+		taskDir = abs
+	}
+	if taskDir != "" {
+		q, err := syntax.Quote(taskDir, syntax.LangBash)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "cd %s\n", q)
+	}
+	for _, e := range task.Env {
+		k, v, _ := strings.Cut(e, "=")
+		fmt.Fprintf(&b, "export %s=%s\n", k, run.QuoteEnvValue(v))
+	}
+	for _, in := range inputs {
+		n, v, _ := strings.Cut(in, "=")
+		q, err := syntax.Quote(v, syntax.LangBash)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "export %s=%s\n", n, q)
+	}
+	b.WriteString(task.Script)
+	return b.String(), nil
+}
+
+// copyToClipboard copies text to the system clipboard.
+// Over SSH, or if no system clipboard is available, an OSC52 escape sequence
+// is written to the terminal instead, which most terminal emulators support.
+func copyToClipboard(text string, terminal io.Writer) error {
+	overSSH := os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+	if !overSSH && !clipboard.Unsupported {
+		if err := clipboard.WriteAll(text); err == nil {
+			return nil
+		}
+	}
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	_, err := seq.WriteTo(terminal)
+	return err
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/shlex"
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/run"
+)
+
+func depsCommand(_ context.Context, p project, args []string) error {
+	if len(args) < 1 {
+		return errors.New(i18n.T("usage: xc deps <task> [inputs...]"))
+	}
+	runner, err := run.NewRunner(p.tasks, p.dir, run.WithJobs(p.cfg.jobs))
+	if err != nil {
+		return parseError{i18n.Errorf("xc parse error: %w", err)}
+	}
+	plan, err := runner.Plan(args[0], args[1:])
+	if err != nil {
+		return i18n.Errorf("xc: %w", err)
+	}
+	printSteps(plan, "", "", false)
+	return nil
+}
+
+// dryRun prints how a task given its name followed by its arguments would run, without running anything:
+// the order of its dependencies, and the directory, environment variables and script of each of them.
+// The tasks of --then and --on-failure are printed after it.
+func dryRun(p project, args []string) error {
+	inputs, c, err := splitChain(args[1:])
+	if err != nil {
+		return i18n.Errorf("xc: %w", err)
+	}
+	if err := c.validate(p.tasks); err != nil {
+		return i18n.Errorf("xc: %w", err)
+	}
+	runner, err := run.NewRunner(p.tasks, p.dir, run.WithJobs(p.cfg.jobs), run.WithHost(p.cfg.host), dirOption(p, args[0]), strictEnvOption(p), forceDepsOption(p))
+	if err != nil {
+		return parseError{i18n.Errorf("xc parse error: %w", err)}
+	}
+	plan, err := runner.Plan(args[0], inputs)
+	if err != nil {
+		return i18n.Errorf("xc: %w", err)
+	}
+	printSteps(plan, "", "", true)
+	for _, next := range []struct {
+		heading string
+		tasks   []string
+	}{{i18n.T("then:"), c.then}, {i18n.T("on failure:"), c.onFailure}} {
+		for _, t := range next.tasks {
+			ta, err := shlex.Split(t)
+			if err != nil {
+				return i18n.Errorf("xc: %w", err)
+			}
+			plan, err := runner.Plan(ta[0], ta[1:])
+			if err != nil {
+				return i18n.Errorf("xc: %w", err)
+			}
+			fmt.Println(next.heading)
+			printSteps(plan, "  ", "  ", true)
+		}
+	}
+	return nil
+}
+
+// printSteps prints steps in order, the first line is prefixed with first and the rest with indent.
+// With details the directory, environment variables and script of each task that would run are printed below it.
+func printSteps(steps []run.Step, first, indent string, details bool) {
+	for i, s := range steps {
+		prefix := indent
+		if i == 0 {
+			prefix = first
+		}
+		if len(s.Branches) > 0 {
+			fmt.Printf("%s%s\n", prefix, i18n.T("parallel:"))
+			for _, b := range s.Branches {
+				printSteps(b, indent+"  - ", indent+"    ", details)
+			}
+			continue
+		}
+		name := strings.Join(append([]string{nameStyle.Render(s.Task)}, s.Args...), " ")
+		if s.Skip != "" {
+			fmt.Printf("%s%s %s\n", prefix, name, descriptionStyle.Render(i18n.Sprintf("(skipped: %s)", s.Skip)))
+			continue
+		}
+		fmt.Printf("%s%s\n", prefix, name)
+		if details && s.Script != "" {
+			printExecution(s, indent+"  ")
+		}
+		if len(s.Finally) > 0 {
+			fmt.Printf("%s  %s\n", indent, i18n.T("finally:"))
+			printSteps(s.Finally, indent+"  - ", indent+"    ", details)
+		}
+	}
+}
+
+// printExecution prints the directory, environment variables and script of a step, prefixed with indent.
+func printExecution(s run.Step, indent string) {
+	detail := func(label, value string) {
+		fmt.Printf("%s%s %s\n", indent, descriptionStyle.Render(label), value)
+	}
+	if s.DeclaredDir != "" {
+		detail(i18n.T("dir:"), i18n.Sprintf("%s (declared: %s)", displayPath(s.Dir), displayPath(s.DeclaredDir)))
+	} else {
+		detail(i18n.T("dir:"), displayPath(s.Dir))
+	}
+	for _, e := range s.Env {
+		detail(i18n.T("env:"), e)
+	}
+	switch {
+	case s.Interpreter != "":
+		detail(i18n.T("interpreter:"), s.Interpreter)
+	case s.Shell != "":
+		detail(i18n.T("shell:"), s.Shell)
+	}
+	if s.Container != "" {
+		detail(i18n.T("container:"), s.Container)
+	}
+	if s.Remote != "" {
+		detail(i18n.T("remote:"), s.Remote)
+	}
+	for _, line := range strings.Split(strings.TrimRight(s.Script, "\n"), "\n") {
+		fmt.Printf("%s  %s\n", indent, line)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/parser"
+)
+
+func diffCommand(_ context.Context, p project, args []string) error {
+	if len(args) > 1 {
+		return errors.New(i18n.T("usage: xc diff [ref]"))
+	}
+	ref := "HEAD"
+	if len(args) == 1 {
+		ref = args[0]
+	}
+	old, err := tasksAtRef(p, ref)
+	if err != nil {
+		return i18n.Errorf("xc diff: %w", err)
+	}
+	// Parse the working tree again, so that overrides from .xc.yaml don't show up as changes.
+	current, _, err := tryParse(p.file, p.cfg.heading)
+	if err != nil {
+		return err
+	}
+	changes := models.Diff(old, current)
+	if len(changes) == 0 {
+		i18n.Printf("no task changes since %s\n", ref)
+		return nil
+	}
+	for _, c := range changes {
+		printChange(c)
+	}
+	return nil
+}
+
+// tasksAtRef parses the task file as it was at the given git ref.
+func tasksAtRef(p project, ref string) (models.Tasks, error) {
+	cmd := exec.Command("git", "show", ref+":./"+filepath.ToSlash(filepath.Base(p.file)))
+	cmd.Dir = filepath.Dir(p.file)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.New(msg)
+		}
+		return nil, err
+	}
+	ps, err := parser.NewParser(bytes.NewReader(out), p.cfg.heading)
+	if err != nil {
+		return nil, i18n.Errorf("%s: %w", ref, err)
+	}
+	return ps.Parse()
+}
+
+func printChange(c models.TaskChange) {
+	switch c.Kind {
+	case models.TaskAdded:
+		fmt.Printf("+ %s\n", c.Name)
+	case models.TaskRemoved:
+		fmt.Printf("- %s\n", c.Name)
+	case models.TaskChanged:
+		fmt.Printf("~ %s\n", c.Name)
+	}
+	for _, a := range c.Attributes {
+		if !strings.Contains(a.Old, "\n") && !strings.Contains(a.New, "\n") {
+			fmt.Printf("    %s: %q -> %q\n", a.Attribute, a.Old, a.New)
+			continue
+		}
+		fmt.Printf("    %s:\n", a.Attribute)
+		printLines("-", a.Old)
+		printLines("+", a.New)
+	}
+}
+
+func printLines(prefix, s string) {
+	if s == "" {
+		return
+	}
+	for _, l := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		fmt.Printf("      %s %s\n", prefix, l)
+	}
+}
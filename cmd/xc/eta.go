@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/joerdav/xc/history"
+	"github.com/joerdav/xc/i18n"
+	"golang.org/x/term"
+)
+
+const (
+	// etaRuns is the number of recent successful runs of a task that are averaged for its estimate.
+	etaRuns = 10
+	// progressInterval is how often the elapsed time of a running task is shown.
+	progressInterval = 30 * time.Second
+	// minEstimate is the shortest estimate that is shown, quicker tasks aren't worth the noise.
+	minEstimate = time.Second
+)
+
+// averageDurations returns the mean duration of the recent successful runs of each task in the history,
+// tasks that take less than minEstimate are left out.
+// A history that can't be read results in no estimates.
+func averageDurations(p project) map[string]time.Duration {
+	s, err := openHistory(p)
+	if err != nil {
+		return nil
+	}
+	defer s.Close()
+	runs, err := s.Runs(projectKey(p), func(r history.Run) bool { return !r.Failed() }, 0)
+	if err != nil {
+		return nil
+	}
+	byTask := map[string][]history.Run{}
+	for _, r := range runs {
+		if len(byTask[r.Task]) < etaRuns {
+			byTask[r.Task] = append(byTask[r.Task], r)
+		}
+	}
+	result := map[string]time.Duration{}
+	for task, runs := range byTask {
+		if avg, ok := history.Average(runs); ok && avg >= minEstimate {
+			result[task] = avg
+		}
+	}
+	return result
+}
+
+// showProgress writes the estimated duration of a task to w,
+// and the elapsed time every progressInterval while the task runs if w is a terminal.
+// The returned func stops the updates.
+func showProgress(w *os.File, task string, avg time.Duration) func() {
+	fmt.Fprintln(w, i18n.Sprintf("xc: %s ~%s avg", task, formatDuration(avg)))
+	if !term.IsTerminal(int(w.Fd())) {
+		return func() {}
+	}
+	start := time.Now()
+	t := time.NewTicker(progressInterval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				elapsed := formatDuration(time.Since(start))
+				fmt.Fprintln(w, i18n.Sprintf("xc: %s ~%s avg, %s elapsed", task, formatDuration(avg), elapsed))
+			}
+		}
+	}()
+	return func() {
+		t.Stop()
+		close(done)
+	}
+}
+
+// formatDuration rounds d to the second for display.
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/run"
+)
+
+// The values of -log-format, json can be followed by =path to write the events to a file.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// eventLog returns the handler that writes the events of a run as JSON lines for -log-format,
+// and a func that closes the file they are written to. The handler is nil for the text format.
+// When the events are written to the standard output everything else xc prints is written to the standard error,
+// so that the standard output only holds events.
+func eventLog(format string) (h run.EventHandler, closeLog func() error, err error) {
+	closeLog = func() error { return nil }
+	name, path, hasPath := strings.Cut(format, "=")
+	switch {
+	case format == logFormatText:
+		return nil, closeLog, nil
+	case name != logFormatJSON || (hasPath && path == ""):
+		return nil, closeLog, i18n.Errorf("-log-format should be one of (%s, %s, %s=<path>), not %s", logFormatText, logFormatJSON, logFormatJSON, format)
+	}
+	var w io.Writer = os.Stdout
+	if hasPath {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, closeLog, err
+		}
+		w, closeLog = f, f.Close
+	} else {
+		os.Stdout = os.Stderr
+	}
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(e run.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		_ = enc.Encode(e)
+	}, closeLog, nil
+}
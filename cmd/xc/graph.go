@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/manifest"
+	"github.com/joerdav/xc/run"
+)
+
+//go:embed graph.html
+var graphPage []byte
+
+func graphCommand(ctx context.Context, p project, args []string) error {
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	serve := fs.Bool("serve", false, "open the graph in the browser")
+	addr := fs.String("addr", "localhost:0", "the address to serve the graph on")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return errors.New(i18n.T("usage: xc graph [-serve] [-addr <address>] [task]"))
+	}
+	var target string
+	if fs.NArg() == 1 {
+		t, ok := p.tasks.Get(fs.Arg(0))
+		if !ok {
+			return run.TaskNotFoundError{Name: fs.Arg(0)}
+		}
+		target = t.Name
+	}
+	m := manifest.New(p.tasks, displayPath(p.file), p.cfg.heading)
+	if *serve {
+		return serveGraph(ctx, m, target, *addr)
+	}
+	return writeDot(os.Stdout, m.Graph, target)
+}
+
+// writeDot writes the graph in the Graphviz DOT language, with an edge from each task to the tasks it requires.
+// If target is set only the tasks that run with it are written.
+func writeDot(w io.Writer, g manifest.Graph, target string) error {
+	include := map[string]bool{}
+	for _, n := range g.Nodes {
+		include[n] = target == ""
+	}
+	for _, n := range g.Path(target) {
+		include[n] = true
+	}
+	if _, err := fmt.Fprintln(w, "digraph xc {"); err != nil {
+		return err
+	}
+	for _, n := range g.Nodes {
+		if include[n] {
+			fmt.Fprintf(w, "\t%q;\n", n)
+		}
+	}
+	for _, e := range g.Edges {
+		switch {
+		case !include[e.From]:
+		case e.Missing:
+			fmt.Fprintf(w, "\t%q -> %q [style=dashed];\n", e.From, e.To)
+		default:
+			fmt.Fprintf(w, "\t%q -> %q;\n", e.From, e.To)
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+// serveGraph serves a page rendering the graph of m until ctx is done, and opens it in the browser.
+func serveGraph(ctx context.Context, m manifest.Manifest, target, addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return i18n.Errorf("xc graph: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(graphPage)
+	})
+	mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		m.Write(w)
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	u := "http://" + l.Addr().String() + "/"
+	if target != "" {
+		u += "?task=" + url.QueryEscape(target)
+	}
+	i18n.Printf("xc: serving the graph on %s, press ctrl+c to stop\n", u)
+	// The URL is printed, so failing to open a browser isn't an error.
+	_ = openBrowser(u)
+	if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return i18n.Errorf("xc graph: %w", err)
+	}
+	return nil
+}
+
+func openBrowser(u string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", u).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", u).Start()
+	}
+	return exec.Command("xdg-open", u).Start()
+}
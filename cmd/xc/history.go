@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joerdav/xc/config"
+	"github.com/joerdav/xc/dirs"
+	"github.com/joerdav/xc/history"
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/run"
+)
+
+func historyPath(d dirs.Dirs) string {
+	return filepath.Join(d.Data, "history.db")
+}
+
+// projectKey identifies the task file of a project in the history.
+func projectKey(p project) string {
+	abs, err := filepath.Abs(p.file)
+	if err != nil {
+		return p.file
+	}
+	return abs
+}
+
+func openHistory(p project) (*history.Store, error) {
+	if p.paths.Data == "" {
+		return nil, errors.New(i18n.T("no data directory"))
+	}
+	if err := os.MkdirAll(p.paths.Data, 0o755); err != nil {
+		return nil, err
+	}
+	return history.Open(historyPath(p.paths))
+}
+
+// inputEnv returns the inputs of the task that are set in the environment rather than by arguments,
+// so that they can be repeated by a rerun.
+func inputEnv(task models.Task, args []string) []string {
+	var env []string
+	for i, n := range task.Inputs {
+		if i < len(args) {
+			continue
+		}
+		if v, ok := os.LookupEnv(n); ok {
+			env = append(env, n+"="+v)
+		}
+	}
+	return env
+}
+
+// recordRun adds a run to the history, after the dependencies with a Throttle that ran,
+// so that they are throttled when they run as dependencies again.
+// A run of a task with a Throttle where every script was skipped isn't recorded, so it doesn't extend the throttle.
+func recordRun(p project, args []string, start time.Time, err error, results []run.Result) error {
+	task, ok := p.tasks.Get(args[0])
+	if !ok {
+		return nil
+	}
+	s, openErr := openHistory(p)
+	if openErr != nil {
+		return openErr
+	}
+	defer s.Close()
+	var runs []history.Run
+	ran := false
+	for _, res := range results {
+		ran = ran || !res.Skipped
+		dep, ok := p.tasks.Get(res.Task)
+		if !ok || res.Skipped || dep.Throttle == 0 || dep.Name == task.Name {
+			continue
+		}
+		d := history.Run{Project: projectKey(p), Task: dep.Name, Profile: p.cfg.profile, Start: res.Start, Duration: res.Duration, Dependency: true}
+		if res.Err != nil {
+			d.Error = res.Err.Error()
+		}
+		runs = append(runs, d)
+	}
+	r := history.Run{
+		Project:  projectKey(p),
+		Task:     task.Name,
+		Args:     args[1:],
+		Env:      inputEnv(task, args[1:]),
+		Profile:  p.cfg.profile,
+		Start:    start,
+		Duration: time.Since(start),
+		Commit:   gitCommit(p.dir),
+	}
+	if err != nil {
+		r.Error = err.Error()
+		r.ExitCode = exitCode(err)
+	}
+	if ran || task.Throttle == 0 {
+		runs = append(runs, r)
+	}
+	for _, r := range runs {
+		if addErr := s.Add(r); addErr != nil {
+			return addErr
+		}
+	}
+	if len(runs) == 0 {
+		return nil
+	}
+	_, pruneErr := s.Prune(r.Project, p.retention, time.Now())
+	return pruneErr
+}
+
+// gitCommit returns the commit that is checked out in dir, or an empty string if dir isn't in a git repository.
+func gitCommit(dir string) string {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// historyThrottler returns when a task last succeeded according to the history.
+// A history that can't be read means the task isn't throttled.
+func historyThrottler(p project) run.Throttler {
+	return func(task models.Task) (time.Time, bool) {
+		s, err := openHistory(p)
+		if err != nil {
+			return time.Time{}, false
+		}
+		defer s.Close()
+		r, ok, err := s.Last(projectKey(p), func(r history.Run) bool {
+			return r.Task == task.Name && !r.Failed()
+		})
+		if err != nil || !ok {
+			return time.Time{}, false
+		}
+		return r.Start.Add(r.Duration), true
+	}
+}
+
+func historyRetention(c config.History) (history.Retention, error) {
+	r := history.Retention{MaxRuns: c.MaxRuns}
+	if c.MaxAge != "" {
+		age, err := parseAge(c.MaxAge)
+		if err != nil {
+			return r, i18n.Errorf("xc config error: history maxAge: %w", err)
+		}
+		r.MaxAge = age
+	}
+	return r, nil
+}
+
+// invocations matches the runs of tasks that were invoked from the command line, rather than as dependencies,
+// only failed runs are matched if failed is true.
+func invocations(failed bool) func(history.Run) bool {
+	return func(r history.Run) bool {
+		return !r.Dependency && (!failed || r.Failed())
+	}
+}
+
+const historyUsage = "usage: xc history [-n <runs>] [-failed] [task] | xc history export"
+
+func historyCommand(_ context.Context, p project, args []string) error {
+	if len(args) > 0 && args[0] == "export" {
+		if len(args) > 1 {
+			return errors.New(i18n.T(historyUsage))
+		}
+		return exportHistory(p, os.Stdout)
+	}
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	n := fs.Int("n", 20, "the number of runs to show")
+	failed := fs.Bool("failed", false, "only show failed runs")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return errors.New(i18n.T(historyUsage))
+	}
+	s, err := openHistory(p)
+	if err != nil {
+		return i18n.Errorf("xc history: %w", err)
+	}
+	defer s.Close()
+	match := invocations(*failed)
+	if name := fs.Arg(0); name != "" {
+		// Runs of tasks that have since been removed from the task file can still be listed.
+		if t, ok := p.tasks.Get(name); ok {
+			name = t.Name
+		}
+		invoked := match
+		match = func(r history.Run) bool {
+			return r.Task == name && invoked(r)
+		}
+	}
+	runs, err := s.Runs(projectKey(p), match, *n)
+	if err != nil {
+		return i18n.Errorf("xc history: %w", err)
+	}
+	if len(runs) == 0 {
+		fmt.Println(i18n.T("no runs recorded"))
+		return nil
+	}
+	maxLen := 0
+	for _, r := range runs {
+		if len(r.Task) > maxLen {
+			maxLen = len(r.Task)
+		}
+	}
+	// Show the most recent run last, like a shell history.
+	for i := len(runs) - 1; i >= 0; i-- {
+		r := runs[i]
+		status, code := i18n.T("ok"), ""
+		if r.Failed() {
+			status = i18n.T("failed")
+		}
+		if r.ExitCode != 0 {
+			code = strconv.Itoa(r.ExitCode)
+		}
+		commit := r.Commit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		invocation := strings.Join(append([]string{nameStyle.Render(fmt.Sprintf("%-*s", maxLen, r.Task))}, r.Args...), " ")
+		fmt.Printf("%s  %-6s %3s  %8s  %-7s  %s\n", r.Start.Local().Format("2006-01-02 15:04:05"), status, code, formatDuration(r.Duration), commit, invocation)
+	}
+	return nil
+}
+
+// exportHistory writes every run of the project to w as JSON lines, oldest first.
+func exportHistory(p project, w io.Writer) error {
+	s, err := openHistory(p)
+	if err != nil {
+		return i18n.Errorf("xc history: %w", err)
+	}
+	defer s.Close()
+	runs, err := s.Runs(projectKey(p), nil, 0)
+	if err != nil {
+		return i18n.Errorf("xc history: %w", err)
+	}
+	enc := json.NewEncoder(w)
+	for i := len(runs) - 1; i >= 0; i-- {
+		if err := enc.Encode(runs[i]); err != nil {
+			return i18n.Errorf("xc history: %w", err)
+		}
+	}
+	return nil
+}
+
+func rerunCommand(ctx context.Context, p project, args []string) error {
+	fs := flag.NewFlagSet("rerun", flag.ContinueOnError)
+	failed := fs.Bool("failed", false, "repeat the most recent failed run")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New(i18n.T("usage: xc rerun [-failed]"))
+	}
+	s, err := openHistory(p)
+	if err != nil {
+		return i18n.Errorf("xc rerun: %w", err)
+	}
+	r, ok, err := s.Last(projectKey(p), invocations(*failed))
+	// Close the store before running, so other xc processes can record their runs.
+	s.Close()
+	if err != nil {
+		return i18n.Errorf("xc rerun: %w", err)
+	}
+	if !ok {
+		return errors.New(i18n.T("xc rerun: no previous run found"))
+	}
+	if r.Profile != p.cfg.profile {
+		if p, err = withProfile(p, r.Profile); err != nil {
+			return err
+		}
+	}
+	for _, e := range r.Env {
+		k, v, _ := strings.Cut(e, "=")
+		os.Setenv(k, v)
+	}
+	invocation := strings.Join(append(append(append([]string{}, r.Env...), "xc", r.Task), r.Args...), " ")
+	i18n.Printf("xc: rerunning %s\n", invocation)
+	return runTask(ctx, p, append([]string{r.Task}, r.Args...))
+}
+
+// withProfile returns the project with its tasks loaded using another profile.
+func withProfile(p project, profile string) (project, error) {
+	tasks, _, err := tryParse(p.file, p.cfg.heading)
+	if err != nil {
+		return p, err
+	}
+	p.cfg.profile = profile
+	tasks, _, err = loadConfig(tasks, p.dir, profile)
+	if err != nil {
+		return p, err
+	}
+	p.tasks = tasks
+	return p, nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+)
+
+// inputField is a single input of a task in the input form.
+// Inputs with choices are rendered as a select field, other inputs as a text field.
+type inputField struct {
+	name, label string
+	choices     []string
+	cursor      int
+	text        textinput.Model
+	// fallback is used if the text field is left empty.
+	fallback string
+	secret   bool
+}
+
+func (f inputField) value() string {
+	if len(f.choices) > 0 {
+		return f.choices[f.cursor]
+	}
+	if f.text.Value() == "" {
+		return f.fallback
+	}
+	return f.text.Value()
+}
+
+// display returns the value to show once the field is submitted.
+func (f inputField) display() string {
+	if f.secret {
+		return strings.Repeat("*", len(f.value()))
+	}
+	return f.value()
+}
+
+type inputForm struct {
+	task     string
+	fields   []inputField
+	current  int
+	quitting bool
+	// done is true if every field was submitted.
+	done bool
+}
+
+func newInputForm(task models.Task, names []string) inputForm {
+	f := inputForm{task: task.Name}
+	for _, n := range names {
+		spec := task.Input(n)
+		field := inputField{name: n, label: n, choices: spec.Choices, fallback: spec.Fallback()}
+		if len(field.choices) == 0 && spec.Type == models.InputTypeBool {
+			field.choices = []string{"true", "false"}
+		}
+		if spec.Prompt != nil {
+			field.label = spec.Prompt.Question
+			field.secret = spec.Prompt.Secret
+		}
+		for i, c := range field.choices {
+			if c == field.fallback {
+				field.cursor = i
+			}
+		}
+		if len(field.choices) == 0 {
+			field.text = textinput.New()
+			field.text.Prompt = ""
+			field.text.Placeholder = field.fallback
+			if field.secret {
+				field.text.EchoMode = textinput.EchoPassword
+			}
+		}
+		f.fields = append(f.fields, field)
+	}
+	f.focus()
+	return f
+}
+
+func (f *inputForm) focus() {
+	if f.current < len(f.fields) && len(f.fields[f.current].choices) == 0 {
+		f.fields[f.current].text.Focus()
+	}
+}
+
+func (f inputForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (f inputForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	field := &f.fields[f.current]
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			f.quitting = true
+			return f, tea.Quit
+		case "enter":
+			field.text.Blur()
+			f.current++
+			if f.current == len(f.fields) {
+				f.done, f.quitting = true, true
+				return f, tea.Quit
+			}
+			f.focus()
+			return f, nil
+		case "up", "k":
+			if len(field.choices) > 0 && field.cursor > 0 {
+				field.cursor--
+				return f, nil
+			}
+		case "down", "j":
+			if len(field.choices) > 0 && field.cursor < len(field.choices)-1 {
+				field.cursor++
+				return f, nil
+			}
+		}
+	}
+	if len(field.choices) > 0 {
+		return f, nil
+	}
+	var cmd tea.Cmd
+	field.text, cmd = field.text.Update(msg)
+	return f, cmd
+}
+
+func (f inputForm) View() string {
+	if f.quitting {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s\n\n", titleStyle.Render(i18n.Sprintf("xc: Inputs for %s", f.task)))
+	for i, field := range f.fields {
+		switch {
+		case i < f.current:
+			fmt.Fprintf(&b, "%s\n", itemStyle.Render(field.label+": "+field.display()))
+		case i > f.current:
+			fmt.Fprintf(&b, "%s\n", itemStyle.Render(field.label+":"))
+		case len(field.choices) == 0:
+			fmt.Fprintf(&b, "%s\n", selectedItemStyle.Render("> "+field.label+": "+field.text.View()))
+		default:
+			fmt.Fprintf(&b, "%s\n", selectedItemStyle.Render("> "+field.label+":"))
+			for j, c := range field.choices {
+				if j == field.cursor {
+					fmt.Fprintf(&b, "%s\n", selectedItemStyle.Render("    > "+c))
+					continue
+				}
+				fmt.Fprintf(&b, "%s\n", itemStyle.Render("    "+c))
+			}
+		}
+	}
+	return b.String()
+}
+
+// lookupInput returns the value of an input of task from the environment.
+func lookupInput(task models.Task, name string) (value string, ok bool) {
+	for _, e := range append(os.Environ(), task.Env...) {
+		if k, v, _ := strings.Cut(e, "="); k == name {
+			value, ok = v, true
+		}
+	}
+	return
+}
+
+// promptInputs shows a form asking for the inputs of task that aren't set in the environment,
+// and returns the values of all inputs in order, to be passed as arguments to the task.
+// ok is false if the form was cancelled.
+func promptInputs(task models.Task) (inputs []string, ok bool, err error) {
+	var missing []string
+	for _, n := range task.Inputs {
+		if _, ok := lookupInput(task, n); !ok {
+			missing = append(missing, n)
+		}
+	}
+	if len(missing) == 0 {
+		return nil, true, nil
+	}
+	m, err := tea.NewProgram(newInputForm(task, missing)).Run()
+	if err != nil {
+		return nil, false, err
+	}
+	f := m.(inputForm)
+	if !f.done {
+		return nil, false, nil
+	}
+	values := map[string]string{}
+	for _, field := range f.fields {
+		values[field.name] = field.value()
+	}
+	for _, n := range task.Inputs {
+		v, ok := values[n]
+		if !ok {
+			v, _ = lookupInput(task, n)
+		}
+		inputs = append(inputs, v)
+	}
+	return inputs, true, nil
+}
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/joerdav/xc/i18n"
 	"github.com/joerdav/xc/models"
-	"github.com/joerdav/xc/run"
 )
 
 var (
@@ -29,14 +31,18 @@ const (
 	helpPadding         = 4
 	listItemWidth       = 20
 	listItemHeight      = 6
+	// previewLines is the most lines of the description of the selected task shown below the list.
+	previewLines = 8
 )
 
 type taskItem struct {
 	models.Task
+	// avg is the mean duration of recent runs of the task, it is zero if there are none.
+	avg time.Duration
 }
 
 func (ti taskItem) FilterValue() string {
-	return ti.Name
+	return strings.Join(append([]string{ti.Name}, ti.Aliases...), " ")
 }
 
 type itemDelegate struct{}
@@ -50,7 +56,17 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 		return
 	}
 
-	str := i.Name
+	str := taskLabel(i.Task)
+	if ns := i.Namespace(); ns != "" {
+		// Dim the namespace so the tasks of a namespace read as a group.
+		str = descriptionStyle.Render(ns+":") + strings.TrimPrefix(str, ns+":")
+	}
+	if i.Deprecated != "" {
+		str += " " + descriptionStyle.Render(i18n.T("(deprecated)"))
+	}
+	if i.avg > 0 {
+		str += " " + descriptionStyle.Render(i18n.Sprintf("~%s avg", formatDuration(i.avg)))
+	}
 
 	fn := itemStyle.Render
 	if index == m.Index() {
@@ -66,6 +82,8 @@ type model struct {
 	list     list.Model
 	choice   *models.Task
 	quitting bool
+	// rerun is true if the user asked to repeat the most recent run instead of picking a task.
+	rerun bool
 }
 
 func (m model) Init() tea.Cmd {
@@ -84,6 +102,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.quitting = true
 			return m, tea.Quit
 
+		case "ctrl+r":
+			m.rerun = true
+			m.quitting = true
+			return m, tea.Quit
+
 		case "enter":
 			i, ok := m.list.SelectedItem().(taskItem)
 			if ok {
@@ -103,39 +126,79 @@ func (m model) View() string {
 	if m.quitting {
 		return ""
 	}
-	return "\n" + m.list.View()
+	return "\n" + m.list.View() + m.preview()
+}
+
+// preview returns the description of the selected task, as it is written in the task file.
+func (m model) preview() string {
+	i, ok := m.list.SelectedItem().(taskItem)
+	if !ok || len(i.Doc) == 0 {
+		return ""
+	}
+	lines := strings.Split(models.FormatDoc(i.Doc), "\n")
+	if len(lines) > previewLines {
+		lines = append(lines[:previewLines], "…")
+	}
+	return "\n" + itemStyle.Render(descriptionStyle.Render(strings.Join(lines, "\n")))
+}
+
+// filterTasks returns the tasks that fuzzily match query, best matches first.
+func filterTasks(tasks []models.Task, query string) []models.Task {
+	if query == "" {
+		return tasks
+	}
+	names := make([]string, len(tasks))
+	for i, t := range tasks {
+		names[i] = t.Name
+	}
+	var result []models.Task
+	for _, r := range list.DefaultFilter(query, names) {
+		result = append(result, tasks[r.Index])
+	}
+	return result
 }
 
-func interactivePicker(ctx context.Context, tasks []models.Task, dir string) error {
+// interactivePicker lets the user pick a task to run, from the tasks that match query.
+func interactivePicker(ctx context.Context, p project, query string) error {
 	var items []list.Item
-	for _, t := range tasks {
-		items = append(items, taskItem{t})
+	tasks, err := listedTasks(p)
+	if err != nil {
+		return err
+	}
+	matches := filterTasks(tasks, query)
+	if len(matches) == 0 {
+		return i18n.Errorf("no tasks match %q", query)
+	}
+	avgs := averageDurations(p)
+	for _, t := range matches {
+		items = append(items, taskItem{Task: t, avg: avgs[t.Name]})
 	}
-	l := list.New(items, itemDelegate{}, listItemWidth, listItemHeight+len(tasks))
-	l.Title = "xc: Choose a task"
+	l := list.New(items, itemDelegate{}, listItemWidth, listItemHeight+len(matches))
+	l.Title = i18n.T("xc: Choose a task")
 	l.SetShowStatusBar(false)
 	l.DisableQuitKeybindings()
 	l.SetFilteringEnabled(true)
 	l.Styles.Title = titleStyle
 	l.Styles.PaginationStyle = paginationStyle
 	l.Styles.HelpStyle = helpStyle
+	rerun := key.NewBinding(key.WithKeys("ctrl+r"), key.WithHelp("ctrl+r", i18n.T("rerun last")))
+	l.AdditionalShortHelpKeys = func() []key.Binding { return []key.Binding{rerun} }
 
 	m := model{list: l}
 	tm, err := tea.NewProgram(m).Run()
 	if err != nil {
 		return err
 	}
+	if tm.(model).rerun {
+		return rerunCommand(ctx, p, nil)
+	}
 	task := tm.(model).choice
 	if task == nil {
 		return nil
 	}
-	runner, err := run.NewRunner(tasks, dir)
-	if err != nil {
-		return fmt.Errorf("xc parse error: %w", err)
-	}
-	err = runner.Run(ctx, task.Name, nil)
-	if err != nil {
-		return fmt.Errorf("xc: %w", err)
+	inputs, ok, err := promptInputs(*task)
+	if err != nil || !ok {
+		return err
 	}
-	return nil
+	return runTask(ctx, p, append([]string{task.Name}, inputs...))
 }
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+
+	"github.com/joerdav/xc/i18n"
+)
+
+// jobsVar is the environment variable that sets the number of jobs when -j isn't given.
+const jobsVar = "XC_JOBS"
+
+// jobCount returns the number of scripts that run in parallel: -j if it is given,
+// otherwise XC_JOBS, otherwise the jobs of the config, otherwise the number of CPUs.
+func jobCount(flagJobs, configJobs int) (int, error) {
+	if flagJobs > 0 {
+		return flagJobs, nil
+	}
+	if flagJobs < 0 {
+		return 0, i18n.Errorf("xc: -j should be a positive number of jobs, not %d", flagJobs)
+	}
+	if v := os.Getenv(jobsVar); v != "" {
+		jobs, err := strconv.Atoi(v)
+		if err != nil || jobs < 1 {
+			return 0, i18n.Errorf("xc: %s should be a positive number of jobs, not %s", jobsVar, v)
+		}
+		return jobs, nil
+	}
+	if configJobs < 0 {
+		return 0, parseError{i18n.Errorf("xc config error: jobs should be a positive number, not %d", configJobs)}
+	}
+	if configJobs > 0 {
+		return configJobs, nil
+	}
+	return runtime.NumCPU(), nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"time"
+
+	"github.com/joerdav/xc/config"
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/logs"
+)
+
+func logRetention(c config.Logs) (logs.Policy, error) {
+	p := logs.Policy{Keep: c.Keep}
+	if c.MaxAge != "" {
+		age, err := parseAge(c.MaxAge)
+		if err != nil {
+			return p, i18n.Errorf("xc config error: logs maxAge: %w", err)
+		}
+		p.MaxAge = age
+	}
+	if c.MaxSize != "" {
+		size, err := parseSize(c.MaxSize)
+		if err != nil {
+			return p, i18n.Errorf("xc config error: logs maxSize: %w", err)
+		}
+		p.MaxSize = size
+	}
+	return p, nil
+}
+
+// pruneLogs removes the log files that fall outside of the log retention policy of the project.
+func pruneLogs(p project) error {
+	if p.paths.Logs == "" {
+		return nil
+	}
+	_, err := logs.Prune(p.paths.Logs, p.logPolicy, time.Now())
+	return err
+}
@@ -11,11 +11,21 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"runtime"
 	"runtime/debug"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/joerdav/xc/cache"
+	"github.com/joerdav/xc/config"
+	"github.com/joerdav/xc/dirs"
+	"github.com/joerdav/xc/history"
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/logs"
 	"github.com/joerdav/xc/models"
 	"github.com/joerdav/xc/parser"
+	"github.com/joerdav/xc/report"
 	"github.com/joerdav/xc/run"
 	"github.com/posener/complete/v2"
 	"github.com/posener/complete/v2/install"
@@ -25,12 +35,29 @@ import (
 //go:embed usage.txt
 var usage string
 
+//go:embed usage.de.txt
+var usageDE string
+
+// localUsage returns the usage text in the current locale, falling back to English.
+func localUsage() string {
+	if i18n.Locale() == "de" {
+		return usageDE
+	}
+	return usage
+}
+
 // ErrNoMarkdownFile will be returned if no markdown file is found in the cwd or any parent directories.
-var ErrNoMarkdownFile = errors.New("no xc compatible markdown file found")
+var ErrNoMarkdownFile = errors.New(i18n.T("no xc compatible markdown file found"))
 
-type config struct {
+type flagConfig struct {
 	version, help, short, display, noTTY, complete, uncomplete bool
-	filename, heading                                          string
+	interactive, watch, force, noCache, dryRun, keepGoing      bool
+	watchRestart, watchQueue, watchIgnore, noSummary, last     bool
+	strictEnv, forceDeps                                       bool
+	filename, heading, profile, report, each, tag, output      string
+	logFormat, host, dir, logDir                               string
+	jobs                                                       int
+	timeout, gracePeriod                                       time.Duration
 }
 
 var version = ""
@@ -38,17 +65,34 @@ var version = ""
 func main() {
 	if err := runMain(); err != nil {
 		fmt.Println(err.Error())
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
-func flags() config {
-	var cfg config
+// parseError is an error in the task file or the config, xc exits with run.ExitCodeParse after it.
+type parseError struct {
+	error
+}
+
+func (e parseError) Unwrap() error {
+	return e.error
+}
+
+// exitCode returns the exit code xc exits with after err, see run.ExitCode.
+func exitCode(err error) int {
+	if errors.As(err, &parseError{}) {
+		return run.ExitCodeParse
+	}
+	return run.ExitCode(err)
+}
+
+func flags() *flagConfig {
+	cfg := &flagConfig{}
 
 	log.SetFlags(0)
 	log.SetOutput(os.Stderr)
 	flag.Usage = func() {
-		fmt.Print(usage)
+		fmt.Print(localUsage())
 	}
 	flag.BoolVar(&cfg.version, "version", false, "show xc version")
 	flag.BoolVar(&cfg.version, "V", false, "show xc version")
@@ -73,57 +117,201 @@ func flags() config {
 
 	flag.BoolVar(&cfg.noTTY, "no-tty", false, "disable interactive picker")
 
+	flag.BoolVar(&cfg.interactive, "i", false, "open the interactive picker, filtered to the arguments")
+	flag.BoolVar(&cfg.interactive, "interactive", false, "open the interactive picker, filtered to the arguments")
+	flag.BoolVar(&cfg.last, "last", false, "repeat the most recent run in this project, like xc rerun")
+
+	flag.BoolVar(&cfg.watch, "watch", false, "re-run the task when its watched paths change")
+	flag.BoolVar(&cfg.watchRestart, "watch-restart", false, "with -watch, stop the task and start it again when paths change while it runs")
+	flag.BoolVar(&cfg.watchQueue, "watch-queue", false, "with -watch, run the task again after it finishes when paths change while it runs")
+	flag.BoolVar(&cfg.watchIgnore, "watch-ignore", false, "with -watch, ignore changes while the task runs")
+
+	flag.BoolVar(&cfg.force, "force", false, "run tasks even if they succeeded within their Throttle, their outputs are up to date or they are cached")
+	flag.BoolVar(&cfg.forceDeps, "force-deps", false, "run the tasks that are required more than once each time they are required, rather than once per run")
+	flag.BoolVar(&cfg.noCache, "no-cache", false, "run tasks without reading or storing their results in the cache")
+
+	flag.BoolVar(&cfg.dryRun, "dry-run", false, "print the tasks that would run, with their directories, environment and scripts, without running anything")
+
+	flag.StringVar(&cfg.report, "report", "", "write a report of the run, e.g. junit=report.xml or trace=trace.json")
+
+	flag.StringVar(&cfg.each, "each", "", "run the task once for each file matching a glob pattern, with $ITEM set to the file")
+	flag.IntVar(&cfg.jobs, "jobs", 0, "the number of scripts to run in parallel (default: the number of CPUs)")
+	flag.IntVar(&cfg.jobs, "j", 0, "the number of scripts to run in parallel (default: the number of CPUs)")
+	flag.BoolVar(&cfg.keepGoing, "keep-going", false, "keep running the tasks that don't depend on a task that failed")
+	flag.BoolVar(&cfg.keepGoing, "k", false, "keep running the tasks that don't depend on a task that failed")
+	flag.BoolVar(&cfg.noSummary, "no-summary", false, "don't print a summary of the tasks after a run of more than one task")
+	flag.StringVar(&cfg.output, "output", outputPrefixed, "how the output of tasks is shown, prefixed or grouped")
+	flag.StringVar(&cfg.logFormat, "log-format", logFormatText, "text, or json to write the events of the run as JSON lines, e.g. json=events.jsonl")
+	flag.DurationVar(&cfg.timeout, "timeout", 0, "stop the run if it takes longer than a duration such as 10m, including dependencies")
+	flag.DurationVar(&cfg.gracePeriod, "grace-period", 2*time.Second, "how long a script is given to exit after xc is interrupted, before it is killed")
+	flag.StringVar(&cfg.host, "host", "", "run the scripts of the tasks on a host over ssh, e.g. deploy@example.com")
+	flag.StringVar(&cfg.logDir, "log-dir", "", "also write the output of each script to a file in a directory, e.g. .xc/logs")
+	flag.BoolVar(&cfg.strictEnv, "strict-env", false, "fail if the Directory, Requires or Env of a task refers to a variable that isn't defined")
+	flag.StringVar(&cfg.dir, "dir", "", "run the task in a directory instead of its declared directory, relative to the current directory")
+
+	flag.StringVar(&cfg.tag, "tag", "", "only list or run tasks with a tag")
+
+	flag.StringVar(&cfg.profile, "profile", os.Getenv("XC_PROFILE"), "specify a config profile to apply")
+
 	flag.Parse()
 	return cfg
 }
 
+// parse parses the task file, or searches for one from the current directory up if filename is empty.
+// It returns the path of the task file, which is README.md in the current directory if none is found.
 func parse(filename, heading string) (models.Tasks, string, error) {
 	if filename != "" {
-		return tryParse(filename, heading)
+		path := filepath.Clean(filepath.FromSlash(filename))
+		tasks, _, err := tryParse(path, heading)
+		return tasks, path, err
 	}
 	curr, err := filepath.Abs(filepath.Dir("."))
 	if err != nil {
-		return nil, "", fmt.Errorf("error getting current directory: %w", err)
+		return nil, "README.md", i18n.Errorf("error getting current directory: %w", err)
 	}
-	return searchUpForFile(curr, heading)
+	tasks, path, err := searchUpForFile(curr, heading)
+	if err != nil {
+		return nil, "README.md", err
+	}
+	return tasks, path, nil
 }
 
+// searchUpForFile returns the tasks of the first task file in curr, or the closest parent of it,
+// of the files named by the config of each directory.
 func searchUpForFile(curr, heading string) (models.Tasks, string, error) {
-	rm := filepath.Join(curr, "README.md")
-	tasks, directory, err := tryParse(rm, heading)
-	if err == nil {
-		return tasks, directory, nil
+	// Errors in the config are reported when it is loaded for the tasks.
+	c, _ := loadDirConfig(curr)
+	var found []string
+	for _, name := range c.TaskFiles.Files() {
+		path := filepath.Join(curr, filepath.FromSlash(name))
+		tasks, _, err := tryParse(path, heading)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, parser.ErrNoTasksHeading) {
+			return nil, "", err
+		}
+		if err != nil {
+			continue
+		}
+		if !c.TaskFiles.Merge {
+			return tasks, path, nil
+		}
+		found = append(found, path)
 	}
-	if err != nil && !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, parser.ErrNoTasksHeading) {
-		return nil, "", err
+	if len(found) > 0 {
+		return mergeFiles(found, heading)
 	}
 	git := filepath.Join(curr, ".git")
-	_, err = os.Stat(git)
+	_, err := os.Stat(git)
 	if err == nil {
 		return nil, "", ErrNoMarkdownFile
 	}
 	next := filepath.Dir(curr)
-	if strings.HasSuffix(next, string([]rune{filepath.Separator})) {
+	// The root of a drive or UNC share is its own parent.
+	if next == curr || strings.HasSuffix(next, string([]rune{filepath.Separator})) {
 		return nil, "", ErrNoMarkdownFile
 	}
 	return searchUpForFile(next, heading)
 }
 
-func tryParse(path, heading string) (models.Tasks, string, error) {
+// mergeFiles parses the first of files that is closest to the directory they were found in,
+// with the rest of them added as discovered files.
+func mergeFiles(files []string, heading string) (models.Tasks, string, error) {
+	main := files[0]
+	for _, f := range files {
+		if strings.Count(f, string(filepath.Separator)) < strings.Count(main, string(filepath.Separator)) {
+			main = f
+		}
+	}
+	var others []string
+	for _, f := range files {
+		if f == main {
+			continue
+		}
+		rel, err := filepath.Rel(filepath.Dir(main), f)
+		if err != nil {
+			return nil, "", err
+		}
+		others = append(others, filepath.ToSlash(rel))
+	}
+	tasks, _, err := tryParse(main, heading, others...)
+	return tasks, main, err
+}
+
+// tryParse parses the task file at path, with the files it discovers and the given files relative to it.
+func tryParse(path, heading string, files ...string) (models.Tasks, string, error) {
 	directory := filepath.Dir(path)
-	b, err := os.Open(path)
+	if _, err := os.Stat(path); err != nil {
+		return nil, "", i18n.Errorf("xc error opening file: %w", err)
+	}
+	// Errors in the config are reported when it is loaded for the tasks.
+	c, _ := loadDirConfig(directory)
+	tasks, err := parser.ParseFile(path, heading, append(files, c.Discover...)...)
+	if err != nil {
+		return nil, "", parseError{i18n.Errorf("xc parse error: %w", err)}
+	}
+	return tasks, directory, nil
+}
+
+// loadedConfig is the config of a directory and the error loading it, see loadDirConfig.
+type loadedConfig struct {
+	config config.Config
+	err    error
+}
+
+// loadedConfigs holds the config of each directory that has been loaded, by absolute path.
+var loadedConfigs = map[string]loadedConfig{}
+
+// loadDirConfig returns the config of dir, which is only loaded the first time,
+// as finding and parsing the task file needs it more than once.
+func loadDirConfig(dir string) (config.Config, error) {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	l, ok := loadedConfigs[dir]
+	if !ok {
+		l.config, l.err = config.Load(dir)
+		loadedConfigs[dir] = l
+	}
+	return l.config, l.err
+}
+
+func loadConfig(tasks models.Tasks, dir, profile string) (models.Tasks, config.Config, error) {
+	c, err := loadDirConfig(dir)
 	if err != nil {
-		return nil, "", fmt.Errorf("xc error opening file: %w", err)
+		return nil, c, parseError{i18n.Errorf("xc config error: %w", err)}
 	}
-	p, err := parser.NewParser(b, heading)
+	p, err := applyProfile(c, profile)
 	if err != nil {
-		return nil, "", fmt.Errorf("xc parse error: %w", err)
+		return nil, c, parseError{i18n.Errorf("xc config error: %w", err)}
 	}
-	tasks, err := p.Parse()
+	tasks, err = p.Apply(tasks, dir)
 	if err != nil {
-		return nil, "", fmt.Errorf("xc parse error: %w", err)
+		return nil, c, parseError{i18n.Errorf("xc config error: %w", err)}
 	}
-	return tasks, directory, nil
+	tasks, err = c.Apply(tasks)
+	if err != nil {
+		return nil, c, parseError{i18n.Errorf("xc config error: %w", err)}
+	}
+	return tasks, c, nil
+}
+
+// applyProfile sets the flags of a profile, unless they were set on the command line.
+// The flags used to locate the task file have already been used at this point.
+func applyProfile(c config.Config, name string) (config.Profile, error) {
+	p, err := c.Profile(name)
+	if err != nil {
+		return p, err
+	}
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for name, value := range p.Flags {
+		if set[name] {
+			continue
+		}
+		if err := flag.Set(name, value); err != nil {
+			return p, i18n.Errorf("profile flag %s: %w", name, err)
+		}
+	}
+	return p, nil
 }
 
 func printTasks(tasks models.Tasks, short bool) {
@@ -133,47 +321,91 @@ func printTasks(tasks models.Tasks, short bool) {
 	}
 	maxLen := 0
 	for _, n := range tasks {
-		if len(n.Name) > maxLen {
-			maxLen = len(n.Name)
+		if len(taskLabel(n)) > maxLen {
+			maxLen = len(taskLabel(n))
 		}
 	}
+	var namespace string
 	for _, n := range tasks {
+		if ns := n.Namespace(); ns != namespace && !short {
+			fmt.Printf("  %s\n", nameStyle.Render(ns+":"))
+			namespace = ns
+		}
 		print(n, maxLen)
 	}
 }
 
-func displayAndRunTasks(ctx context.Context, tasks models.Tasks, dir string, cfg config) error {
-	if cfg.noTTY || cfg.short {
-		printTasks(tasks, cfg.short)
+// taskLabel returns the name of a task followed by its aliases, e.g. `build (b, compile)`.
+func taskLabel(t models.Task) string {
+	if len(t.Aliases) == 0 {
+		return t.Name
+	}
+	return t.Name + " (" + strings.Join(t.Aliases, ", ") + ")"
+}
+
+func displayAndRunTasks(ctx context.Context, p project) error {
+	if p.cfg.noTTY || p.cfg.short {
+		tasks, err := listedTasks(p)
+		if err != nil {
+			return err
+		}
+		printTasks(tasks, p.cfg.short)
 		return nil
 	}
-	return interactivePicker(ctx, tasks, dir)
+	return interactivePicker(ctx, p, "")
+}
+
+// listedTasks returns the tasks that are listed and can be picked, the visible tasks with the -tag tag if it is set.
+func listedTasks(p project) (models.Tasks, error) {
+	tasks := p.tasks.Visible().Supported(runtime.GOOS, runtime.GOARCH).Grouped()
+	if p.cfg.tag == "" {
+		return tasks, nil
+	}
+	tasks = tasks.Tagged(p.cfg.tag)
+	if len(tasks) == 0 {
+		return nil, i18n.Errorf("no tasks are tagged %s", p.cfg.tag)
+	}
+	return tasks, nil
 }
 
 func printTask(task models.Task, maxLen int) {
-	padLen := maxLen - len(task.Name)
+	padLen := maxLen - len(taskLabel(task))
 	pad := strings.Repeat(" ", padLen)
 	desc := task.Description
 	if len(task.DependsOn) > 0 {
 		desc = append(desc, fmt.Sprintf("Requires:  %s", strings.Join(task.DependsOn, ", ")))
 	}
-	if len(desc) == 0 {
+	if len(task.Finally) > 0 {
+		desc = append(desc, fmt.Sprintf("Finally:  %s", strings.Join(task.Finally, ", ")))
+	}
+	if len(desc) == 0 && task.Script != "" {
 		desc = strings.Split(task.Script, "\n")
 	}
-	fmt.Printf("    %s%s  %s\n", task.Name, pad, desc[0])
+	if len(task.Inputs) > 0 {
+		desc = append(desc, fmt.Sprintf("Usage:  xc %s %s", task.Command(), task.Signature()))
+	}
+	if task.Deprecated != "" {
+		desc = append(desc, fmt.Sprintf("Deprecated:  %s", task.Deprecated))
+	}
+	if task.File != "" {
+		desc = append(desc, i18n.Sprintf("File:  %s", task.File))
+	}
+	if len(desc) == 0 {
+		desc = []string{""}
+	}
+	fmt.Printf("    %s%s  %s\n", nameStyle.Render(taskLabel(task)), pad, descriptionStyle.Render(desc[0]))
 	for _, d := range desc[1:] {
-		fmt.Printf("    %s  %s\n", strings.Repeat(" ", maxLen), d)
+		fmt.Printf("    %s  %s\n", strings.Repeat(" ", maxLen), descriptionStyle.Render(d))
 	}
 }
 
 func runMain() error {
-	ctx, cancel := context.WithCancel(context.Background())
-	// handle SIGINT (control+c)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	// handle SIGINT (control+c) and SIGTERM, the signal is forwarded to the scripts that are running.
 	go func() {
 		c := make(chan os.Signal, 1)
-		signal.Notify(c, os.Interrupt)
-		<-c
-		cancel()
+		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+		cancel(run.Interrupted{Signal: <-c})
 	}()
 	cfg := flags()
 	if cfg.uncomplete {
@@ -182,11 +414,47 @@ func runMain() error {
 	if cfg.complete {
 		return install.Install("xc")
 	}
-	tasks, dir, err := parse(cfg.filename, cfg.heading)
+	start := time.Now()
+	usage := "list"
+	tasks, file, err := parse(cfg.filename, cfg.heading)
+	var dir string
+	if err == nil {
+		dir = filepath.Dir(file)
+	}
+	var conf config.Config
+	if err == nil {
+		tasks, conf, err = loadConfig(tasks, dir, cfg.profile)
+	}
+	var retention history.Retention
+	if err == nil {
+		retention, err = historyRetention(conf.History)
+	}
+	var logPolicy logs.Policy
+	if err == nil {
+		logPolicy, err = logRetention(conf.Logs)
+	}
+	if err == nil {
+		err = applyResources(conf.Resources)
+		languageInterpreters = conf.Languages
+	}
+	var remote cache.RemoteConfig
+	if err == nil {
+		remote, err = cacheRemote(conf.Cache)
+	}
+	if err == nil {
+		cfg.jobs, err = jobCount(cfg.jobs, conf.Jobs)
+	}
+	applyTheme(conf.Theme)
+	applyNotify(conf.Notify)
+	paths, pathsErr := dirs.Resolve(conf.Dirs, dir)
+	if pathsErr == nil {
+		defer bestEffort(func() error { return recordUsage(paths, usage, len(tasks), time.Since(start)) })
+	}
+	p := project{tasks: tasks, dir: dir, file: file, cfg: cfg, paths: paths, retention: retention, logPolicy: logPolicy, cacheRemote: remote}
 	completion(tasks).Complete("xc")
 	// xc -version
 	if cfg.version {
-		fmt.Printf("xc version: %s\n", getVersion())
+		i18n.Printf("xc version: %s\n", getVersion())
 		return nil
 	}
 	// xc -h / xc -help
@@ -194,31 +462,206 @@ func runMain() error {
 		flag.Usage()
 		return nil
 	}
+	tav := flag.Args()
+	// xc -i build
+	if cfg.interactive {
+		if err != nil {
+			return err
+		}
+		return interactivePicker(ctx, p, strings.Join(tav, " "))
+	}
+	// xc -last
+	if cfg.last {
+		if err != nil {
+			return err
+		}
+		if pathsErr != nil {
+			return i18n.Errorf("xc: %w", pathsErr)
+		}
+		if len(tav) > 0 {
+			return errors.New(i18n.T("usage: xc -last"))
+		}
+		usage = "rerun"
+		return rerunCommand(ctx, p, nil)
+	}
+	// xc telemetry on
+	if c, ok := lookupCommand(tav, tasks); ok && (err == nil || !c.needsTasks) {
+		usage = tav[0]
+		if pathsErr != nil {
+			return i18n.Errorf("xc: %w", pathsErr)
+		}
+		return c.run(ctx, p, tav[1:])
+	}
 	if err != nil {
 		return err
 	}
-	tav := flag.Args()
 	// xc
 	if len(tav) == 0 {
-		return displayAndRunTasks(ctx, tasks, dir, cfg)
+		return displayAndRunTasks(ctx, p)
 	}
 	ta, ok := tasks.Get(tav[0])
 	if !ok {
-		fmt.Printf("task \"%s\" not found\n", tav[0])
+		return run.TaskNotFoundError{Name: tav[0]}
+	}
+	if cfg.tag != "" && !ta.HasTag(cfg.tag) {
+		return i18n.Errorf("task %s is not tagged %s", ta.Name, cfg.tag)
+	}
+	if cfg.dir != "" {
+		if cfg.dir, err = checkDir(cfg.dir); err != nil {
+			return i18n.Errorf("xc: %w", err)
+		}
 	}
 	// xc -display task1
 	if cfg.display {
+		usage = "display"
 		ta.Display(os.Stdout)
 		return nil
 	}
-	// xc task1
-	runner, err := run.NewRunner(tasks, dir)
+	// xc -dry-run task1
+	if cfg.dryRun {
+		usage = "dry-run"
+		return dryRun(p, tav)
+	}
+	// xc -watch task1
+	if cfg.watch {
+		usage = "watch"
+		mode, err := watchMode(cfg)
+		if err != nil {
+			return i18n.Errorf("xc: %w", err)
+		}
+		return watchTask(ctx, tasks, dir, tav[0], tav[1:], mode,
+			run.WithJobs(cfg.jobs), run.WithGracePeriod(cfg.gracePeriod), run.WithHost(cfg.host), dirOption(p, tav[0]), run.WithLogDir(cfg.logDir), strictEnvOption(p), forceDepsOption(p))
+	}
+	// xc task1 --then task2 --on-failure task3
+	usage = "run"
+	return runTask(ctx, p, tav)
+}
+
+// The values of -output.
+const (
+	// outputPrefixed prefixes each line of output with the name of its task as it is written.
+	outputPrefixed = "prefixed"
+	// outputGrouped writes the output of each task at once when it finishes, see run.WithGroupedOutput.
+	outputGrouped = "grouped"
+)
+
+// reportOutput is the number of bytes of the output of each task that is included in a report.
+const reportOutput = 64 * 1024
+
+// runTask runs a task given its name followed by its arguments, and records the run in the history.
+func runTask(ctx context.Context, p project, args []string) error {
+	inputs, c, err := splitChain(args[1:])
+	if err != nil {
+		return i18n.Errorf("xc: %w", err)
+	}
+	if err := c.validate(p.tasks); err != nil {
+		return i18n.Errorf("xc: %w", err)
+	}
+	opts := append(runnerOptions(), run.WithJobs(p.cfg.jobs), run.WithGracePeriod(p.cfg.gracePeriod), run.WithHost(p.cfg.host),
+		dirOption(p, args[0]), strictEnvOption(p), forceDepsOption(p))
+	switch p.cfg.output {
+	case outputPrefixed:
+	case outputGrouped:
+		opts = append(opts, run.WithGroupedOutput())
+	default:
+		return i18n.Errorf("xc: -output should be one of (%s, %s), not %s", outputPrefixed, outputGrouped, p.cfg.output)
+	}
+	if p.cfg.keepGoing {
+		opts = append(opts, run.WithKeepGoing())
+	}
+	if p.cfg.logDir != "" {
+		// The log files are pruned with the retention policy of the logs directory.
+		p.paths.Logs = p.cfg.logDir
+		opts = append(opts, run.WithLogDir(p.cfg.logDir))
+	}
+	events, closeLog, err := eventLog(p.cfg.logFormat)
+	if err != nil {
+		return i18n.Errorf("xc: %w", err)
+	}
+	defer closeLog()
+	if events != nil {
+		opts = append(opts, run.WithEvents(events))
+	}
+	if p.cfg.force {
+		opts = append(opts, run.WithForce())
+	} else {
+		opts = append(opts, run.WithThrottler(historyThrottler(p)))
+	}
+	// The files of -each aren't part of the cache key, so those runs aren't cached.
+	if !p.cfg.noCache && p.cfg.each == "" {
+		c, err := taskCache(p)
+		if err != nil {
+			return i18n.Errorf("xc: %w", err)
+		}
+		opts = append(opts, run.WithCache(c))
+	}
+	var rep *report.Spec
+	if p.cfg.report != "" {
+		s, err := report.ParseSpec(p.cfg.report)
+		if err != nil {
+			return i18n.Errorf("xc: %w", err)
+		}
+		rep = &s
+		opts = append(opts, run.WithOutputCapture(reportOutput))
+	}
+	runner, err := run.NewRunner(p.tasks, p.dir, opts...)
 	if err != nil {
-		return fmt.Errorf("xc parse error: %w", err)
+		return parseError{i18n.Errorf("xc parse error: %w", err)}
+	}
+	stopProgress := func() {}
+	if task, ok := p.tasks.Get(args[0]); ok {
+		if avg, ok := averageDurations(p)[task.Name]; ok {
+			stopProgress = showProgress(os.Stderr, task.Name, avg)
+		}
+	}
+	if p.cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.cfg.timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	first := func(ctx context.Context) error {
+		return runner.Run(ctx, args[0], inputs)
+	}
+	var summary []run.Result
+	names := multipleTasks(p.tasks, args[0], inputs)
+	switch {
+	case p.cfg.each != "":
+		first = func(ctx context.Context) error {
+			return runner.RunEach(ctx, args[0], inputs, p.cfg.each, p.cfg.jobs)
+		}
+	case names != nil:
+		first = func(ctx context.Context) (err error) {
+			summary, err = runner.RunAll(ctx, names)
+			return err
+		}
+	}
+	err = runChain(ctx, &runner, first, c)
+	stopProgress()
+	var summarized bool
+	if !p.cfg.noSummary {
+		summarized = printSummary(os.Stderr, runner.Results(), summary)
+	}
+	bestEffort(func() error { return recordRun(p, args, start, err, runner.Results()) })
+	bestEffort(func() error { return pruneLogs(p) })
+	printProblems(os.Stderr, runner.Problems())
+	if p.cfg.keepGoing && !summarized {
+		printFailures(os.Stderr, runner.Results())
+	}
+	if rep != nil {
+		if reportErr := rep.Write(displayPath(p.file), runner.Results()); reportErr != nil {
+			return i18n.Errorf("xc: failed to write report: %w", reportErr)
+		}
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return i18n.Errorf("xc: %s timed out after %s: %w", args[0], p.cfg.timeout, ctx.Err())
+	}
+	var interrupted run.Interrupted
+	if err != nil && errors.As(context.Cause(ctx), &interrupted) {
+		return i18n.Errorf("xc: %s stopped, %w: %w", args[0], interrupted, err)
 	}
-	err = runner.Run(ctx, tav[0], tav[1:])
 	if err != nil {
-		return fmt.Errorf("xc: %w", err)
+		return i18n.Errorf("xc: %w", err)
 	}
 	return nil
 }
@@ -244,29 +687,133 @@ func getVersion() string {
 func completion(tasks models.Tasks) *complete.Command {
 	return &complete.Command{
 		Flags: map[string]complete.Predictor{
-			"version": predict.Nothing,
-			"V":       predict.Nothing,
-			"h":       predict.Nothing,
-			"help":    predict.Nothing,
-			"f":       predict.Files("*.md"),
-			"file":    predict.Files("*.md"),
-			"s":       predict.Nothing,
-			"short":   predict.Nothing,
-			"d":       predict.Nothing,
-			"display": predict.Nothing,
-			"H":       predict.Nothing,
-			"heading": predict.Nothing,
+			"version":       predict.Nothing,
+			"V":             predict.Nothing,
+			"h":             predict.Nothing,
+			"help":          predict.Nothing,
+			"f":             predict.Files("*.md"),
+			"file":          predict.Files("*.md"),
+			"s":             predict.Nothing,
+			"short":         predict.Nothing,
+			"d":             predict.Nothing,
+			"display":       predict.Nothing,
+			"H":             predict.Nothing,
+			"heading":       predict.Nothing,
+			"profile":       predict.Something,
+			"i":             predict.Nothing,
+			"interactive":   predict.Nothing,
+			"last":          predict.Nothing,
+			"watch":         predict.Nothing,
+			"watch-restart": predict.Nothing,
+			"watch-queue":   predict.Nothing,
+			"watch-ignore":  predict.Nothing,
+			"force":         predict.Nothing,
+			"no-cache":      predict.Nothing,
+			"force-deps":    predict.Nothing,
+			"dry-run":       predict.Nothing,
+			"keep-going":    predict.Nothing,
+			"k":             predict.Nothing,
+			"no-summary":    predict.Nothing,
+			"timeout":       predict.Something,
+			"grace-period":  predict.Something,
+			"host":          predict.Something,
+			"dir":           predict.Dirs("*"),
+			"strict-env":    predict.Nothing,
+			"output":        predict.Set{outputPrefixed, outputGrouped},
+			"log-format":    predict.Set{logFormatText, logFormatJSON},
+			"log-dir":       predict.Dirs("*"),
+			"tag":           predict.Set(tagNames(tasks)),
+			"report":        predict.Something,
+			"each":          predict.Something,
+			"jobs":          predict.Something,
+			"j":             predict.Something,
 		},
 		Sub: completeTasks(tasks),
 	}
 }
 
+// tagNames returns the tags of tasks, without duplicates.
+func tagNames(tasks models.Tasks) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, t := range tasks {
+		for _, tag := range t.Tags {
+			if !seen[strings.ToLower(tag)] {
+				seen[strings.ToLower(tag)] = true
+				names = append(names, tag)
+			}
+		}
+	}
+	return names
+}
+
 func completeTasks(tasks models.Tasks) map[string]*complete.Command {
 	result := map[string]*complete.Command{}
 	for _, t := range tasks {
+		var choices []string
+		for _, n := range t.Inputs {
+			choices = append(choices, t.Input(n).Choices...)
+		}
+		var args complete.Predictor = predict.Something
+		if len(choices) > 0 {
+			args = predict.Set(choices)
+		}
 		result[t.Name] = &complete.Command{
-			Args: predict.Something,
+			Args: args,
+		}
+		// A name that is awkward to type can be completed in the form it is invoked with.
+		result[t.Command()] = result[t.Name]
+		for _, a := range t.Aliases {
+			result[a] = result[t.Name]
 		}
 	}
 	return result
 }
+
+// checkDir returns the absolute path of the directory of -dir, or an error if it isn't a directory.
+func checkDir(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", i18n.Errorf("directory %s doesn't exist", dir)
+	}
+	if !info.IsDir() {
+		return "", i18n.Errorf("%s is not a directory", dir)
+	}
+	return abs, nil
+}
+
+// dirOption returns the Option that runs the named task in the directory of -dir, it does nothing if -dir isn't set.
+func dirOption(p project, name string) run.Option {
+	task, ok := p.tasks.Get(name)
+	if p.cfg.dir == "" || !ok {
+		return func(*run.Runner) {}
+	}
+	return run.WithDir(task.Name, p.cfg.dir)
+}
+
+// strictEnvOption returns run.WithStrictEnv if -strict-env is set, it does nothing otherwise.
+func strictEnvOption(p project) run.Option {
+	if !p.cfg.strictEnv {
+		return func(*run.Runner) {}
+	}
+	return run.WithStrictEnv()
+}
+
+// forceDepsOption returns run.WithForceDeps if -force-deps is set, it does nothing otherwise.
+func forceDepsOption(p project) run.Option {
+	if !p.cfg.forceDeps {
+		return func(*run.Runner) {}
+	}
+	return run.WithForceDeps()
+}
+
+// bestEffort runs f, which does something around a run rather than running tasks, such as recording it in the history.
+// That must never affect the outcome of the run, so an error or a panic of f is ignored.
+func bestEffort(f func() error) {
+	defer func() { _ = recover() }()
+	_ = f()
+}
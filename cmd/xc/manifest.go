@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/manifest"
+)
+
+func manifestCommand(_ context.Context, p project, args []string) error {
+	if len(args) > 0 {
+		return errors.New(i18n.T("usage: xc manifest"))
+	}
+	m := manifest.New(p.tasks, displayPath(p.file), p.cfg.heading)
+	if err := m.Write(os.Stdout); err != nil {
+		return i18n.Errorf("xc manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/parser"
+	"golang.org/x/term"
+)
+
+// interpreters are offered by xc new, the first runs the script with the builtin shell.
+var interpreters = []string{"sh", "bash", "python3", "node", "pwsh"}
+
+// Steps of the new task form.
+const (
+	stepName = iota
+	stepDescription
+	stepTags
+	stepRequires
+	stepInterpreter
+	stepScript
+	stepDone
+)
+
+type newTaskForm struct {
+	tasks       models.Tasks
+	step        int
+	name        textinput.Model
+	description textinput.Model
+	// tags holds the tags of the task, separated by commas.
+	tags textinput.Model
+	// requires holds the selected tasks, by index into tasks.
+	requires map[int]bool
+	cursor   int
+	// interpreter is the index of the selected interpreter.
+	interpreter int
+	script      textarea.Model
+	err         string
+	quitting    bool
+}
+
+func newNewTaskForm(tasks models.Tasks) newTaskForm {
+	f := newTaskForm{
+		tasks:       tasks,
+		name:        textinput.New(),
+		description: textinput.New(),
+		tags:        textinput.New(),
+		requires:    map[int]bool{},
+		script:      textarea.New(),
+	}
+	f.name.Prompt, f.description.Prompt, f.tags.Prompt = "", "", ""
+	f.tags.Placeholder = "ci, release"
+	f.script.Placeholder = "echo hello"
+	f.script.ShowLineNumbers = false
+	f.name.Focus()
+	return f
+}
+
+func (f newTaskForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// task returns the task described by the form.
+func (f newTaskForm) task() models.Task {
+	t := models.Task{Name: strings.TrimSpace(f.name.Value())}
+	if d := strings.TrimSpace(f.description.Value()); d != "" {
+		t.Description = []string{d}
+	}
+	for _, tag := range strings.Split(f.tags.Value(), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			t.Tags = append(t.Tags, tag)
+		}
+	}
+	for i, task := range f.tasks {
+		if f.requires[i] {
+			t.DependsOn = append(t.DependsOn, task.Name)
+		}
+	}
+	if script := strings.TrimSpace(f.script.Value()); script != "" {
+		if f.interpreter > 0 {
+			script = "#!/usr/bin/env " + interpreters[f.interpreter] + "\n" + script
+		}
+		t.Script = script + "\n"
+	}
+	return t
+}
+
+// validate returns the error to show for the current step, if any.
+func (f newTaskForm) validate() string {
+	switch f.step {
+	case stepName:
+		name := strings.TrimSpace(f.name.Value())
+		if name == "" {
+			return i18n.T("a task needs a name")
+		}
+		if _, ok := f.tasks.Get(name); ok {
+			return i18n.Sprintf("task %s already exists", name)
+		}
+	case stepScript:
+		t := f.task()
+		if t.Script == "" && len(t.DependsOn) == 0 {
+			return i18n.T("a task needs a script or required tasks")
+		}
+	}
+	return ""
+}
+
+func (f newTaskForm) next() (newTaskForm, tea.Cmd) {
+	if f.err = f.validate(); f.err != "" {
+		return f, nil
+	}
+	f.name.Blur()
+	f.description.Blur()
+	f.tags.Blur()
+	f.step++
+	if f.step == stepRequires && len(f.tasks) == 0 {
+		f.step++
+	}
+	switch f.step {
+	case stepDescription:
+		f.description.Focus()
+	case stepTags:
+		f.tags.Focus()
+	case stepScript:
+		return f, f.script.Focus()
+	case stepDone:
+		f.quitting = true
+		return f, tea.Quit
+	}
+	return f, nil
+}
+
+func (f newTaskForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			f.quitting = true
+			return f, tea.Quit
+		case "ctrl+d":
+			if f.step == stepScript {
+				return f.next()
+			}
+		case "enter":
+			if f.step != stepScript {
+				return f.next()
+			}
+		case "up", "k":
+			if f.step == stepRequires && f.cursor > 0 {
+				f.cursor--
+			}
+			if f.step == stepInterpreter && f.interpreter > 0 {
+				f.interpreter--
+			}
+		case "down", "j":
+			if f.step == stepRequires && f.cursor < len(f.tasks)-1 {
+				f.cursor++
+			}
+			if f.step == stepInterpreter && f.interpreter < len(interpreters)-1 {
+				f.interpreter++
+			}
+		case " ":
+			if f.step == stepRequires {
+				f.requires[f.cursor] = !f.requires[f.cursor]
+				return f, nil
+			}
+		}
+	}
+	var cmd tea.Cmd
+	switch f.step {
+	case stepName:
+		f.name, cmd = f.name.Update(msg)
+	case stepDescription:
+		f.description, cmd = f.description.Update(msg)
+	case stepTags:
+		f.tags, cmd = f.tags.Update(msg)
+	case stepScript:
+		f.script, cmd = f.script.Update(msg)
+	}
+	return f, cmd
+}
+
+func (f newTaskForm) View() string {
+	if f.quitting {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s\n\n", titleStyle.Render(i18n.T("xc: New task")))
+	field := func(step int, label, value string) {
+		switch {
+		case step < f.step:
+			fmt.Fprintf(&b, "%s\n", itemStyle.Render(label+": "+value))
+		case step == f.step:
+			fmt.Fprintf(&b, "%s\n", selectedItemStyle.Render("> "+label+": "+value))
+		}
+	}
+	field(stepName, i18n.T("Name"), f.name.View())
+	field(stepDescription, i18n.T("Description"), f.description.View())
+	field(stepTags, i18n.T("Tags"), f.tags.View())
+	if len(f.tasks) > 0 {
+		t := f.task()
+		field(stepRequires, i18n.T("Requires"), strings.Join(t.DependsOn, ", "))
+		if f.step == stepRequires {
+			for i, task := range f.tasks {
+				check := "[ ]"
+				if f.requires[i] {
+					check = "[x]"
+				}
+				line := "    " + check + " " + task.Name
+				if i == f.cursor {
+					fmt.Fprintf(&b, "%s\n", selectedItemStyle.Render(line))
+					continue
+				}
+				fmt.Fprintf(&b, "%s\n", itemStyle.Render(line))
+			}
+		}
+	}
+	field(stepInterpreter, i18n.T("Interpreter"), interpreters[f.interpreter])
+	if f.step == stepInterpreter {
+		for i, in := range interpreters {
+			if i == f.interpreter {
+				fmt.Fprintf(&b, "%s\n", selectedItemStyle.Render("    > "+in))
+				continue
+			}
+			fmt.Fprintf(&b, "%s\n", itemStyle.Render("    "+in))
+		}
+	}
+	if f.step == stepScript {
+		fmt.Fprintf(&b, "%s\n%s\n", selectedItemStyle.Render("> "+i18n.T("Script")+":"), f.script.View())
+	}
+	if f.err != "" {
+		fmt.Fprintf(&b, "\n%s\n", itemStyle.Render(f.err))
+	}
+	help := i18n.T("enter: next • esc: cancel")
+	switch f.step {
+	case stepRequires:
+		help = i18n.T("space: select • enter: next • esc: cancel")
+	case stepScript:
+		help = i18n.T("ctrl+d: save • esc: cancel")
+	}
+	fmt.Fprintf(&b, "\n%s\n", helpStyle.Render(help))
+	return b.String()
+}
+
+func newCommand(_ context.Context, p project, args []string) error {
+	if len(args) > 0 {
+		return errors.New(i18n.T("usage: xc new"))
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return errors.New(i18n.T("xc new: requires a terminal"))
+	}
+	m, err := tea.NewProgram(newNewTaskForm(p.tasks)).Run()
+	if err != nil {
+		return i18n.Errorf("xc new: %w", err)
+	}
+	f := m.(newTaskForm)
+	if f.step != stepDone {
+		return nil
+	}
+	task := f.task()
+	if err := addTask(p.file, p.cfg.heading, task); err != nil {
+		return i18n.Errorf("xc new: %w", err)
+	}
+	i18n.Printf("xc: added task %s to %s\n", task.Name, displayPath(p.file))
+	return nil
+}
+
+// addTask writes task to the end of the tasks section of a task file.
+// The file, and the tasks heading, are created if they don't exist.
+func addTask(file, heading string, task models.Task) error {
+	src, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	out, err := parser.AppendTask(src, heading, task)
+	if errors.Is(err, parser.ErrNoTasksHeading) {
+		if len(src) > 0 {
+			src = append([]byte(strings.TrimRight(string(src), "\n")), "\n\n"...)
+		}
+		src = append(src, "## "+heading+"\n"...)
+		out, err = parser.AppendTask(src, heading, task)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, out, 0o644)
+}
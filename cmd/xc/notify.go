@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/joerdav/xc/config"
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/notify"
+	"github.com/joerdav/xc/run"
+)
+
+// notifyTimeout is how long a notification can take before it is abandoned.
+const notifyTimeout = 10 * time.Second
+
+var notifySender notify.Sender
+
+// applyNotify configures the sender of the notifications of tasks with Notify targets.
+// XC_SLACK_WEBHOOK takes precedence over the config, so the secret can be kept out of the repository.
+func applyNotify(c config.Notify) {
+	notifySender.SlackWebhook = c.SlackWebhook
+	if w := os.Getenv("XC_SLACK_WEBHOOK"); w != "" {
+		notifySender.SlackWebhook = w
+	}
+}
+
+// notifyTask sends a notification to each Notify target of a task that finished.
+// A target that can't be notified is reported on stderr, and the other targets are still notified.
+func notifyTask(task models.Task, r run.Result) {
+	m := notify.Message{
+		Title: i18n.Sprintf("xc: %s", task.Name),
+		Text:  i18n.Sprintf("succeeded in %s", formatDuration(r.Duration)),
+	}
+	if r.Err != nil {
+		m.Failed = true
+		m.Text = i18n.Sprintf("failed after %s: %s", formatDuration(r.Duration), r.Err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	for _, n := range task.Notify {
+		t, err := notify.ParseTarget(n)
+		if err == nil {
+			err = notifySender.Send(ctx, t, m)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("xc: failed to notify %s: %s", n, err))
+		}
+	}
+}
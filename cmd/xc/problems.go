@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/problem"
+	"github.com/joerdav/xc/run"
+)
+
+// printProblems writes a summary of the problems found in the output of a run.
+func printProblems(w io.Writer, problems []problem.Problem) {
+	if len(problems) == 0 {
+		return
+	}
+	errs, warnings := problem.Count(problems)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, i18n.Sprintf("xc: found %s and %s", i18n.Plural(errs, "%d error", "%d errors"), i18n.Plural(warnings, "%d warning", "%d warnings")))
+	for _, p := range problems {
+		loc := p.Location()
+		if loc != "" {
+			loc += ": "
+		}
+		fmt.Fprintf(w, "  %s %s%s: %s\n", nameStyle.Render(p.Task), loc, p.Severity, p.Message)
+	}
+}
+
+// printFailures prints the tasks that failed, so that failures aren't lost in the output of the tasks that kept going.
+func printFailures(w io.Writer, results []run.Result) {
+	var failed []run.Result
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	if len(failed) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, i18n.Sprintf("xc: %s failed", i18n.Plural(len(failed), "%d task", "%d tasks")))
+	for _, r := range failed {
+		fmt.Fprintf(w, "  %s: %v\n", nameStyle.Render(r.Task), r.Err)
+	}
+}
+
+// printSummary prints a table of the tasks that ran, with how they went, how long they took and the exit codes of the ones that failed.
+// It is printed when more than one task ran, so that the result of each isn't lost in the output of the others.
+// notStarted are the tasks of `xc lint test build` that weren't started because another failed.
+func printSummary(w io.Writer, results, notStarted []run.Result) bool {
+	for _, r := range notStarted {
+		if errors.Is(r.Err, run.ErrNotStarted) {
+			results = append(results, r)
+		}
+	}
+	if len(results) < 2 {
+		return false
+	}
+	rows := make([][3]string, len(results))
+	var nameWidth, statusWidth int
+	for i, r := range results {
+		switch {
+		case errors.Is(r.Err, run.ErrNotStarted):
+			rows[i] = [3]string{r.Task, i18n.T("not started")}
+		case r.Err != nil:
+			rows[i] = [3]string{r.Task, i18n.T("failed"), formatDuration(r.Duration) + "  " + i18n.Sprintf("exit code %d", run.ExitCode(r.Err))}
+		case r.Skipped:
+			rows[i] = [3]string{r.Task, i18n.T("skipped"), r.SkipReason}
+		default:
+			rows[i] = [3]string{r.Task, i18n.T("succeeded"), formatDuration(r.Duration)}
+		}
+		if len(r.Task) > nameWidth {
+			nameWidth = len(r.Task)
+		}
+		if n := utf8.RuneCountInString(rows[i][1]); n > statusWidth {
+			statusWidth = n
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, i18n.Sprintf("xc: summary of %d tasks", len(results)))
+	for _, row := range rows {
+		line := fmt.Sprintf("  %s  %s", nameStyle.Render(fmt.Sprintf("%-*s", nameWidth, row[0])), row[1])
+		if row[2] != "" {
+			line += strings.Repeat(" ", statusWidth-utf8.RuneCountInString(row[1])) + "  " + row[2]
+		}
+		fmt.Fprintln(w, line)
+	}
+	return true
+}
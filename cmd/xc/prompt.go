@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/run"
+	"golang.org/x/term"
+)
+
+// runnerOptions returns the options of runners that run tasks for the user.
+// Missing inputs are only prompted for when stdin is a terminal, otherwise they remain an error.
+func runnerOptions() []run.Option {
+	opts := []run.Option{
+		run.WithStyles(runStyles),
+		run.WithNotifier(notifyTask),
+		run.WithResources(resourceCapacities),
+		run.WithLanguages(languageInterpreters),
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		opts = append(opts, run.WithPrompter(terminalPrompter))
+	}
+	return opts
+}
+
+// terminalPrompter asks for an input on stderr, and reads the answer from stdin.
+func terminalPrompter(task models.Task, input string, p models.Prompt) (string, error) {
+	question := p.Question
+	if c := task.Input(input).Choices; len(c) > 0 {
+		question += fmt.Sprintf(" (%s)", strings.Join(c, "|"))
+	}
+	if d := task.Input(input).Fallback(); d != "" {
+		question += fmt.Sprintf(" [%s]", d)
+	}
+	fmt.Fprintf(os.Stderr, "%s: ", question)
+	if p.Secret {
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		return string(b), err
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import "github.com/joerdav/xc/i18n"
+
+// resourceCapacities are the capacities of the resources of the project, see the Resources attribute.
+var resourceCapacities map[string]int
+
+// languageInterpreters map the languages of code blocks to the interpreters that run them, from the config.
+var languageInterpreters map[string]string
+
+// applyResources sets the capacities of the resources of the project from the config.
+func applyResources(c map[string]int) error {
+	for name, capacity := range c {
+		if capacity < 1 {
+			return i18n.Errorf("xc config error: resources %s: capacity must be at least 1, got %d", name, capacity)
+		}
+	}
+	resourceCapacities = c
+	return nil
+}
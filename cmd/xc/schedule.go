@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/run"
+	"github.com/joerdav/xc/schedule"
+)
+
+// scheduledTask is a task with a Schedule, and the next time it runs.
+type scheduledTask struct {
+	task     models.Task
+	schedule schedule.Schedule
+	next     time.Time
+}
+
+// scheduleCommand runs the tasks with a Schedule, or the named ones, whenever their schedule fires,
+// until xc is interrupted. It then waits for the tasks that are running, which are sent the signal.
+func scheduleCommand(ctx context.Context, p project, args []string) error {
+	fs := flag.NewFlagSet("schedule", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	tasks, err := scheduledTasks(p.tasks, fs.Args(), time.Now())
+	if err != nil {
+		return i18n.Errorf("xc schedule: %w", err)
+	}
+	for _, s := range tasks {
+		scheduleLog("%s is scheduled %s, next at %s", s.task.Name, s.task.Schedule, s.next.Format(time.RFC1123))
+	}
+	if p.cfg.logDir != "" {
+		p.paths.Logs = p.cfg.logDir
+	}
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		running = map[string]bool{}
+	)
+	for len(tasks) > 0 {
+		next := tasks[0].next
+		for _, s := range tasks[1:] {
+			if s.next.Before(next) {
+				next = s.next
+			}
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			mu.Lock()
+			n := len(running)
+			mu.Unlock()
+			scheduleLog("stopping, waiting for %d running tasks", n)
+			wg.Wait()
+			return nil
+		case <-timer.C:
+		}
+		now := time.Now()
+		var pending []scheduledTask
+		for _, s := range tasks {
+			if s.next.After(now) {
+				pending = append(pending, s)
+				continue
+			}
+			if s.next = s.schedule.Next(now); !s.next.IsZero() {
+				pending = append(pending, s)
+			}
+			mu.Lock()
+			busy := running[s.task.Name]
+			running[s.task.Name] = true
+			mu.Unlock()
+			if busy {
+				scheduleLog("%s is still running, skipping this run", s.task.Name)
+				continue
+			}
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+				runScheduled(ctx, p, name)
+				mu.Lock()
+				delete(running, name)
+				mu.Unlock()
+			}(s.task.Name)
+		}
+		tasks = pending
+	}
+	// No schedule fires again in the next 5 years.
+	wg.Wait()
+	return nil
+}
+
+// scheduledTasks returns the tasks in names, or every task with a Schedule if there are none, with the next time they run after now.
+func scheduledTasks(tasks models.Tasks, names []string, now time.Time) ([]scheduledTask, error) {
+	var selected models.Tasks
+	for _, name := range names {
+		t, ok := tasks.Get(name)
+		if !ok {
+			return nil, run.TaskNotFoundError{Name: name}
+		}
+		if t.Schedule == "" {
+			return nil, i18n.Errorf("task %s has no schedule", t.Name)
+		}
+		selected = append(selected, t)
+	}
+	if len(names) == 0 {
+		for _, t := range tasks {
+			if t.Schedule != "" {
+				selected = append(selected, t)
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return nil, errors.New(i18n.T("no tasks have a schedule"))
+	}
+	var scheduled []scheduledTask
+	for _, t := range selected {
+		s, err := schedule.Parse(t.Schedule)
+		if err != nil {
+			return nil, err
+		}
+		next := s.Next(now)
+		if next.IsZero() {
+			return nil, i18n.Errorf("the schedule of %s never fires", t.Name)
+		}
+		scheduled = append(scheduled, scheduledTask{task: t, schedule: s, next: next})
+	}
+	return scheduled, nil
+}
+
+// runScheduled runs a scheduled task with a new runner, so that Run: once tasks run every time, and logs how it went.
+func runScheduled(ctx context.Context, p project, name string) {
+	start := time.Now()
+	scheduleLog("running %s", name)
+	opts := append(runnerOptions(), run.WithJobs(p.cfg.jobs), run.WithGracePeriod(p.cfg.gracePeriod), run.WithLogDir(p.cfg.logDir), strictEnvOption(p), forceDepsOption(p))
+	runner, err := run.NewRunner(p.tasks, p.dir, opts...)
+	if err == nil {
+		err = runner.Run(ctx, name, nil)
+		bestEffort(func() error { return recordRun(p, []string{name}, start, err, runner.Results()) })
+		bestEffort(func() error { return pruneLogs(p) })
+	}
+	if err != nil {
+		scheduleLog("%s failed after %s: %v", name, formatDuration(time.Since(start)), err)
+		return
+	}
+	scheduleLog("%s succeeded in %s", name, formatDuration(time.Since(start)))
+}
+
+// scheduleLog prints a message of xc schedule with the time, as it runs for a long time.
+func scheduleLog(format string, args ...any) {
+	fmt.Printf("%s xc: %s\n", time.Now().Format("2006-01-02 15:04:05"), i18n.Sprintf(format, args...))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+)
+
+func searchCommand(_ context.Context, p project, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	regex := fs.Bool("regex", false, "interpret the query as a regular expression")
+	fs.BoolVar(regex, "e", false, "interpret the query as a regular expression")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 {
+		return errors.New(i18n.T("usage: xc search [-regex] <query>"))
+	}
+	query := strings.Join(fs.Args(), " ")
+	if !*regex {
+		query = "(?i)" + regexp.QuoteMeta(query)
+	}
+	re, err := regexp.Compile(query)
+	if err != nil {
+		return i18n.Errorf("xc search: %w", err)
+	}
+	results := models.Search(p.tasks, re)
+	if len(results) == 0 {
+		i18n.Printf("no tasks match %q\n", strings.Join(fs.Args(), " "))
+		return nil
+	}
+	for _, r := range results {
+		fmt.Printf("%s:%d %s\n", displayPath(r.Task.Path(p.file)), r.Task.Line, nameStyle.Render(r.Task.Name))
+		for _, m := range r.Matches {
+			if m.Attribute == "name" {
+				continue
+			}
+			fmt.Printf("    %s: %s\n", m.Attribute, descriptionStyle.Render(strings.TrimSpace(m.Text)))
+		}
+	}
+	return nil
+}
+
+// displayPath returns path relative to the working directory, if it is below it.
+func displayPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joerdav/xc/dirs"
+	"github.com/joerdav/xc/i18n"
+)
+
+// telemetryState is stored locally and only ever contains aggregate counts.
+type telemetryState struct {
+	Enabled bool `json:"enabled"`
+	// Counts holds the number of invocations keyed by
+	// "command,task count bucket,duration bucket,os".
+	Counts map[string]int `json:"counts,omitempty"`
+}
+
+func telemetryPath(d dirs.Dirs) string {
+	return filepath.Join(d.Config, "telemetry.json")
+}
+
+func loadTelemetry(path string) (telemetryState, error) {
+	var s telemetryState
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(b, &s)
+	return s, err
+}
+
+func saveTelemetry(path string, s telemetryState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o600)
+}
+
+func telemetryCommand(_ context.Context, p project, args []string) error {
+	if len(args) != 1 {
+		return errors.New(i18n.T("usage: xc telemetry on|off|status"))
+	}
+	path := telemetryPath(p.paths)
+	s, err := loadTelemetry(path)
+	if err != nil {
+		return i18n.Errorf("xc telemetry: %w", err)
+	}
+	switch args[0] {
+	case "on":
+		s.Enabled = true
+	case "off":
+		s = telemetryState{}
+	case "status":
+		printTelemetry(path, s)
+		return nil
+	default:
+		return errors.New(i18n.T("usage: xc telemetry on|off|status"))
+	}
+	if err := saveTelemetry(path, s); err != nil {
+		return i18n.Errorf("xc telemetry: %w", err)
+	}
+	printTelemetry(path, s)
+	return nil
+}
+
+func printTelemetry(path string, s telemetryState) {
+	if !s.Enabled {
+		fmt.Println(i18n.T("telemetry: off"))
+		return
+	}
+	fmt.Println(i18n.T("telemetry: on"))
+	fmt.Println(i18n.T("data:"), path)
+	keys := make([]string, 0, len(s.Counts))
+	for k := range s.Counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("    %s  %d\n", strings.ReplaceAll(k, ",", " "), s.Counts[k])
+	}
+}
+
+// recordUsage adds an invocation to the aggregate counts if telemetry is enabled.
+func recordUsage(paths dirs.Dirs, command string, taskCount int, d time.Duration) error {
+	if command == "telemetry" || os.Getenv("DO_NOT_TRACK") == "1" {
+		return nil
+	}
+	path := telemetryPath(paths)
+	s, err := loadTelemetry(path)
+	if err != nil || !s.Enabled {
+		return err
+	}
+	if s.Counts == nil {
+		s.Counts = map[string]int{}
+	}
+	key := strings.Join([]string{command, taskCountBucket(taskCount), durationBucket(d), runtime.GOOS}, ",")
+	s.Counts[key]++
+	return saveTelemetry(path, s)
+}
+
+func taskCountBucket(n int) string {
+	switch {
+	case n == 0:
+		return "tasks=0"
+	case n <= 5:
+		return "tasks=1-5"
+	case n <= 20:
+		return "tasks=6-20"
+	default:
+		return "tasks=21+"
+	}
+}
+
+func durationBucket(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return "duration<1s"
+	case d < 10*time.Second:
+		return "duration<10s"
+	case d < time.Minute:
+		return "duration<1m"
+	case d < 10*time.Minute:
+		return "duration<10m"
+	default:
+		return "duration>=10m"
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/joerdav/xc/config"
+	"github.com/joerdav/xc/run"
+)
+
+const defaultAccent = "170"
+
+var (
+	nameStyle        = lipgloss.NewStyle()
+	descriptionStyle = lipgloss.NewStyle()
+	runStyles        run.Styles
+)
+
+// applyTheme sets the styles used by the picker, task lists and task output.
+func applyTheme(t config.Theme) {
+	selectedItemStyle = colour(selectedItemStyle, t.Accent, defaultAccent)
+	nameStyle = colour(nameStyle, t.Name, "")
+	descriptionStyle = colour(descriptionStyle, t.Description, "")
+	prefix := styleFunc(t.Prefix)
+	if t.Prefix == "" {
+		prefix = taskColours(prefixPalette)
+	}
+	runStyles = run.Styles{
+		Prefix: prefix,
+		Echo:   styleFunc(t.Echo),
+	}
+}
+
+// prefixPalette are the colours of the task names prefixing task output when the theme doesn't set one.
+// Red is left out, so a task name isn't mistaken for an error.
+var prefixPalette = []string{"6", "3", "2", "5", "4", "14", "11", "10", "13", "12"}
+
+// taskColours returns a Style that gives each task the next colour of palette the first time it is seen,
+// the same as docker compose, so that the output of tasks running in parallel can be told apart.
+// Copies of a task running for each file of -each, named task[file], share its colour.
+func taskColours(palette []string) run.Style {
+	var mu sync.Mutex
+	styles := map[string]lipgloss.Style{}
+	return func(s string) string {
+		name, _, _ := strings.Cut(strings.TrimSpace(s), "[")
+		mu.Lock()
+		style, ok := styles[name]
+		if !ok {
+			style = lipgloss.NewStyle().Foreground(lipgloss.Color(palette[len(styles)%len(palette)]))
+			styles[name] = style
+		}
+		mu.Unlock()
+		return style.Render(s)
+	}
+}
+
+func colour(s lipgloss.Style, c, fallback string) lipgloss.Style {
+	if c == "" {
+		c = fallback
+	}
+	if c == "" || c == config.NoColour {
+		return s.UnsetForeground()
+	}
+	return s.Foreground(lipgloss.Color(c))
+}
+
+func styleFunc(c string) run.Style {
+	if c == "" || c == config.NoColour {
+		return nil
+	}
+	style := colour(lipgloss.NewStyle(), c, "")
+	return func(s string) string { return style.Render(s) }
+}
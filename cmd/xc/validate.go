@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/lint"
+	"github.com/joerdav/xc/parser"
+	"github.com/joerdav/xc/problem"
+)
+
+func validateCommand(ctx context.Context, p project, args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	shellcheck := fs.Bool("shellcheck", false, "check the scripts of tasks with shellcheck")
+	strict := fs.Bool("strict", false, "also report unknown attributes, empty code blocks, headings that aren't tasks and duplicate names")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New(i18n.T("usage: xc validate [-shellcheck] [-strict]"))
+	}
+	file := displayPath(p.file)
+	problems := lint.Tasks(p.tasks, file)
+	if *strict {
+		// Errors in the config are reported when it is loaded for the tasks.
+		c, _ := loadDirConfig(p.dir)
+		_, err := parser.ParseFileStrict(p.file, p.cfg.heading, c.Discover...)
+		var strictErr *parser.StrictError
+		switch {
+		case errors.As(err, &strictErr):
+			for _, pr := range strictErr.Problems {
+				pr.File = displayPath(pr.File)
+				problems = append(problems, pr)
+			}
+		case err != nil:
+			return i18n.Errorf("xc validate: %w", err)
+		}
+	}
+	if *shellcheck {
+		var s lint.Shellcheck
+		for _, t := range p.tasks {
+			found, err := s.Check(ctx, t, file)
+			if err != nil {
+				return i18n.Errorf("xc validate: %w", err)
+			}
+			problems = append(problems, found...)
+		}
+	}
+	for _, pr := range problems {
+		fmt.Printf("%s: %s: %s %s\n", pr.Location(), pr.Severity, pr.Message, descriptionStyle.Render("("+pr.Task+")"))
+	}
+	errs, warnings := problem.Count(problems)
+	if errs+warnings > 0 {
+		return i18n.Errorf("xc validate: found %s and %s", i18n.Plural(errs, "%d error", "%d errors"), i18n.Plural(warnings, "%d warning", "%d warnings"))
+	}
+	i18n.Printf("xc: %s is valid\n", file)
+	return nil
+}
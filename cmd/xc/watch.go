@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/run"
+	"github.com/joerdav/xc/watch"
+)
+
+// watchIgnores are the paths that are not watched when every file in the directory is watched.
+var watchIgnores = []string{"!node_modules"}
+
+// watchPatterns returns the directory and the paths to watch for a task.
+// The Watch attribute is relative to dir, the directory of the task file.
+// Without it the Sources of the task are watched, which are relative to the directory of the task,
+// and without either every file in dir is watched.
+// The Outputs of the task are never watched, so that the task doesn't trigger itself.
+func watchPatterns(dir string, task models.Task) (string, []string) {
+	if len(task.Watch) > 0 {
+		return dir, task.Watch
+	}
+	if len(task.Sources) > 0 {
+		taskDir := dir
+		if task.Dir != "" {
+			taskDir = filepath.Join(dir, filepath.FromSlash(task.Dir))
+			if filepath.IsAbs(task.Dir) {
+				taskDir = task.Dir
+			}
+		}
+		return taskDir, append(append([]string{}, task.Sources...), excludeOutputs(task.Outputs, "")...)
+	}
+	patterns := append([]string{"**"}, watchIgnores...)
+	if !filepath.IsAbs(task.Dir) {
+		patterns = append(patterns, excludeOutputs(task.Outputs, filepath.ToSlash(task.Dir))...)
+	}
+	return dir, patterns
+}
+
+// excludeOutputs returns patterns that exclude outputs, which are relative to the directory dir.
+func excludeOutputs(outputs []string, dir string) []string {
+	var patterns []string
+	for _, o := range outputs {
+		if strings.HasPrefix(o, "!") {
+			continue
+		}
+		patterns = append(patterns, "!"+path.Join(dir, o))
+	}
+	return patterns
+}
+
+// What -watch does when paths change while the task is running.
+const (
+	// watchRestart stops the run and starts a new one.
+	watchRestart = "restart"
+	// watchQueue lets the run finish, then starts a new one.
+	watchQueue = "queue"
+	// watchIgnore lets the run finish, and ignores the changes.
+	watchIgnore = "ignore"
+)
+
+// watchMode returns the mode chosen by the -watch-restart, -watch-queue and -watch-ignore flags,
+// restart is the default.
+func watchMode(cfg *flagConfig) (string, error) {
+	mode := watchRestart
+	var n int
+	for m, set := range map[string]bool{watchRestart: cfg.watchRestart, watchQueue: cfg.watchQueue, watchIgnore: cfg.watchIgnore} {
+		if set {
+			mode = m
+			n++
+		}
+	}
+	if n > 1 {
+		return "", errors.New(i18n.T("only one of -watch-restart, -watch-queue and -watch-ignore can be used"))
+	}
+	return mode, nil
+}
+
+// watchTask runs the task, and runs it again whenever one of its watched paths changes, see watchPatterns.
+// The mode decides what happens to changes while the task is running, and opts are added to the options of each runner.
+func watchTask(ctx context.Context, tasks models.Tasks, dir, name string, inputs []string, mode string, opts ...run.Option) error {
+	task, ok := tasks.Get(name)
+	if !ok {
+		return run.TaskNotFoundError{Name: name}
+	}
+	watchDir, patterns := watchPatterns(dir, task)
+	changes, err := watch.New(watchDir, patterns, watch.DefaultInterval).Watch(ctx)
+	if err != nil {
+		return i18n.Errorf("xc watch: %w", err)
+	}
+	for {
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() {
+			// A new runner is used for each run, so that Run: once tasks run again.
+			runner, err := run.NewRunner(tasks, dir, append(runnerOptions(), opts...)...)
+			if err == nil {
+				err = runner.Run(runCtx, task.Name, inputs)
+			}
+			done <- err
+		}()
+		changed, restarted := waitForRun(ctx, task.Name, done, changes, cancel, mode)
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		switch {
+		case restarted:
+			i18n.Printf("xc: %s changed, restarting %s\n", strings.Join(changed, ", "), task.Name)
+			continue
+		case len(changed) > 0:
+			i18n.Printf("xc: %s changed, running %s again\n", strings.Join(changed, ", "), task.Name)
+			continue
+		}
+		fmt.Println(i18n.T("xc: waiting for changes"))
+		select {
+		case changed = <-changes:
+		case <-ctx.Done():
+			return nil
+		}
+		i18n.Printf("xc: %s changed, restarting %s\n", strings.Join(changed, ", "), task.Name)
+	}
+}
+
+// waitForRun waits for a run to finish, with changes that arrive while it runs handled by mode.
+// It returns the changes that should cause another run, and whether the run was stopped because of them.
+func waitForRun(ctx context.Context, name string, done <-chan error, changes <-chan []string, cancel func(), mode string) (changed []string, restarted bool) {
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				fmt.Println(i18n.Errorf("xc: %w", err))
+			}
+			return changed, false
+		case c := <-changes:
+			switch mode {
+			case watchRestart:
+				cancel()
+				<-done
+				return c, true
+			case watchQueue:
+				changed = appendNew(changed, c)
+				i18n.Printf("xc: %s changed, %s will run again when it finishes\n", strings.Join(c, ", "), name)
+			case watchIgnore:
+				i18n.Printf("xc: %s changed while %s is running, ignoring\n", strings.Join(c, ", "), name)
+			}
+		case <-ctx.Done():
+			<-done
+			return nil, false
+		}
+	}
+}
+
+// appendNew appends the values of add that aren't in s already.
+func appendNew(s, add []string) []string {
+	seen := map[string]bool{}
+	for _, v := range s {
+		seen[v] = true
+	}
+	for _, v := range add {
+		if !seen[v] {
+			seen[v] = true
+			s = append(s, v)
+		}
+	}
+	return s
+}
@@ -0,0 +1,350 @@
+package config
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/joerdav/xc/cache"
+	"github.com/joerdav/xc/dirs"
+	"github.com/joerdav/xc/dotenv"
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/notify"
+	"github.com/joerdav/xc/problem"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the project config file.
+// It is read from the directory containing the task file.
+const FileName = ".xc.yaml"
+
+// Config represents the project level configuration of xc.
+type Config struct {
+	// Tasks overrides attributes of tasks, keyed by task name.
+	Tasks map[string]TaskOverride `yaml:"tasks"`
+	// Profiles are named bundles of settings, activated with -profile or XC_PROFILE.
+	Profiles map[string]Profile `yaml:"profiles"`
+	// Dirs overrides the directories xc writes files to,
+	// relative paths are resolved from the config directory.
+	Dirs dirs.Dirs `yaml:"dirs"`
+	// Theme configures the colours of xc output.
+	Theme Theme `yaml:"theme"`
+	// History configures how long the runs of the project are kept in the run history.
+	History History `yaml:"history"`
+	// Logs configures how long the log files in the logs directory are kept.
+	Logs Logs `yaml:"logs"`
+	// Notify configures where notifications are sent, see the Notify attribute.
+	Notify Notify `yaml:"notify"`
+	// Resources are the capacities of the resources named by the Resources attribute,
+	// keyed by resource name. Resources that are not listed have a capacity of 1.
+	Resources map[string]int `yaml:"resources"`
+	// Cache configures the task result cache.
+	Cache Cache `yaml:"cache"`
+	// Discover holds glob patterns of more task files below the config directory to add the tasks of,
+	// patterns starting with ! exclude files.
+	Discover []string `yaml:"discover"`
+	// Languages maps the languages of code blocks to the interpreters that run their scripts,
+	// in addition to the defaults of xc, e.g. python: python3.11.
+	Languages map[string]string `yaml:"languages"`
+	// TaskFiles configures the files that are searched for tasks when -file isn't given.
+	TaskFiles TaskFiles `yaml:"taskFiles"`
+	// Jobs is the number of scripts that run in parallel when -j and XC_JOBS aren't set,
+	// the number of CPUs if it is not set.
+	Jobs int `yaml:"jobs"`
+}
+
+// DefaultTaskFiles are the files that are searched for tasks in each directory, in order of preference.
+var DefaultTaskFiles = []string{"README.md", "TASKS.md", "CONTRIBUTING.md", "docs/tasks.md"}
+
+// TaskFiles configures the files that are searched for tasks in the current directory and its parents.
+type TaskFiles struct {
+	// Names are the slash separated paths of the files relative to each directory, in order of preference.
+	// DefaultTaskFiles are searched if it is empty.
+	Names []string `yaml:"names"`
+	// Merge adds the tasks of every file that is found in a directory,
+	// rather than only those of the first file with tasks.
+	Merge bool `yaml:"merge"`
+}
+
+// Files returns the paths of the files to search for tasks, in order of preference.
+func (f TaskFiles) Files() []string {
+	if len(f.Names) == 0 {
+		return DefaultTaskFiles
+	}
+	return f.Names
+}
+
+// Cache configures the task result cache of a project.
+type Cache struct {
+	// Remote shares the cache through a server, it isn't shared if the URL is empty.
+	Remote cache.RemoteConfig `yaml:"remote"`
+}
+
+// Notify configures the notification targets of a project.
+type Notify struct {
+	// SlackWebhook is the URL of the Slack incoming webhook used for slack targets.
+	// It is a secret, so it is usually set with the XC_SLACK_WEBHOOK environment variable instead.
+	SlackWebhook string `yaml:"slackWebhook"`
+}
+
+// Logs configures the retention of the log files of a project.
+type Logs struct {
+	// Keep is the number of most recent log files that are kept, 100 if it is not set.
+	Keep int `yaml:"keep"`
+	// MaxAge is how long log files are kept for, e.g. 7d or 48h.
+	MaxAge string `yaml:"maxAge"`
+	// MaxSize is the total size of the log files that are kept, e.g. 500MB.
+	// The oldest files are removed first.
+	MaxSize string `yaml:"maxSize"`
+}
+
+// History configures the retention of the run history of a project.
+type History struct {
+	// MaxRuns is the number of most recent runs that are kept, 1000 if it is not set.
+	MaxRuns int `yaml:"maxRuns"`
+	// MaxAge is how long runs are kept for, e.g. 30d or 720h.
+	// Runs are kept regardless of age if it is not set.
+	MaxAge string `yaml:"maxAge"`
+}
+
+// NoColour disables the colour of a Theme element.
+const NoColour = "none"
+
+// Theme configures the colours used by xc, both in the interactive picker and plain output.
+// Colours are ANSI numbers such as "170", or hex codes such as "#ff87d7".
+// Unset elements use the default colour, NoColour disables the colour.
+type Theme struct {
+	// Accent is the colour of the selected task in the picker.
+	Accent string `yaml:"accent"`
+	// Name is the colour of task names in task lists.
+	Name string `yaml:"name"`
+	// Description is the colour of task descriptions in task lists.
+	Description string `yaml:"description"`
+	// Prefix is the colour of the task name prefixing each line of task output,
+	// each task is given a colour of its own if it is unset.
+	Prefix string `yaml:"prefix"`
+	// Echo is the colour of the command echo, e.g. `+ go test ./...`.
+	Echo string `yaml:"echo"`
+}
+
+// Profile is a named bundle of settings.
+type Profile struct {
+	// Flags are default values for xc flags, keyed by flag name.
+	// Flags provided on the command line take precedence.
+	Flags map[string]string `yaml:"flags"`
+	// Env is added to the environment variables of every task.
+	Env []string `yaml:"env"`
+	// EnvFile lists dotenv files, relative to the config directory,
+	// that are loaded before Env.
+	EnvFile []string `yaml:"envFile"`
+}
+
+// Apply returns a copy of tasks with the profile environment added to every task.
+// Later env files override earlier ones, and Env overrides all env files.
+func (p Profile) Apply(tasks models.Tasks, dir string) (models.Tasks, error) {
+	var env []string
+	for _, f := range p.EnvFile {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(dir, f)
+		}
+		e, err := dotenv.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, e...)
+	}
+	env = append(env, p.Env...)
+	result := make(models.Tasks, len(tasks))
+	for i, t := range tasks {
+		if len(env) > 0 {
+			t.Env = append(append([]string{}, t.Env...), env...)
+		}
+		result[i] = t
+	}
+	return result, nil
+}
+
+// Profile returns the profile with the given name.
+// An empty name returns an empty Profile.
+func (c Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		return Profile{}, nil
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, i18n.Errorf("profile %s not found", name)
+	}
+	return p, nil
+}
+
+// TaskOverride holds the task attributes that can be overridden from config.
+// Values set in config take precedence over values set in markdown.
+// Env is appended to the markdown env so that config values win,
+// all other attributes replace the markdown value when set.
+type TaskOverride struct {
+	Env         []string `yaml:"env"`
+	Dir         *string  `yaml:"dir"`
+	Requires    []string `yaml:"requires"`
+	Inputs      []string `yaml:"inputs"`
+	Run         string   `yaml:"run"`
+	RunDeps     string   `yaml:"runDeps"`
+	Interactive *bool    `yaml:"interactive"`
+	Watch       []string `yaml:"watch"`
+	Problems    []string `yaml:"problems"`
+	InheritEnv  []string `yaml:"inheritEnv"`
+	Notify      []string `yaml:"notify"`
+	Resources   []string `yaml:"resources"`
+	// Throttle is a duration such as 1h, 0s stops the task from being throttled.
+	Throttle string `yaml:"throttle"`
+	// Container, Shell, Interpreter and Remote replace how the script runs, an empty value unsets them.
+	Container   *string `yaml:"container"`
+	Shell       *string `yaml:"shell"`
+	Interpreter *string `yaml:"interpreter"`
+	Remote      *string `yaml:"remote"`
+}
+
+// Load reads the config file from dir.
+// A missing config file is not an error, an empty Config is returned.
+func Load(dir string) (Config, error) {
+	f, err := os.Open(filepath.Join(dir, FileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, i18n.Errorf("failed to open config: %w", err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads a Config from r.
+func Parse(r io.Reader) (Config, error) {
+	var c Config
+	d := yaml.NewDecoder(r)
+	d.KnownFields(true)
+	if err := d.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, i18n.Errorf("failed to parse config: %w", err)
+	}
+	return c, nil
+}
+
+// Apply returns a copy of tasks with the task overrides applied.
+func (c Config) Apply(tasks models.Tasks) (models.Tasks, error) {
+	result := make(models.Tasks, len(tasks))
+	copy(result, tasks)
+	for name, o := range c.Tasks {
+		i := indexOf(result, name)
+		if i < 0 {
+			return nil, i18n.Errorf("config overrides task %s which does not exist", name)
+		}
+		t, err := o.apply(result[i])
+		if err != nil {
+			return nil, err
+		}
+		result[i] = t
+	}
+	return result, nil
+}
+
+func (o TaskOverride) apply(t models.Task) (models.Task, error) {
+	if len(o.Env) > 0 {
+		t.Env = append(append([]string{}, t.Env...), o.Env...)
+	}
+	if o.Dir != nil {
+		t.Dir = *o.Dir
+	}
+	if o.Requires != nil {
+		t.DependsOn = o.Requires
+	}
+	if o.Inputs != nil {
+		specs := t.InputSpecs
+		t.Inputs, t.InputSpecs = nil, nil
+		for _, in := range o.Inputs {
+			name, spec, ok := models.ParseInput(in)
+			if !ok {
+				return t, i18n.Errorf("config inputs contains invalid input %q: %s", in, t.Name)
+			}
+			t.Inputs = append(t.Inputs, name)
+			// Keep the prompt declared in the markdown.
+			spec.Prompt = specs[name].Prompt
+			if len(spec.Choices) > 0 || spec.Type != models.InputTypeString || spec.Default != nil || spec.Prompt != nil {
+				t.SetInput(name, spec)
+			}
+		}
+	}
+	if o.Run != "" {
+		r, ok := models.ParseRequiredBehaviour(o.Run)
+		if !ok {
+			return t, i18n.Errorf("config run contains invalid behaviour %q should be (default, always, once): %s", o.Run, t.Name)
+		}
+		t.RequiredBehaviour = r
+	}
+	if o.RunDeps != "" {
+		r, ok := models.ParseDepsBehaviour(o.RunDeps)
+		if !ok {
+			return t, i18n.Errorf("config runDeps contains invalid behaviour %q should be (sync, async): %s", o.RunDeps, t.Name)
+		}
+		t.DepsBehaviour = r
+	}
+	if o.Interactive != nil {
+		t.Interactive = *o.Interactive
+	}
+	if o.Watch != nil {
+		t.Watch = o.Watch
+	}
+	if o.Notify != nil {
+		for _, n := range o.Notify {
+			if _, err := notify.ParseTarget(n); err != nil {
+				return t, i18n.Errorf("invalid notify for %s: %w", t.Name, err)
+			}
+		}
+		t.Notify = o.Notify
+	}
+	if o.Resources != nil {
+		t.Resources = o.Resources
+	}
+	if o.Throttle != "" {
+		d, err := time.ParseDuration(o.Throttle)
+		if err != nil || d < 0 {
+			return t, i18n.Errorf("config throttle contains invalid duration %q: %s", o.Throttle, t.Name)
+		}
+		t.Throttle = d
+	}
+	if o.InheritEnv != nil {
+		t.InheritEnv = o.InheritEnv
+	}
+	if o.Container != nil {
+		t.Container = *o.Container
+	}
+	if o.Shell != nil {
+		t.Shell = *o.Shell
+	}
+	if o.Interpreter != nil {
+		t.Interpreter = *o.Interpreter
+	}
+	if o.Remote != nil {
+		t.Remote = *o.Remote
+	}
+	if o.Problems != nil {
+		for _, m := range o.Problems {
+			if _, err := problem.Parse(m); err != nil {
+				return t, i18n.Errorf("invalid problems for %s: %w", t.Name, err)
+			}
+		}
+		t.Problems = o.Problems
+	}
+	return t, nil
+}
+
+func indexOf(tasks models.Tasks, name string) int {
+	for i, t := range tasks {
+		if models.SameName(t.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
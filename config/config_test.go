@@ -0,0 +1,322 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joerdav/xc/models"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("given an empty config, should parse", func(t *testing.T) {
+		c, err := Parse(strings.NewReader(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c.Tasks) != 0 {
+			t.Fatalf("expected no overrides got %d", len(c.Tasks))
+		}
+	})
+	t.Run("given task overrides, should parse", func(t *testing.T) {
+		c, err := Parse(strings.NewReader(`
+tasks:
+  test:
+    env: [CI=true]
+    dir: ./sub
+`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		o := c.Tasks["test"]
+		if len(o.Env) != 1 || o.Env[0] != "CI=true" {
+			t.Fatalf("env want=[CI=true] got=%v", o.Env)
+		}
+		if o.Dir == nil || *o.Dir != "./sub" {
+			t.Fatalf("dir want=./sub got=%v", o.Dir)
+		}
+	})
+	t.Run("given how the script runs overrides, should parse", func(t *testing.T) {
+		c, err := Parse(strings.NewReader("tasks:\n  test:\n    container: golang:1.22\n    shell: bash\n    interpreter: python3\n    remote: \"\"\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		o := c.Tasks["test"]
+		if o.Container == nil || *o.Container != "golang:1.22" || o.Shell == nil || *o.Shell != "bash" || o.Interpreter == nil || *o.Interpreter != "python3" {
+			t.Fatalf("container, shell, interpreter want=golang:1.22, bash, python3 got=%v, %v, %v", o.Container, o.Shell, o.Interpreter)
+		}
+		if o.Remote == nil || *o.Remote != "" {
+			t.Fatalf("remote want=empty got=%v", o.Remote)
+		}
+	})
+	t.Run("given resource capacities, should parse", func(t *testing.T) {
+		c, err := Parse(strings.NewReader("resources:\n  gpu: 2\ntasks:\n  train:\n    resources: [gpu]\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Resources["gpu"] != 2 {
+			t.Fatalf("gpu capacity want=2 got=%d", c.Resources["gpu"])
+		}
+		if r := c.Tasks["train"].Resources; len(r) != 1 || r[0] != "gpu" {
+			t.Fatalf("resources want=[gpu] got=%v", r)
+		}
+	})
+	t.Run("given discover patterns, should parse", func(t *testing.T) {
+		c, err := Parse(strings.NewReader("discover:\n  - \"**/TASKS.md\"\n  - \"!node_modules/**\"\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(c.Discover, ",") != "**/TASKS.md,!node_modules/**" {
+			t.Fatalf("discover want=[**/TASKS.md !node_modules/**] got=%v", c.Discover)
+		}
+	})
+	t.Run("given task files, should search them in order", func(t *testing.T) {
+		c, err := Parse(strings.NewReader("taskFiles:\n  names: [TASKS.md, docs/tasks.md]\n  merge: true\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(c.TaskFiles.Files(), ","); got != "TASKS.md,docs/tasks.md" || !c.TaskFiles.Merge {
+			t.Fatalf("task files want=[TASKS.md docs/tasks.md] merged got=%v %v", got, c.TaskFiles.Merge)
+		}
+		if got := strings.Join(Config{}.TaskFiles.Files(), ","); got != strings.Join(DefaultTaskFiles, ",") {
+			t.Fatalf("default task files want=%v got=%v", DefaultTaskFiles, got)
+		}
+	})
+	t.Run("given an unknown key, should error", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("tasks:\n  test:\n    image: golang\n"))
+		if err == nil {
+			t.Fatal("expected error got nil")
+		}
+	})
+}
+
+func TestProfile(t *testing.T) {
+	c, err := Parse(strings.NewReader(`
+profiles:
+  ci:
+    flags:
+      no-tty: true
+      heading: Tasks
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Run("given no profile name, should return empty profile", func(t *testing.T) {
+		p, err := c.Profile("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p.Flags) != 0 {
+			t.Fatalf("expected no flags got %v", p.Flags)
+		}
+	})
+	t.Run("given a known profile, should return its flags", func(t *testing.T) {
+		p, err := c.Profile("ci")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Flags["no-tty"] != "true" {
+			t.Fatalf("no-tty want=true got=%q", p.Flags["no-tty"])
+		}
+	})
+	t.Run("given an unknown profile, should error", func(t *testing.T) {
+		if _, err := c.Profile("local"); err == nil {
+			t.Fatal("expected error got nil")
+		}
+	})
+}
+
+func TestProfileApply(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "prod.env"), []byte("HOST=prod\nREGION=eu\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Run("given env and env files, env should be appended in order", func(t *testing.T) {
+		p := Profile{Env: []string{"REGION=us"}, EnvFile: []string{"prod.env"}}
+		tasks, err := p.Apply(models.Tasks{{Name: "deploy", Env: []string{"HOST=local"}}}, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "HOST=local,HOST=prod,REGION=eu,REGION=us"
+		if strings.Join(tasks[0].Env, ",") != expected {
+			t.Fatalf("env want=%s got=%v", expected, tasks[0].Env)
+		}
+	})
+	t.Run("given a missing env file, should error", func(t *testing.T) {
+		p := Profile{EnvFile: []string{"missing.env"}}
+		if _, err := p.Apply(models.Tasks{{Name: "deploy"}}, dir); err == nil {
+			t.Fatal("expected error got nil")
+		}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("given no config file, should return empty config", func(t *testing.T) {
+		c, err := Load(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c.Tasks) != 0 {
+			t.Fatalf("expected no overrides got %d", len(c.Tasks))
+		}
+	})
+	t.Run("given a config file, should read it", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, FileName), []byte("tasks:\n  a: {run: once}\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		c, err := Load(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Tasks["a"].Run != "once" {
+			t.Fatalf("run want=once got=%q", c.Tasks["a"].Run)
+		}
+	})
+}
+
+func TestApply(t *testing.T) {
+	dir := "other"
+	interactive := true
+	port := "8080"
+	container, shell, interpreter, remote := "golang:1.22", "bash", "python3", "deploy@example.com"
+	tests := []struct {
+		name        string
+		config      Config
+		expected    models.Task
+		expectError bool
+	}{
+		{
+			name:     "given no overrides, task should be unchanged",
+			expected: models.Task{Name: "test", Env: []string{"A=1"}, Dir: "dir"},
+		},
+		{
+			name: "given env override, env should be appended",
+			config: Config{Tasks: map[string]TaskOverride{
+				"test": {Env: []string{"A=2"}},
+			}},
+			expected: models.Task{Name: "test", Env: []string{"A=1", "A=2"}, Dir: "dir"},
+		},
+		{
+			name: "given override with different casing, should apply",
+			config: Config{Tasks: map[string]TaskOverride{
+				"TEST": {Dir: &dir, Interactive: &interactive},
+			}},
+			expected: models.Task{Name: "test", Env: []string{"A=1"}, Dir: "other", Interactive: true},
+		},
+		{
+			name: "given behaviour overrides, should apply",
+			config: Config{Tasks: map[string]TaskOverride{
+				"test": {Run: "once", RunDeps: "async", Requires: []string{"lint"}},
+			}},
+			expected: models.Task{
+				Name:              "test",
+				Env:               []string{"A=1"},
+				Dir:               "dir",
+				DependsOn:         []string{"lint"},
+				RequiredBehaviour: models.RequiredBehaviourOnce,
+				DepsBehaviour:     models.DependencyBehaviourAsync,
+			},
+		},
+		{
+			name: "given an invalid run behaviour, should error",
+			config: Config{Tasks: map[string]TaskOverride{
+				"test": {Run: "never"},
+			}},
+			expectError: true,
+		},
+		{
+			name: "given a throttle override, should apply",
+			config: Config{Tasks: map[string]TaskOverride{
+				"test": {Throttle: "2h"},
+			}},
+			expected: models.Task{Name: "test", Env: []string{"A=1"}, Dir: "dir", Throttle: 2 * time.Hour},
+		},
+		{
+			name: "given an invalid throttle, should error",
+			config: Config{Tasks: map[string]TaskOverride{
+				"test": {Throttle: "soon"},
+			}},
+			expectError: true,
+		},
+		{
+			name: "given a typed input with a default, should keep its type and default",
+			config: Config{Tasks: map[string]TaskOverride{
+				"test": {Inputs: []string{"PORT:int=8080"}},
+			}},
+			expected: models.Task{
+				Name:       "test",
+				Env:        []string{"A=1"},
+				Dir:        "dir",
+				Inputs:     []string{"PORT"},
+				InputSpecs: map[string]models.InputSpec{"PORT": {Type: models.InputTypeInt, Default: &port}},
+			},
+		},
+		{
+			name: "given how the script runs overrides, should apply",
+			config: Config{Tasks: map[string]TaskOverride{
+				"test": {Container: &container, Shell: &shell, Interpreter: &interpreter, Remote: &remote},
+			}},
+			expected: models.Task{Name: "test", Env: []string{"A=1"}, Dir: "dir", Container: "golang:1.22", Shell: "bash", Interpreter: "python3", Remote: "deploy@example.com"},
+		},
+		{
+			name: "given an unknown task, should error",
+			config: Config{Tasks: map[string]TaskOverride{
+				"missing": {Run: "once"},
+			}},
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			original := models.Tasks{{Name: "test", Env: []string{"A=1"}, Dir: "dir"}}
+			result, err := tt.config.Apply(original)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if err != nil {
+				return
+			}
+			if len(original[0].Env) != 1 {
+				t.Fatalf("original task was modified: %v", original[0].Env)
+			}
+			got := result[0]
+			if strings.Join(got.Env, ",") != strings.Join(tt.expected.Env, ",") {
+				t.Fatalf("env want=%v got=%v", tt.expected.Env, got.Env)
+			}
+			if got.Dir != tt.expected.Dir {
+				t.Fatalf("dir want=%q got=%q", tt.expected.Dir, got.Dir)
+			}
+			if strings.Join(got.DependsOn, ",") != strings.Join(tt.expected.DependsOn, ",") {
+				t.Fatalf("requires want=%v got=%v", tt.expected.DependsOn, got.DependsOn)
+			}
+			if got.RequiredBehaviour != tt.expected.RequiredBehaviour {
+				t.Fatalf("run want=%q got=%q", tt.expected.RequiredBehaviour, got.RequiredBehaviour)
+			}
+			if got.DepsBehaviour != tt.expected.DepsBehaviour {
+				t.Fatalf("runDeps want=%q got=%q", tt.expected.DepsBehaviour, got.DepsBehaviour)
+			}
+			if got.Interactive != tt.expected.Interactive {
+				t.Fatalf("interactive want=%v got=%v", tt.expected.Interactive, got.Interactive)
+			}
+			if got.Throttle != tt.expected.Throttle {
+				t.Fatalf("throttle want=%s got=%s", tt.expected.Throttle, got.Throttle)
+			}
+			if got.Container != tt.expected.Container || got.Shell != tt.expected.Shell || got.Interpreter != tt.expected.Interpreter || got.Remote != tt.expected.Remote {
+				t.Fatalf("container, shell, interpreter, remote want=%q, %q, %q, %q got=%q, %q, %q, %q",
+					tt.expected.Container, tt.expected.Shell, tt.expected.Interpreter, tt.expected.Remote,
+					got.Container, got.Shell, got.Interpreter, got.Remote)
+			}
+			if strings.Join(got.Inputs, ",") != strings.Join(tt.expected.Inputs, ",") {
+				t.Fatalf("inputs want=%v got=%v", tt.expected.Inputs, got.Inputs)
+			}
+			if !reflect.DeepEqual(got.InputSpecs, tt.expected.InputSpecs) {
+				t.Fatalf("input specs want=%+v got=%+v", tt.expected.InputSpecs, got.InputSpecs)
+			}
+		})
+	}
+}
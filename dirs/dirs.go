@@ -0,0 +1,122 @@
+package dirs
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const appName = "xc"
+
+// Dirs holds the directories that xc writes files to.
+type Dirs struct {
+	// Config holds user preferences, such as the telemetry setting.
+	Config string `yaml:"config"`
+	// Data holds persistent data, such as the run history.
+	Data string `yaml:"data"`
+	// Cache holds data that can be regenerated, such as task results.
+	Cache string `yaml:"cache"`
+	// State holds data that should persist between runs but isn't important, such as the last run.
+	State string `yaml:"state"`
+	// Logs holds captured task logs.
+	Logs string `yaml:"logs"`
+}
+
+// Resolve returns the directories xc should use, in order of precedence:
+//   - XC_CONFIG_DIR, XC_DATA_DIR, XC_CACHE_DIR, XC_STATE_DIR and XC_LOG_DIR environment variables.
+//   - overrides, relative paths are resolved from base.
+//   - XDG_CONFIG_HOME, XDG_DATA_HOME, XDG_CACHE_HOME and XDG_STATE_HOME environment variables.
+//   - The platform defaults, see platformDefaults.
+func Resolve(overrides Dirs, base string) (Dirs, error) {
+	d, err := platformDefaults()
+	if err != nil {
+		return d, err
+	}
+	d.Config = xdg("XDG_CONFIG_HOME", d.Config)
+	d.Data = xdg("XDG_DATA_HOME", d.Data)
+	d.Cache = xdg("XDG_CACHE_HOME", d.Cache)
+	if s, ok := os.LookupEnv("XDG_STATE_HOME"); ok && s != "" {
+		d.State = filepath.Join(s, appName)
+		d.Logs = filepath.Join(d.State, "logs")
+	}
+	d = d.override(overrides, base)
+	d = d.override(Dirs{
+		Config: os.Getenv("XC_CONFIG_DIR"),
+		Data:   os.Getenv("XC_DATA_DIR"),
+		Cache:  os.Getenv("XC_CACHE_DIR"),
+		State:  os.Getenv("XC_STATE_DIR"),
+		Logs:   os.Getenv("XC_LOG_DIR"),
+	}, base)
+	return d, nil
+}
+
+func xdg(env, fallback string) string {
+	if s := os.Getenv(env); s != "" {
+		return filepath.Join(s, appName)
+	}
+	return fallback
+}
+
+func (d Dirs) override(o Dirs, base string) Dirs {
+	set := func(dst *string, v string) {
+		if v == "" {
+			return
+		}
+		if !filepath.IsAbs(v) && base != "" {
+			v = filepath.Join(base, v)
+		}
+		*dst = v
+	}
+	set(&d.Config, o.Config)
+	set(&d.Data, o.Data)
+	set(&d.Cache, o.Cache)
+	set(&d.State, o.State)
+	set(&d.Logs, o.Logs)
+	return d
+}
+
+// platformDefaults returns the conventional directories for the current OS.
+//   - Linux and others: ~/.config/xc, ~/.local/share/xc, ~/.cache/xc, ~/.local/state/xc and ~/.local/state/xc/logs.
+//   - macOS: ~/Library/Application Support/xc, ~/Library/Caches/xc and ~/Library/Logs/xc.
+//   - Windows: %AppData%\xc and %LocalAppData%\xc.
+func platformDefaults() (Dirs, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Dirs{}, err
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		support := filepath.Join(home, "Library", "Application Support", appName)
+		return Dirs{
+			Config: support,
+			Data:   support,
+			Cache:  filepath.Join(home, "Library", "Caches", appName),
+			State:  filepath.Join(support, "state"),
+			Logs:   filepath.Join(home, "Library", "Logs", appName),
+		}, nil
+	case "windows":
+		roaming := os.Getenv("AppData")
+		local := os.Getenv("LocalAppData")
+		if roaming == "" {
+			roaming = filepath.Join(home, "AppData", "Roaming")
+		}
+		if local == "" {
+			local = filepath.Join(home, "AppData", "Local")
+		}
+		return Dirs{
+			Config: filepath.Join(roaming, appName),
+			Data:   filepath.Join(local, appName),
+			Cache:  filepath.Join(local, appName, "cache"),
+			State:  filepath.Join(local, appName, "state"),
+			Logs:   filepath.Join(local, appName, "logs"),
+		}, nil
+	}
+	state := filepath.Join(home, ".local", "state", appName)
+	return Dirs{
+		Config: filepath.Join(home, ".config", appName),
+		Data:   filepath.Join(home, ".local", "share", appName),
+		Cache:  filepath.Join(home, ".cache", appName),
+		State:  state,
+		Logs:   filepath.Join(state, "logs"),
+	}, nil
+}
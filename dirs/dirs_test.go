@@ -0,0 +1,85 @@
+package dirs
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, v := range []string{
+		"XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_CACHE_HOME", "XDG_STATE_HOME",
+		"XC_CONFIG_DIR", "XC_DATA_DIR", "XC_CACHE_DIR", "XC_STATE_DIR", "XC_LOG_DIR",
+	} {
+		t.Setenv(v, "")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("XDG defaults only apply on unix-like systems")
+	}
+	home := t.TempDir()
+	t.Run("given no environment, should use platform defaults", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("HOME", home)
+		d, err := Resolve(Dirs{}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := Dirs{
+			Config: filepath.Join(home, ".config", "xc"),
+			Data:   filepath.Join(home, ".local", "share", "xc"),
+			Cache:  filepath.Join(home, ".cache", "xc"),
+			State:  filepath.Join(home, ".local", "state", "xc"),
+			Logs:   filepath.Join(home, ".local", "state", "xc", "logs"),
+		}
+		if d != expected {
+			t.Fatalf("want=%+v got=%+v", expected, d)
+		}
+	})
+	t.Run("given XDG variables, should use them", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("HOME", home)
+		t.Setenv("XDG_DATA_HOME", "/data")
+		t.Setenv("XDG_STATE_HOME", "/state")
+		d, err := Resolve(Dirs{}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Data != "/data/xc" {
+			t.Fatalf("data want=/data/xc got=%s", d.Data)
+		}
+		if d.Logs != "/state/xc/logs" {
+			t.Fatalf("logs want=/state/xc/logs got=%s", d.Logs)
+		}
+	})
+	t.Run("given overrides, should resolve them from base", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("HOME", home)
+		t.Setenv("XDG_CACHE_HOME", "/cache")
+		d, err := Resolve(Dirs{Cache: ".xc/cache", Logs: "/logs"}, "/project")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Cache != "/project/.xc/cache" {
+			t.Fatalf("cache want=/project/.xc/cache got=%s", d.Cache)
+		}
+		if d.Logs != "/logs" {
+			t.Fatalf("logs want=/logs got=%s", d.Logs)
+		}
+	})
+	t.Run("given XC variables, should take precedence over overrides", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("HOME", home)
+		t.Setenv("XC_CACHE_DIR", "/xc-cache")
+		d, err := Resolve(Dirs{Cache: ".xc/cache"}, "/project")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Cache != "/xc-cache" {
+			t.Fatalf("cache want=/xc-cache got=%s", d.Cache)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package dotenv
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/joerdav/xc/i18n"
+)
+
+// ReadFile reads a dotenv file and returns its variables in KEY=VALUE form.
+func ReadFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, i18n.Errorf("failed to open env file: %w", err)
+	}
+	defer f.Close()
+	env, err := Parse(f)
+	if err != nil {
+		return nil, i18n.Errorf("%s: %w", path, err)
+	}
+	return env, nil
+}
+
+// nameRe matches the names of the variables of a dotenv file.
+var nameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// Parse reads dotenv formatted variables from r and returns them in KEY=VALUE form, in order.
+//
+// Blank lines and lines starting with # are ignored, an optional `export ` prefix is allowed.
+// Values may be wrapped in single quotes, which are taken literally,
+// or double quotes, which can span lines and support \n, \r, \t, \" and \\ escapes, other escapes are kept as they are.
+// Unquoted values are trimmed and may be followed by a # comment. Values are not expanded.
+func Parse(r io.Reader) ([]string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var env []string
+	lines := strings.Split(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		t := strings.TrimSpace(lines[i])
+		if t == "" || strings.HasPrefix(t, "#") {
+			continue
+		}
+		line := i + 1
+		t = strings.TrimPrefix(t, "export ")
+		key, value, found := strings.Cut(t, "=")
+		key = strings.TrimSpace(key)
+		if !found {
+			return nil, i18n.Errorf("line %d: expected NAME=value", line)
+		}
+		if !nameRe.MatchString(key) {
+			return nil, i18n.Errorf("line %d: invalid variable name %q", line, key)
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case strings.HasPrefix(value, "'"):
+			end := strings.IndexByte(value[1:], '\'')
+			if end < 0 {
+				return nil, i18n.Errorf("line %d: unclosed quote", line)
+			}
+			value = value[1 : end+1]
+		case strings.HasPrefix(value, `"`):
+			// A double quoted value continues on the next lines until its closing quote.
+			quoted := value[1:]
+			for {
+				if v, ok := unquote(quoted); ok {
+					value = v
+					break
+				}
+				if i++; i >= len(lines) {
+					return nil, i18n.Errorf("line %d: unclosed quote", line)
+				}
+				quoted += "\n" + lines[i]
+			}
+		default:
+			if c := strings.Index(value, " #"); c >= 0 {
+				value = strings.TrimSpace(value[:c])
+			}
+		}
+		env = append(env, key+"="+value)
+	}
+	return env, nil
+}
+
+// unquote returns the value of s up to its closing double quote, with escapes replaced.
+// ok is false if s has no closing quote.
+func unquote(s string) (value string, ok bool) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			return b.String(), true
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\':
+				b.WriteByte(s[i])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", false
+}
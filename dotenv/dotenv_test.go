@@ -0,0 +1,127 @@
+package dotenv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    []string
+		expectError bool
+	}{
+		{
+			name:     "given a basic variable, should parse",
+			in:       "FOO=bar",
+			expected: []string{"FOO=bar"},
+		},
+		{
+			name:     "given comments and blank lines, should ignore them",
+			in:       "# comment\n\nFOO=bar\n  # indented comment",
+			expected: []string{"FOO=bar"},
+		},
+		{
+			name:     "given export prefix, should parse",
+			in:       "export FOO=bar",
+			expected: []string{"FOO=bar"},
+		},
+		{
+			name:     "given an inline comment, should strip it",
+			in:       "FOO=bar # the foo",
+			expected: []string{"FOO=bar"},
+		},
+		{
+			name:     "given single quotes, should take value literally",
+			in:       `FOO='bar $baz \n # x'`,
+			expected: []string{`FOO=bar $baz \n # x`},
+		},
+		{
+			name:     "given double quotes, should handle escapes",
+			in:       `FOO="a\nb \"c\""`,
+			expected: []string{"FOO=a\nb \"c\""},
+		},
+		{
+			name:     "given an empty value, should parse",
+			in:       "FOO=",
+			expected: []string{"FOO="},
+		},
+		{
+			name:     "given values with equals, should keep them",
+			in:       "URL=http://x?a=b",
+			expected: []string{"URL=http://x?a=b"},
+		},
+		{
+			name:     "given double quotes, should keep unknown escapes",
+			in:       `FOO="a\q\r\\b"`,
+			expected: []string{"FOO=a\\q\r\\b"},
+		},
+		{
+			name:     "given double quotes over lines, should join them",
+			in:       "CERT=\"-----BEGIN\nabc\n-----END\"\nB=2",
+			expected: []string{"CERT=-----BEGIN\nabc\n-----END", "B=2"},
+		},
+		{
+			name:     "given windows line endings, should remove them",
+			in:       "A=1\r\nB=2\r\n",
+			expected: []string{"A=1", "B=2"},
+		},
+		{
+			name:     "given a # without a space before it, should keep it",
+			in:       "FOO=a#b",
+			expected: []string{"FOO=a#b"},
+		},
+		{
+			name:        "given a line without equals, should error",
+			in:          "FOO",
+			expectError: true,
+		},
+		{
+			name:        "given an unterminated quote, should error",
+			in:          `FOO="bar`,
+			expectError: true,
+		},
+		{
+			name:        "given an invalid name, should error",
+			in:          "1A=1",
+			expectError: true,
+		},
+		{
+			name:        "given an unclosed quote over lines, should error",
+			in:          "A=\"abc\nB=1",
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := Parse(strings.NewReader(tt.in))
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if strings.Join(env, ",") != strings.Join(tt.expected, ",") {
+				t.Fatalf("want=%q got=%q", tt.expected, env)
+			}
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("A=1\nB=2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	env, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(env, ",") != "A=1,B=2" {
+		t.Fatalf("want=[A=1 B=2] got=%v", env)
+	}
+	if _, err := ReadFile(path + ".missing"); err == nil {
+		t.Fatal("expected error got nil")
+	}
+}
@@ -0,0 +1,257 @@
+// Package expr parses and evaluates the conditions of tasks, the If and Skip attributes and the MatrixExclude of a matrix,
+// so they behave the same wherever they appear.
+package expr
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/joerdav/xc/i18n"
+)
+
+// Expr is a parsed condition such as `os() == "linux" && !fileExists("certs/server.pem")`.
+//
+// Values are strings, they are true unless they are empty, "false" or "0".
+// Strings are quoted with " or ', and can be compared with == and !=.
+// Values are combined with !, && and ||, and grouped with parentheses.
+// The functions are env(name), fileExists(path), os() and arch().
+type Expr struct {
+	root node
+}
+
+// Env is what the functions of an Expr look at.
+type Env struct {
+	// Getenv returns the value of an environment variable, or an empty string if it isn't set.
+	Getenv func(name string) string
+	// FileExists returns true if a file or directory exists at path.
+	FileExists func(path string) bool
+	// OS and Arch are the operating system and architecture, such as linux and amd64.
+	OS, Arch string
+}
+
+// funcs are the functions of an Expr and the number of arguments they take.
+var funcs = map[string]int{"env": 1, "fileExists": 1, "os": 0, "arch": 0}
+
+type node struct {
+	op       string
+	value    string
+	children []node
+}
+
+// Parse parses a condition, see Expr.
+func Parse(s string) (Expr, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return Expr{}, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.or()
+	if err != nil {
+		return Expr{}, err
+	}
+	if p.pos < len(p.tokens) {
+		return Expr{}, i18n.Errorf("unexpected %s in condition", p.tokens[p.pos])
+	}
+	return Expr{root: root}, nil
+}
+
+// Eval returns true if the condition holds in env.
+func (e Expr) Eval(env Env) bool {
+	return truthy(e.root.eval(env))
+}
+
+func truthy(v string) bool {
+	return v != "" && v != "false" && v != "0"
+}
+
+func boolValue(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+func (n node) eval(env Env) string {
+	switch n.op {
+	case "value":
+		return n.value
+	case "!":
+		return boolValue(!truthy(n.children[0].eval(env)))
+	case "&&":
+		return boolValue(truthy(n.children[0].eval(env)) && truthy(n.children[1].eval(env)))
+	case "||":
+		return boolValue(truthy(n.children[0].eval(env)) || truthy(n.children[1].eval(env)))
+	case "==":
+		return boolValue(n.children[0].eval(env) == n.children[1].eval(env))
+	case "!=":
+		return boolValue(n.children[0].eval(env) != n.children[1].eval(env))
+	}
+	args := make([]string, len(n.children))
+	for i, c := range n.children {
+		args[i] = c.eval(env)
+	}
+	switch n.value {
+	case "env":
+		if env.Getenv == nil {
+			return ""
+		}
+		return env.Getenv(args[0])
+	case "fileExists":
+		return boolValue(env.FileExists != nil && env.FileExists(args[0]))
+	case "os":
+		return env.OS
+	default:
+		return env.Arch
+	}
+}
+
+// tokenize splits s into strings, which keep their opening quote, names and operators.
+func tokenize(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, i18n.Errorf("unclosed string in condition %q", s)
+			}
+			tokens = append(tokens, s[i:i+end+1])
+			i += end + 2
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, string(c))
+			i++
+		case strings.HasPrefix(s[i:], "&&") || strings.HasPrefix(s[i:], "||") ||
+			strings.HasPrefix(s[i:], "==") || strings.HasPrefix(s[i:], "!="):
+			tokens = append(tokens, s[i:i+2])
+			i += 2
+		case c == '!':
+			tokens = append(tokens, "!")
+			i++
+		case unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)) || c == '_' || c == '.':
+			start := i
+			for i < len(s) && (unicode.IsLetter(rune(s[i])) || unicode.IsDigit(rune(s[i])) || s[i] == '_' || s[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, s[start:i])
+		default:
+			return nil, i18n.Errorf("unexpected %s in condition", string(c))
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) or() (node, error) {
+	return p.binary(p.and, "||")
+}
+
+func (p *parser) and() (node, error) {
+	return p.binary(p.unary, "&&")
+}
+
+// binary parses operands joined by op, which is left associative.
+func (p *parser) binary(operand func() (node, error), op string) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return left, err
+	}
+	for p.peek() == op {
+		p.next()
+		right, err := operand()
+		if err != nil {
+			return right, err
+		}
+		left = node{op: op, children: []node{left, right}}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.peek() == "!" {
+		p.next()
+		n, err := p.unary()
+		return node{op: "!", children: []node{n}}, err
+	}
+	left, err := p.primary()
+	if err != nil {
+		return left, err
+	}
+	if op := p.peek(); op == "==" || op == "!=" {
+		p.next()
+		right, err := p.primary()
+		if err != nil {
+			return right, err
+		}
+		return node{op: op, children: []node{left, right}}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch {
+	case t == "":
+		return node{}, i18n.Errorf("unexpected end of condition")
+	case t == "(":
+		n, err := p.or()
+		if err != nil {
+			return n, err
+		}
+		if p.next() != ")" {
+			return n, i18n.Errorf("missing ) in condition")
+		}
+		return n, nil
+	case t[0] == '"' || t[0] == '\'':
+		return node{op: "value", value: t[1:]}, nil
+	case p.peek() == "(":
+		return p.call(t)
+	case strings.ContainsAny(t[:1], "0123456789") || t == "true" || t == "false":
+		return node{op: "value", value: t}, nil
+	}
+	return node{}, i18n.Errorf("unexpected %s in condition", t)
+}
+
+// call parses the arguments of a call of the function name.
+func (p *parser) call(name string) (node, error) {
+	arity, ok := funcs[name]
+	if !ok {
+		return node{}, i18n.Errorf("unknown function %s in condition", name)
+	}
+	p.next()
+	n := node{op: "call", value: name}
+	for p.peek() != ")" {
+		if len(n.children) > 0 && p.next() != "," {
+			return n, i18n.Errorf("missing ) in condition")
+		}
+		arg, err := p.or()
+		if err != nil {
+			return n, err
+		}
+		n.children = append(n.children, arg)
+	}
+	p.next()
+	if len(n.children) != arity {
+		return n, i18n.Errorf("function %s takes %d arguments, not %d", name, arity, len(n.children))
+	}
+	return n, nil
+}
@@ -0,0 +1,57 @@
+package expr
+
+import "testing"
+
+func TestExpr(t *testing.T) {
+	env := Env{
+		Getenv: func(name string) string {
+			return map[string]string{"CI": "true", "STAGE": "prod", "DEBUG": "0"}[name]
+		},
+		FileExists: func(path string) bool {
+			return path == "go.mod"
+		},
+		OS:   "linux",
+		Arch: "amd64",
+	}
+	tests := []struct {
+		name     string
+		in       string
+		expected bool
+		err      bool
+	}{
+		{name: "given a set variable, should be true", in: `env("CI")`, expected: true},
+		{name: "given an unset variable, should be false", in: `env("MISSING")`},
+		{name: "given a variable set to 0, should be false", in: `env("DEBUG")`},
+		{name: "given a comparison, should compare the values", in: `env("STAGE") == "prod"`, expected: true},
+		{name: "given a not equal comparison, should compare the values", in: `env('STAGE') != 'prod'`},
+		{name: "given an existing file, should be true", in: `fileExists("go.mod")`, expected: true},
+		{name: "given a negated missing file, should be true", in: `!fileExists("certs/server.pem")`, expected: true},
+		{name: "given os and arch, should compare them", in: `os() == "linux" && arch() == "amd64"`, expected: true},
+		{name: "given or, should be true if either is", in: `os() == "windows" || env("CI")`, expected: true},
+		{name: "given and before or, should bind and tighter", in: `os() == "linux" || env("CI") && env("MISSING")`, expected: true},
+		{name: "given parentheses, should group", in: `(os() == "linux" || env("CI")) && env("MISSING")`},
+		{name: "given literals, should be truthy", in: `true && !false && 1`, expected: true},
+		{name: "given an unknown function, should error", in: `exists("go.mod")`, err: true},
+		{name: "given the wrong number of arguments, should error", in: `os("linux")`, err: true},
+		{name: "given an unclosed string, should error", in: `env("CI) == "1"`, err: true},
+		{name: "given a bare name, should error", in: `CI == "true"`, err: true},
+		{name: "given a missing operand, should error", in: `env("CI") &&`, err: true},
+		{name: "given an unclosed parenthesis, should error", in: `(env("CI")`, err: true},
+		{name: "given trailing tokens, should error", in: `env("CI") env("CI")`, err: true},
+		{name: "given an empty condition, should error", in: ``, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.in)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v got %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if got := c.Eval(env); got != tt.expected {
+				t.Fatalf("want=%v got=%v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// Package glob matches slash separated paths against patterns that support `**`.
+package glob
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Match reports whether name matches pattern.
+// Patterns use the syntax of path.Match for each path segment,
+// and a `**` segment matches zero or more segments.
+// Both pattern and name use forward slashes.
+func Match(pattern, name string) bool {
+	return matchSegments(split(clean(pattern)), split(clean(name)))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+func clean(p string) string {
+	p = path.Clean(filepath.ToSlash(p))
+	return strings.TrimPrefix(p, "./")
+}
+
+func split(p string) []string {
+	if p == "." || p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// hasMeta reports whether p contains any of the special characters of a pattern.
+func hasMeta(p string) bool {
+	return strings.ContainsAny(p, `*?[\`)
+}
+
+// Matcher matches paths against a list of include patterns and exclude patterns.
+type Matcher struct {
+	include, exclude []string
+}
+
+// NewMatcher returns a Matcher for patterns, patterns starting with `!` exclude paths.
+// A pattern without special characters matches the path itself and everything below it,
+// so a directory such as `./src` can be used as a pattern.
+func NewMatcher(patterns []string) Matcher {
+	var m Matcher
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		exclude := strings.HasPrefix(p, "!")
+		p = clean(strings.TrimPrefix(p, "!"))
+		ps := []string{p}
+		if !hasMeta(p) {
+			ps = append(ps, path.Join(p, "**"))
+		}
+		if exclude {
+			m.exclude = append(m.exclude, ps...)
+			continue
+		}
+		m.include = append(m.include, ps...)
+	}
+	return m
+}
+
+// Match reports whether name matches an include pattern and no exclude pattern.
+func (m Matcher) Match(name string) bool {
+	return matchAny(m.include, name) && !matchAny(m.exclude, name)
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if Match(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Files returns the files below dir that match the patterns, as slash separated paths relative to dir.
+// The .git directory is never included.
+func Files(dir string, patterns []string) ([]string, error) {
+	m := NewMatcher(patterns)
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if d.Name() == ".git" || (rel != "." && matchAny(m.exclude, rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if m.Match(rel) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
+}
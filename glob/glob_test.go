@@ -0,0 +1,75 @@
+package glob
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		expected      bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/xc/main.go", true},
+		{"cmd/**", "cmd/xc/main.go", true},
+		{"cmd/**", "run/run.go", false},
+		{"cmd/**/*.txt", "cmd/xc/usage.txt", true},
+		{"cmd/**/*.txt", "cmd/usage.txt", true},
+		{"./src/*.ts", "src/app.ts", true},
+		{"src/[ab].ts", "src/c.ts", false},
+		{"**", "any/path/at/all", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			if got := Match(tt.pattern, tt.name); got != tt.expected {
+				t.Fatalf("want=%v got=%v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	m := NewMatcher([]string{"./src", "**/*.go", "!**/*_test.go", "!src/gen"})
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"src/app.ts", true},
+		{"src/gen/api.ts", false},
+		{"run/run.go", true},
+		{"run/run_test.go", false},
+		{"README.md", false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.name); got != tt.expected {
+			t.Fatalf("%s want=%v got=%v", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"main.go", "cmd/xc/main.go", "dist/out.go", ".git/config.go", "README.md"} {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := Files(dir, []string{"**/*.go", "!dist"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "cmd/xc/main.go,main.go"
+	if strings.Join(files, ",") != expected {
+		t.Fatalf("want=%s got=%v", expected, files)
+	}
+}
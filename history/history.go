@@ -0,0 +1,190 @@
+// Package history records the tasks that were run by xc.
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var runsBucket = []byte("runs")
+
+// openTimeout is how long to wait for another xc process to release the store.
+const openTimeout = time.Second
+
+// Run is a single invocation of a task.
+type Run struct {
+	// Project is the path of the task file.
+	Project string   `json:"project"`
+	Task    string   `json:"task"`
+	Args    []string `json:"args,omitempty"`
+	// Env holds the inputs of the task that were provided as environment variables.
+	Env      []string      `json:"env,omitempty"`
+	Profile  string        `json:"profile,omitempty"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	// Error is the error returned by the run, it is empty if the run succeeded.
+	Error string `json:"error,omitempty"`
+	// ExitCode is the exit code of xc after the run, it is 0 if the run succeeded.
+	ExitCode int `json:"exitCode,omitempty"`
+	// Commit is the git commit that was checked out in the project when the run started, if it is in a git repository.
+	Commit string `json:"commit,omitempty"`
+	// Dependency is true if the task ran as a dependency of another run,
+	// only the dependencies that have a Throttle are recorded.
+	Dependency bool `json:"dependency,omitempty"`
+}
+
+// DefaultMaxRuns is the number of runs of a project that are kept if Retention.MaxRuns isn't set.
+const DefaultMaxRuns = 1000
+
+// Retention configures which runs of a project are kept by Prune.
+type Retention struct {
+	// MaxRuns is the number of most recent runs that are kept, DefaultMaxRuns is used if it is zero.
+	MaxRuns int
+	// MaxAge is how long runs are kept for, runs are kept regardless of age if it is zero.
+	MaxAge time.Duration
+}
+
+// Failed returns true if the run returned an error.
+func (r Run) Failed() bool {
+	return r.Error != ""
+}
+
+// Store is a history of runs kept in a bbolt database.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens the store at path, creating it if it doesn't exist.
+// The store is locked until it is closed, so it should only be held open briefly.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the store.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Add records a run.
+func (s *Store) Add(r Run) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(runsBucket)
+		if err != nil {
+			return err
+		}
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		v, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		return b.Put(key(seq), v)
+	})
+}
+
+// Last returns the most recent run of project for which match returns true.
+// A nil match matches every run.
+func (s *Store) Last(project string, match func(Run) bool) (r Run, ok bool, err error) {
+	runs, err := s.Runs(project, match, 1)
+	if err != nil || len(runs) == 0 {
+		return Run{}, false, err
+	}
+	return runs[0], true, nil
+}
+
+// Runs returns the runs of project for which match returns true, most recent first.
+// A nil match matches every run, and at most limit runs are returned if limit is positive.
+func (s *Store) Runs(project string, match func(Run) bool, limit int) (runs []Run, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(runsBucket)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil && (limit <= 0 || len(runs) < limit); k, v = c.Prev() {
+			var run Run
+			if err := json.Unmarshal(v, &run); err != nil {
+				return err
+			}
+			if run.Project != project || (match != nil && !match(run)) {
+				continue
+			}
+			runs = append(runs, run)
+		}
+		return nil
+	})
+	return
+}
+
+// Prune removes the runs of project that fall outside of r, and returns the number of runs removed.
+func (s *Store) Prune(project string, r Retention, now time.Time) (removed int, err error) {
+	maxRuns := r.MaxRuns
+	if maxRuns <= 0 {
+		maxRuns = DefaultMaxRuns
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(runsBucket)
+		if b == nil {
+			return nil
+		}
+		var expired [][]byte
+		var kept int
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var run Run
+			if err := json.Unmarshal(v, &run); err != nil {
+				return err
+			}
+			if run.Project != project {
+				continue
+			}
+			if kept >= maxRuns || (r.MaxAge > 0 && now.Sub(run.Start) > r.MaxAge) {
+				expired = append(expired, append([]byte{}, k...))
+				continue
+			}
+			kept++
+		}
+		// Keys are deleted after iterating, deleting under a cursor can skip keys.
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		removed = len(expired)
+		return nil
+	})
+	return
+}
+
+// Average returns the mean duration of the runs that succeeded,
+// ok is false if none of the runs succeeded.
+func Average(runs []Run) (avg time.Duration, ok bool) {
+	var total time.Duration
+	var n int
+	for _, r := range runs {
+		if r.Failed() {
+			continue
+		}
+		total += r.Duration
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return total / time.Duration(n), true
+}
+
+func key(seq uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, seq)
+	return k
+}
@@ -0,0 +1,145 @@
+package history
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	t.Run("given no runs, should return nothing", func(t *testing.T) {
+		_, ok, err := s.Last("README.md", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			t.Fatal("expected no run")
+		}
+	})
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	runs := []Run{
+		{Project: "README.md", Task: "test", Start: start, Error: "exit status 1", ExitCode: 1, Commit: "0123456789abcdef"},
+		{Project: "README.md", Task: "deploy", Args: []string{"prod"}, Env: []string{"REGION=eu"}, Start: start.Add(time.Minute)},
+		{Project: "other/README.md", Task: "build", Start: start.Add(2 * time.Minute)},
+	}
+	for _, r := range runs {
+		if err := s.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Run("given runs, should return the last run of the project", func(t *testing.T) {
+		r, ok, err := s.Last("README.md", nil)
+		if err != nil || !ok {
+			t.Fatalf("expected a run got ok=%v err=%v", ok, err)
+		}
+		if r.Task != "deploy" || r.Args[0] != "prod" || r.Env[0] != "REGION=eu" {
+			t.Fatalf("unexpected run %+v", r)
+		}
+	})
+	t.Run("given a match, should return the last matching run", func(t *testing.T) {
+		r, ok, err := s.Last("README.md", Run.Failed)
+		if err != nil || !ok {
+			t.Fatalf("expected a run got ok=%v err=%v", ok, err)
+		}
+		if r.Task != "test" || !r.Failed() || r.ExitCode != 1 || r.Commit != "0123456789abcdef" {
+			t.Fatalf("unexpected run %+v", r)
+		}
+	})
+	t.Run("given a limit, should return the most recent runs first", func(t *testing.T) {
+		runs, err := s.Runs("README.md", nil, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(runs) != 1 || runs[0].Task != "deploy" {
+			t.Fatalf("unexpected runs %+v", runs)
+		}
+		if runs, err = s.Runs("README.md", nil, 0); err != nil || len(runs) != 2 {
+			t.Fatalf("expected 2 runs got %d err=%v", len(runs), err)
+		}
+	})
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		retention Retention
+		expected  []string
+	}{
+		{name: "given the default retention, should keep every run", expected: []string{"d", "c", "b", "a"}},
+		{name: "given max runs, should keep the most recent runs", retention: Retention{MaxRuns: 2}, expected: []string{"d", "c"}},
+		{name: "given max age, should remove old runs", retention: Retention{MaxAge: 36 * time.Hour}, expected: []string{"d", "c"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Open(filepath.Join(t.TempDir(), "history.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			for i, task := range []string{"a", "b", "c", "d"} {
+				if err := s.Add(Run{Project: "README.md", Task: task, Start: now.Add(time.Duration(i-3) * 24 * time.Hour)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := s.Add(Run{Project: "other/README.md", Task: "old", Start: now.Add(-100 * 24 * time.Hour)}); err != nil {
+				t.Fatal(err)
+			}
+			removed, err := s.Prune("README.md", tt.retention, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if removed != 4-len(tt.expected) {
+				t.Fatalf("removed=%d want=%d", removed, 4-len(tt.expected))
+			}
+			runs, err := s.Runs("README.md", nil, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range runs {
+				got = append(got, r.Task)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Fatalf("want=%v got=%v", tt.expected, got)
+			}
+			if other, _ := s.Runs("other/README.md", nil, 0); len(other) != 1 {
+				t.Fatalf("expected the runs of other projects to be kept, got %d", len(other))
+			}
+		})
+	}
+}
+
+func TestAverage(t *testing.T) {
+	tests := []struct {
+		name     string
+		runs     []Run
+		expected time.Duration
+		ok       bool
+	}{
+		{name: "given no runs, should not be ok"},
+		{name: "given only failed runs, should not be ok", runs: []Run{{Duration: time.Second, Error: "exit status 1"}}},
+		{
+			name:     "given runs, should average the runs that succeeded",
+			runs:     []Run{{Duration: time.Second}, {Duration: 3 * time.Second}, {Duration: time.Hour, Error: "killed"}},
+			expected: 2 * time.Second,
+			ok:       true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			avg, ok := Average(tt.runs)
+			if avg != tt.expected || ok != tt.ok {
+				t.Fatalf("want=%v,%v got=%v,%v", tt.expected, tt.ok, avg, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package i18n
+
+// de holds the German translations.
+var de = map[string]string{
+	"Task has required inputs:\n\t%s\n\t%s":                                          "Der Task hat erforderliche Eingaben:\n\t%s\n\t%s",
+	"command block in task %s was not ended":                                         "der Befehlsblock im Task %s wurde nicht beendet",
+	"config overrides task %s which does not exist":                                  "die Konfiguration überschreibt den nicht vorhandenen Task %s",
+	"config run contains invalid behaviour %q should be (default, always, once): %s": "config run enthält ungültiges Verhalten %q, erlaubt sind (default, always, once): %s",
+	"config runDeps contains invalid behaviour %q should be (sync, async): %s": "config runDeps enthält ungültiges Verhalten %q, " +
+		"erlaubt sind (sync, async): %s",
+	"data:": "Daten:",
+	"directory appears more than once for %s": "directory ist für %s mehrfach angegeben",
+	"error getting current directory: %w":     "Fehler beim Ermitteln des aktuellen Verzeichnisses: %w",
+	"failed to compose script: %w":            "Skript konnte nicht erstellt werden: %w",
+	"failed to create execution file":         "Ausführungsdatei konnte nicht erstellt werden",
+	"failed to open config: %w":               "Konfiguration konnte nicht geöffnet werden: %w",
+	"failed to parse config: %w":              "Konfiguration konnte nicht gelesen werden: %w",
+	"failed to parse task: %w":                "Task konnte nicht gelesen werden: %w",
+	"failed to read file: %w":                 "Datei konnte nicht gelesen werden: %w",
+	"failed to write execution file":          "Ausführungsdatei konnte nicht geschrieben werden",
+	"failed to write script header: %w":       "Skriptkopf konnte nicht geschrieben werden: %w",
+	"failed to write script: %w":              "Skript konnte nicht geschrieben werden: %w",
+	"max dependency depth of %d reached":      "maximale Abhängigkeitstiefe von %d erreicht",
+	"no xc block found":                       "kein xc-Block gefunden",
+	"no xc compatible markdown file found":    "keine xc-kompatible Markdown-Datei gefunden",
+	"profile %s not found":                    "Profil %s nicht gefunden",
+	"profile flag %s: %w":                     "Profil-Flag %s: %w",
+	"run contains invalid behaviour %q should be (default, always, once): %s": "run enthält ungültiges Verhalten %q, erlaubt sind (default, always, once): %s",
+	"runDeps contains invalid behaviour %q should be (sync, async): %s":       "runDeps enthält ungültiges Verhalten %q, erlaubt sind (sync, async): %s",
+	"task %q ran already: skipping\n":                                         "Task %q wurde bereits ausgeführt: wird übersprungen\n",
+	"copied %s to clipboard\n":                                                "%s in die Zwischenablage kopiert\n",
+	"no tasks match %q":                                                       "keine Tasks passen zu %q",
+	"no tasks match %q\n":                                                     "keine Tasks passen zu %q\n",
+	"parallel:":                                                               "parallel:",
+	"ran already":                                                             "bereits ausgeführt",
+	"(skipped: %s)":                                                           "(übersprungen: %s)",
+	"cache entry has no key":                                                  "Cache-Eintrag hat keinen Schlüssel",
+	"cache cleared":                                                           "Cache geleert",
+	"cache:":                                                                  "Cache:",
+	"%d entries, %s\n":                                                        "%d Einträge, %s\n",
+	"created %s ago":                                                          "vor %s erstellt",
+	"used %s":                                                                 "verwendet %s",
+	"removed %d cache entries\n":                                              "%d Cache-Einträge entfernt\n",
+	"invalid age %q":                                                          "ungültiges Alter %q",
+	"invalid value %q for input %s of task %s, should be one of (%s)":    "ungültiger Wert %q für Eingabe %s von Task %s, erlaubt sind (%s)",
+	"inputs contains invalid input %q: %s":                               "inputs enthält ungültige Eingabe %q: %s",
+	"config inputs contains invalid input %q: %s":                        "config inputs enthält ungültige Eingabe %q: %s",
+	"usage: xc history [-n <runs>] [-failed] [task] | xc history export": "Verwendung: xc history [-n <runs>] [-failed] [task] | xc history export",
+	"xc history: %w":                                                     "xc history: %w",
+	"xc config error: logs maxAge: %w":                                   "xc Konfigurationsfehler: logs maxAge: %w",
+	"xc config error: logs maxSize: %w":                                  "xc Konfigurationsfehler: logs maxSize: %w",
+	"invalid report %q, should be format=path":                           "ungültiger Bericht %q, sollte format=pfad sein",
+	"unknown report format %q":                                           "unbekanntes Berichtsformat %q",
+	"xc: failed to write report: %w":                                     "xc: Bericht konnte nicht geschrieben werden: %w",
+	"invalid notify for %s: %w":                                          "ungültiges Notify in %s: %w",
+	"invalid notification target %q, desktop has no channel":             "ungültiges Benachrichtigungsziel %q, desktop hat keinen Kanal",
+	"invalid notification target %q, should be desktop or slack#channel": "ungültiges Benachrichtigungsziel %q, sollte desktop oder slack#kanal sein",
+	"desktop notifications are not supported on %s":                      "Desktop-Benachrichtigungen werden auf %s nicht unterstützt",
+	"no slack webhook configured":                                        "kein Slack-Webhook konfiguriert",
+	"slack webhook returned %s":                                          "Slack-Webhook antwortete mit %s",
+	"xc: %s":                                                             "xc: %s",
+	"succeeded in %s":                                                    "erfolgreich in %s",
+	"failed after %s: %s":                                                "fehlgeschlagen nach %s: %s",
+	"xc: failed to notify %s: %s":                                        "xc: Benachrichtigung an %s fehlgeschlagen: %s",
+	"invalid size %q":                                                    "ungültige Größe %q",
+	"xc config error: history maxAge: %w":                                "xc Konfigurationsfehler: history maxAge: %w",
+	"no runs recorded":                                                   "keine Ausführungen aufgezeichnet",
+	"ok":                                                                 "ok",
+	"failed":                                                             "fehlgeschlagen",
+	"no data directory":                                                  "kein Datenverzeichnis",
+	"prompt %q should be NAME=question: %s":                              "prompt %q sollte NAME=Frage sein: %s",
+	"prompt for input %s appears more than once for %s":                  "prompt für Eingabe %s kommt mehrmals vor in %s",
+	"task %s has a prompt for %s which is not one of its inputs":         "Task %s hat einen prompt für %s, das keine seiner Eingaben ist",
+	"inheritEnv contains invalid pattern %q: %s":                         "inheritEnv enthält ungültiges Muster %q: %s",
+	"invalid problems for %s: %w":                                        "ungültige Problems in %s: %w",
+	"invalid problem matcher %q: %w":                                     "ungültiger Problem-Matcher %q: %w",
+	"problem matcher %q has no message group":                            "Problem-Matcher %q hat keine Gruppe message",
+	"xc: %s ~%s avg":                                                     "xc: %s ~%s im Schnitt",
+	"xc: %s ~%s avg, %s elapsed":                                         "xc: %s ~%s im Schnitt, %s vergangen",
+	"~%s avg":                                                            "~%s im Schnitt",
+	"stdin appears more than once for %s":                                "stdin kommt mehrmals vor in %s",
+	"stdout appears more than once for %s":                               "stdout kommt mehrmals vor in %s",
+	"failed to open stdin of task %s: %w":                                "stdin von Task %s konnte nicht geöffnet werden: %w",
+	"failed to open stdout of task %s: %w":                               "stdout von Task %s konnte nicht geöffnet werden: %w",
+	"no task changes since %s\n":                                         "keine Änderungen an Tasks seit %s\n",
+	"task %s has a parsing error: %s":                                    "Task %s hat einen Lesefehler: %s",
+	"task %s has no commands or required tasks":                          "Task %s hat keine Befehle oder erforderlichen Tasks",
+	"task %s not found":                                                  "Task %s nicht gefunden",
+	"telemetry: off":                                                     "Telemetrie: aus",
+	"telemetry: on":                                                      "Telemetrie: an",
+	"usage: xc cache status|prune <age>|clear|push":                      "Verwendung: xc cache status|prune <age>|clear|push",
+	"usage: xc copy [-script] <task> [inputs...]":                        "Verwendung: xc copy [-script] <task> [inputs...]",
+	"usage: xc deps <task> [inputs...]":                                  "Verwendung: xc deps <task> [inputs...]",
+	"usage: xc diff [ref]":                                               "Verwendung: xc diff [ref]",
+	"usage: xc rerun [-failed]":                                          "Verwendung: xc rerun [-failed]",
+	"usage: xc search [-regex] <query>":                                  "Verwendung: xc search [-regex] <query>",
+	"usage: xc telemetry on|off|status":                                  "Verwendung: xc telemetry on|off|status",
+	"xc copy: %w":                                                        "xc copy: %w",
+	"xc diff: %w":                                                        "xc diff: %w",
+	"%s requires a task":                                                 "%s benötigt einen Task",
+	"%s: %w":                                                             "%s: %w",
+	"xc search: %w":                                                      "xc search: %w",
+	"xc cache: %w":                                                       "xc Cache: %w",
+	"xc config error: %w":                                                "xc Konfigurationsfehler: %w",
+	"xc error opening file: %w":                                          "xc Fehler beim Öffnen der Datei: %w",
+	"xc parse error: %w":                                                 "xc Lesefehler: %w",
+	"xc telemetry: %w":                                                   "xc Telemetrie: %w",
+	"xc version: %s\n":                                                   "xc Version: %s\n",
+	"xc: %w":                                                             "xc: %w",
+	"xc: Inputs for %s":                                                  "xc: Eingaben für %s",
+	"xc: waiting for changes":                                            "xc: warte auf Änderungen",
+	"xc: %s changed, restarting %s\n":                                    "xc: %s geändert, starte %s neu\n",
+	"xc watch: %w":                                                       "xc watch: %w",
+	"xc: rerunning %s\n":                                                 "xc: führe %s erneut aus\n",
+	"xc rerun: %w":                                                       "xc rerun: %w",
+	"xc rerun: no previous run found":                                    "xc rerun: keine vorherige Ausführung gefunden",
+	"xc: Choose a task":                                                  "xc: Wähle einen Task",
+	"a task needs a name":                                                "ein Task braucht einen Namen",
+	"task %s already exists":                                             "der Task %s existiert bereits",
+	"a task needs a script or required tasks":                            "ein Task braucht ein Skript oder benötigte Tasks",
+	"xc: New task":                                                       "xc: Neuer Task",
+	"Name":                                                               "Name",
+	"Description":                                                        "Beschreibung",
+	"Requires":                                                           "Benötigt",
+	"Interpreter":                                                        "Interpreter",
+	"Script":                                                             "Skript",
+	"enter: next • esc: cancel":                                          "enter: weiter • esc: abbrechen",
+	"space: select • enter: next • esc: cancel":                          "leertaste: auswählen • enter: weiter • esc: abbrechen",
+	"ctrl+d: save • esc: cancel":                                         "ctrl+d: speichern • esc: abbrechen",
+	"usage: xc new":                                                      "Verwendung: xc new",
+	"xc new: requires a terminal":                                        "xc new: benötigt ein Terminal",
+	"xc new: %w":                                                         "xc new: %w",
+	"xc: added task %s to %s\n":                                          "xc: Task %s zu %s hinzugefügt\n",
+	"usage: xc manifest":                                                 "Verwendung: xc manifest",
+	"xc manifest: %w":                                                    "xc manifest: %w",
+	"usage: xc graph [-serve] [-addr <address>] [task]":                  "Verwendung: xc graph [-serve] [-addr <address>] [task]",
+	"xc graph: %w":                                                       "xc graph: %w",
+	"xc: serving the graph on %s, press ctrl+c to stop\n":                "xc: der Graph wird unter %s bereitgestellt, ctrl+c zum Beenden\n",
+	"task %s requires the input %s":                                      "der Task %s benötigt die Eingabe %s",
+	"invalid value for input %s of task %s, should be one of (%s)": "ungültiger Wert für Eingabe %s von Task %s, erlaubt sind (%s)",
+	"usage: xc bundle <task> [-o <file>]":                          "Verwendung: xc bundle <task> [-o <file>]",
+	"xc bundle: %w":                                                "xc bundle: %w",
+	"xc: bundled %s to %s\n":                                       "xc: %s nach %s gebündelt\n",
+	"%s not found, install it from https://www.shellcheck.net":     "%s nicht gefunden, installiere es von https://www.shellcheck.net",
+	"failed to run %s: %w":                                         "%s konnte nicht ausgeführt werden: %w",
+	"failed to read the output of %s: %w":                          "die Ausgabe von %s konnte nicht gelesen werden: %w",
+	"usage: xc validate [-shellcheck] [-strict]":                   "Verwendung: xc validate [-shellcheck] [-strict]",
+	"xc validate: %w":                                              "xc validate: %w",
+	"xc: %s is valid\n":                                            "xc: %s ist gültig\n",
+	"no files match %s":                                            "keine Dateien passen zu %s",
+	"only one of -watch-restart, -watch-queue and -watch-ignore can be used":                          "nur eines von -watch-restart, -watch-queue und -watch-ignore kann verwendet werden",
+	"xc: %s changed, running %s again\n":                                                              "xc: %s geändert, führe %s erneut aus\n",
+	"xc: %s changed, %s will run again when it finishes\n":                                            "xc: %s geändert, %s wird erneut ausgeführt, sobald er beendet ist\n",
+	"xc: %s changed while %s is running, ignoring\n":                                                  "xc: %s geändert, während %s läuft, wird ignoriert\n",
+	"resources contains an empty name: %s":                                                            "resources enthält einen leeren Namen: %s",
+	"xc config error: resources %s: capacity must be at least 1, got %d":                              "xc Konfigurationsfehler: resources %s: Kapazität muss mindestens 1 sein, ist %d",
+	"task %q succeeded %s ago, within its throttle of %s: skipping\n":                                 "Task %q war vor %s erfolgreich, innerhalb seines Throttle von %s: wird übersprungen\n",
+	"throttle contains invalid duration %q: %s":                                                       "throttle enthält ungültige Dauer %q: %s",
+	"config throttle contains invalid duration %q: %s":                                                "config throttle enthält ungültige Dauer %q: %s",
+	"invalid cache remote %q: %w":                                                                     "ungültiges Cache-Remote %q: %w",
+	"invalid cache remote %q, should start with s3://, gs://, http:// or https://":                    "ungültiges Cache-Remote %q, sollte mit s3://, gs://, http:// oder https:// beginnen",
+	"cache entry %s failed verification, its digest doesn't match":                                    "Cache-Eintrag %s hat die Prüfung nicht bestanden, seine Prüfsumme stimmt nicht überein",
+	"cache remote is read only":                                                                       "Cache-Remote ist schreibgeschützt",
+	"failed to download %s from the cache remote: %w":                                                 "%s konnte nicht vom Cache-Remote heruntergeladen werden: %w",
+	"failed to download %s from the cache remote: %s":                                                 "%s konnte nicht vom Cache-Remote heruntergeladen werden: %s",
+	"failed to upload %s to the cache remote: %w":                                                     "%s konnte nicht zum Cache-Remote hochgeladen werden: %w",
+	"failed to upload %s to the cache remote: %s":                                                     "%s konnte nicht zum Cache-Remote hochgeladen werden: %s",
+	"no credentials for the gs cache remote, set GOOGLE_OAUTH_ACCESS_TOKEN or log in with gcloud: %w": "keine Anmeldedaten für das gs-Cache-Remote, GOOGLE_OAUTH_ACCESS_TOKEN setzen oder mit gcloud anmelden: %w",
+	"no credentials for the s3 cache remote, set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY":         "keine Anmeldedaten für das s3-Cache-Remote, AWS_ACCESS_KEY_ID und AWS_SECRET_ACCESS_KEY setzen",
+	"cache archive contains invalid path %q":                                                          "Cache-Archiv enthält ungültigen Pfad %q",
+	"cache archive of %s has no metadata":                                                             "Cache-Archiv von %s hat keine Metadaten",
+	"xc config error: cache remote mode %q should be (read-only, read-write)":                         "xc Konfigurationsfehler: Cache-Remote-Modus %q, erlaubt sind (read-only, read-write)",
+	"remote: %s (%s)\n":                                                               "Remote: %s (%s)\n",
+	"xc cache: no cache remote configured":                                            "xc Cache: kein Cache-Remote konfiguriert",
+	"uploaded %d cache entries\n":                                                     "%d Cache-Einträge hochgeladen\n",
+	"aliases contains invalid alias %q: %s":                                           "aliases enthält ungültigen Alias %q: %s",
+	"alias %s of task %s is already used by task %s":                                  "Alias %s von Task %s wird bereits von Task %s verwendet",
+	"task %s is defined in both %s and %s":                                            "Task %s ist sowohl in %s als auch in %s definiert",
+	"failed to include %s: %s":                                                        "%s konnte nicht eingebunden werden: %s",
+	"frontmatter was not ended":                                                       "Frontmatter wurde nicht beendet",
+	"failed to parse frontmatter: %w":                                                 "Frontmatter konnte nicht gelesen werden: %w",
+	"interpreter appears more than once for %s":                                       "interpreter kommt mehrmals vor in %s",
+	"no tasks are tagged %s":                                                          "keine Tasks haben das Tag %s",
+	"task %s is not tagged %s":                                                        "Task %s hat nicht das Tag %s",
+	"tags contains an empty name: %s":                                                 "tags enthält einen leeren Namen: %s",
+	"task %q is deprecated: %s\n":                                                     "Task %q ist veraltet: %s\n",
+	"deprecated should have a notice, e.g. use another-task: %s":                      "deprecated braucht einen Hinweis, z. B. use anderer-task: %s",
+	"(deprecated)":                                                                    "(veraltet)",
+	"invalid value %q for input %s of task %s, should be an integer":                  "ungültiger Wert %q für Eingabe %s von Task %s, erwartet wird eine ganze Zahl",
+	"invalid value %q for input %s of task %s, should be true or false":               "ungültiger Wert %q für Eingabe %s von Task %s, erwartet wird true oder false",
+	"requiresEnv contains invalid name %q: %s":                                        "requiresEnv enthält ungültigen Namen %q: %s",
+	"task %s requires the environment variables %s, which are not set":                "der Task %s benötigt die Umgebungsvariablen %s, die nicht gesetzt sind",
+	"task %s requires the environment variable %s":                                    "der Task %s benötigt die Umgebungsvariable %s",
+	"platform contains invalid value %q, should be GOOS or GOOS/GOARCH: %s":           "platform enthält ungültigen Wert %q, erlaubt ist GOOS oder GOOS/GOARCH: %s",
+	"task %s only runs on %s, not on %s/%s":                                           "der Task %s läuft nur auf %s, nicht auf %s/%s",
+	"%s has nested tasks, so it can only have a description":                          "%s enthält verschachtelte Tasks und kann daher nur eine Beschreibung haben",
+	"matrix appears more than once for %s":                                            "matrix kommt mehrmals vor in %s",
+	"invalid matrix %q, should be like os=[linux,darwin] arch=[amd64]: %s":            "ungültige matrix %q, erwartet wird z. B. os=[linux,darwin] arch=[amd64]: %s",
+	"extends appears more than once for %s":                                           "extends kommt mehrmals vor in %s",
+	"extends should name a task: %s":                                                  "extends muss einen Task nennen: %s",
+	"tasks extend each other in a cycle: %s":                                          "Tasks erweitern sich gegenseitig im Kreis: %s",
+	"task %s extends %s, which is not found":                                          "der Task %s erweitert %s, der nicht gefunden wurde",
+	"only the first command block of task %s can have a shebang":                      "nur der erste Befehlsblock des Tasks %s kann ein Shebang haben",
+	"the command blocks of task %s are in different languages":                        "die Befehlsblöcke des Tasks %s sind in verschiedenen Sprachen",
+	"task %q is written in %s, which has no interpreter: running it with the shell\n": "Task %q ist in %s geschrieben, wofür es keinen Interpreter gibt: er wird mit der Shell ausgeführt\n",
+	"required task %s failed":                                                         "der erforderliche Task %s ist fehlgeschlagen",
+	"unexpected %s in condition":                                                      "unerwartetes %s in der Bedingung",
+	"unclosed string in condition %q":                                                 "nicht geschlossene Zeichenkette in der Bedingung %q",
+	"unexpected end of condition":                                                     "unerwartetes Ende der Bedingung",
+	"missing ) in condition":                                                          "fehlende ) in der Bedingung",
+	"unknown function %s in condition":                                                "unbekannte Funktion %s in der Bedingung",
+	"function %s takes %d arguments, not %d":                                          "die Funktion %s nimmt %d Argumente, nicht %d",
+	"if appears more than once for %s":                                                "if kommt für %s mehr als einmal vor",
+	"invalid condition of %s: %w":                                                     "ungültige Bedingung von %s: %w",
+	"within its throttle":                                                             "innerhalb seines Throttle",
+	"condition is false":                                                              "Bedingung ist falsch",
+	"task %q runs if %s, which is false: skipping\n":                                  "Task %q läuft nur, wenn %s, was falsch ist: wird übersprungen\n",
+	"args should name at least one argument: %s":                                      "args sollte mindestens ein Argument nennen: %s",
+	"task %q is up to date: skipping\n":                                               "Task %q ist aktuell: wird übersprungen\n",
+	"up to date":                                                                      "aktuell",
+	"%s contains an empty pattern: %s":                                                "%s enthält ein leeres Muster: %s",
+	"heading %q is not a task, it is part of the description of %s":                   "die Überschrift %q ist kein Task, sie gehört zur Beschreibung von %s",
+	"heading %q is not a task, it is nested too deeply":                               "die Überschrift %q ist kein Task, sie ist zu tief verschachtelt",
+	"unknown attribute %s":                                                            "unbekanntes Attribut %s",
+	"task %s is already defined on line %d":                                           "der Task %s ist bereits in Zeile %d definiert",
+	"the command block of task %s is empty":                                           "der Befehlsblock des Tasks %s ist leer",
+	"failed to load %s required by %s: %w":                                            "%s, benötigt von %s, konnte nicht geladen werden: %w",
+	"task %s required by %s not found in %s":                                          "der von %s benötigte Task %s wurde nicht in %s gefunden",
+	"invalid annotation of %s: %w":                                                    "ungültige Annotation von %s: %w",
+	"invalid annotation %q of %s, it should be key=value":                             "ungültige Annotation %q von %s, sie sollte die Form key=value haben",
+	"shell appears more than once for %s":                                             "shell kommt mehrmals vor in %s",
+	"shell should name a command: %s":                                                 "shell sollte einen Befehl nennen: %s",
+	"not started because another task failed":                                         "nicht gestartet, weil ein anderer Task fehlgeschlagen ist",
+	"cached":                                        "zwischengespeichert",
+	"task %q is cached: skipping\n":                 "Task %q ist zwischengespeichert: wird übersprungen\n",
+	"failed to hash the sources of %s: %w":          "Hash der Quellen von %s konnte nicht berechnet werden: %w",
+	"task %q couldn't be read from the cache: %v\n": "Task %q konnte nicht aus dem Cache gelesen werden: %v\n",
+	"task %q couldn't be cached: %v\n":              "Task %q konnte nicht zwischengespeichert werden: %v\n",
+	"then:":                                         "danach:",
+	"on failure:":                                   "bei Fehler:",
+	"dir:":                                          "Verzeichnis:",
+	"env:":                                          "Umgebung:",
+	"interpreter:":                                  "Interpreter:",
+	"shell:":                                        "Shell:",
+	"xc: %s timed out after %s: %w":                 "xc: Zeitüberschreitung von %s nach %s: %w",
+	"xc: -output should be one of (%s, %s), not %s":            "xc: -output sollte eines von (%s, %s) sein, nicht %s",
+	"-log-format should be one of (%s, %s, %s=<path>), not %s": "-log-format sollte eines von (%s, %s, %s=<path>) sein, nicht %s",
+	"%s signal received":     "Signal %s empfangen",
+	"xc: %s stopped, %w: %w": "xc: %s gestoppt, %w: %w",
+	"finally:":               "abschließend:",
+	"failed to read dotenv file %s of %s: %w": "Dotenv-Datei %s von %s konnte nicht gelesen werden: %w",
+	"invalid dotenv file %s of %s: %w":        "ungültige Dotenv-Datei %s von %s: %w",
+	"line %d: expected NAME=value":            "Zeile %d: NAME=Wert erwartet",
+	"line %d: invalid variable name %q":       "Zeile %d: ungültiger Variablenname %q",
+	"line %d: unclosed quote":                 "Zeile %d: nicht geschlossenes Anführungszeichen",
+	"dotenv contains an empty path: %s":       "dotenv enthält einen leeren Pfad: %s",
+	"xc: task %s is reading from the terminal, set Interactive: true if it needs it\n": "xc: Task %s liest vom Terminal, Interactive: true setzen, falls er es braucht\n",
+	"container:": "Container:",
+	"container appears more than once for %s":                             "container kommt mehrmals vor in %s",
+	"container should name an image: %s":                                  "container sollte ein Image nennen: %s",
+	"no container runtime was found, install docker or podman, or set %s": "keine Container-Laufzeit gefunden, docker oder podman installieren oder %s setzen",
+	"failed to find the directory to mount: %w":                           "das einzuhängende Verzeichnis wurde nicht gefunden: %w",
+	"remote:":                              "Remote:",
+	"remote appears more than once for %s": "remote kommt mehrmals vor in %s",
+	"remote should name a host: %s":        "remote sollte einen Host nennen: %s",
+	"a script can't run both in the container %s and on the host %s": "ein Skript kann nicht sowohl im Container %s als auch auf dem Host %s laufen",
+	"%s (declared: %s)":          "%s (angegeben: %s)",
+	"directory %s doesn't exist": "Verzeichnis %s existiert nicht",
+	"%s is not a directory":      "%s ist kein Verzeichnis",
+	"not started":                "nicht gestartet",
+	"xc: summary of %d tasks":    "xc: Zusammenfassung von %d Tasks",
+	"exit code %d":               "Exit-Code %d",
+	"skipped":                    "übersprungen",
+	"succeeded":                  "erfolgreich",
+	"xc: -j should be a positive number of jobs, not %d":        "xc: -j sollte eine positive Anzahl von Jobs sein, nicht %d",
+	"xc: %s should be a positive number of jobs, not %s":        "xc: %s sollte eine positive Anzahl von Jobs sein, nicht %s",
+	"xc config error: jobs should be a positive number, not %d": "xc Konfigurationsfehler: jobs sollte eine positive Zahl sein, nicht %d",
+	"lock contains an empty name: %s":                           "lock enthält einen leeren Namen: %s",
+	"schedule %q should have 5 fields, not %d":                  "Zeitplan %q sollte 5 Felder haben, nicht %d",
+	"invalid %s in schedule %q: %w":                             "ungültiges Feld %s im Zeitplan %q: %w",
+	"invalid step %s":                                           "ungültige Schrittweite %s",
+	"invalid range %s":                                          "ungültiger Bereich %s",
+	"a step should follow * or a range, not %s":                 "eine Schrittweite sollte auf * oder einen Bereich folgen, nicht auf %s",
+	"%s should be between %d and %d":                            "%s sollte zwischen %d und %d liegen",
+	"invalid schedule for %s: %w":                               "ungültiger Zeitplan für %s: %w",
+	"xc schedule: %w":                                           "xc schedule: %w",
+	"%s is scheduled %s, next at %s":                            "%s ist geplant mit %s, nächste Ausführung %s",
+	"stopping, waiting for %d running tasks":                    "wird beendet, warte auf %d laufende Tasks",
+	"%s is still running, skipping this run":                    "%s läuft noch, diese Ausführung wird übersprungen",
+	"task %s has no schedule":                                   "Task %s hat keinen Zeitplan",
+	"no tasks have a schedule":                                  "keine Tasks haben einen Zeitplan",
+	"the schedule of %s never fires":                            "der Zeitplan von %s wird nie fällig",
+	"running %s":                                                "führe %s aus",
+	"%s failed after %s: %v":                                    "%s nach %s fehlgeschlagen: %v",
+	"%s succeeded in %s":                                        "%s erfolgreich in %s",
+	"usage: xc -last":                                           "Verwendung: xc -last",
+	"rerun last":                                                "letzte wiederholen",
+	"task %s refers to undefined variables: %s":                 "Task %s verweist auf nicht definierte Variablen: %s",
+	"skip appears more than once for %s":                        "skip kommt mehrmals vor in %s",
+	"matrixExclude appears more than once for %s":               "matrixExclude kommt mehrmals vor in %s",
+	"matrixExclude of %s needs a matrix":                        "matrixExclude von %s braucht eine matrix",
+	"task %q is skipped if %s, which is true: skipping\n":       "Task %q soll übersprungen werden, wenn %s, was wahr ist: wird übersprungen\n",
+	"task %q is excluded from its matrix by %s: skipping\n":     "Task %q ist durch %s aus seiner Matrix ausgeschlossen: wird übersprungen\n",
+	"skip condition is true":                                    "Überspringbedingung ist wahr",
+	"excluded from the matrix":                                  "aus der Matrix ausgeschlossen",
+	"dependency cycle: %s":                                      "Zyklische Abhängigkeit: %s",
+	"  %s is defined at %s:%d":                                  "  %s ist in %s:%d definiert",
+	"  %s is defined on line %d":                                "  %s ist in Zeile %d definiert",
+	"Tags":                                                      "Tags",
+	"xc: found %s and %s":                                       "xc: %s und %s gefunden",
+	"xc validate: found %s and %s":                              "xc validate: %s und %s gefunden",
+	"xc: %s failed":                                             "xc: %s fehlgeschlagen",
+	"%d error":                                                  "%d Fehler",
+	"%d errors":                                                 "%d Fehler",
+	"%d warning":                                                "%d Warnung",
+	"%d warnings":                                               "%d Warnungen",
+	"%d task":                                                   "%d Task",
+	"%d tasks":                                                  "%d Tasks",
+	"File:  %s":                                                 "Datei:  %s",
+	"task file %s is included in a cycle":                       "Task-Datei %s wird in einem Zyklus eingebunden",
+	"cache entry %s has the file %q, which is not an output of task %s": "Cache-Eintrag %s enthält die Datei %q, die keine Ausgabe der Aufgabe %s ist",
+	"failed to open env file: %w":                                       "Env-Datei konnte nicht geöffnet werden: %w",
+}
@@ -0,0 +1,85 @@
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// catalogs holds the translations for each supported language.
+// Messages are keyed by their English format string,
+// so a missing translation falls back to English.
+var catalogs = map[string]map[string]string{
+	"de": de,
+}
+
+var (
+	locale   = Detect()
+	localeMu sync.RWMutex
+)
+
+// Detect returns the language from the LC_ALL, LC_MESSAGES or LANG environment variables.
+// For example "de_DE.UTF-8" results in "de". The default is "en".
+func Detect() string {
+	for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		l := os.Getenv(v)
+		if l == "" {
+			continue
+		}
+		if l == "C" || l == "POSIX" {
+			return "en"
+		}
+		l, _, _ = strings.Cut(l, ".")
+		l, _, _ = strings.Cut(l, "_")
+		return strings.ToLower(l)
+	}
+	return "en"
+}
+
+// Locale returns the language used for translations.
+func Locale() string {
+	localeMu.RLock()
+	defer localeMu.RUnlock()
+	return locale
+}
+
+// SetLocale sets the language used for translations.
+func SetLocale(l string) {
+	localeMu.Lock()
+	defer localeMu.Unlock()
+	locale = l
+}
+
+// T returns the translation of the message, or the message itself if there is none.
+func T(message string) string {
+	if t, ok := catalogs[Locale()][message]; ok {
+		return t
+	}
+	return message
+}
+
+// Sprintf formats the translation of format.
+func Sprintf(format string, a ...interface{}) string {
+	return fmt.Sprintf(T(format), a...)
+}
+
+// Plural formats the translation of one if n is 1, otherwise of other, with n as the only argument.
+// One is the singular form, such as "%d error", and other the plural form, such as "%d errors".
+func Plural(n int, one, other string) string {
+	if n == 1 {
+		return Sprintf(one, n)
+	}
+	return Sprintf(other, n)
+}
+
+// Printf prints the translation of format to stdout.
+func Printf(format string, a ...interface{}) {
+	fmt.Printf(T(format), a...)
+}
+
+// Errorf returns an error formatted with the translation of format.
+// As with fmt.Errorf, %w can be used to wrap errors.
+func Errorf(format string, a ...interface{}) error {
+	return fmt.Errorf(T(format), a...)
+}
@@ -0,0 +1,93 @@
+package i18n
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lcAll, lcMessages, lng string
+		expected               string
+	}{
+		{name: "given no locale, should default to en", expected: "en"},
+		{name: "given LANG, should use language", lng: "de_DE.UTF-8", expected: "de"},
+		{name: "given LC_MESSAGES, should take precedence over LANG", lcMessages: "fr_FR", lng: "de_DE", expected: "fr"},
+		{name: "given LC_ALL, should take precedence", lcAll: "de", lcMessages: "fr_FR", expected: "de"},
+		{name: "given C locale, should use en", lng: "C", expected: "en"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LC_ALL", tt.lcAll)
+			t.Setenv("LC_MESSAGES", tt.lcMessages)
+			t.Setenv("LANG", tt.lng)
+			if got := Detect(); got != tt.expected {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	defer SetLocale(Locale())
+	t.Run("given the en locale, should return the message", func(t *testing.T) {
+		SetLocale("en")
+		if got := Sprintf("task %s not found", "a"); got != "task a not found" {
+			t.Fatalf("got=%q", got)
+		}
+	})
+	t.Run("given a translated message, should return the translation", func(t *testing.T) {
+		SetLocale("de")
+		if got := Sprintf("task %s not found", "a"); got != "Task a nicht gefunden" {
+			t.Fatalf("got=%q", got)
+		}
+	})
+	t.Run("given an untranslated message, should fall back to the message", func(t *testing.T) {
+		SetLocale("de")
+		if got := T("not translated"); got != "not translated" {
+			t.Fatalf("got=%q", got)
+		}
+	})
+	t.Run("given a count of one, should use the singular form", func(t *testing.T) {
+		SetLocale("de")
+		if got := Plural(1, "%d warning", "%d warnings"); got != "1 Warnung" {
+			t.Fatalf("got=%q", got)
+		}
+	})
+	t.Run("given another count, should use the plural form", func(t *testing.T) {
+		SetLocale("en")
+		if got := Plural(0, "%d warning", "%d warnings"); got != "0 warnings" {
+			t.Fatalf("got=%q", got)
+		}
+	})
+	t.Run("given a wrapped error, should be unwrappable", func(t *testing.T) {
+		SetLocale("de")
+		inner := errors.New("inner")
+		if err := Errorf("xc: %w", inner); !errors.Is(err, inner) {
+			t.Fatalf("expected %v to wrap %v", err, inner)
+		}
+	})
+}
+
+func TestCatalogsHaveMatchingVerbs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for message, translation := range catalog {
+			if verbs(message) != verbs(translation) {
+				t.Errorf("%s: translation of %q has different verbs: %q", lang, message, translation)
+			}
+		}
+	}
+}
+
+func verbs(s string) string {
+	var result []byte
+	for i := 0; i < len(s)-1; i++ {
+		if s[i] == '%' {
+			result = append(result, s[i+1])
+			i++
+		}
+	}
+	return string(result)
+}
@@ -0,0 +1,153 @@
+// Package lint finds mistakes in tasks before they run.
+package lint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/notify"
+	"github.com/joerdav/xc/problem"
+	"github.com/joerdav/xc/run"
+)
+
+// Tasks returns the problems with the attributes and dependencies of tasks, which are parsed from file
+// or the files it includes.
+func Tasks(tasks models.Tasks, file string) []problem.Problem {
+	var problems []problem.Problem
+	add := func(t models.Task, err error) {
+		problems = append(problems, problem.Problem{
+			Task:     t.Name,
+			File:     t.Path(file),
+			Line:     t.Line,
+			Severity: problem.SeverityError,
+			Message:  err.Error(),
+		})
+	}
+	// The runner is only used to validate dependencies, each task is validated separately below.
+	runner, _ := run.NewRunner(tasks, "")
+	for _, t := range tasks {
+		if err := runner.ValidateDependencies(t.Name, []string{}); err != nil {
+			add(t, err)
+		}
+		for _, m := range t.Problems {
+			if _, err := problem.Parse(m); err != nil {
+				add(t, err)
+			}
+		}
+		for _, n := range t.Notify {
+			if _, err := notify.ParseTarget(n); err != nil {
+				add(t, err)
+			}
+		}
+	}
+	return problems
+}
+
+// shellcheckShebangRe matches the shebangs of scripts that shellcheck understands.
+var shellcheckShebangRe = regexp.MustCompile(`^#!\s?/(usr/)?bin/(env\s+)?(sh|bash|mksh|bats|dash|ksh)\b`)
+
+// shellcheckShells are the shells that shellcheck understands.
+var shellcheckShells = map[string]bool{"sh": true, "bash": true, "dash": true, "ksh": true}
+
+// Shellcheck checks the scripts of tasks with shellcheck.
+type Shellcheck struct {
+	// Command is the shellcheck binary, shellcheck is looked up in the PATH if it is empty.
+	Command string
+	// run runs shellcheck with the script as its standard input, it is replaced in tests.
+	run func(ctx context.Context, command string, stdin []byte, args ...string) ([]byte, error)
+}
+
+type shellcheckOutput struct {
+	Comments []struct {
+		Line    int    `json:"line"`
+		Column  int    `json:"column"`
+		Level   string `json:"level"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"comments"`
+}
+
+// Check returns the findings of shellcheck in the script of task, located in file.
+// Scripts without a shebang are checked as bash, which is closest to the shell built into xc,
+// scripts for other interpreters, shells or languages are not checked.
+func (s Shellcheck) Check(ctx context.Context, task models.Task, file string) ([]problem.Problem, error) {
+	if task.Script == "" || task.Interpreter != "" || !run.IsShellLanguage(task.Language) {
+		return nil, nil
+	}
+	args := []string{"--format=json1"}
+	if fields := strings.Fields(task.Shell); len(fields) > 0 {
+		// The shell of the task runs the script, unless it has a shebang for another interpreter.
+		name := strings.TrimSuffix(filepath.Base(fields[0]), ".exe")
+		if !shellcheckShells[name] {
+			return nil, nil
+		}
+		args = append(args, "--shell="+name)
+	}
+	if line, _, _ := strings.Cut(task.Script, "\n"); strings.HasPrefix(line, "#!") {
+		if !shellcheckShebangRe.MatchString(line) {
+			return nil, nil
+		}
+	} else if task.Shell == "" {
+		args = append(args, "--shell=bash")
+	}
+	command := s.Command
+	if command == "" {
+		command = "shellcheck"
+	}
+	runShellcheck := s.run
+	if runShellcheck == nil {
+		runShellcheck = runCommand
+	}
+	out, err := runShellcheck(ctx, command, []byte(task.Script), append(args, "-")...)
+	// shellcheck exits with 1 if it found anything, the output is still valid.
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, i18n.Errorf("%s not found, install it from https://www.shellcheck.net", command)
+		}
+		return nil, i18n.Errorf("failed to run %s: %w", command, err)
+	}
+	var result shellcheckOutput
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, i18n.Errorf("failed to read the output of %s: %w", command, err)
+	}
+	problems := make([]problem.Problem, 0, len(result.Comments))
+	for _, c := range result.Comments {
+		p := problem.Problem{
+			Task:     task.Name,
+			File:     task.Path(file),
+			Line:     scriptLine(task, c.Line),
+			Column:   c.Column,
+			Severity: c.Level,
+			Message:  "SC" + strconv.Itoa(c.Code) + ": " + c.Message,
+		}
+		if p.Line == 0 {
+			p.Line, p.Column = task.Line, 0
+		}
+		problems = append(problems, p)
+	}
+	return problems, nil
+}
+
+// scriptLine returns the line in the task file of the given line of the script of task, or 0 if it is unknown.
+func scriptLine(task models.Task, line int) int {
+	if line < 1 || line > len(task.ScriptLines) {
+		return 0
+	}
+	return task.ScriptLines[line-1]
+}
+
+func runCommand(ctx context.Context, command string, stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	return cmd.Output()
+}
@@ -0,0 +1,136 @@
+package lint
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/problem"
+)
+
+func TestTasks(t *testing.T) {
+	tasks := models.Tasks{
+		{Name: "build", Script: "go build", Line: 3},
+		{Name: "deploy", DependsOn: []string{"build", "push"}, Notify: []string{"email"}, Line: 8},
+		{Name: "test", Script: "go test", Problems: []string{"go", "(unclosed"}, Line: 12, File: "docs/tasks.md"},
+	}
+	got := Tasks(tasks, "README.md")
+	expected := []problem.Problem{
+		{Task: "deploy", File: "README.md", Line: 8, Severity: "error", Message: "task push not found"},
+		{Task: "deploy", File: "README.md", Line: 8, Severity: "error", Message: `invalid notification target "email", should be desktop or slack#channel`},
+		{Task: "test", File: filepath.Join("docs", "tasks.md"), Line: 12, Severity: "error"},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("want=%+v got=%+v", expected, got)
+	}
+	for i := range expected {
+		// The message of an invalid regular expression comes from the regexp package.
+		if expected[i].Message == "" {
+			expected[i].Message = got[i].Message
+		}
+		if got[i] != expected[i] {
+			t.Fatalf("want=%+v got=%+v", expected[i], got[i])
+		}
+	}
+	if want := "invalid problem matcher"; !strings.HasPrefix(got[2].Message, want) {
+		t.Fatalf("want message %q got %q", want, got[2].Message)
+	}
+}
+
+func TestShellcheck(t *testing.T) {
+	sc2086 := `{"file":"-","line":2,"column":6,"level":"warning","code":2086,"message":"Double quote to prevent globbing and word splitting."}`
+	sc2001 := `{"file":"-","line":9,"column":1,"level":"info","code":2001,"message":"See if you can use ${variable//search/replace} instead."}`
+	tests := []struct {
+		name     string
+		task     models.Task
+		output   string
+		args     []string
+		expected []problem.Problem
+	}{
+		{
+			name:   "given a script without a shebang, should check it as bash and map lines to the task file",
+			task:   models.Task{Name: "greet", Script: "NAME=world\necho $NAME\n", Line: 10, ScriptLines: []int{13, 15}},
+			output: `{"comments":[` + sc2086 + "," + sc2001 + `]}`,
+			args:   []string{"--format=json1", "--shell=bash", "-"},
+			expected: []problem.Problem{
+				{Task: "greet", File: "README.md", Line: 15, Column: 6, Severity: "warning", Message: "SC2086: Double quote to prevent globbing and word splitting."},
+				{Task: "greet", File: "README.md", Line: 10, Severity: "info", Message: "SC2001: See if you can use ${variable//search/replace} instead."},
+			},
+		},
+		{
+			name:     "given a script with a sh shebang, should let shellcheck read the shebang",
+			task:     models.Task{Name: "greet", Script: "#!/bin/sh\necho $NAME\n", Line: 10, ScriptLines: []int{12, 13}},
+			output:   `{"comments":[` + sc2086 + `]}`,
+			args:     []string{"--format=json1", "-"},
+			expected: []problem.Problem{{Task: "greet", File: "README.md", Line: 13, Column: 6, Severity: "warning", Message: "SC2086: Double quote to prevent globbing and word splitting."}},
+		},
+		{
+			name: "given a python script, should not check it",
+			task: models.Task{Name: "greet", Script: "#!/usr/bin/env python3\nprint(1)\n"},
+		},
+		{
+			name: "given a script in a python code block, should not check it",
+			task: models.Task{Name: "greet", Script: "print(1)\n", Language: "python"},
+		},
+		{
+			name:   "given a dash shell, should check the script as dash",
+			task:   models.Task{Name: "greet", Script: "echo hi\n", Shell: "/bin/dash"},
+			output: `{"comments":[]}`,
+			args:   []string{"--format=json1", "--shell=dash", "-"},
+		},
+		{
+			name: "given a fish shell, should not check it",
+			task: models.Task{Name: "greet", Script: "echo hi\n", Shell: "fish"},
+		},
+		{
+			name: "given a task without a script, should not check it",
+			task: models.Task{Name: "all", DependsOn: []string{"greet"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotArgs []string
+			s := Shellcheck{run: func(_ context.Context, command string, stdin []byte, args ...string) ([]byte, error) {
+				if command != "shellcheck" || string(stdin) != tt.task.Script {
+					t.Fatalf("unexpected command %s with input %q", command, stdin)
+				}
+				gotArgs = args
+				return []byte(tt.output), nil
+			}}
+			got, err := s.Check(context.Background(), tt.task, "README.md")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.args) {
+				t.Fatalf("args want=%q got=%q", tt.args, gotArgs)
+			}
+			if len(got) != len(tt.expected) || (len(got) > 0 && !reflect.DeepEqual(got, tt.expected)) {
+				t.Fatalf("want=%+v got=%+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestShellcheckErrors(t *testing.T) {
+	task := models.Task{Name: "greet", Script: "echo hi\n"}
+	s := Shellcheck{run: func(context.Context, string, []byte, ...string) ([]byte, error) {
+		return nil, errors.New("boom")
+	}}
+	if _, err := s.Check(context.Background(), task, "README.md"); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected error got %v", err)
+	}
+	s = Shellcheck{run: func(context.Context, string, []byte, ...string) ([]byte, error) {
+		return []byte("not json"), nil
+	}}
+	if _, err := s.Check(context.Background(), task, "README.md"); err == nil {
+		t.Fatal("expected error got nil")
+	}
+	s = Shellcheck{Command: "xc-missing-shellcheck"}
+	if _, err := s.Check(context.Background(), task, "README.md"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+// Package logs keeps the log files written by xc within a retention policy.
+package logs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DefaultKeep is the number of log files that are kept if Policy.Keep isn't set.
+const DefaultKeep = 100
+
+// Policy configures which log files are kept by Prune.
+type Policy struct {
+	// Keep is the number of most recent log files that are kept, DefaultKeep is used if it is zero.
+	Keep int
+	// MaxAge is how long log files are kept for, files are kept regardless of age if it is zero.
+	MaxAge time.Duration
+	// MaxSize is the total size in bytes of the log files that are kept,
+	// the oldest files are removed first. There is no limit if it is zero.
+	MaxSize int64
+}
+
+// File is a log file.
+type File struct {
+	// Path is the path of the file, relative to the log directory.
+	Path    string
+	ModTime time.Time
+	Size    int64
+}
+
+// Files returns the log files below dir, most recently modified first.
+// A missing dir has no files.
+func Files(dir string) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == dir {
+			return filepath.SkipDir
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, File{Path: rel, ModTime: info.ModTime(), Size: info.Size()})
+		return nil
+	})
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].ModTime.After(files[j].ModTime)
+	})
+	return files, err
+}
+
+// Prune removes the log files below dir that fall outside of p, and returns the files that were removed.
+// Directories left empty are removed as well.
+func Prune(dir string, p Policy, now time.Time) ([]File, error) {
+	files, err := Files(dir)
+	if err != nil {
+		return nil, err
+	}
+	keep := p.Keep
+	if keep <= 0 {
+		keep = DefaultKeep
+	}
+	var removed []File
+	var kept int
+	var size int64
+	for _, f := range files {
+		// size includes the newer files, so once MaxSize is reached every older file is removed.
+		size += f.Size
+		if kept < keep && (p.MaxAge <= 0 || now.Sub(f.ModTime) <= p.MaxAge) && (p.MaxSize <= 0 || size <= p.MaxSize) {
+			kept++
+			continue
+		}
+		path := filepath.Join(dir, f.Path)
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, f)
+		removeEmptyParents(dir, filepath.Dir(path))
+	}
+	return removed, nil
+}
+
+func removeEmptyParents(root, dir string) {
+	for dir != root && len(dir) > len(root) {
+		// Remove fails if the directory isn't empty.
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,83 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		policy   Policy
+		expected []string
+	}{
+		{name: "given the default policy, should keep every file", expected: []string{"d.log", "test/c.log", "b.log", "test/a.log"}},
+		{name: "given keep, should keep the most recent files", policy: Policy{Keep: 2}, expected: []string{"d.log", "test/c.log"}},
+		{name: "given max age, should remove old files", policy: Policy{MaxAge: 36 * time.Hour}, expected: []string{"d.log", "test/c.log"}},
+		{name: "given max size, should remove the oldest files", policy: Policy{MaxSize: 25}, expected: []string{"d.log", "test/c.log"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for i, name := range []string{"test/a.log", "b.log", "test/c.log", "d.log"} {
+				p := filepath.Join(dir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, []byte("0123456789"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				mod := now.Add(time.Duration(i-3) * 24 * time.Hour)
+				if err := os.Chtimes(p, mod, mod); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := Prune(dir, tt.policy, now); err != nil {
+				t.Fatal(err)
+			}
+			files, err := Files(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, f := range files {
+				got = append(got, filepath.ToSlash(f.Path))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Fatalf("want=%v got=%v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPruneRemovesEmptyDirs(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "test", "run", "a.log")
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Prune(dir, Policy{MaxAge: time.Nanosecond}, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "test")); !os.IsNotExist(err) {
+		t.Fatalf("expected empty dirs to be removed, got %v", err)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Fatalf("expected the log dir to be kept, got %v", err)
+	}
+}
+
+func TestFilesMissingDir(t *testing.T) {
+	files, err := Files(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || len(files) != 0 {
+		t.Fatalf("expected no files got %v err=%v", files, err)
+	}
+}
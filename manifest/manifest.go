@@ -0,0 +1,284 @@
+// Package manifest describes the tasks of a project as a versioned JSON document,
+// for use by exporters, editors and other tooling.
+package manifest
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/joerdav/xc/models"
+)
+
+// SchemaID identifies the version of the manifest format.
+// It changes whenever a field is removed or its meaning changes, new fields can be added within a version.
+const SchemaID = "https://xcfile.dev/schema/manifest/v1.json"
+
+// Manifest describes every task of a project.
+type Manifest struct {
+	Schema string `json:"$schema"`
+	// File is the path of the task file.
+	File string `json:"file"`
+	// Heading is the heading of the tasks section in the task file.
+	Heading string `json:"heading"`
+	Tasks   []Task `json:"tasks"`
+	// Graph is the dependency graph of the tasks.
+	Graph Graph `json:"graph"`
+}
+
+// Task describes a single task and its attributes.
+type Task struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases"`
+	Description []string `json:"description"`
+	Script      string   `json:"script"`
+	Dir         string   `json:"dir,omitempty"`
+	Env         []string `json:"env"`
+	Requires    []string `json:"requires"`
+	Inputs      []Input  `json:"inputs"`
+	// Run is default, always or once.
+	Run string `json:"run"`
+	// RunDeps is either sync or async.
+	RunDeps      string `json:"runDeps"`
+	Interactive  bool   `json:"interactive"`
+	Stdin        string `json:"stdin,omitempty"`
+	Stdout       string `json:"stdout,omitempty"`
+	AppendStdout bool   `json:"appendStdout,omitempty"`
+	// InheritEnv is null if every host environment variable is inherited.
+	InheritEnv []string `json:"inheritEnv"`
+	Problems   []string `json:"problems"`
+	Notify     []string `json:"notify"`
+	Resources  []string `json:"resources"`
+	Watch      []string `json:"watch"`
+	Tags       []string `json:"tags"`
+	// RequiresEnv holds the environment variables that must be set for the task to run.
+	RequiresEnv []string `json:"requiresEnv"`
+	// Platforms holds the platforms the task runs on, as GOOS or GOOS/GOARCH, it runs on every platform if it is empty.
+	Platforms []string `json:"platforms"`
+	// Sources and Outputs hold the glob patterns of the files the script reads and writes,
+	// the task is skipped if its outputs are newer than its sources.
+	Sources []string `json:"sources"`
+	Outputs []string `json:"outputs"`
+	// If is the condition the task runs under, it always runs if it is empty.
+	If string `json:"if,omitempty"`
+	// Skip is the condition the task is skipped under, the opposite of If.
+	Skip string `json:"skip,omitempty"`
+	// Metadata holds the key=value pairs of the `<!-- xc: -->` comments of the task.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Matrix holds the axes that the task is expanded by, its variants are listed as tasks too.
+	Matrix []MatrixAxis `json:"matrix"`
+	// MatrixExclude is the condition a variant of a matrix is skipped under, with its values as environment variables.
+	MatrixExclude string `json:"matrixExclude,omitempty"`
+	// Throttle is the duration after a successful run during which the task is skipped, e.g. 1h0m0s.
+	Throttle string `json:"throttle,omitempty"`
+	// Finally holds the tasks that run after the task, even if it fails or is cancelled.
+	Finally []string `json:"finally,omitempty"`
+	// Dotenv holds the paths of the dotenv files loaded for the task, relative to its directory.
+	Dotenv []string `json:"dotenv,omitempty"`
+	// Language is the language of the code blocks of the script, e.g. python.
+	Language string `json:"language,omitempty"`
+	// Interpreter runs the script instead of the shell if it has no shebang, e.g. python3.
+	Interpreter string `json:"interpreter,omitempty"`
+	// Shell runs shell scripts instead of the shell built into xc, e.g. bash.
+	Shell string `json:"shell,omitempty"`
+	// Container is the image that runs the script, e.g. golang:1.22.
+	Container string `json:"container,omitempty"`
+	// Remote is the host that runs the script over ssh, e.g. deploy@example.com.
+	Remote string `json:"remote,omitempty"`
+	// ScriptFile is true if the script is run from a temporary file with its shebang, rather than by the shell built into xc.
+	ScriptFile bool `json:"scriptFile,omitempty"`
+	// Locks are the locks the script holds while it runs, tasks that hold the same lock never run at the same time.
+	Locks []string `json:"locks,omitempty"`
+	// Schedule is the cron expression of when `xc schedule` runs the task, e.g. */5 * * * *.
+	Schedule string `json:"schedule,omitempty"`
+	// Extends is the task that the task inherits from, its attributes are listed after inheriting.
+	Extends string `json:"extends,omitempty"`
+	// ExtendsAppend is true if the script of the task is appended to the script it inherits.
+	ExtendsAppend bool `json:"extendsAppend,omitempty"`
+	// Hidden is true if the task is left out of listings.
+	Hidden bool `json:"hidden,omitempty"`
+	// Deprecated is the notice printed when the task runs, e.g. use deploy.
+	Deprecated string `json:"deprecated,omitempty"`
+	// File is the task file the task was included from, relative to the directory of the main task file.
+	File string `json:"file,omitempty"`
+	// Line is the line number of the task heading in the task file.
+	Line int `json:"line"`
+	// Error is set if the task failed to parse.
+	Error string `json:"error,omitempty"`
+}
+
+// MatrixAxis is a variable of a task matrix and the values it takes.
+type MatrixAxis struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// Input describes an input of a task.
+type Input struct {
+	Name string `json:"name"`
+	// Type is string, int or bool.
+	Type string `json:"type"`
+	// Choices are the allowed values of the input, any value is allowed if there are none.
+	Choices []string `json:"choices"`
+	// Default is used if the input isn't provided, the input is required if it is null.
+	Default *string `json:"default,omitempty"`
+	Prompt  *Prompt `json:"prompt,omitempty"`
+}
+
+// Prompt describes how a missing input is asked for.
+type Prompt struct {
+	Question string `json:"question"`
+	Default  string `json:"default,omitempty"`
+	Secret   bool   `json:"secret,omitempty"`
+}
+
+// Graph is the dependency graph of the tasks, with a node for each task.
+type Graph struct {
+	Nodes []string `json:"nodes"`
+	Edges []Edge   `json:"edges"`
+}
+
+// Edge is a dependency of a task on a required task.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Missing is true if the required task doesn't exist.
+	Missing bool `json:"missing,omitempty"`
+}
+
+// New returns the manifest of tasks parsed from the tasks section under heading in file.
+func New(tasks models.Tasks, file, heading string) Manifest {
+	m := Manifest{
+		Schema:  SchemaID,
+		File:    file,
+		Heading: heading,
+		Tasks:   make([]Task, 0, len(tasks)),
+		Graph:   Graph{Nodes: make([]string, 0, len(tasks)), Edges: []Edge{}},
+	}
+	for _, t := range tasks {
+		m.Tasks = append(m.Tasks, newTask(t))
+		m.Graph.Nodes = append(m.Graph.Nodes, t.Name)
+		for _, d := range t.DependsOn {
+			e := Edge{From: t.Name, To: d}
+			if r, ok := tasks.Get(d); ok {
+				e.To = r.Name
+			} else {
+				e.Missing = true
+			}
+			m.Graph.Edges = append(m.Graph.Edges, e)
+		}
+	}
+	return m
+}
+
+func newTask(t models.Task) Task {
+	task := Task{
+		Name:         t.Name,
+		Aliases:      nonNil(t.Aliases),
+		Description:  nonNil(t.Description),
+		Script:       t.Script,
+		Dir:          t.Dir,
+		Env:          nonNil(t.Env),
+		Requires:     nonNil(t.DependsOn),
+		Finally:      t.Finally,
+		Inputs:       make([]Input, 0, len(t.Inputs)),
+		Run:          t.RequiredBehaviour.String(),
+		RunDeps:      t.DepsBehaviour.String(),
+		Interactive:  t.Interactive,
+		Extends:      t.Extends,
+		Hidden:       !t.Visible(),
+		Deprecated:   t.Deprecated,
+		Stdin:        t.Stdin,
+		Stdout:       t.Stdout,
+		AppendStdout: t.AppendStdout,
+		InheritEnv:   t.InheritEnv,
+		Dotenv:       t.Dotenv,
+		RequiresEnv:  nonNil(t.RequiresEnv),
+		Platforms:    nonNil(t.Platforms),
+		Matrix:       make([]MatrixAxis, 0, len(t.Matrix)),
+		Problems:     nonNil(t.Problems),
+		Notify:       nonNil(t.Notify),
+		Resources:    nonNil(t.Resources),
+		Watch:        nonNil(t.Watch),
+		Tags:         nonNil(t.Tags),
+		Language:     t.Language,
+		Interpreter:  t.Interpreter,
+		Shell:        t.Shell,
+		Container:    t.Container,
+		Remote:       t.Remote,
+		ScriptFile:   t.ScriptFile,
+		Locks:        t.Locks,
+		Schedule:     t.Schedule,
+		File:         t.File,
+		Line:         t.Line,
+		Error:        t.ParsingError,
+	}
+	task.ExtendsAppend = t.ExtendsAppend
+	task.If, task.Skip = t.If, t.Skip
+	task.MatrixExclude = t.MatrixExclude
+	task.Metadata = t.Metadata
+	task.Sources, task.Outputs = nonNil(t.Sources), nonNil(t.Outputs)
+	if t.Throttle > 0 {
+		task.Throttle = t.Throttle.String()
+	}
+	for _, a := range t.Matrix {
+		task.Matrix = append(task.Matrix, MatrixAxis{Name: a.Name, Values: a.Values})
+	}
+	for _, n := range t.Inputs {
+		spec := t.Input(n)
+		in := Input{Name: n, Type: spec.Type.String(), Choices: nonNil(spec.Choices), Default: spec.Default}
+		if p := spec.Prompt; p != nil {
+			in.Prompt = &Prompt{Question: p.Question, Default: p.Default, Secret: p.Secret}
+		}
+		task.Inputs = append(task.Inputs, in)
+	}
+	return task
+}
+
+// nonNil returns s, or an empty slice if s is nil, so that lists are never null in the JSON.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// Write writes m to w as indented JSON.
+func (m Manifest) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// Path returns the nodes that run when task runs: the task and everything it requires, directly or indirectly.
+// Nodes are returned in the order of the graph, missing requirements are left out.
+func (g Graph) Path(task string) []string {
+	requires := map[string][]string{}
+	for _, e := range g.Edges {
+		if !e.Missing {
+			requires[e.From] = append(requires[e.From], e.To)
+		}
+	}
+	seen := map[string]bool{}
+	var visit func(n string)
+	visit = func(n string) {
+		if seen[n] {
+			return
+		}
+		seen[n] = true
+		for _, r := range requires[n] {
+			visit(r)
+		}
+	}
+	for _, n := range g.Nodes {
+		if models.SameName(n, task) {
+			visit(n)
+		}
+	}
+	var path []string
+	for _, n := range g.Nodes {
+		if seen[n] {
+			path = append(path, n)
+		}
+	}
+	return path
+}
@@ -0,0 +1,202 @@
+package manifest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joerdav/xc/models"
+)
+
+func TestNew(t *testing.T) {
+	build := models.Task{Name: "build", Aliases: []string{"b"}, Script: "go build\n", Line: 3, DependsOn: []string{"Lint", "gen"}}
+	deploy := models.Task{
+		Name:              "deploy",
+		Description:       []string{"Deploy the app"},
+		Inputs:            []string{"ENV"},
+		InheritEnv:        []string{},
+		RequiredBehaviour: models.RequiredBehaviourOnce,
+		DepsBehaviour:     models.DependencyBehaviourAsync,
+		Line:              8,
+	}
+	deploy.SetInput("ENV", models.InputSpec{Choices: []string{"dev", "prod"}, Prompt: &models.Prompt{Question: "Where?", Default: "dev"}})
+	lint := models.Task{Name: "lint", Script: "golangci-lint run\n", Line: 14, Throttle: time.Hour, Shell: "bash"}
+	m := New(models.Tasks{build, deploy, lint}, "README.md", "Tasks")
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "$schema": "https://xcfile.dev/schema/manifest/v1.json",
+  "file": "README.md",
+  "heading": "Tasks",
+  "tasks": [
+    {
+      "name": "build",
+      "aliases": [
+        "b"
+      ],
+      "description": [],
+      "script": "go build\n",
+      "env": [],
+      "requires": [
+        "Lint",
+        "gen"
+      ],
+      "inputs": [],
+      "run": "default",
+      "runDeps": "sync",
+      "interactive": false,
+      "inheritEnv": null,
+      "problems": [],
+      "notify": [],
+      "resources": [],
+      "watch": [],
+      "tags": [],
+      "requiresEnv": [],
+      "platforms": [],
+      "sources": [],
+      "outputs": [],
+      "matrix": [],
+      "line": 3
+    },
+    {
+      "name": "deploy",
+      "aliases": [],
+      "description": [
+        "Deploy the app"
+      ],
+      "script": "",
+      "env": [],
+      "requires": [],
+      "inputs": [
+        {
+          "name": "ENV",
+          "type": "string",
+          "choices": [
+            "dev",
+            "prod"
+          ],
+          "prompt": {
+            "question": "Where?",
+            "default": "dev"
+          }
+        }
+      ],
+      "run": "once",
+      "runDeps": "async",
+      "interactive": false,
+      "inheritEnv": [],
+      "problems": [],
+      "notify": [],
+      "resources": [],
+      "watch": [],
+      "tags": [],
+      "requiresEnv": [],
+      "platforms": [],
+      "sources": [],
+      "outputs": [],
+      "matrix": [],
+      "line": 8
+    },
+    {
+      "name": "lint",
+      "aliases": [],
+      "description": [],
+      "script": "golangci-lint run\n",
+      "env": [],
+      "requires": [],
+      "inputs": [],
+      "run": "default",
+      "runDeps": "sync",
+      "interactive": false,
+      "inheritEnv": null,
+      "problems": [],
+      "notify": [],
+      "resources": [],
+      "watch": [],
+      "tags": [],
+      "requiresEnv": [],
+      "platforms": [],
+      "sources": [],
+      "outputs": [],
+      "matrix": [],
+      "throttle": "1h0m0s",
+      "shell": "bash",
+      "line": 14
+    }
+  ],
+  "graph": {
+    "nodes": [
+      "build",
+      "deploy",
+      "lint"
+    ],
+    "edges": [
+      {
+        "from": "build",
+        "to": "lint"
+      },
+      {
+        "from": "build",
+        "to": "gen",
+        "missing": true
+      }
+    ]
+  }
+}
+`
+	if got := buf.String(); got != expected {
+		t.Fatalf("want:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestNewEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := New(nil, "README.md", "Tasks").Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "$schema": "https://xcfile.dev/schema/manifest/v1.json",
+  "file": "README.md",
+  "heading": "Tasks",
+  "tasks": [],
+  "graph": {
+    "nodes": [],
+    "edges": []
+  }
+}
+`
+	if got := buf.String(); got != expected {
+		t.Fatalf("want:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestPath(t *testing.T) {
+	g := Graph{
+		Nodes: []string{"build", "deploy", "gen", "lint", "test"},
+		Edges: []Edge{
+			{From: "deploy", To: "build"},
+			{From: "deploy", To: "test"},
+			{From: "build", To: "gen"},
+			{From: "test", To: "build"},
+			{From: "test", To: "fixtures", Missing: true},
+			{From: "gen", To: "deploy"},
+		},
+	}
+	tests := []struct {
+		task     string
+		expected []string
+	}{
+		{task: "lint", expected: []string{"lint"}},
+		{task: "Test", expected: []string{"build", "deploy", "gen", "test"}},
+		{task: "missing"},
+	}
+	for _, tt := range tests {
+		got := g.Path(tt.task)
+		if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+			t.Fatalf("%s: want=%v got=%v", tt.task, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ChangeKind describes how a task differs between two sets of tasks.
+type ChangeKind int
+
+const (
+	// TaskAdded is used for a task that only exists in the new tasks.
+	TaskAdded ChangeKind = iota
+	// TaskRemoved is used for a task that only exists in the old tasks.
+	TaskRemoved
+	// TaskChanged is used for a task that exists in both, with different attributes.
+	TaskChanged
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case TaskAdded:
+		return "added"
+	case TaskRemoved:
+		return "removed"
+	default:
+		return "changed"
+	}
+}
+
+// AttributeChange is the old and new value of a single task attribute.
+type AttributeChange struct {
+	Attribute string
+	Old, New  string
+}
+
+// TaskChange describes the difference of a single task.
+// Attributes is only set for changed tasks.
+type TaskChange struct {
+	Name       string
+	Kind       ChangeKind
+	Attributes []AttributeChange
+}
+
+// Diff returns the tasks that were added, removed or changed between old and new.
+// Tasks are matched by name case insensitively, changes are ordered by the new tasks
+// followed by the removed tasks.
+func Diff(old, new Tasks) []TaskChange {
+	var changes []TaskChange
+	for _, n := range new {
+		o, ok := old.Get(n.Name)
+		if !ok {
+			changes = append(changes, TaskChange{Name: n.Name, Kind: TaskAdded})
+			continue
+		}
+		if attrs := diffAttributes(o, n); len(attrs) > 0 {
+			changes = append(changes, TaskChange{Name: n.Name, Kind: TaskChanged, Attributes: attrs})
+		}
+	}
+	for _, o := range old {
+		if _, ok := new.Get(o.Name); !ok {
+			changes = append(changes, TaskChange{Name: o.Name, Kind: TaskRemoved})
+		}
+	}
+	return changes
+}
+
+func diffAttributes(o, n Task) []AttributeChange {
+	oa, na := attributes(o), attributes(n)
+	var changes []AttributeChange
+	for i := range oa {
+		if oa[i].value != na[i].value {
+			changes = append(changes, AttributeChange{Attribute: oa[i].name, Old: oa[i].value, New: na[i].value})
+		}
+	}
+	return changes
+}
+
+type attribute struct {
+	name, value string
+}
+
+// attributes returns the comparable attributes of a task, always in the same order.
+func attributes(t Task) []attribute {
+	return []attribute{
+		{"description", strings.Join(t.Description, "\n")},
+		{"aliases", strings.Join(t.Aliases, ", ")},
+		{"requires", strings.Join(t.DependsOn, ", ")},
+		{"runDeps", t.DepsBehaviour.String()},
+		{"finally", strings.Join(t.Finally, ", ")},
+		{"directory", t.Dir},
+		{"env", strings.Join(t.Env, ", ")},
+		{"inputs", strings.Join(t.FormatInputs(), ", ")},
+		{"prompts", strings.Join(t.FormatPrompts(), "\n")},
+		{"run", t.RequiredBehaviour.String()},
+		{"interactive", strconv.FormatBool(t.Interactive)},
+		{"stdin", t.Stdin},
+		{"stdout", t.Stdout},
+		{"appendStdout", strconv.FormatBool(t.AppendStdout)},
+		{"inheritEnv", strings.Join(t.InheritEnv, ", ")},
+		{"dotenv", strings.Join(t.Dotenv, ", ")},
+		{"requiresEnv", strings.Join(t.RequiresEnv, ", ")},
+		{"problems", strings.Join(t.Problems, ", ")},
+		{"notify", strings.Join(t.Notify, ", ")},
+		{"resources", strings.Join(t.Resources, ", ")},
+		{"lock", strings.Join(t.Locks, ", ")},
+		{"schedule", t.Schedule},
+		{"watch", strings.Join(t.Watch, ", ")},
+		{"sources", strings.Join(t.Sources, ", ")},
+		{"outputs", strings.Join(t.Outputs, ", ")},
+		{"throttle", throttle(t)},
+		{"language", t.Language},
+		{"interpreter", t.Interpreter},
+		{"shell", t.Shell},
+		{"container", t.Container},
+		{"remote", t.Remote},
+		{"scriptFile", strconv.FormatBool(t.ScriptFile)},
+		{"tags", strings.Join(t.Tags, ", ")},
+		{"platform", strings.Join(t.Platforms, ", ")},
+		{"if", t.If},
+		{"skip", t.Skip},
+		{"matrix", FormatMatrix(t.Matrix)},
+		{"matrixExclude", t.MatrixExclude},
+		{"extends", t.Extends},
+		{"extendsAppend", strconv.FormatBool(t.ExtendsAppend)},
+		{"hidden", strconv.FormatBool(t.Hidden)},
+		{"deprecated", t.Deprecated},
+		{"metadata", FormatMetadata(t.Metadata)},
+		{"script", t.Script},
+	}
+}
+
+func throttle(t Task) string {
+	if t.Throttle == 0 {
+		return ""
+	}
+	return t.Throttle.String()
+}
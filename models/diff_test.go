@@ -0,0 +1,61 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new Tasks
+		expected []TaskChange
+	}{
+		{
+			name: "given identical tasks, should return no changes",
+			old:  Tasks{{Name: "build", Script: "go build"}},
+			new:  Tasks{{Name: "build", Script: "go build"}},
+		},
+		{
+			name: "given a new task, should be added",
+			old:  Tasks{{Name: "build"}},
+			new:  Tasks{{Name: "build"}, {Name: "test"}},
+			expected: []TaskChange{
+				{Name: "test", Kind: TaskAdded},
+			},
+		},
+		{
+			name: "given a missing task, should be removed",
+			old:  Tasks{{Name: "build"}, {Name: "test"}},
+			new:  Tasks{{Name: "build"}},
+			expected: []TaskChange{
+				{Name: "test", Kind: TaskRemoved},
+			},
+		},
+		{
+			name: "given a renamed casing, should match case insensitively",
+			old:  Tasks{{Name: "Build"}},
+			new:  Tasks{{Name: "build"}},
+		},
+		{
+			name: "given changed attributes, should report each attribute",
+			old:  Tasks{{Name: "build", Script: "go build", Env: []string{"A=1"}}},
+			new:  Tasks{{Name: "build", Script: "go build ./...", Env: []string{"A=1"}, RequiredBehaviour: RequiredBehaviourOnce}},
+			expected: []TaskChange{
+				{Name: "build", Kind: TaskChanged, Attributes: []AttributeChange{
+					{Attribute: "run", Old: "default", New: "once"},
+					{Attribute: "script", Old: "go build", New: "go build ./..."},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			result := Diff(tt.old, tt.new)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Fatalf("want=%+v got=%+v", tt.expected, result)
+			}
+		})
+	}
+}
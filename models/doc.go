@@ -0,0 +1,122 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DocKind is the kind of a block of the description of a task.
+type DocKind int
+
+const (
+	// DocParagraph is a paragraph of prose, its lines are joined with spaces.
+	DocParagraph DocKind = iota
+	// DocList is a bullet or numbered list, with an item for each entry.
+	DocList
+	// DocHeading is a heading within the description, such as `#### Notes`.
+	DocHeading
+	// DocQuote is a block quote, its lines are joined with spaces.
+	DocQuote
+)
+
+// DocBlock is a block of the description of a task. Text and Items keep their inline markdown, such as `code` and **emphasis**.
+type DocBlock struct {
+	Kind DocKind
+	// Text is the text of a paragraph, heading or quote.
+	Text string
+	// Items are the entries of a list, Ordered is true if it is numbered.
+	Items   []string
+	Ordered bool
+	// Level is the level of a heading.
+	Level int
+}
+
+var (
+	docBulletRe  = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+	docOrderedRe = regexp.MustCompile(`^\d+[.)]\s+(.*)$`)
+	docHeadingRe = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+)
+
+// ParseDoc splits the lines of the description of a task into blocks.
+// Blank lines end paragraphs, an indented line continues the list item before it.
+func ParseDoc(lines []string) []DocBlock {
+	var blocks []DocBlock
+	var open *DocBlock
+	for _, line := range lines {
+		text := strings.TrimSpace(line)
+		indented := text != "" && len(line)-len(strings.TrimLeft(line, " \t")) >= 2
+		item, ordered, isItem := docListItem(text)
+		switch {
+		case text == "":
+			open = nil
+		case isItem:
+			if open == nil || open.Kind != DocList || open.Ordered != ordered {
+				blocks = append(blocks, DocBlock{Kind: DocList, Ordered: ordered})
+				open = &blocks[len(blocks)-1]
+			}
+			open.Items = append(open.Items, item)
+		case open != nil && open.Kind == DocList && indented:
+			open.Items[len(open.Items)-1] += " " + text
+		case docHeadingRe.MatchString(text):
+			m := docHeadingRe.FindStringSubmatch(text)
+			blocks = append(blocks, DocBlock{Kind: DocHeading, Level: len(m[1]), Text: m[2]})
+			open = nil
+		case strings.HasPrefix(text, ">"):
+			text = strings.TrimSpace(strings.TrimPrefix(text, ">"))
+			if open == nil || open.Kind != DocQuote {
+				blocks = append(blocks, DocBlock{Kind: DocQuote, Text: text})
+				open = &blocks[len(blocks)-1]
+				continue
+			}
+			open.Text = strings.TrimSpace(open.Text + " " + text)
+		case open != nil && (open.Kind == DocParagraph || open.Kind == DocQuote):
+			open.Text += " " + text
+		default:
+			blocks = append(blocks, DocBlock{Kind: DocParagraph, Text: text})
+			open = &blocks[len(blocks)-1]
+		}
+	}
+	return blocks
+}
+
+// docListItem returns the text of a list item, and whether the list is numbered.
+func docListItem(text string) (item string, ordered, ok bool) {
+	if m := docBulletRe.FindStringSubmatch(text); m != nil {
+		return m[1], false, true
+	}
+	if m := docOrderedRe.FindStringSubmatch(text); m != nil {
+		return m[1], true, true
+	}
+	return "", false, false
+}
+
+// String returns the block as markdown.
+func (b DocBlock) String() string {
+	switch b.Kind {
+	case DocList:
+		items := make([]string, len(b.Items))
+		for i, item := range b.Items {
+			if b.Ordered {
+				items[i] = strconv.Itoa(i+1) + ". " + item
+			} else {
+				items[i] = "- " + item
+			}
+		}
+		return strings.Join(items, "\n")
+	case DocHeading:
+		return strings.Repeat("#", b.Level) + " " + b.Text
+	case DocQuote:
+		return "> " + b.Text
+	}
+	return b.Text
+}
+
+// FormatDoc returns blocks as markdown, separated by blank lines.
+func FormatDoc(blocks []DocBlock) string {
+	parts := make([]string, len(blocks))
+	for i, b := range blocks {
+		parts[i] = b.String()
+	}
+	return strings.Join(parts, "\n\n")
+}
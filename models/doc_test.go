@@ -0,0 +1,66 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDoc(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       []string
+		expected []DocBlock
+	}{
+		{
+			name: "given paragraphs, should join their lines",
+			in:   []string{"Builds the `server`", "binary.", "", "Run it **first**."},
+			expected: []DocBlock{
+				{Kind: DocParagraph, Text: "Builds the `server` binary."},
+				{Kind: DocParagraph, Text: "Run it **first**."},
+			},
+		},
+		{
+			name: "given lists, should split their items",
+			in:   []string{"Steps:", "- build", "  the binary", "* test", "1. deploy", "2) verify"},
+			expected: []DocBlock{
+				{Kind: DocParagraph, Text: "Steps:"},
+				{Kind: DocList, Items: []string{"build the binary", "test"}},
+				{Kind: DocList, Items: []string{"deploy", "verify"}, Ordered: true},
+			},
+		},
+		{
+			name: "given headings and quotes, should keep them",
+			in:   []string{"#### Notes ####", "> Needs", "> docker.", "After."},
+			expected: []DocBlock{
+				{Kind: DocHeading, Level: 4, Text: "Notes"},
+				{Kind: DocQuote, Text: "Needs docker. After."},
+			},
+		},
+		{
+			name: "given only blank lines, should have no blocks",
+			in:   []string{"", " "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseDoc(tt.in)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("want=%+v got=%+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFormatDoc(t *testing.T) {
+	blocks := []DocBlock{
+		{Kind: DocHeading, Level: 4, Text: "Notes"},
+		{Kind: DocParagraph, Text: "Builds the `server`."},
+		{Kind: DocList, Items: []string{"build", "test"}},
+		{Kind: DocList, Items: []string{"deploy"}, Ordered: true},
+		{Kind: DocQuote, Text: "Needs docker."},
+	}
+	expected := "#### Notes\n\nBuilds the `server`.\n\n- build\n- test\n\n1. deploy\n\n> Needs docker."
+	if got := FormatDoc(blocks); got != expected {
+		t.Fatalf("want=%q got=%q", expected, got)
+	}
+}
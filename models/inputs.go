@@ -0,0 +1,260 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// InputSpec describes the values accepted by an input.
+type InputSpec struct {
+	// Type is the type of the values of the input.
+	Type InputType
+	// Choices are the allowed values of the input, any value is allowed if there are none.
+	Choices []string
+	// Default is used if the input isn't provided, the input is required if it is nil.
+	Default *string
+	// Prompt is used to ask for the input on the terminal when it isn't provided, it is nil if the input isn't prompted for.
+	Prompt *Prompt
+}
+
+// InputType is the type of the values of an input.
+// The default is InputTypeString.
+type InputType int
+
+const (
+	// InputTypeString accepts any value.
+	InputTypeString InputType = iota
+	// InputTypeInt accepts integers.
+	InputTypeInt
+	// InputTypeBool accepts true and false, and the other values accepted by strconv.ParseBool.
+	InputTypeBool
+)
+
+func (t InputType) String() string {
+	switch t {
+	case InputTypeInt:
+		return "int"
+	case InputTypeBool:
+		return "bool"
+	default:
+		return "string"
+	}
+}
+
+// ParseInputType parses the type of an input, enum is a string input that must have choices.
+func ParseInputType(s string) (t InputType, enum, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "string":
+		return InputTypeString, false, true
+	case "int":
+		return InputTypeInt, false, true
+	case "bool":
+		return InputTypeBool, false, true
+	case "enum":
+		return InputTypeString, true, true
+	default:
+		return 0, false, false
+	}
+}
+
+// Prompt describes how to ask for a missing input.
+type Prompt struct {
+	Question string
+	// Default is used if the answer is empty.
+	Default string
+	// Secret inputs are not echoed while they are typed.
+	Secret bool
+}
+
+// ParsePrompt parses a prompt declaration such as `TOKEN=API token? (default: none) (secret)`.
+// The default and secret suffixes are optional, and can be in any order.
+func ParsePrompt(s string) (name string, p Prompt, ok bool) {
+	name, question, found := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if !found || name == "" {
+		return "", p, false
+	}
+	question = strings.TrimSpace(question)
+	for {
+		if q, ok := strings.CutSuffix(question, "(secret)"); ok {
+			p.Secret = true
+			question = strings.TrimSpace(q)
+			continue
+		}
+		i := strings.LastIndex(question, "(default:")
+		if i >= 0 && strings.HasSuffix(question, ")") {
+			p.Default = strings.TrimSpace(question[i+len("(default:") : len(question)-1])
+			question = strings.TrimSpace(question[:i])
+			continue
+		}
+		break
+	}
+	p.Question = question
+	if p.Question == "" {
+		p.Question = name
+	}
+	return name, p, true
+}
+
+// Fallback returns the value of the input when the answer to its prompt is empty,
+// the default of the prompt, or if it has none the default of the input.
+func (s InputSpec) Fallback() string {
+	if s.Prompt != nil && s.Prompt.Default != "" {
+		return s.Prompt.Default
+	}
+	if s.Default != nil {
+		return *s.Default
+	}
+	return ""
+}
+
+// Allows returns true if value is an allowed value of the input.
+func (s InputSpec) Allows(value string) bool {
+	switch s.Type {
+	case InputTypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return false
+		}
+	case InputTypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return false
+		}
+	}
+	if len(s.Choices) == 0 {
+		return true
+	}
+	for _, c := range s.Choices {
+		if c == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseInput parses an input declaration such as `ENV`, `ENV[dev|staging|prod]`, `REPLICAS:int` or `DRY_RUN:bool=false`.
+// The type and the default are optional, the default must be an allowed value.
+func ParseInput(s string) (name string, spec InputSpec, ok bool) {
+	s, def, hasDefault := strings.Cut(s, "=")
+	if hasDefault {
+		def = strings.TrimSpace(def)
+		spec.Default = &def
+	}
+	name, rest, found := strings.Cut(s, "[")
+	// Names can contain colons, the type is only split off if it is a known type.
+	enum := false
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		if t, e, ok := ParseInputType(name[i+1:]); ok {
+			name, spec.Type, enum = name[:i], t, e
+		}
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, "]|") {
+		return "", spec, false
+	}
+	if found {
+		choices, ok := strings.CutSuffix(strings.TrimSpace(rest), "]")
+		if !ok {
+			return "", spec, false
+		}
+		for _, c := range strings.Split(choices, "|") {
+			c = strings.TrimSpace(c)
+			if c == "" {
+				return "", spec, false
+			}
+			spec.Choices = append(spec.Choices, c)
+		}
+	}
+	if enum && len(spec.Choices) == 0 {
+		return "", spec, false
+	}
+	for _, c := range spec.Choices {
+		if !(InputSpec{Type: spec.Type}).Allows(c) {
+			return "", spec, false
+		}
+	}
+	if spec.Default != nil && !spec.Allows(*spec.Default) {
+		return "", spec, false
+	}
+	return name, spec, true
+}
+
+// Input returns the spec of the named input.
+func (t Task) Input(name string) InputSpec {
+	return t.InputSpecs[name]
+}
+
+// SetInput sets the spec of the named input.
+func (t *Task) SetInput(name string, spec InputSpec) {
+	if t.InputSpecs == nil {
+		t.InputSpecs = map[string]InputSpec{}
+	}
+	t.InputSpecs[name] = spec
+}
+
+// Signature returns the arguments of the task for usage text, e.g. `<name> <dev|prod> [replicas]`.
+// Inputs with a default, from their declaration or the environment of the task, are in square brackets.
+func (t Task) Signature() string {
+	args := make([]string, len(t.Inputs))
+	for i, n := range t.Inputs {
+		spec := t.Input(n)
+		placeholder := strings.ToLower(n)
+		if len(spec.Choices) > 0 {
+			placeholder = strings.Join(spec.Choices, "|")
+		}
+		if spec.Default != nil || t.envDefault(n) {
+			args[i] = "[" + placeholder + "]"
+		} else {
+			args[i] = "<" + placeholder + ">"
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+// envDefault returns true if the environment of the task sets the named input.
+func (t Task) envDefault(name string) bool {
+	for _, e := range t.Env {
+		if k, _, _ := strings.Cut(e, "="); k == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatInputs returns the input declarations of the task, in the syntax accepted by ParseInput.
+func (t Task) FormatInputs() []string {
+	result := make([]string, len(t.Inputs))
+	for i, n := range t.Inputs {
+		spec := t.Input(n)
+		result[i] = n
+		if spec.Type != InputTypeString {
+			result[i] += ":" + spec.Type.String()
+		}
+		if len(spec.Choices) > 0 {
+			result[i] += "[" + strings.Join(spec.Choices, "|") + "]"
+		}
+		if spec.Default != nil {
+			result[i] += "=" + *spec.Default
+		}
+	}
+	return result
+}
+
+// FormatPrompts returns the prompt declarations of the task, in the syntax accepted by ParsePrompt.
+func (t Task) FormatPrompts() []string {
+	var result []string
+	for _, n := range t.Inputs {
+		p := t.Input(n).Prompt
+		if p == nil {
+			continue
+		}
+		s := n + "=" + p.Question
+		if p.Default != "" {
+			s += " (default: " + p.Default + ")"
+		}
+		if p.Secret {
+			s += " (secret)"
+		}
+		result = append(result, s)
+	}
+	return result
+}
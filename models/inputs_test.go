@@ -0,0 +1,199 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInput(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedName string
+		expectedSpec InputSpec
+		expectedOk   bool
+	}{
+		{input: "ENV", expectedName: "ENV", expectedOk: true},
+		{input: " ENV ", expectedName: "ENV", expectedOk: true},
+		{
+			input:        "ENV[dev|staging|prod]",
+			expectedName: "ENV",
+			expectedSpec: InputSpec{Choices: []string{"dev", "staging", "prod"}},
+			expectedOk:   true,
+		},
+		{
+			input:        "ENV [ dev | prod ]",
+			expectedName: "ENV",
+			expectedSpec: InputSpec{Choices: []string{"dev", "prod"}},
+			expectedOk:   true,
+		},
+		{input: "REPLICAS:int=2", expectedName: "REPLICAS", expectedSpec: InputSpec{Type: InputTypeInt, Default: ptr("2")}, expectedOk: true},
+		{input: "DRY_RUN : bool", expectedName: "DRY_RUN", expectedSpec: InputSpec{Type: InputTypeBool}, expectedOk: true},
+		{
+			input:        "ENV:enum[dev|prod]=dev",
+			expectedName: "ENV",
+			expectedSpec: InputSpec{Choices: []string{"dev", "prod"}, Default: ptr("dev")},
+			expectedOk:   true,
+		},
+		{input: "NAME=", expectedName: "NAME", expectedSpec: InputSpec{Default: ptr("")}, expectedOk: true},
+		{input: "my:attribute", expectedName: "my:attribute", expectedOk: true},
+		{input: "ENV:enum", expectedOk: false},
+		{input: "REPLICAS:int=many", expectedOk: false},
+		{input: "ENV[dev|prod]=qa", expectedOk: false},
+		{input: "N:int[1|two]", expectedOk: false},
+		{input: "ENV[dev|prod", expectedOk: false},
+		{input: "ENV[dev||prod]", expectedOk: false},
+		{input: "[dev]", expectedOk: false},
+		{input: "", expectedOk: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			name, spec, ok := ParseInput(tt.input)
+			if ok != tt.expectedOk {
+				t.Fatalf("ok want=%v got=%v", tt.expectedOk, ok)
+			}
+			if !ok {
+				return
+			}
+			if name != tt.expectedName {
+				t.Fatalf("name want=%q got=%q", tt.expectedName, name)
+			}
+			if !reflect.DeepEqual(spec, tt.expectedSpec) {
+				t.Fatalf("spec want=%+v got=%+v", tt.expectedSpec, spec)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+func TestInputSpecAllows(t *testing.T) {
+	if !(InputSpec{}).Allows("anything") {
+		t.Fatal("expected an input without choices to allow any value")
+	}
+	s := InputSpec{Choices: []string{"dev", "prod"}}
+	if !s.Allows("prod") {
+		t.Fatal("expected a choice to be allowed")
+	}
+	if s.Allows("staging") {
+		t.Fatal("expected a value that isn't a choice to not be allowed")
+	}
+	if i := (InputSpec{Type: InputTypeInt}); !i.Allows("-3") || i.Allows("3.5") {
+		t.Fatal("expected an int input to only allow integers")
+	}
+	if b := (InputSpec{Type: InputTypeBool}); !b.Allows("true") || !b.Allows("0") || b.Allows("maybe") {
+		t.Fatal("expected a bool input to only allow booleans")
+	}
+}
+
+func TestInputSpecFallback(t *testing.T) {
+	dev, prod := "dev", "prod"
+	tests := []struct {
+		name     string
+		spec     InputSpec
+		expected string
+	}{
+		{name: "given a prompt with a default, should be the default of the prompt", spec: InputSpec{Default: &dev, Prompt: &Prompt{Default: prod}}, expected: prod},
+		{name: "given a prompt without a default, should be the default of the input", spec: InputSpec{Default: &dev, Prompt: &Prompt{}}, expected: dev},
+		{name: "given no defaults, should be empty", spec: InputSpec{Prompt: &Prompt{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.spec.Fallback(); got != tt.expected {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFormatInputs(t *testing.T) {
+	in := []string{"ENV[dev|prod]=dev", "REPLICAS:int=2", "DRY_RUN:bool", "NAME"}
+	var task Task
+	for _, s := range in {
+		name, spec, ok := ParseInput(s)
+		if !ok {
+			t.Fatalf("failed to parse %q", s)
+		}
+		task.Inputs = append(task.Inputs, name)
+		task.SetInput(name, spec)
+	}
+	if got := task.FormatInputs(); !reflect.DeepEqual(got, in) {
+		t.Fatalf("want=%q got=%q", in, got)
+	}
+}
+
+func TestSignature(t *testing.T) {
+	task := Task{
+		Inputs:     []string{"name", "ENV", "REPLICAS", "REGION"},
+		InputSpecs: map[string]InputSpec{"ENV": {Choices: []string{"dev", "prod"}}, "REPLICAS": {Type: InputTypeInt, Default: new(string)}},
+		Env:        []string{"REGION=eu-west-1"},
+	}
+	if got, want := task.Signature(), "<name> <dev|prod> [replicas] [region]"; got != want {
+		t.Fatalf("want=%q got=%q", want, got)
+	}
+}
+
+func TestParsePrompt(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedName   string
+		expectedPrompt Prompt
+		expectedOk     bool
+	}{
+		{
+			input:          "VERSION=Which version?",
+			expectedName:   "VERSION",
+			expectedPrompt: Prompt{Question: "Which version?"},
+			expectedOk:     true,
+		},
+		{
+			input:          " VERSION = Which version? (default: latest) ",
+			expectedName:   "VERSION",
+			expectedPrompt: Prompt{Question: "Which version?", Default: "latest"},
+			expectedOk:     true,
+		},
+		{
+			input:          "TOKEN=API token (secret) (default: none)",
+			expectedName:   "TOKEN",
+			expectedPrompt: Prompt{Question: "API token", Default: "none", Secret: true},
+			expectedOk:     true,
+		},
+		{
+			input:          "TOKEN=(secret)",
+			expectedName:   "TOKEN",
+			expectedPrompt: Prompt{Question: "TOKEN", Secret: true},
+			expectedOk:     true,
+		},
+		{input: "Which version?", expectedOk: false},
+		{input: "=Which version?", expectedOk: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			name, p, ok := ParsePrompt(tt.input)
+			if ok {
+				// Formatting the prompt should give a declaration that parses to the same prompt.
+				task := Task{Inputs: []string{name}}
+				task.SetInput(name, InputSpec{Prompt: &p})
+				_, formatted, _ := ParsePrompt(task.FormatPrompts()[0])
+				if formatted != p {
+					t.Fatalf("formatted prompt want=%+v got=%+v", p, formatted)
+				}
+			}
+			if ok != tt.expectedOk {
+				t.Fatalf("ok want=%v got=%v", tt.expectedOk, ok)
+			}
+			if !ok {
+				return
+			}
+			if name != tt.expectedName {
+				t.Fatalf("name want=%q got=%q", tt.expectedName, name)
+			}
+			if p != tt.expectedPrompt {
+				t.Fatalf("prompt want=%+v got=%+v", tt.expectedPrompt, p)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MatrixAxis is a variable of a task matrix and the values it takes.
+type MatrixAxis struct {
+	Name   string
+	Values []string
+}
+
+// matrixAxisRe matches an axis of a matrix such as `os=[linux,darwin]`.
+var matrixAxisRe = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)=\[([^\]]*)\]`)
+
+// ParseMatrix parses a matrix declaration such as `os=[linux,darwin] arch=[amd64,arm64]`,
+// every axis should have a distinct name and at least one value.
+func ParseMatrix(s string) (axes []MatrixAxis, ok bool) {
+	s = strings.TrimSpace(s)
+	seen := map[string]bool{}
+	for s != "" {
+		m := matrixAxisRe.FindStringSubmatch(s)
+		if m == nil || seen[m[1]] {
+			return nil, false
+		}
+		seen[m[1]] = true
+		axis := MatrixAxis{Name: m[1]}
+		for _, v := range strings.Split(m[2], ",") {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				return nil, false
+			}
+			axis.Values = append(axis.Values, v)
+		}
+		axes = append(axes, axis)
+		s = strings.TrimLeft(s[len(m[0]):], " \t,")
+	}
+	return axes, len(axes) > 0
+}
+
+// FormatMatrix formats axes as they are declared, e.g. `os=[linux,darwin] arch=[amd64,arm64]`.
+func FormatMatrix(axes []MatrixAxis) string {
+	s := make([]string, len(axes))
+	for i, a := range axes {
+		s[i] = a.Name + "=[" + strings.Join(a.Values, ",") + "]"
+	}
+	return strings.Join(s, " ")
+}
+
+// ExpandMatrix returns the task followed by a variant of it for every combination of the values of its Matrix,
+// with the values set as environment variables. The variants are named after the task and their values,
+// e.g. build:linux-amd64, and the task requires each of them in place of its script.
+// A task without a Matrix is returned by itself.
+func (t Task) ExpandMatrix() Tasks {
+	if len(t.Matrix) == 0 {
+		return Tasks{t}
+	}
+	combinations := [][]string{nil}
+	for _, a := range t.Matrix {
+		var next [][]string
+		for _, c := range combinations {
+			for _, v := range a.Values {
+				next = append(next, append(append([]string{}, c...), v))
+			}
+		}
+		combinations = next
+	}
+	parent := t
+	parent.Script, parent.ScriptLines, parent.DependsOn, parent.MatrixExclude = "", nil, nil, ""
+	// The inputs are given to each variant.
+	parent.Inputs, parent.InputSpecs = nil, nil
+	variants := make(Tasks, 0, len(combinations))
+	for _, c := range combinations {
+		v := t
+		v.Name = t.Name + ":" + strings.Join(c, "-")
+		v.Aliases, v.Matrix = nil, nil
+		var env, desc []string
+		for i, a := range t.Matrix {
+			env = append(env, a.Name+"="+c[i])
+			desc = append(desc, a.Name+"="+c[i])
+		}
+		v.Env = append(env, t.Env...)
+		v.Description = []string{strings.Join(desc, " ")}
+		parent.DependsOn = append(parent.DependsOn, v.Name)
+		variants = append(variants, v)
+	}
+	return append(Tasks{parent}, variants...)
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMatrix(t *testing.T) {
+	tests := []struct {
+		input      string
+		expected   []MatrixAxis
+		expectedOk bool
+	}{
+		{
+			input:      "os=[linux,darwin] arch=[amd64, arm64]",
+			expected:   []MatrixAxis{{Name: "os", Values: []string{"linux", "darwin"}}, {Name: "arch", Values: []string{"amd64", "arm64"}}},
+			expectedOk: true,
+		},
+		{input: " GOOS=[windows] ", expected: []MatrixAxis{{Name: "GOOS", Values: []string{"windows"}}}, expectedOk: true},
+		{input: ""},
+		{input: "os=linux"},
+		{input: "os=[linux,]"},
+		{input: "os=[linux] os=[darwin]"},
+		{input: "os=[linux] arch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := ParseMatrix(tt.input)
+			if ok != tt.expectedOk {
+				t.Fatalf("ok want=%v got=%v", tt.expectedOk, ok)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("want=%+v got=%+v", tt.expected, got)
+			}
+			if ok && FormatMatrix(got) != FormatMatrix(tt.expected) {
+				t.Fatalf("format want=%s got=%s", FormatMatrix(tt.expected), FormatMatrix(got))
+			}
+		})
+	}
+}
+
+func TestExpandMatrix(t *testing.T) {
+	task := Task{
+		Name:      "build",
+		Aliases:   []string{"b"},
+		Script:    "go build -o bin/$os-$arch\n",
+		Env:       []string{"CGO_ENABLED=0"},
+		DependsOn: []string{"gen"},
+		Inputs:    []string{"VERSION"},
+		Matrix:    []MatrixAxis{{Name: "os", Values: []string{"linux", "darwin"}}, {Name: "arch", Values: []string{"amd64", "arm64"}}},
+	}
+	got := task.ExpandMatrix()
+	var names []string
+	for _, v := range got {
+		names = append(names, v.Name)
+	}
+	expectedNames := []string{"build", "build:linux-amd64", "build:linux-arm64", "build:darwin-amd64", "build:darwin-arm64"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Fatalf("names want=%q got=%q", expectedNames, names)
+	}
+	parent := got[0]
+	if parent.Script != "" || parent.Inputs != nil || !reflect.DeepEqual(parent.DependsOn, expectedNames[1:]) || !reflect.DeepEqual(parent.Aliases, task.Aliases) {
+		t.Fatalf("unexpected parent %+v", parent)
+	}
+	v := got[3]
+	if v.Script != task.Script || v.Aliases != nil || v.Matrix != nil || !reflect.DeepEqual(v.DependsOn, task.DependsOn) || !reflect.DeepEqual(v.Inputs, task.Inputs) {
+		t.Fatalf("unexpected variant %+v", v)
+	}
+	if expected := []string{"os=darwin", "arch=amd64", "CGO_ENABLED=0"}; !reflect.DeepEqual(v.Env, expected) {
+		t.Fatalf("env want=%q got=%q", expected, v.Env)
+	}
+	if got := (Task{Name: "test"}).ExpandMatrix(); len(got) != 1 || got[0].Name != "test" {
+		t.Fatalf("want only the task got %+v", got)
+	}
+}
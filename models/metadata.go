@@ -0,0 +1,26 @@
+package models
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FormatMetadata returns metadata as space separated key=value pairs in key order,
+// values that are empty or have spaces, quotes or commas are quoted.
+func FormatMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		v := metadata[k]
+		if v == "" || strings.ContainsAny(v, " \t,\"'\\") {
+			v = strconv.Quote(v)
+		}
+		pairs[i] = k + "=" + v
+	}
+	return strings.Join(pairs, " ")
+}
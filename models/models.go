@@ -3,29 +3,135 @@ package models
 import (
 	"fmt"
 	"io"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 // Task represents a parsed Task.
 type Task struct {
-	Name              string
-	Description       []string
-	Script            string
-	Dir               string
-	Env               []string
-	DependsOn         []string
-	Inputs            []string
+	Name        string
+	Description []string
+	Script      string
+	Dir         string
+	Env         []string
+	DependsOn   []string
+	Inputs      []string
+	// InputSpecs holds the values accepted by each of the Inputs, keyed by input name.
+	InputSpecs        map[string]InputSpec
 	ParsingError      string
 	RequiredBehaviour RequiredBehaviour
 	DepsBehaviour     DepsBehaviour
 	Interactive       bool
+	// Stdin is the path of a file to use as the standard input of the script.
+	Stdin string
+	// Stdout is the path of a file to write the standard output of the script to.
+	Stdout string
+	// AppendStdout is true if Stdout should be appended to, rather than replaced.
+	AppendStdout bool
+	// InheritEnv holds the names of the host environment variables that are passed to the script,
+	// they can contain wildcards. A nil InheritEnv passes every variable.
+	InheritEnv []string
+	// Dotenv holds the paths of the dotenv files whose variables are set for the script, relative to Dir.
+	// Later files override earlier ones, and Env overrides them all.
+	Dotenv []string
+	// RequiresEnv holds the names of the environment variables that must be set, and not empty, for the task to run.
+	RequiresEnv []string
+	// Problems holds the problem matchers used to find errors and warnings in the output of the script,
+	// see problem.Parse.
+	Problems []string
+	// Notify holds the targets that are notified when the task finishes, e.g. desktop or slack#deploys.
+	Notify []string
+	// Resources holds the names of the resources the script holds while it runs,
+	// tasks that hold the same resource only run in parallel up to the capacity of the resource.
+	Resources []string
+	// Locks holds the names of the locks the script holds while it runs, tasks that hold the same lock never run at the same time.
+	Locks []string
+	// Schedule is the cron expression of when `xc schedule` runs the task, e.g. */5 * * * *, see schedule.Parse.
+	Schedule string
+	// Watch holds the glob patterns of the paths that trigger a re-run in watch mode.
+	Watch []string
+	// Sources and Outputs hold the glob patterns of the files the script reads and writes,
+	// the task is skipped if every output is newer than every source.
+	Sources []string
+	Outputs []string
+	// Throttle skips the task if it succeeded less than Throttle ago, it is never skipped if it is zero.
+	Throttle time.Duration
+	// Aliases are other names the task can be run by, e.g. b for build.
+	Aliases []string
+	// Finally holds the tasks that run after the task, even if it failed or was cancelled, e.g. to stop the services it started.
+	// Like DependsOn, each can be followed by its inputs.
+	Finally []string
+	// Language is the language in the info string of the code blocks of the script, e.g. python.
+	// It picks the interpreter of scripts without an Interpreter or shebang.
+	Language string
+	// Interpreter is the command that runs a script without a shebang instead of the shell, e.g. python3.
+	Interpreter string
+	// Shell is the command that runs shell scripts instead of the shell built into xc, e.g. bash.
+	Shell string
+	// Container is the image that runs the script instead of the host, with the directory of the task file mounted,
+	// e.g. golang:1.22.
+	Container string
+	// Remote is the host that runs the script over ssh instead of the local machine, e.g. deploy@example.com.
+	Remote string
+	// ScriptFile is true if the script is written to a temporary file that is run with its shebang, including shell shebangs,
+	// or with sh, rather than run by the shell built into xc.
+	ScriptFile bool
+	// Tags group tasks so they can be listed or run by tag, e.g. ci.
+	Tags []string
+	// Platforms restricts the task to platforms given as GOOS or GOOS/GOARCH, e.g. linux or darwin/arm64.
+	// The task runs on every platform if it is empty.
+	Platforms []string
+	// If is the condition the task runs under, see expr.Parse. The task is skipped if it is false,
+	// and always runs if it is empty.
+	If string
+	// Skip is the condition the task is skipped under, the opposite of If, see expr.Parse.
+	Skip string
+	// Extends is the name of the task that the task inherits its environment, directory, requires, inputs and script from.
+	Extends string
+	// ExtendsAppend is true if the script of the task runs after the script of the task it extends, rather than replacing it.
+	ExtendsAppend bool
+	// Matrix expands the task into a variant for each combination of the values of its axes, see ExpandMatrix.
+	Matrix []MatrixAxis
+	// MatrixExclude is the condition, with the values of a variant of the Matrix as environment variables,
+	// that the variant is skipped under, see expr.Parse. The variants keep it, the task itself doesn't.
+	MatrixExclude string
+	// Hidden leaves the task out of listings and the picker, it can still be required and run by name.
+	Hidden bool
+	// Deprecated is the notice printed when the task runs, e.g. `use deploy`, the task isn't deprecated if it is empty.
+	Deprecated string
+	// Doc is the whole description of the task as written, split into paragraphs, lists and headings.
+	// Description holds the same text as plain lines.
+	Doc []DocBlock
+	// Metadata holds the key=value pairs of `<!-- xc: owner=platform-team -->` comments, which xc doesn't use itself.
+	Metadata map[string]string
+	// File is the task file the task was included from, relative to the directory of the main task file.
+	// It is empty for the tasks of the main task file.
+	File string
+	// Line is the line number of the task heading in the task file.
+	Line int
+	// ScriptLines holds the line number in the task file of each line of Script.
+	ScriptLines []int
 }
 
 // Display writes a Task as Markdown.
 func (t Task) Display(w io.Writer) {
 	fmt.Fprintf(w, "## %s\n\n", t.Name)
-	for _, d := range t.Description {
-		fmt.Fprintln(w, d)
+	if len(t.Doc) > 0 {
+		fmt.Fprintln(w, FormatDoc(t.Doc))
+		fmt.Fprintln(w)
+	} else {
+		for _, d := range t.Description {
+			fmt.Fprintln(w, d)
+			fmt.Fprintln(w)
+		}
+	}
+	if len(t.Metadata) > 0 {
+		fmt.Fprintf(w, "<!-- xc: %s -->\n", FormatMetadata(t.Metadata))
+		fmt.Fprintln(w)
+	}
+	if len(t.Aliases) > 0 {
+		fmt.Fprintln(w, "Aliases:", strings.Join(t.Aliases, ", "))
 		fmt.Fprintln(w)
 	}
 	if len(t.DependsOn) > 0 {
@@ -33,6 +139,10 @@ func (t Task) Display(w io.Writer) {
 		fmt.Fprintln(w, "RunDeps:", t.DepsBehaviour)
 		fmt.Fprintln(w)
 	}
+	if len(t.Finally) > 0 {
+		fmt.Fprintln(w, "Finally:", strings.Join(t.Finally, ", "))
+		fmt.Fprintln(w)
+	}
 	if t.Dir != "" {
 		fmt.Fprintln(w, "Directory:", t.Dir)
 		fmt.Fprintln(w)
@@ -42,16 +152,135 @@ func (t Task) Display(w io.Writer) {
 		fmt.Fprintln(w)
 	}
 	if len(t.Inputs) > 0 {
-		fmt.Fprintln(w, "Inputs:", strings.Join(t.Inputs, ", "))
+		fmt.Fprintln(w, "Inputs:", strings.Join(t.FormatInputs(), ", "))
+		for _, p := range t.FormatPrompts() {
+			fmt.Fprintln(w, "Prompt:", p)
+		}
+		fmt.Fprintln(w)
+	}
+	if t.Stdin != "" {
+		fmt.Fprintln(w, "Stdin:", t.Stdin)
+		fmt.Fprintln(w)
+	}
+	if t.Stdout != "" && t.AppendStdout {
+		fmt.Fprintln(w, "AppendStdout:", t.Stdout)
+		fmt.Fprintln(w)
+	} else if t.Stdout != "" {
+		fmt.Fprintln(w, "Stdout:", t.Stdout)
+		fmt.Fprintln(w)
+	}
+	if t.InheritEnv != nil {
+		fmt.Fprintln(w, "InheritEnv:", strings.Join(t.InheritEnv, ", "))
+		fmt.Fprintln(w)
+	}
+	if len(t.Dotenv) > 0 {
+		fmt.Fprintln(w, "Dotenv:", strings.Join(t.Dotenv, ", "))
+		fmt.Fprintln(w)
+	}
+	if len(t.RequiresEnv) > 0 {
+		fmt.Fprintln(w, "RequiresEnv:", strings.Join(t.RequiresEnv, ", "))
+		fmt.Fprintln(w)
+	}
+	for _, m := range t.Problems {
+		fmt.Fprintf(w, "Problems: `%s`\n", m)
+		fmt.Fprintln(w)
+	}
+	if len(t.Notify) > 0 {
+		fmt.Fprintln(w, "Notify:", strings.Join(t.Notify, ", "))
+		fmt.Fprintln(w)
+	}
+	if len(t.Resources) > 0 {
+		fmt.Fprintln(w, "Resources:", strings.Join(t.Resources, ", "))
+		fmt.Fprintln(w)
+	}
+	if len(t.Locks) > 0 {
+		fmt.Fprintln(w, "Lock:", strings.Join(t.Locks, ", "))
+		fmt.Fprintln(w)
+	}
+	if t.Schedule != "" {
+		fmt.Fprintf(w, "Schedule: `%s`\n", t.Schedule)
+		fmt.Fprintln(w)
+	}
+	if len(t.Watch) > 0 {
+		fmt.Fprintln(w, "Watch:", strings.Join(t.Watch, ", "))
+		fmt.Fprintln(w)
+	}
+	if len(t.Sources) > 0 {
+		fmt.Fprintln(w, "Sources:", strings.Join(t.Sources, ", "))
+		fmt.Fprintln(w)
+	}
+	if len(t.Outputs) > 0 {
+		fmt.Fprintln(w, "Outputs:", strings.Join(t.Outputs, ", "))
+		fmt.Fprintln(w)
+	}
+	if t.Throttle > 0 {
+		fmt.Fprintln(w, "Throttle:", t.Throttle)
+		fmt.Fprintln(w)
+	}
+	if t.Interpreter != "" {
+		fmt.Fprintln(w, "Interpreter:", t.Interpreter)
+		fmt.Fprintln(w)
+	}
+	if t.Shell != "" {
+		fmt.Fprintln(w, "Shell:", t.Shell)
+		fmt.Fprintln(w)
+	}
+	if t.Container != "" {
+		fmt.Fprintln(w, "Container:", t.Container)
+		fmt.Fprintln(w)
+	}
+	if t.Remote != "" {
+		fmt.Fprintln(w, "Remote:", t.Remote)
+		fmt.Fprintln(w)
+	}
+	if t.ScriptFile {
+		fmt.Fprintln(w, "ScriptFile: true")
+		fmt.Fprintln(w)
+	}
+	if len(t.Tags) > 0 {
+		fmt.Fprintln(w, "Tags:", strings.Join(t.Tags, ", "))
+		fmt.Fprintln(w)
+	}
+	if t.Extends != "" && t.ExtendsAppend {
+		fmt.Fprintln(w, "Extends:", t.Extends, "(append)")
+		fmt.Fprintln(w)
+	} else if t.Extends != "" {
+		fmt.Fprintln(w, "Extends:", t.Extends)
+		fmt.Fprintln(w)
+	}
+	if len(t.Matrix) > 0 {
+		fmt.Fprintln(w, "Matrix:", FormatMatrix(t.Matrix))
+		fmt.Fprintln(w)
+	}
+	if t.MatrixExclude != "" {
+		fmt.Fprintln(w, "MatrixExclude:", t.MatrixExclude)
+		fmt.Fprintln(w)
+	}
+	if len(t.Platforms) > 0 {
+		fmt.Fprintln(w, "Platform:", strings.Join(t.Platforms, ", "))
+		fmt.Fprintln(w)
+	}
+	if t.If != "" {
+		fmt.Fprintln(w, "If:", t.If)
+		fmt.Fprintln(w)
+	}
+	if t.Skip != "" {
+		fmt.Fprintln(w, "Skip:", t.Skip)
 		fmt.Fprintln(w)
 	}
 	fmt.Fprintln(w, "Run:", t.RequiredBehaviour)
 	if t.Interactive {
 		fmt.Fprintln(w, "Interactive: true")
 	}
+	if t.Hidden {
+		fmt.Fprintln(w, "Hidden: true")
+	}
+	if t.Deprecated != "" {
+		fmt.Fprintln(w, "Deprecated:", t.Deprecated)
+	}
 	fmt.Fprintln(w)
 	if len(t.Script) > 0 {
-		fmt.Fprintln(w, "```")
+		fmt.Fprintln(w, "```"+t.Language)
 		fmt.Fprintln(w, t.Script)
 		fmt.Fprintln(w, "```")
 	}
@@ -60,39 +289,176 @@ func (t Task) Display(w io.Writer) {
 // Tasks is an alias type for []Task
 type Tasks []Task
 
-// Get returns a task by name, case insensitively.
+// Get returns a task by name, case insensitively and ignoring the differences that NormalizeName removes.
 func (ts Tasks) Get(tsname string) (task Task, ok bool) {
 	for _, t := range ts {
-		if strings.EqualFold(tsname, t.Name) {
+		if SameName(tsname, t.Name) {
 			ok = true
 			task = t
-			break
+			return
+		}
+	}
+	// Names take precedence over aliases.
+	for _, t := range ts {
+		for _, a := range t.Aliases {
+			if SameName(tsname, a) {
+				return t, true
+			}
 		}
 	}
 	return
 }
 
+// HasTag returns true if the task has the tag, case insensitively.
+func (t Task) HasTag(tag string) bool {
+	for _, v := range t.Tags {
+		if strings.EqualFold(v, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Path returns the path of the task file the task is defined in, file is the path of the main task file.
+func (t Task) Path(file string) string {
+	if t.File == "" {
+		return file
+	}
+	return filepath.Join(filepath.Dir(file), filepath.FromSlash(t.File))
+}
+
+// Supports returns true if the task can run on the platform with the given GOOS and GOARCH.
+func (t Task) Supports(goos, goarch string) bool {
+	if len(t.Platforms) == 0 {
+		return true
+	}
+	for _, p := range t.Platforms {
+		if p == goos || p == goos+"/"+goarch {
+			return true
+		}
+	}
+	return false
+}
+
+// Forward returns the name of the task that a deprecated task runs instead,
+// which it does if it has no script or dependencies and its notice is `use <task>`.
+func (t Task) Forward() (string, bool) {
+	if t.Deprecated == "" || t.Script != "" || len(t.DependsOn) > 0 {
+		return "", false
+	}
+	use, name, ok := strings.Cut(strings.TrimSpace(t.Deprecated), " ")
+	name = strings.TrimSpace(name)
+	if !ok || !strings.EqualFold(use, "use") || name == "" || strings.ContainsAny(name, " \t") {
+		return "", false
+	}
+	return name, true
+}
+
+// The names of the hook tasks, which run before and after every task that is invoked.
+const (
+	BeforeHook = "xc:before"
+	AfterHook  = "xc:after"
+)
+
+// IsHook returns true if the task is BeforeHook or AfterHook.
+func (t Task) IsHook() bool {
+	return t.Name == BeforeHook || t.Name == AfterHook
+}
+
+// Visible returns true if the task is listed, which it isn't if it is Hidden, a hook or its name starts with an underscore.
+func (t Task) Visible() bool {
+	return !t.Hidden && !t.IsHook() && !strings.HasPrefix(t.Name, "_")
+}
+
+// Visible returns the tasks that are listed.
+func (ts Tasks) Visible() Tasks {
+	var visible Tasks
+	for _, t := range ts {
+		if t.Visible() {
+			visible = append(visible, t)
+		}
+	}
+	return visible
+}
+
+// Supported returns the tasks that can run on the platform with the given GOOS and GOARCH.
+func (ts Tasks) Supported(goos, goarch string) Tasks {
+	var supported Tasks
+	for _, t := range ts {
+		if t.Supports(goos, goarch) {
+			supported = append(supported, t)
+		}
+	}
+	return supported
+}
+
+// Namespace returns the namespace of the task, which is the part of its name before the last colon,
+// e.g. docker for docker:build. It is empty if the task isn't in a namespace.
+func (t Task) Namespace() string {
+	if i := strings.LastIndex(t.Name, ":"); i > 0 {
+		return t.Name[:i]
+	}
+	return ""
+}
+
+// Grouped returns the tasks without a namespace, followed by the tasks of each namespace
+// in the order that the namespaces first appear.
+func (ts Tasks) Grouped() Tasks {
+	order := []string{""}
+	groups := map[string]Tasks{"": nil}
+	for _, t := range ts {
+		ns := t.Namespace()
+		if _, ok := groups[ns]; !ok {
+			order = append(order, ns)
+		}
+		groups[ns] = append(groups[ns], t)
+	}
+	grouped := make(Tasks, 0, len(ts))
+	for _, ns := range order {
+		grouped = append(grouped, groups[ns]...)
+	}
+	return grouped
+}
+
+// Tagged returns the tasks that have the tag.
+func (ts Tasks) Tagged(tag string) Tasks {
+	var tagged Tasks
+	for _, t := range ts {
+		if t.HasTag(tag) {
+			tagged = append(tagged, t)
+		}
+	}
+	return tagged
+}
+
 // RequiredBehaviour represents a tasks behaviour when
 // required by another task.
-// The default is RequiredBehaviourAlways
+// The default is RequiredBehaviourDefault
 type RequiredBehaviour int
 
 const (
+	// RequiredBehaviourDefault runs the task once for each set of inputs it is required with, in each invocation of xc.
+	RequiredBehaviourDefault RequiredBehaviour = iota
 	// RequiredBehaviourAlways should be used if the task is to be run every time it is required.
-	RequiredBehaviourAlways RequiredBehaviour = iota
+	RequiredBehaviourAlways
 	// RequiredBehaviourOnce should be used if a task should be run once, even if required multiple times.
 	RequiredBehaviourOnce
 )
 
 func (b RequiredBehaviour) String() string {
-	if b == RequiredBehaviourOnce {
+	switch b {
+	case RequiredBehaviourOnce:
 		return "once"
+	case RequiredBehaviourAlways:
+		return "always"
 	}
-	return "always"
+	return "default"
 }
 
 func ParseRequiredBehaviour(s string) (RequiredBehaviour, bool) {
 	switch strings.ToLower(s) {
+	case "default":
+		return RequiredBehaviourDefault, true
 	case "once":
 		return RequiredBehaviourOnce, true
 	case "always":
@@ -114,17 +480,17 @@ const (
 )
 
 func (b DepsBehaviour) String() string {
-	if b == DependencyBehaviourSync {
-		return "sync"
+	if b == DependencyBehaviourAsync {
+		return "async"
 	}
-	return "async"
+	return "sync"
 }
 
 func ParseDepsBehaviour(s string) (DepsBehaviour, bool) {
 	switch strings.ToLower(s) {
-	case "sync":
+	case "sync", "serial":
 		return DependencyBehaviourSync, true
-	case "async":
+	case "async", "parallel":
 		return DependencyBehaviourAsync, true
 	default:
 		return 0, false
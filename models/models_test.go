@@ -0,0 +1,167 @@
+package models
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTasksGet(t *testing.T) {
+	tasks := Tasks{
+		{Name: "build", Aliases: []string{"b", "compile"}},
+		{Name: "b"},
+		{Name: "test", Aliases: []string{"t"}},
+		{Name: "🚀 Déployer"},
+	}
+	tests := []struct {
+		name     string
+		in       string
+		expected string
+		ok       bool
+	}{
+		{name: "given a name, should get the task", in: "test", expected: "test", ok: true},
+		{name: "given an alias in another case, should get the task", in: "Compile", expected: "build", ok: true},
+		{name: "given a name that is also an alias, should prefer the name", in: "b", expected: "b", ok: true},
+		{name: "given a normalized name, should get the task", in: "deployer", expected: "🚀 Déployer", ok: true},
+		{name: "given an unknown name, should not get a task", in: "lint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tasks.Get(tt.in)
+			if ok != tt.ok || got.Name != tt.expected {
+				t.Fatalf("want=%q, %v got=%q, %v", tt.expected, tt.ok, got.Name, ok)
+			}
+		})
+	}
+}
+
+func TestTasksTagged(t *testing.T) {
+	tasks := Tasks{
+		{Name: "test", Tags: []string{"ci"}},
+		{Name: "release", Tags: []string{"CI", "release"}},
+		{Name: "dev"},
+	}
+	tests := []struct {
+		name     string
+		tag      string
+		expected []string
+	}{
+		{name: "given a tag, should get the tasks with it case insensitively", tag: "ci", expected: []string{"test", "release"}},
+		{name: "given an unused tag, should get no tasks", tag: "docs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, task := range tasks.Tagged(tt.tag) {
+				got = append(got, task.Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTasksVisible(t *testing.T) {
+	tasks := Tasks{
+		{Name: "build"},
+		{Name: "setup", Hidden: true},
+		{Name: "_fixtures"},
+		{Name: BeforeHook},
+	}
+	var got []string
+	for _, task := range tasks.Visible() {
+		got = append(got, task.Name)
+	}
+	if strings.Join(got, ",") != "build" {
+		t.Fatalf("want=[build] got=%q", got)
+	}
+}
+
+func TestTaskSupports(t *testing.T) {
+	tests := []struct {
+		name      string
+		platforms []string
+		expected  bool
+	}{
+		{name: "given no platforms, should support every platform", expected: true},
+		{name: "given the GOOS, should support it", platforms: []string{"windows", "linux"}, expected: true},
+		{name: "given the GOOS and GOARCH, should support it", platforms: []string{"linux/amd64"}, expected: true},
+		{name: "given another GOARCH, should not support it", platforms: []string{"linux/arm64"}},
+		{name: "given another GOOS, should not support it", platforms: []string{"darwin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Task{Platforms: tt.platforms}).Supports("linux", "amd64"); got != tt.expected {
+				t.Fatalf("want=%v got=%v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTaskPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		expected string
+	}{
+		{name: "given a task of the main file, should be the main file", expected: filepath.Join("project", "README.md")},
+		{name: "given an included task, should be relative to the main file", file: "sub/TASKS.md", expected: filepath.Join("project", "sub", "TASKS.md")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Task{File: tt.file}).Path(filepath.Join("project", "README.md")); got != tt.expected {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTasksGrouped(t *testing.T) {
+	tasks := Tasks{
+		{Name: "docker:build"},
+		{Name: "lint"},
+		{Name: "k8s:apply"},
+		{Name: "docker:push"},
+		{Name: "test"},
+	}
+	var got []string
+	for _, task := range tasks.Grouped() {
+		got = append(got, task.Name+"@"+task.Namespace())
+	}
+	expected := "lint@,test@,docker:build@docker,docker:push@docker,k8s:apply@k8s"
+	if strings.Join(got, ",") != expected {
+		t.Fatalf("want=%s got=%s", expected, strings.Join(got, ","))
+	}
+}
+
+func TestTaskForward(t *testing.T) {
+	tests := []struct {
+		name     string
+		task     Task
+		expected string
+		ok       bool
+	}{
+		{name: "given use and a task, should forward", task: Task{Deprecated: "use deploy"}, expected: "deploy", ok: true},
+		{name: "given a script, should not forward", task: Task{Deprecated: "use deploy", Script: "push"}},
+		{name: "given dependencies, should not forward", task: Task{Deprecated: "use deploy", DependsOn: []string{"build"}}},
+		{name: "given a notice, should not forward", task: Task{Deprecated: "use deploy instead"}},
+		{name: "given no notice, should not forward", task: Task{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.task.Forward()
+			if got != tt.expected || ok != tt.ok {
+				t.Fatalf("want=%q, %v got=%q, %v", tt.expected, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestFormatMetadata(t *testing.T) {
+	got := FormatMetadata(map[string]string{"owner": "platform team", "ci-only": "true", "empty": ""})
+	expected := `ci-only=true empty="" owner="platform team"`
+	if got != expected {
+		t.Fatalf("want=%q got=%q", expected, got)
+	}
+}
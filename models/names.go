@@ -0,0 +1,63 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeName returns the form of a task name that it can be invoked and required by,
+// so that headings such as `🚀 Déployer l'app` can be run with `xc deployer-l-app`.
+//
+// Accents are removed from latin letters and letters are lower cased, letters and digits of any script are kept along with - _ . and :,
+// and every other run of characters, such as spaces, punctuation and emoji, becomes a single -.
+// A name that has no letters or digits at all is only lower cased.
+func NormalizeName(name string) string {
+	var b strings.Builder
+	sep, latin := false, false
+	for _, r := range norm.NFKD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Marks of other scripts, such as the dakuten of kana, are part of the letter.
+			if !latin && b.Len() > 0 && !sep {
+				b.WriteRune(r)
+			}
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_.:", r):
+			if sep && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			sep, latin = false, unicode.Is(unicode.Latin, r)
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			sep = true
+		}
+	}
+	if b.Len() == 0 {
+		return strings.ToLower(strings.TrimSpace(name))
+	}
+	return norm.NFC.String(b.String())
+}
+
+// SameName returns true if a and b name the same task, ignoring case and the differences that NormalizeName removes.
+func SameName(a, b string) bool {
+	return strings.EqualFold(a, b) || NormalizeName(a) == NormalizeName(b)
+}
+
+// needsNormalizing returns true if name has characters that are awkward to type in a shell.
+func needsNormalizing(name string) bool {
+	for _, r := range name {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_.:", r)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Command returns the name to invoke the task with, which is its normalized name if its name is awkward to type.
+func (t Task) Command() string {
+	if needsNormalizing(t.Name) {
+		return NormalizeName(t.Name)
+	}
+	return t.Name
+}
@@ -0,0 +1,34 @@
+package models
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		expected string
+		command  string
+	}{
+		{name: "given a plain name, should lower case it", in: "Build", expected: "build", command: "Build"},
+		{name: "given a namespaced name, should keep the separators", in: "db:migrate_up.sql", expected: "db:migrate_up.sql", command: "db:migrate_up.sql"},
+		{name: "given an emoji, should drop it", in: "🚀 deploy", expected: "deploy", command: "deploy"},
+		{name: "given accents, should remove them", in: "Déployer l'app", expected: "deployer-l-app", command: "deployer-l-app"},
+		{name: "given spaces and punctuation, should use a single dash", in: "Build  (all)!", expected: "build-all", command: "build-all"},
+		{name: "given another script, should keep its letters", in: "ビルド 🔨", expected: "ビルド", command: "ビルド"},
+		{name: "given a compatibility character, should decompose it", in: "ﬁle", expected: "file", command: "file"},
+		{name: "given only emoji, should keep them", in: "🚀", expected: "🚀", command: "🚀"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeName(tt.in); got != tt.expected {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+			if got := (Task{Name: tt.in}).Command(); got != tt.command {
+				t.Fatalf("command want=%q got=%q", tt.command, got)
+			}
+			if !SameName(tt.in, tt.expected) {
+				t.Fatalf("expected %q to be the same name as %q", tt.in, tt.expected)
+			}
+		})
+	}
+}
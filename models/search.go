@@ -0,0 +1,49 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SearchMatch is a line of a task that matched a search.
+type SearchMatch struct {
+	// Attribute is the part of the task that matched, either name, description, tag or script.
+	Attribute string
+	Text      string
+}
+
+// SearchResult is a task with at least one line that matched a search.
+type SearchResult struct {
+	Task    Task
+	Matches []SearchMatch
+}
+
+// Search returns the tasks with a name, description, tag or script line matching re.
+func Search(ts Tasks, re *regexp.Regexp) []SearchResult {
+	var results []SearchResult
+	for _, t := range ts {
+		var matches []SearchMatch
+		if re.MatchString(t.Name) {
+			matches = append(matches, SearchMatch{Attribute: "name", Text: t.Name})
+		}
+		for _, d := range t.Description {
+			if re.MatchString(d) {
+				matches = append(matches, SearchMatch{Attribute: "description", Text: d})
+			}
+		}
+		for _, tag := range t.Tags {
+			if re.MatchString(tag) {
+				matches = append(matches, SearchMatch{Attribute: "tag", Text: tag})
+			}
+		}
+		for _, l := range strings.Split(strings.TrimSuffix(t.Script, "\n"), "\n") {
+			if l != "" && re.MatchString(l) {
+				matches = append(matches, SearchMatch{Attribute: "script", Text: l})
+			}
+		}
+		if len(matches) > 0 {
+			results = append(results, SearchResult{Task: t, Matches: matches})
+		}
+	}
+	return results
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	tasks := Tasks{
+		{Name: "build", Description: []string{"Builds the binary"}, Script: "go build ./...\n"},
+		{Name: "test", Description: []string{"Runs the tests"}, Tags: []string{"ci", "go"}, Script: "go vet ./...\ngo test ./...\n"},
+	}
+	tests := []struct {
+		name     string
+		query    string
+		expected map[string][]SearchMatch
+	}{
+		{
+			name:  "given a name, should match the name",
+			query: "^build$",
+			expected: map[string][]SearchMatch{
+				"build": {{Attribute: "name", Text: "build"}},
+			},
+		},
+		{
+			name:  "given a description, should match the description",
+			query: "(?i)binary",
+			expected: map[string][]SearchMatch{
+				"build": {{Attribute: "description", Text: "Builds the binary"}},
+			},
+		},
+		{
+			name:  "given a script, should match each line",
+			query: `go (vet|test)`,
+			expected: map[string][]SearchMatch{
+				"test": {
+					{Attribute: "script", Text: "go vet ./..."},
+					{Attribute: "script", Text: "go test ./..."},
+				},
+			},
+		},
+		{
+			name:  "given a tag, should match the tag",
+			query: "(?i)^ci$",
+			expected: map[string][]SearchMatch{
+				"test": {{Attribute: "tag", Text: "ci"}},
+			},
+		},
+		{
+			name:     "given no matches, should return nothing",
+			query:    "deploy",
+			expected: map[string][]SearchMatch{},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			results := Search(tasks, regexp.MustCompile(tt.query))
+			got := map[string][]SearchMatch{}
+			for _, r := range results {
+				got[r.Task.Name] = r.Matches
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("want=%+v got=%+v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Package notify sends notifications, for example when a task finishes.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/joerdav/xc/i18n"
+)
+
+// Kinds of Target.
+const (
+	KindDesktop = "desktop"
+	KindSlack   = "slack"
+)
+
+// Target is where a notification is sent, parsed from a string such as `desktop` or `slack#deploys`.
+type Target struct {
+	Kind string
+	// Channel is the Slack channel, without the #.
+	// The default channel of the webhook is used if it is empty.
+	Channel string
+}
+
+// ParseTarget parses a Target of the form `desktop`, `slack` or `slack#channel`.
+func ParseTarget(s string) (Target, error) {
+	kind, channel, _ := strings.Cut(strings.TrimSpace(s), "#")
+	t := Target{Kind: strings.ToLower(kind), Channel: channel}
+	switch t.Kind {
+	case KindDesktop:
+		if channel != "" {
+			return t, i18n.Errorf("invalid notification target %q, desktop has no channel", s)
+		}
+	case KindSlack:
+	default:
+		return t, i18n.Errorf("invalid notification target %q, should be desktop or slack#channel", s)
+	}
+	return t, nil
+}
+
+// String returns the Target in the form accepted by ParseTarget.
+func (t Target) String() string {
+	if t.Channel == "" {
+		return t.Kind
+	}
+	return t.Kind + "#" + t.Channel
+}
+
+// Message is a notification.
+type Message struct {
+	Title, Text string
+	// Failed is true if the notification reports a failure, it is shown as urgent where supported.
+	Failed bool
+}
+
+// Sender sends notifications to targets.
+type Sender struct {
+	// SlackWebhook is the URL of the Slack incoming webhook used for slack targets.
+	SlackWebhook string
+	// Client is the client used to call the webhook, http.DefaultClient is used if it is nil.
+	Client *http.Client
+	// command runs a command, it is replaced in tests.
+	command func(ctx context.Context, name string, args ...string) error
+}
+
+// Send sends m to t.
+func (s Sender) Send(ctx context.Context, t Target, m Message) error {
+	switch t.Kind {
+	case KindDesktop:
+		return s.desktop(ctx, m)
+	case KindSlack:
+		return s.slack(ctx, t.Channel, m)
+	}
+	return i18n.Errorf("invalid notification target %q, should be desktop or slack#channel", t.String())
+}
+
+func (s Sender) desktop(ctx context.Context, m Message) error {
+	command := s.command
+	if command == nil {
+		command = runCommand
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		script := "display notification " + appleScriptString(m.Text) + " with title " + appleScriptString(m.Title)
+		return command(ctx, "osascript", "-e", script)
+	case "linux", "freebsd", "openbsd", "netbsd":
+		urgency := "normal"
+		if m.Failed {
+			urgency = "critical"
+		}
+		return command(ctx, "notify-send", "-u", urgency, m.Title, m.Text)
+	}
+	return i18n.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+}
+
+func runCommand(ctx context.Context, name string, args ...string) error {
+	return exec.CommandContext(ctx, name, args...).Run()
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (s Sender) slack(ctx context.Context, channel string, m Message) error {
+	if s.SlackWebhook == "" {
+		return errors.New(i18n.T("no slack webhook configured"))
+	}
+	payload := map[string]string{"text": "*" + m.Title + "*\n" + m.Text}
+	if channel != "" {
+		payload["channel"] = "#" + channel
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.SlackWebhook, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return i18n.Errorf("slack webhook returned %s", resp.Status)
+	}
+	return nil
+}
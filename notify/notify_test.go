@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected Target
+		err      bool
+	}{
+		{in: "desktop", expected: Target{Kind: KindDesktop}},
+		{in: " Slack#deploys", expected: Target{Kind: KindSlack, Channel: "deploys"}},
+		{in: "slack", expected: Target{Kind: KindSlack}},
+		{in: "desktop#deploys", err: true},
+		{in: "email", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTarget(tt.in)
+		if (err != nil) != tt.err {
+			t.Fatalf("%s: expected error %v got %v", tt.in, tt.err, err)
+		}
+		if err == nil && got != tt.expected {
+			t.Fatalf("%s: want=%+v got=%+v", tt.in, tt.expected, got)
+		}
+	}
+}
+
+func TestSlack(t *testing.T) {
+	var payload map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+	s := Sender{SlackWebhook: srv.URL}
+	err := s.Send(context.Background(), Target{Kind: KindSlack, Channel: "deploys"}, Message{Title: "xc: deploy", Text: "succeeded in 3s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload["channel"] != "#deploys" || payload["text"] != "*xc: deploy*\nsucceeded in 3s" {
+		t.Fatalf("unexpected payload %v", payload)
+	}
+	if err := (Sender{}).Send(context.Background(), Target{Kind: KindSlack}, Message{}); err == nil {
+		t.Fatal("expected error without a webhook got nil")
+	}
+}
+
+func TestSlackError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no_service", http.StatusNotFound)
+	}))
+	defer srv.Close()
+	err := Sender{SlackWebhook: srv.URL}.Send(context.Background(), Target{Kind: KindSlack}, Message{})
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected a 404 error got %v", err)
+	}
+}
+
+func TestDesktop(t *testing.T) {
+	var got []string
+	s := Sender{command: func(_ context.Context, name string, args ...string) error {
+		got = append([]string{name}, args...)
+		return nil
+	}}
+	err := s.Send(context.Background(), Target{Kind: KindDesktop}, Message{Title: "xc: deploy", Text: `failed: "exit 1"`, Failed: true})
+	switch runtime.GOOS {
+	case "darwin":
+		if err != nil || got[2] != `display notification "failed: \"exit 1\"" with title "xc: deploy"` {
+			t.Fatalf("unexpected command %q err=%v", got, err)
+		}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if err != nil || strings.Join(got, " ") != `notify-send -u critical xc: deploy failed: "exit 1"` {
+			t.Fatalf("unexpected command %q err=%v", got, err)
+		}
+	default:
+		if err == nil {
+			t.Fatal("expected error got nil")
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/google/shlex"
+	"github.com/joerdav/xc/i18n"
+)
+
+// parseAnnotation reads a `<!-- xc: owner=platform-team ci-only=true -->` comment into the metadata of the current task,
+// the comment isn't shown when the markdown is rendered. Values with spaces are quoted, pairs can also be separated by commas.
+func (p *parser) parseAnnotation() (bool, error) {
+	s := strings.TrimSpace(p.currentLine)
+	if !strings.HasPrefix(s, "<!--") || !strings.HasSuffix(s, "-->") {
+		return false, nil
+	}
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(s, "<!--"), "-->"))
+	prefix, rest, ok := strings.Cut(s, ":")
+	if !ok || !strings.EqualFold(strings.TrimSpace(prefix), "xc") {
+		return false, nil
+	}
+	fields, err := shlex.Split(strings.ReplaceAll(rest, ",", " "))
+	if err != nil {
+		return false, i18n.Errorf("invalid annotation of %s: %w", p.currTask.Name, err)
+	}
+	for _, f := range fields {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok || k == "" {
+			return false, i18n.Errorf("invalid annotation %q of %s, it should be key=value", f, p.currTask.Name)
+		}
+		if p.currTask.Metadata == nil {
+			p.currTask.Metadata = map[string]string{}
+		}
+		p.currTask.Metadata[k] = v
+	}
+	p.scan()
+	return true, nil
+}
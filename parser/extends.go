@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+)
+
+// extendsAppendSuffix follows the name of the base task of a task that appends its script to the base script,
+// e.g. `Extends: test (append)`.
+const extendsAppendSuffix = "(append)"
+
+// parseExtends parses the value of an Extends attribute into the name of the base task,
+// and whether the script of the task is appended to the script of the base task.
+func parseExtends(s string) (name string, appendScript bool) {
+	s = strings.TrimSpace(s)
+	if n, ok := strings.CutSuffix(s, extendsAppendSuffix); ok {
+		return strings.Trim(n, trimValues), true
+	}
+	return strings.Trim(s, trimValues), false
+}
+
+// resolveExtends returns tasks with each task that extends a base task in tasks
+// inheriting the environment, directory, requires, inputs, interpreter, shell and script of the base task.
+func resolveExtends(tasks models.Tasks) (models.Tasks, error) {
+	resolved := make(models.Tasks, len(tasks))
+	done := make([]bool, len(tasks))
+	var resolve func(i int, chain []string) error
+	resolve = func(i int, chain []string) error {
+		if done[i] {
+			return nil
+		}
+		t := tasks[i]
+		if t.Extends == "" {
+			resolved[i], done[i] = t, true
+			return nil
+		}
+		for _, n := range chain {
+			if models.SameName(n, t.Name) {
+				return i18n.Errorf("tasks extend each other in a cycle: %s", strings.Join(append(chain, t.Name), " -> "))
+			}
+		}
+		b := -1
+		for j := range tasks {
+			if models.SameName(tasks[j].Name, t.Extends) {
+				b = j
+			}
+		}
+		if b < 0 {
+			return i18n.Errorf("task %s extends %s, which is not found", t.Name, t.Extends)
+		}
+		if err := resolve(b, append(chain, t.Name)); err != nil {
+			return err
+		}
+		resolved[i], done[i] = extend(t, resolved[b]), true
+		return nil
+	}
+	for i := range tasks {
+		if err := resolve(i, nil); err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
+
+// extend returns task with the attributes it inherits from base.
+func extend(task, base models.Task) models.Task {
+	task.Env = append(append([]string{}, base.Env...), task.Env...)
+	task.Dotenv = append(append([]string{}, base.Dotenv...), task.Dotenv...)
+	if task.Dir == "" {
+		task.Dir = base.Dir
+	}
+	task.DependsOn = append(append([]string{}, base.DependsOn...), task.DependsOn...)
+	for _, n := range base.Inputs {
+		if contains(task.Inputs, n) {
+			continue
+		}
+		task.Inputs = append(task.Inputs, n)
+		if spec, ok := base.InputSpecs[n]; ok {
+			task.SetInput(n, spec)
+		}
+	}
+	if task.Interpreter == "" {
+		task.Interpreter = base.Interpreter
+	}
+	if task.Shell == "" {
+		task.Shell = base.Shell
+	}
+	if task.Container == "" {
+		task.Container = base.Container
+	}
+	if task.Remote == "" {
+		task.Remote = base.Remote
+	}
+	switch {
+	case task.Script == "":
+		task.Script, task.ScriptLines, task.Language = base.Script, base.ScriptLines, base.Language
+	case task.ExtendsAppend:
+		task.Script = base.Script + task.Script
+		task.ScriptLines = append(append([]int{}, base.ScriptLines...), task.ScriptLines...)
+	}
+	return task
+}
@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/joerdav/xc/models"
+)
+
+// Format returns the markdown of a task, with a heading of the given level.
+// Only the name, description, requirements, finally tasks, tags and script of the task are written.
+func Format(task models.Task, level int) string {
+	var b strings.Builder
+	b.WriteString(strings.Repeat("#", level) + " " + task.Name + "\n\n")
+	for _, d := range task.Description {
+		b.WriteString(d + "\n\n")
+	}
+	if len(task.DependsOn) > 0 {
+		b.WriteString("Requires: " + strings.Join(task.DependsOn, ", ") + "\n\n")
+	}
+	if len(task.Finally) > 0 {
+		b.WriteString("Finally: " + strings.Join(task.Finally, ", ") + "\n\n")
+	}
+	if len(task.Tags) > 0 {
+		b.WriteString("Tags: " + strings.Join(task.Tags, ", ") + "\n\n")
+	}
+	if task.Script != "" {
+		b.WriteString(codeBlockStarter + task.Language + "\n")
+		b.WriteString(strings.TrimRight(task.Script, "\n") + "\n")
+		b.WriteString(codeBlockStarter + "\n")
+	}
+	return b.String()
+}
+
+// AppendTask returns src with task added to the end of the tasks section under heading.
+func AppendTask(src []byte, heading string, task models.Task) ([]byte, error) {
+	var lines []string
+	s := bufio.NewScanner(bytes.NewReader(src))
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	start, level := -1, 0
+	end := len(lines)
+	var fence string
+	for i, l := range lines {
+		if fence != "" {
+			if closesFence(l, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if fence = codeFence(l); fence != "" {
+			continue
+		}
+		var next string
+		if i+1 < len(lines) {
+			next = lines[i+1]
+		}
+		ok, lvl, text := headingOf(l, next)
+		if !ok || strings.TrimSpace(text) == "" {
+			continue
+		}
+		if start < 0 && strings.EqualFold(strings.TrimSpace(text), strings.TrimSpace(heading)) {
+			start, level = i, lvl
+			continue
+		}
+		if start >= 0 && lvl <= level {
+			end = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil, ErrNoTasksHeading
+	}
+	before := lines[:end]
+	for len(before) > start+1 && strings.TrimSpace(before[len(before)-1]) == "" {
+		before = before[:len(before)-1]
+	}
+	var b strings.Builder
+	for _, l := range before {
+		b.WriteString(l + "\n")
+	}
+	b.WriteString("\n" + Format(task, level+1))
+	if end < len(lines) {
+		b.WriteString("\n")
+		for _, l := range lines[end:] {
+			b.WriteString(l + "\n")
+		}
+	}
+	return []byte(b.String()), nil
+}
+
+// headingOf returns the heading of line, next is the following line which can underline the heading.
+func headingOf(line, next string) (ok bool, level int, text string) {
+	p := parser{currentLine: line, nextLine: next}
+	return p.parseHeading(false)
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/joerdav/xc/models"
+)
+
+func TestAppendTask(t *testing.T) {
+	task := models.Task{
+		Name:        "lint",
+		Description: []string{"Lint the code."},
+		DependsOn:   []string{"build"},
+		Tags:        []string{"ci", "go"},
+		Script:      "#!/usr/bin/env python3\nprint('lint')\n",
+	}
+	tests := []struct {
+		name, in, expected string
+	}{
+		{
+			name: "given tasks at the end of the file, should append",
+			in:   "# Project\n\n## Tasks\n\n### build\n\n```\n# not a heading\ngo build\n```\n",
+			expected: "# Project\n\n## Tasks\n\n### build\n\n```\n# not a heading\ngo build\n```\n\n" +
+				"### lint\n\nLint the code.\n\nRequires: build\n\nTags: ci, go\n\n```\n#!/usr/bin/env python3\nprint('lint')\n```\n",
+		},
+		{
+			name: "given a section after the tasks, should insert before it",
+			in:   "Tasks\n-----\n### build\n```\ngo build\n```\n\n\n## License\nMIT\n",
+			expected: "Tasks\n-----\n### build\n```\ngo build\n```\n\n" +
+				"### lint\n\nLint the code.\n\nRequires: build\n\nTags: ci, go\n\n```\n#!/usr/bin/env python3\nprint('lint')\n```\n\n## License\nMIT\n",
+		},
+		{
+			name: "given a tilde fence containing a heading, should skip it",
+			in:   "## Tasks\n### build\n~~~\n```\n## not a heading\n~~~\n",
+			expected: "## Tasks\n### build\n~~~\n```\n## not a heading\n~~~\n\n" +
+				"### lint\n\nLint the code.\n\nRequires: build\n\nTags: ci, go\n\n```\n#!/usr/bin/env python3\nprint('lint')\n```\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AppendTask([]byte(tt.in), "tasks", task)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.expected {
+				t.Fatalf("want:\n%q\ngot:\n%q", tt.expected, string(got))
+			}
+			p, err := NewParser(bytes.NewReader(got), "tasks")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tasks, err := p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, ok := tasks.Get("lint")
+			if !ok {
+				t.Fatalf("expected lint to be parsed, got %v", tasks)
+			}
+			if !reflect.DeepEqual(parsed.DependsOn, task.DependsOn) || !reflect.DeepEqual(parsed.Tags, task.Tags) || parsed.Script != task.Script || parsed.Description[0] != task.Description[0] {
+				t.Fatalf("want=%+v got=%+v", task, parsed)
+			}
+		})
+	}
+}
+
+func TestAppendTaskNoHeading(t *testing.T) {
+	if _, err := AppendTask([]byte("# Project\n"), "tasks", models.Task{Name: "lint"}); err != ErrNoTasksHeading {
+		t.Fatalf("expected %v got %v", ErrNoTasksHeading, err)
+	}
+}
@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+	"gopkg.in/yaml.v3"
+)
+
+const frontmatterDelimiter = "---"
+
+// frontmatter holds the defaults for every task of a task file, read from a YAML block at the top of the file:
+//
+//	---
+//	shell: bash
+//	env: [GOFLAGS=-mod=mod]
+//	---
+type frontmatter struct {
+	// Shell runs shell scripts instead of the shell built into xc.
+	Shell string `yaml:"shell"`
+	// Interpreter runs the scripts without a shebang.
+	Interpreter string `yaml:"interpreter"`
+	// Container runs the scripts of tasks without a container attribute.
+	Container string `yaml:"container"`
+	// ScriptFile runs the scripts of every task from a temporary file with their shebang.
+	ScriptFile bool `yaml:"scriptFile"`
+	// Dir is the directory of tasks without a directory attribute.
+	Dir string `yaml:"dir"`
+	// Env is set before the environment variables of each task, so a task can override them.
+	Env []string `yaml:"env"`
+	// Dotenv is loaded before the dotenv files of each task, so a task can override their variables.
+	Dotenv []string `yaml:"dotenv"`
+}
+
+// parseFrontmatter reads the frontmatter if the next line starts one, leaving the closing delimiter as the current line.
+func (p *parser) parseFrontmatter() error {
+	if strings.TrimSpace(p.nextLine) != frontmatterDelimiter {
+		return nil
+	}
+	p.scan()
+	var lines []string
+	ended := false
+	for p.scan() {
+		if strings.TrimSpace(p.currentLine) == frontmatterDelimiter {
+			ended = true
+			break
+		}
+		lines = append(lines, p.currentLine)
+	}
+	if !ended {
+		return i18n.Errorf("frontmatter was not ended")
+	}
+	d := yaml.NewDecoder(strings.NewReader(strings.Join(lines, "\n")))
+	d.KnownFields(true)
+	if err := d.Decode(&p.frontmatter); err != nil && !errors.Is(err, io.EOF) {
+		return i18n.Errorf("failed to parse frontmatter: %w", err)
+	}
+	return nil
+}
+
+// apply returns task with the defaults of the frontmatter.
+func (f frontmatter) apply(task models.Task) models.Task {
+	if len(f.Env) > 0 {
+		task.Env = append(append([]string{}, f.Env...), task.Env...)
+	}
+	if len(f.Dotenv) > 0 {
+		task.Dotenv = append(append([]string{}, f.Dotenv...), task.Dotenv...)
+	}
+	if task.Dir == "" {
+		task.Dir = f.Dir
+	}
+	if task.Interpreter == "" {
+		task.Interpreter = f.Interpreter
+	}
+	if task.Shell == "" {
+		task.Shell = f.Shell
+	}
+	if task.Container == "" {
+		task.Container = f.Container
+	}
+	if f.ScriptFile {
+		task.ScriptFile = true
+	}
+	return task
+}
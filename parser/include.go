@@ -0,0 +1,185 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/joerdav/xc/glob"
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/problem"
+)
+
+// parseIncludes reads an `Includes: ./docs/tasks.md, ./services/*/README.md` line,
+// which adds the tasks of other files to the task set.
+func (p *parser) parseIncludes() {
+	a, rest, found := strings.Cut(p.currentLine, ":")
+	if !found || !strings.EqualFold(strings.Trim(a, trimValues), "includes") {
+		return
+	}
+	for _, v := range strings.Split(rest, ",") {
+		if v = strings.Trim(v, trimValues); v != "" {
+			p.includes = append(p.includes, v)
+		}
+	}
+}
+
+// Includes returns the paths of the task files included with an `Includes:` line before the first task, as they are written.
+func (p *parser) Includes() []string {
+	return p.includes
+}
+
+// ParseFile parses the tasks under heading in the task file at path, along with the tasks of the files it includes.
+// Included paths are relative to the file that includes them, and can be glob patterns.
+// The tasks of an included file run in the directory of that file, the same as they would when run from there.
+// The tasks of the files below the directory of path that match the discover glob patterns are added
+// the same way as included files, patterns starting with ! exclude files.
+// A required task can be a link to a task in another file, such as `[build](./backend/README.md#build)`,
+// which loads that file the same as an include.
+func ParseFile(path, heading string, discover ...string) (models.Tasks, error) {
+	return parseFiles(path, heading, discover, nil)
+}
+
+// parseFiles parses the task file at path and the files it includes or discovers,
+// adding the problems found in them to problems if it isn't nil.
+func parseFiles(path, heading string, discover []string, problems *[]problem.Problem) (models.Tasks, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	root := filepath.Dir(abs)
+	files := map[string]string{}
+	seen := map[string]bool{}
+	tasks, err := parseFile(abs, heading, root, false, seen, files, problems)
+	if err != nil {
+		return nil, err
+	}
+	if len(discover) > 0 {
+		discovered, err := glob.Files(root, discover)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range discovered {
+			file = filepath.Join(root, filepath.FromSlash(file))
+			// A discovered file can also be included by another task file, its tasks are only added once.
+			if _, ok := seen[file]; ok {
+				continue
+			}
+			ts, err := parseFile(file, heading, root, true, seen, files, problems)
+			if err != nil {
+				return nil, err
+			}
+			tasks = append(tasks, ts...)
+		}
+	}
+	if err := validateAliases(tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// parseFile parses the file at path and the files it includes, included is false for the main task file in root.
+// seen holds the files on the current include stack as false, which can't be included again as that is a cycle,
+// and the files that have been parsed as true, which are skipped as their tasks have been added already.
+// files holds the file each normalized task name was parsed from, and the problems of the file are added to problems if it isn't nil.
+func parseFile(path, heading, root string, included bool, seen map[string]bool, files map[string]string, problems *[]problem.Problem) (models.Tasks, error) {
+	if parsed, ok := seen[path]; ok {
+		if parsed {
+			return nil, nil
+		}
+		return nil, i18n.Errorf("task file %s is included in a cycle", path)
+	}
+	seen[path] = false
+	var tasks models.Tasks
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dir, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	p, err := NewParser(f, heading)
+	if err == nil {
+		tasks, err = p.Parse()
+	}
+	if err != nil && included {
+		// Errors of included files aren't wrapped, a missing heading in an included file
+		// shouldn't be mistaken for a missing heading in the main file.
+		return nil, i18n.Errorf("%s: %s", path, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if problems != nil {
+		for _, pr := range p.Problems() {
+			if included {
+				pr.File = filepath.ToSlash(filepath.Join(dir, filepath.Base(path)))
+			}
+			*problems = append(*problems, pr)
+		}
+	}
+	for i, t := range tasks {
+		// Duplicates in the same file are located by the problems in strict mode.
+		if other, ok := files[models.NormalizeName(t.Name)]; ok && !(problems != nil && other == path) {
+			return nil, i18n.Errorf("task %s is defined in both %s and %s", t.Name, other, path)
+		}
+		files[models.NormalizeName(t.Name)] = path
+		if !included {
+			continue
+		}
+		tasks[i].File = filepath.ToSlash(filepath.Join(dir, filepath.Base(path)))
+		if dir == "." {
+			continue
+		}
+		if t.Dir == "" {
+			tasks[i].Dir = filepath.ToSlash(dir)
+		} else if !filepath.IsAbs(filepath.FromSlash(t.Dir)) {
+			tasks[i].Dir = filepath.ToSlash(filepath.Join(dir, filepath.FromSlash(t.Dir)))
+		}
+	}
+	linked, err := resolveLinks(tasks, path, heading, root, seen, files, problems)
+	if err != nil {
+		return nil, err
+	}
+	tasks = append(tasks, linked...)
+	for _, inc := range p.Includes() {
+		paths, err := includedFiles(filepath.Dir(path), inc)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range paths {
+			ts, err := parseFile(file, heading, root, true, seen, files, problems)
+			if err != nil {
+				return nil, err
+			}
+			tasks = append(tasks, ts...)
+		}
+	}
+	seen[path] = true
+	return tasks, nil
+}
+
+// includedFiles returns the files that an include refers to, relative to dir.
+// A glob pattern can match no files, but a path must exist.
+func includedFiles(dir, include string) ([]string, error) {
+	pattern := filepath.FromSlash(include)
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+	if !strings.ContainsAny(include, "*?[") {
+		if _, err := os.Stat(pattern); err != nil {
+			return nil, i18n.Errorf("failed to include %s: %s", include, err.Error())
+		}
+		return []string{pattern}, nil
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, i18n.Errorf("failed to include %s: %s", include, err.Error())
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
@@ -0,0 +1,240 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestParseFileIncludes(t *testing.T) {
+	task := func(name, attributes string) string {
+		return "### " + name + "\n" + attributes + "```\necho " + name + "\n```\n"
+	}
+	tests := []struct {
+		name          string
+		files         map[string]string
+		discover      []string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name: "given includes, should merge the tasks, running them in the directory of their file",
+			files: map[string]string{
+				"README.md":              "# Project\n\n## Tasks\nIncludes: ./docs/tasks.md, services/*/README.md\n" + task("build", ""),
+				"docs/tasks.md":          "## Tasks\n" + task("docs", ""),
+				"services/api/README.md": "## Tasks\n" + task("api", "Directory: cmd\n"),
+				"services/web/README.md": "## Tasks\n" + task("web", "Directory: /srv\n"),
+			},
+			expected: []string{"build  ", "docs docs docs/tasks.md", "api services/api/cmd services/api/README.md", "web /srv services/web/README.md"},
+		},
+		{
+			name: "given an include that doesn't exist, should error",
+			files: map[string]string{
+				"README.md": "## Tasks\nIncludes: missing.md\n" + task("build", ""),
+			},
+			expectedError: "failed to include missing.md",
+		},
+		{
+			name: "given an included file without the heading, should error",
+			files: map[string]string{
+				"README.md": "## Tasks\nIncludes: other.md\n" + task("build", ""),
+				"other.md":  "## Other\n" + task("other", ""),
+			},
+			expectedError: "no xc block found",
+		},
+		{
+			name: "given a task in two files, should error",
+			files: map[string]string{
+				"README.md": "## Tasks\nIncludes: other.md\n" + task("build", ""),
+				"other.md":  "## Tasks\n" + task("Build", ""),
+			},
+			expectedError: "task Build is defined in both",
+		},
+		{
+			name: "given files that include each other, should error",
+			files: map[string]string{
+				"README.md": "## Tasks\nIncludes: other.md\n" + task("build", ""),
+				"other.md":  "## Tasks\nIncludes: README.md\n" + task("other", ""),
+			},
+			expectedError: "is included in a cycle",
+		},
+		{
+			name: "given files that include the same file, should add its tasks once",
+			files: map[string]string{
+				"README.md":   "## Tasks\nIncludes: a.md, b/b.md\n" + task("build", ""),
+				"a.md":        "## Tasks\nIncludes: shared/c.md\n" + task("a", ""),
+				"b/b.md":      "## Tasks\nIncludes: ../shared/c.md\n" + task("b", ""),
+				"shared/c.md": "## Tasks\n" + task("c", ""),
+			},
+			expected: []string{"build  ", "a  a.md", "c shared shared/c.md", "b b b/b.md"},
+		},
+		{
+			name: "given a discovered file that includes the main file, should add the main file once",
+			files: map[string]string{
+				"README.md":    "## Tasks\n" + task("build", ""),
+				"sub/TASKS.md": "## Tasks\nIncludes: ../README.md\n" + task("sub", ""),
+			},
+			discover: []string{"**/TASKS.md"},
+			expected: []string{"build  ", "sub sub sub/TASKS.md"},
+		},
+		{
+			name: "given a cycle of includes below the main file, should error",
+			files: map[string]string{
+				"README.md": "## Tasks\nIncludes: a.md\n" + task("build", ""),
+				"a.md":      "## Tasks\nIncludes: b.md\n" + task("a", ""),
+				"b.md":      "## Tasks\nIncludes: a.md\n" + task("b", ""),
+			},
+			expectedError: "is included in a cycle",
+		},
+		{
+			name: "given discover patterns, should add the tasks of matching files once, skipping excluded ones",
+			files: map[string]string{
+				"README.md":                  "## Tasks\n" + task("build", ""),
+				"services/api/TASKS.md":      "## Tasks\nIncludes: more/TASKS.md\n" + task("api", ""),
+				"services/api/more/TASKS.md": "## Tasks\n" + task("more", ""),
+				"node_modules/x/TASKS.md":    "## Tasks\n" + task("x", ""),
+			},
+			discover: []string{"**/TASKS.md", "!node_modules/**"},
+			expected: []string{"build  ", "api services/api services/api/TASKS.md", "more services/api/more services/api/more/TASKS.md"},
+		},
+		{
+			name: "given a discovered file that has been included already, should skip it",
+			files: map[string]string{
+				"README.md":    "## Tasks\nIncludes: TASKS.md\n" + task("build", ""),
+				"TASKS.md":     "## Tasks\n" + task("lint", ""),
+				"sub/TASKS.md": "## Tasks\n" + task("sub", ""),
+			},
+			discover: []string{"**/TASKS.md"},
+			expected: []string{"build  ", "lint  TASKS.md", "sub sub sub/TASKS.md"},
+		},
+		{
+			name: "given a discovered file without the heading, should error",
+			files: map[string]string{
+				"README.md":    "## Tasks\n" + task("build", ""),
+				"sub/TASKS.md": "## Other\n" + task("sub", ""),
+			},
+			discover:      []string{"**/TASKS.md"},
+			expectedError: "no xc block found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			tasks, err := ParseFile(filepath.Join(dir, "README.md"), "Tasks", tt.discover...)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error %q got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, task := range tasks {
+				got = append(got, task.Name+" "+task.Dir+" "+task.File)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseFileLinks(t *testing.T) {
+	task := func(name, attributes string) string {
+		return "### " + name + "\n" + attributes + "```\necho " + name + "\n```\n"
+	}
+	tests := []struct {
+		name          string
+		files         map[string]string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name: "given links to tasks in other files, should load the files and require the tasks",
+			files: map[string]string{
+				"README.md":          "## Tasks\n" + task("deploy", "Requires: [build](./backend/README.md#build), [assets](frontend/README.md#assets) MODE=prod, lint\n") + task("lint", ""),
+				"backend/README.md":  "## Tasks\n" + task("build", ""),
+				"frontend/README.md": "## Tasks\n" + task("Assets", ""),
+			},
+			expected: []string{"deploy  build,assets MODE=prod,lint", "lint  ", "build backend ", "Assets frontend "},
+		},
+		{
+			name: "given a link without an anchor, should require the task named by the link",
+			files: map[string]string{
+				"README.md":         "## Tasks\n" + task("deploy", "Requires: [build](backend/README.md)\n"),
+				"backend/README.md": "## Tasks\n" + task("build", ""),
+			},
+			expected: []string{"deploy  build", "build backend "},
+		},
+		{
+			name: "given files that link to each other, should load each once",
+			files: map[string]string{
+				"README.md":         "## Tasks\n" + task("deploy", "Requires: [build](backend/README.md#build)\n") + task("lint", ""),
+				"backend/README.md": "## Tasks\n" + task("build", "Requires: [lint](../README.md#lint)\n"),
+			},
+			expected: []string{"deploy  build", "lint  ", "build backend lint"},
+		},
+		{
+			name: "given a link to a task in the same file, should require it",
+			files: map[string]string{
+				"README.md": "## Tasks\n" + task("deploy", "Requires: [lint](#lint)\n") + task("lint", ""),
+			},
+			expected: []string{"deploy  lint", "lint  "},
+		},
+		{
+			name: "given a link to a missing task, should error",
+			files: map[string]string{
+				"README.md":         "## Tasks\n" + task("deploy", "Requires: [build](backend/README.md#bulid)\n"),
+				"backend/README.md": "## Tasks\n" + task("build", ""),
+			},
+			expectedError: "task bulid required by deploy not found in backend/README.md#bulid",
+		},
+		{
+			name: "given a link to a missing file, should error",
+			files: map[string]string{
+				"README.md": "## Tasks\n" + task("deploy", "Requires: [build](backend/README.md#build)\n"),
+			},
+			expectedError: "failed to load backend/README.md#build required by deploy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			tasks, err := ParseFile(filepath.Join(dir, "README.md"), "Tasks")
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error %q got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, task := range tasks {
+				got = append(got, task.Name+" "+task.Dir+" "+strings.Join(task.DependsOn, ","))
+			}
+			if strings.Join(got, "|") != strings.Join(tt.expected, "|") {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/problem"
+)
+
+// dependencyLink splits a required task written as a markdown link, such as `[build](./backend/README.md#build) GOOS=linux`,
+// into the text of the link, its target and whatever follows it.
+func dependencyLink(dep string) (text, target, rest string, ok bool) {
+	if !strings.HasPrefix(dep, "[") {
+		return "", "", "", false
+	}
+	text, after, ok := strings.Cut(dep[1:], "](")
+	if !ok {
+		return "", "", "", false
+	}
+	target, rest, ok = strings.Cut(after, ")")
+	if !ok || strings.TrimSpace(target) == "" {
+		return "", "", "", false
+	}
+	return text, strings.TrimSpace(target), rest, true
+}
+
+// headingAnchor returns the anchor that markdown renderers such as GitHub give a heading named s.
+func headingAnchor(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// resolveLinks replaces the required tasks of tasks that are links to tasks in other files with the names of those tasks,
+// and returns the tasks of the linked files that haven't been parsed yet.
+// tasks were parsed from the file at path, the other arguments are the same as those of parseFile.
+func resolveLinks(tasks models.Tasks, path, heading, root string, seen map[string]bool, files map[string]string, problems *[]problem.Problem) (models.Tasks, error) {
+	var linked models.Tasks
+	for i, t := range tasks {
+		for j, dep := range t.DependsOn {
+			text, target, rest, ok := dependencyLink(dep)
+			if !ok {
+				continue
+			}
+			file, anchor, _ := strings.Cut(target, "#")
+			if file == "" {
+				file = path
+			} else if file = filepath.FromSlash(file); !filepath.IsAbs(file) {
+				file = filepath.Join(filepath.Dir(path), file)
+			}
+			if _, ok := seen[file]; !ok {
+				ts, err := parseFile(file, heading, root, true, seen, files, problems)
+				if err != nil {
+					return nil, i18n.Errorf("failed to load %s required by %s: %w", target, t.Name, err)
+				}
+				linked = append(linked, ts...)
+			}
+			if anchor == "" {
+				anchor = text
+			}
+			name, ok := linkedTask(files, file, anchor)
+			if !ok {
+				return nil, i18n.Errorf("task %s required by %s not found in %s", anchor, t.Name, target)
+			}
+			if strings.EqualFold(name, text) {
+				name = text
+			}
+			tasks[i].DependsOn[j] = name + rest
+		}
+	}
+	return linked, nil
+}
+
+// linkedTask returns the name of the task parsed from file that anchor refers to, either by its name or the anchor of its heading.
+// The names in files are normalized.
+func linkedTask(files map[string]string, file, anchor string) (string, bool) {
+	anchor = models.NormalizeName(anchor)
+	if files[anchor] == file {
+		return anchor, true
+	}
+	for name, f := range files {
+		if f == file && headingAnchor(name) == headingAnchor(anchor) {
+			return name, true
+		}
+	}
+	return "", false
+}
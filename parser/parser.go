@@ -3,15 +3,24 @@ package parser
 import (
 	"bufio"
 	"errors"
-	"fmt"
 	"io"
+	"path"
+	"reflect"
+	"regexp"
 	"strings"
+	"time"
+	"unicode"
 
+	"github.com/joerdav/xc/expr"
+	"github.com/joerdav/xc/i18n"
 	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/notify"
+	"github.com/joerdav/xc/problem"
+	"github.com/joerdav/xc/schedule"
 )
 
 // ErrNoTasksHeading is returned if the markdown contains no xc block
-var ErrNoTasksHeading = errors.New("no xc block found")
+var ErrNoTasksHeading = errors.New(i18n.T("no xc block found"))
 
 const (
 	trimValues       = "_*` "
@@ -24,7 +33,26 @@ type parser struct {
 	currTask              models.Task
 	rootHeadingLevel      int
 	nextLine, currentLine string
-	reachedEnd            bool
+	// nextLineNo and currentLineNo are the 1-based line numbers of nextLine and currentLine.
+	nextLineNo, currentLineNo int
+	reachedEnd                bool
+	// includes holds the paths of the task files included before the first task.
+	includes []string
+	// frontmatter holds the defaults for the tasks of the file.
+	frontmatter frontmatter
+	// taskHeadingLevel is the heading level of currTask.
+	taskHeadingLevel int
+	// namespaces holds the names of the headings that the current task heading is nested in,
+	// so the tasks under `### docker` are named docker:<name>.
+	namespaces []string
+	// previousLine is the line before currentLine.
+	previousLine string
+	// nested is true if the body of currTask ended at a nested task heading, making it a namespace.
+	nested bool
+	// problems holds the mistakes that don't stop the tasks from parsing, see ParseFileStrict.
+	problems []problem.Problem
+	// docLines holds the lines of the description of currTask as written, attributes and code blocks are blank lines.
+	docLines []string
 }
 
 func (p *parser) Parse() (tasks models.Tasks, err error) {
@@ -35,23 +63,70 @@ func (p *parser) Parse() (tasks models.Tasks, err error) {
 			break
 		}
 	}
-	tasks = p.tasks
+	if err != nil {
+		return p.tasks, err
+	}
+	p.checkDuplicates()
+	resolved, err := resolveExtends(p.tasks)
+	if err != nil {
+		return p.tasks, err
+	}
+	for _, t := range resolved {
+		if t.MatrixExclude != "" && len(t.Matrix) == 0 {
+			return p.tasks, i18n.Errorf("matrixExclude of %s needs a matrix", t.Name)
+		}
+		for _, v := range t.ExpandMatrix() {
+			tasks = append(tasks, p.frontmatter.apply(v))
+		}
+	}
+	err = validateAliases(tasks)
 	return
 }
 
+// validateAliases returns an error if an alias is the name or alias of another task.
+func validateAliases(tasks models.Tasks) error {
+	names := map[string]string{}
+	for _, t := range tasks {
+		names[models.NormalizeName(t.Name)] = t.Name
+	}
+	for _, t := range tasks {
+		for _, a := range t.Aliases {
+			if other, ok := names[models.NormalizeName(a)]; ok && other != t.Name {
+				return i18n.Errorf("alias %s of task %s is already used by task %s", a, t.Name, other)
+			}
+			names[models.NormalizeName(a)] = t.Name
+		}
+	}
+	return nil
+}
+
 func (p *parser) scan() bool {
 	if p.reachedEnd {
 		return false
 	}
+	p.previousLine = p.currentLine
 	p.currentLine = p.nextLine
+	p.currentLineNo = p.nextLineNo
 	if !p.scanner.Scan() {
 		p.reachedEnd = true
 		return true
 	}
 	p.nextLine = p.scanner.Text()
+	p.nextLineNo++
 	return true
 }
 
+// listItem returns the value of a markdown list item such as "- KEY=value".
+func listItem(line string) (string, bool) {
+	t := strings.TrimSpace(line)
+	for _, m := range []string{"- ", "* ", "+ "} {
+		if v, ok := strings.CutPrefix(t, m); ok {
+			return trimCode(v), true
+		}
+	}
+	return "", false
+}
+
 func stringOnlyContains(input string, matcher rune) bool {
 	if len(input) == 0 {
 		return false
@@ -138,8 +213,108 @@ const (
 	// if it is, then logs are not prefixed and the stdout/stderr are passed directly
 	// from the OS
 	AttributeTypeInteractive
+	// AttributeTypeWatch sets the glob patterns of the paths that trigger a re-run of the Task in watch mode.
+	// Patterns starting with `!` exclude paths.
+	AttributeTypeWatch
+	// AttributeTypePrompt sets the question used to ask for a missing input on the terminal,
+	// it can appear once for each input, e.g. `Prompt: TOKEN=API token? (default: none) (secret)`.
+	AttributeTypePrompt
+	// AttributeTypeStdin sets a file to use as the standard input of the Task, relative to its directory.
+	AttributeTypeStdin
+	// AttributeTypeStdout sets a file to write the standard output of the Task to, relative to its directory.
+	AttributeTypeStdout
+	// AttributeTypeAppendStdout sets a file to append the standard output of the Task to, relative to its directory.
+	AttributeTypeAppendStdout
+	// AttributeTypeProblems adds a problem matcher, either the name of a builtin matcher or a regular expression,
+	// it can appear more than once, e.g. Problems: `^(?P<file>[^:]+):(?P<line>\d+): (?P<message>.+)$`.
+	AttributeTypeProblems
+	// AttributeTypeInheritEnv sets the host environment variables that are passed to the Task,
+	// as a comma separated list of names that can contain wildcards, e.g. `InheritEnv: PATH, HOME, GO*`.
+	AttributeTypeInheritEnv
+	// AttributeTypeRequiresEnv sets the environment variables that must be set for the Task to run,
+	// as a comma separated list of names, e.g. `RequiresEnv: AWS_PROFILE, GITHUB_TOKEN`.
+	AttributeTypeRequiresEnv
+	// AttributeTypeNotify sets the targets that are notified when the Task finishes,
+	// as a comma separated list, e.g. `Notify: desktop, slack#deploys`.
+	AttributeTypeNotify
+	// AttributeTypeResources sets the resources the Task holds while its script runs,
+	// as a comma separated list, e.g. `Resources: docker, gpu`.
+	AttributeTypeResources
+	// AttributeTypeThrottle skips the Task if it succeeded recently, as a duration, e.g. `Throttle: 1h`.
+	AttributeTypeThrottle
+	// AttributeTypeAliases sets other names the Task can be run by, as a comma separated list, e.g. `Aliases: b, compile`.
+	AttributeTypeAliases
+	// AttributeTypeInterpreter sets the command that runs the script of the Task instead of the shell,
+	// the script is passed to it as a file, e.g. `Interpreter: deno run`.
+	AttributeTypeInterpreter
+	// AttributeTypeTags sets the tags of the Task, as a comma separated list, e.g. `Tags: ci, release`.
+	AttributeTypeTags
+	// AttributeTypeHidden leaves the Task out of listings and the picker if it is true.
+	AttributeTypeHidden
+	// AttributeTypeDeprecated sets the notice printed when the Task runs, e.g. `Deprecated: use deploy`.
+	// A Task without a script or dependencies runs the task it names instead.
+	AttributeTypeDeprecated
+	// AttributeTypePlatform restricts the Task to platforms, as a comma separated list of GOOS or GOOS/GOARCH,
+	// e.g. `Platform: linux, darwin/arm64`.
+	AttributeTypePlatform
+	// AttributeTypeMatrix expands the Task into a variant for each combination of values,
+	// e.g. `Matrix: GOOS=[linux,darwin] GOARCH=[amd64,arm64]`.
+	AttributeTypeMatrix
+	// AttributeTypeExtends sets the task that the Task inherits its environment, dotenv files, directory, requires, inputs and script from,
+	// e.g. `Extends: test`. The script of the Task replaces the inherited script, unless it is followed by (append).
+	AttributeTypeExtends
+	// AttributeTypeIf sets the condition the Task runs under, the Task is skipped if it is false,
+	// e.g. `If: !fileExists("certs/server.pem")`.
+	AttributeTypeIf
+	// AttributeTypeArgs sets the inputs of the Task as a space separated list, e.g. `Args: name version`,
+	// so they can be given as positional arguments.
+	AttributeTypeArgs
+	// AttributeTypeSources sets the glob patterns of the files the script of the Task reads, e.g. `Sources: **/*.go, go.mod`.
+	AttributeTypeSources
+	// AttributeTypeOutputs sets the glob patterns of the files the script of the Task writes, e.g. `Outputs: bin/app`.
+	// The Task is skipped if every output is newer than every source.
+	AttributeTypeOutputs
+	// AttributeTypeShell sets the shell that runs the script of the Task instead of the shell built into xc,
+	// e.g. `Shell: bash`, `Shell: pwsh` or `Shell: fish`.
+	AttributeTypeShell
+	// AttributeTypeFinally sets the Tasks that run after the Task, even if it fails or is cancelled,
+	// as a comma separated list, e.g. `Finally: compose-down`.
+	AttributeTypeFinally
+	// AttributeTypeDotenv sets the dotenv files that are loaded before the Task runs, relative to its directory,
+	// as a comma separated list, e.g. `Dotenv: .env, .env.local`.
+	AttributeTypeDotenv
+	// AttributeTypeContainer sets the image that runs the script of the Task, e.g. `Container: golang:1.22`.
+	AttributeTypeContainer
+	// AttributeTypeRemote sets the host that runs the script of the Task over ssh, e.g. `Remote: deploy@example.com`.
+	AttributeTypeRemote
+	// AttributeTypeScriptFile sets if the script of the Task is run from a temporary file, with its shebang,
+	// rather than by the shell built into xc, e.g. `ScriptFile: true`.
+	AttributeTypeScriptFile
+	// AttributeTypeLock sets the locks the Task holds while its script runs, as a comma separated list, e.g. `Lock: database`.
+	// Tasks that hold the same lock never run at the same time.
+	AttributeTypeLock
+	// AttributeTypeSchedule sets when `xc schedule` runs the Task, as a cron expression, e.g. `Schedule: "*/5 * * * *"`.
+	AttributeTypeSchedule
+	// AttributeTypeSkip sets the condition the Task is skipped under, the opposite of If, e.g. `Skip: env("CI")`.
+	AttributeTypeSkip
+	// AttributeTypeMatrixExclude sets the condition that a variant of the Matrix of the Task is skipped under,
+	// with the values of the variant as environment variables, e.g. `MatrixExclude: env("GOOS") == "windows"`.
+	AttributeTypeMatrixExclude
 )
 
+// parseCondition returns the condition of an If, Skip or MatrixExclude attribute of the task name,
+// which can be wrapped in backticks, if it is valid.
+func parseCondition(name, rest string) (string, error) {
+	s := trimCode(rest)
+	if _, err := expr.Parse(s); err != nil {
+		return "", i18n.Errorf("invalid condition of %s: %w", name, err)
+	}
+	return s, nil
+}
+
+// platformRe matches a GOOS, optionally followed by a GOARCH, e.g. darwin/arm64.
+var platformRe = regexp.MustCompile(`^[a-z0-9]+(/[a-z0-9]+)?$`)
+
 var attMap = map[string]AttributeType{
 	"req":             AttributeTypeReq,
 	"requires":        AttributeTypeReq,
@@ -152,6 +327,42 @@ var attMap = map[string]AttributeType{
 	"rundeps":         AttributeTypeRunDeps,
 	"rundependencies": AttributeTypeRunDeps,
 	"interactive":     AttributeTypeInteractive,
+	"watch":           AttributeTypeWatch,
+	"prompt":          AttributeTypePrompt,
+	"stdin":           AttributeTypeStdin,
+	"stdout":          AttributeTypeStdout,
+	"appendstdout":    AttributeTypeAppendStdout,
+	"problems":        AttributeTypeProblems,
+	"inheritenv":      AttributeTypeInheritEnv,
+	"requiresenv":     AttributeTypeRequiresEnv,
+	"notify":          AttributeTypeNotify,
+	"resources":       AttributeTypeResources,
+	"throttle":        AttributeTypeThrottle,
+	"aliases":         AttributeTypeAliases,
+	"interpreter":     AttributeTypeInterpreter,
+	"tags":            AttributeTypeTags,
+	"hidden":          AttributeTypeHidden,
+	"deprecated":      AttributeTypeDeprecated,
+	"platform":        AttributeTypePlatform,
+	"platforms":       AttributeTypePlatform,
+	"matrix":          AttributeTypeMatrix,
+	"extends":         AttributeTypeExtends,
+	"if":              AttributeTypeIf,
+	"args":            AttributeTypeArgs,
+	"arguments":       AttributeTypeArgs,
+	"sources":         AttributeTypeSources,
+	"outputs":         AttributeTypeOutputs,
+	"shell":           AttributeTypeShell,
+	"finally":         AttributeTypeFinally,
+	"dotenv":          AttributeTypeDotenv,
+	"container":       AttributeTypeContainer,
+	"remote":          AttributeTypeRemote,
+	"scriptfile":      AttributeTypeScriptFile,
+	"lock":            AttributeTypeLock,
+	"locks":           AttributeTypeLock,
+	"schedule":        AttributeTypeSchedule,
+	"skip":            AttributeTypeSkip,
+	"matrixexclude":   AttributeTypeMatrixExclude,
 }
 
 func (p *parser) parseAttribute() (bool, error) {
@@ -164,10 +375,25 @@ func (p *parser) parseAttribute() (bool, error) {
 		return false, nil
 	}
 	switch ty {
-	case AttributeTypeInp:
+	case AttributeTypeInp, AttributeTypeArgs:
 		vs := strings.Split(rest, ",")
+		if ty == AttributeTypeArgs {
+			vs = strings.FieldsFunc(rest, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+			if len(vs) == 0 {
+				return false, i18n.Errorf("args should name at least one argument: %s", p.currTask.Name)
+			}
+		}
 		for _, v := range vs {
-			p.currTask.Inputs = append(p.currTask.Inputs, strings.Trim(v, trimValues))
+			name, spec, ok := models.ParseInput(strings.Trim(v, trimValues))
+			if !ok {
+				return false, i18n.Errorf("inputs contains invalid input %q: %s", strings.TrimSpace(v), p.currTask.Name)
+			}
+			p.currTask.Inputs = append(p.currTask.Inputs, name)
+			if len(spec.Choices) > 0 || spec.Type != models.InputTypeString || spec.Default != nil {
+				in := p.currTask.Input(name)
+				in.Type, in.Choices, in.Default = spec.Type, spec.Choices, spec.Default
+				p.currTask.SetInput(name, in)
+			}
 		}
 	case AttributeTypeReq:
 		vs := strings.Split(rest, ",")
@@ -175,13 +401,25 @@ func (p *parser) parseAttribute() (bool, error) {
 			p.currTask.DependsOn = append(p.currTask.DependsOn, strings.Trim(v, trimValues))
 		}
 	case AttributeTypeEnv:
+		if strings.Trim(rest, trimValues) == "" {
+			// An empty attribute is followed by a list with a variable on each line.
+			for !p.reachedEnd {
+				v, ok := listItem(p.nextLine)
+				if !ok {
+					break
+				}
+				p.scan()
+				p.currTask.Env = append(p.currTask.Env, v)
+			}
+			break
+		}
 		vs := strings.Split(rest, ",")
 		for _, v := range vs {
 			p.currTask.Env = append(p.currTask.Env, strings.Trim(v, trimValues))
 		}
 	case AttributeTypeDir:
 		if p.currTask.Dir != "" {
-			return false, fmt.Errorf("directory appears more than once for %s", p.currTask.Name)
+			return false, i18n.Errorf("directory appears more than once for %s", p.currTask.Name)
 		}
 		s := strings.Trim(rest, trimValues)
 		p.currTask.Dir = s
@@ -189,93 +427,460 @@ func (p *parser) parseAttribute() (bool, error) {
 		s := strings.Trim(rest, trimValues)
 		r, ok := models.ParseRequiredBehaviour(s)
 		if !ok {
-			return false, fmt.Errorf("run contains invalid behaviour %q should be (always, once): %s", s, p.currTask.Name)
+			return false, i18n.Errorf("run contains invalid behaviour %q should be (default, always, once): %s", s, p.currTask.Name)
 		}
 		p.currTask.RequiredBehaviour = r
 	case AttributeTypeRunDeps:
 		s := strings.Trim(rest, trimValues)
 		r, ok := models.ParseDepsBehaviour(s)
 		if !ok {
-			return false, fmt.Errorf("runDeps contains invalid behaviour %q should be (sync, async): %s", s, p.currTask.Name)
+			return false, i18n.Errorf("runDeps contains invalid behaviour %q should be (sync, async): %s", s, p.currTask.Name)
 		}
 		p.currTask.DepsBehaviour = r
 	case AttributeTypeInteractive:
 		s := strings.Trim(rest, trimValues)
 		p.currTask.Interactive = s == "true"
+	case AttributeTypeScriptFile:
+		s := strings.Trim(rest, trimValues)
+		p.currTask.ScriptFile = s == "true"
+	case AttributeTypeHidden:
+		s := strings.Trim(rest, trimValues)
+		p.currTask.Hidden = s == "true"
+	case AttributeTypeDeprecated:
+		s := strings.Trim(rest, trimValues)
+		if s == "" {
+			return false, i18n.Errorf("deprecated should have a notice, e.g. use another-task: %s", p.currTask.Name)
+		}
+		p.currTask.Deprecated = s
+	case AttributeTypePrompt:
+		name, prompt, ok := models.ParsePrompt(strings.Trim(rest, trimValues))
+		if !ok {
+			return false, i18n.Errorf("prompt %q should be NAME=question: %s", strings.TrimSpace(rest), p.currTask.Name)
+		}
+		in := p.currTask.Input(name)
+		if in.Prompt != nil {
+			return false, i18n.Errorf("prompt for input %s appears more than once for %s", name, p.currTask.Name)
+		}
+		in.Prompt = &prompt
+		p.currTask.SetInput(name, in)
+	case AttributeTypeStdin:
+		if p.currTask.Stdin != "" {
+			return false, i18n.Errorf("stdin appears more than once for %s", p.currTask.Name)
+		}
+		p.currTask.Stdin = strings.Trim(rest, trimValues)
+	case AttributeTypeStdout, AttributeTypeAppendStdout:
+		if p.currTask.Stdout != "" {
+			return false, i18n.Errorf("stdout appears more than once for %s", p.currTask.Name)
+		}
+		p.currTask.Stdout = strings.Trim(rest, trimValues)
+		p.currTask.AppendStdout = ty == AttributeTypeAppendStdout
+	case AttributeTypeProblems:
+		m := trimCode(rest)
+		if _, err := problem.Parse(m); err != nil {
+			return false, i18n.Errorf("invalid problems for %s: %w", p.currTask.Name, err)
+		}
+		p.currTask.Problems = append(p.currTask.Problems, m)
+	case AttributeTypeInheritEnv:
+		if p.currTask.InheritEnv == nil {
+			p.currTask.InheritEnv = []string{}
+		}
+		for _, v := range strings.Split(rest, ",") {
+			v = trimCode(v)
+			if v == "" {
+				continue
+			}
+			if _, err := path.Match(v, ""); err != nil {
+				return false, i18n.Errorf("inheritEnv contains invalid pattern %q: %s", v, p.currTask.Name)
+			}
+			p.currTask.InheritEnv = append(p.currTask.InheritEnv, v)
+		}
+	case AttributeTypeRequiresEnv:
+		for _, v := range strings.Split(rest, ",") {
+			v = trimCode(v)
+			if v == "" || strings.ContainsAny(v, "= ") {
+				return false, i18n.Errorf("requiresEnv contains invalid name %q: %s", v, p.currTask.Name)
+			}
+			p.currTask.RequiresEnv = append(p.currTask.RequiresEnv, v)
+		}
+	case AttributeTypeNotify:
+		for _, v := range strings.Split(rest, ",") {
+			v = strings.Trim(v, trimValues)
+			if _, err := notify.ParseTarget(v); err != nil {
+				return false, i18n.Errorf("invalid notify for %s: %w", p.currTask.Name, err)
+			}
+			p.currTask.Notify = append(p.currTask.Notify, v)
+		}
+	case AttributeTypeResources:
+		for _, v := range strings.Split(rest, ",") {
+			v = strings.Trim(v, trimValues)
+			if v == "" {
+				return false, i18n.Errorf("resources contains an empty name: %s", p.currTask.Name)
+			}
+			p.currTask.Resources = append(p.currTask.Resources, v)
+		}
+	case AttributeTypeSchedule:
+		s := strings.Trim(strings.TrimSpace(rest), "`\"'")
+		if _, err := schedule.Parse(s); err != nil {
+			return false, i18n.Errorf("invalid schedule for %s: %w", p.currTask.Name, err)
+		}
+		p.currTask.Schedule = s
+	case AttributeTypeLock:
+		for _, v := range strings.Split(rest, ",") {
+			v = strings.Trim(v, trimValues)
+			if v == "" {
+				return false, i18n.Errorf("lock contains an empty name: %s", p.currTask.Name)
+			}
+			p.currTask.Locks = append(p.currTask.Locks, v)
+		}
+	case AttributeTypeTags:
+		for _, v := range strings.Split(rest, ",") {
+			v = strings.Trim(v, trimValues)
+			if v == "" {
+				return false, i18n.Errorf("tags contains an empty name: %s", p.currTask.Name)
+			}
+			p.currTask.Tags = append(p.currTask.Tags, v)
+		}
+	case AttributeTypePlatform:
+		for _, v := range strings.Split(rest, ",") {
+			v = strings.ToLower(trimCode(v))
+			if !platformRe.MatchString(v) {
+				return false, i18n.Errorf("platform contains invalid value %q, should be GOOS or GOOS/GOARCH: %s", v, p.currTask.Name)
+			}
+			p.currTask.Platforms = append(p.currTask.Platforms, v)
+		}
+	case AttributeTypeMatrix:
+		if p.currTask.Matrix != nil {
+			return false, i18n.Errorf("matrix appears more than once for %s", p.currTask.Name)
+		}
+		axes, ok := models.ParseMatrix(trimCode(rest))
+		if !ok {
+			return false, i18n.Errorf("invalid matrix %q, should be like os=[linux,darwin] arch=[amd64]: %s", strings.TrimSpace(rest), p.currTask.Name)
+		}
+		p.currTask.Matrix = axes
+	case AttributeTypeExtends:
+		if p.currTask.Extends != "" {
+			return false, i18n.Errorf("extends appears more than once for %s", p.currTask.Name)
+		}
+		p.currTask.Extends, p.currTask.ExtendsAppend = parseExtends(rest)
+		if p.currTask.Extends == "" {
+			return false, i18n.Errorf("extends should name a task: %s", p.currTask.Name)
+		}
+	case AttributeTypeIf:
+		if p.currTask.If != "" {
+			return false, i18n.Errorf("if appears more than once for %s", p.currTask.Name)
+		}
+		s, err := parseCondition(p.currTask.Name, rest)
+		if err != nil {
+			return false, err
+		}
+		p.currTask.If = s
+	case AttributeTypeSkip:
+		if p.currTask.Skip != "" {
+			return false, i18n.Errorf("skip appears more than once for %s", p.currTask.Name)
+		}
+		s, err := parseCondition(p.currTask.Name, rest)
+		if err != nil {
+			return false, err
+		}
+		p.currTask.Skip = s
+	case AttributeTypeMatrixExclude:
+		if p.currTask.MatrixExclude != "" {
+			return false, i18n.Errorf("matrixExclude appears more than once for %s", p.currTask.Name)
+		}
+		s, err := parseCondition(p.currTask.Name, rest)
+		if err != nil {
+			return false, err
+		}
+		p.currTask.MatrixExclude = s
+	case AttributeTypeDotenv:
+		for _, v := range strings.Split(rest, ",") {
+			v = trimCode(v)
+			if v == "" {
+				return false, i18n.Errorf("dotenv contains an empty path: %s", p.currTask.Name)
+			}
+			p.currTask.Dotenv = append(p.currTask.Dotenv, v)
+		}
+	case AttributeTypeFinally:
+		for _, v := range strings.Split(rest, ",") {
+			if v = strings.Trim(v, trimValues); v != "" {
+				p.currTask.Finally = append(p.currTask.Finally, v)
+			}
+		}
+	case AttributeTypeAliases:
+		for _, v := range strings.Split(rest, ",") {
+			v = strings.Trim(v, trimValues)
+			if v == "" || strings.ContainsAny(v, " \t") {
+				return false, i18n.Errorf("aliases contains invalid alias %q: %s", v, p.currTask.Name)
+			}
+			p.currTask.Aliases = append(p.currTask.Aliases, v)
+		}
+	case AttributeTypeThrottle:
+		d, err := time.ParseDuration(strings.Trim(rest, trimValues))
+		if err != nil || d <= 0 {
+			return false, i18n.Errorf("throttle contains invalid duration %q: %s", strings.Trim(rest, trimValues), p.currTask.Name)
+		}
+		p.currTask.Throttle = d
+	case AttributeTypeInterpreter:
+		if p.currTask.Interpreter != "" {
+			return false, i18n.Errorf("interpreter appears more than once for %s", p.currTask.Name)
+		}
+		p.currTask.Interpreter = trimCode(rest)
+	case AttributeTypeShell:
+		if p.currTask.Shell != "" {
+			return false, i18n.Errorf("shell appears more than once for %s", p.currTask.Name)
+		}
+		if p.currTask.Shell = trimCode(rest); p.currTask.Shell == "" {
+			return false, i18n.Errorf("shell should name a command: %s", p.currTask.Name)
+		}
+	case AttributeTypeContainer:
+		if p.currTask.Container != "" {
+			return false, i18n.Errorf("container appears more than once for %s", p.currTask.Name)
+		}
+		if p.currTask.Container = trimCode(rest); p.currTask.Container == "" {
+			return false, i18n.Errorf("container should name an image: %s", p.currTask.Name)
+		}
+	case AttributeTypeRemote:
+		if p.currTask.Remote != "" {
+			return false, i18n.Errorf("remote appears more than once for %s", p.currTask.Name)
+		}
+		if p.currTask.Remote = trimCode(rest); p.currTask.Remote == "" {
+			return false, i18n.Errorf("remote should name a host: %s", p.currTask.Name)
+		}
+	case AttributeTypeWatch:
+		vs := strings.Split(rest, ",")
+		for _, v := range vs {
+			p.currTask.Watch = append(p.currTask.Watch, strings.Trim(v, trimValues))
+		}
+	case AttributeTypeSources, AttributeTypeOutputs:
+		var patterns []string
+		for _, v := range strings.Split(rest, ",") {
+			v = trimCode(v)
+			if v == "" {
+				return false, i18n.Errorf("%s contains an empty pattern: %s", strings.ToLower(strings.TrimSpace(a)), p.currTask.Name)
+			}
+			patterns = append(patterns, v)
+		}
+		if ty == AttributeTypeSources {
+			p.currTask.Sources = append(p.currTask.Sources, patterns...)
+		} else {
+			p.currTask.Outputs = append(p.currTask.Outputs, patterns...)
+		}
 	}
-	p.scan()
+	p.advance()
 	return true, nil
 }
 
+// trimCode removes the spaces and a single pair of backticks around s.
+// It is used for values that can end with characters in trimValues, such as regular expressions and wildcards.
+func trimCode(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > 1 && strings.HasPrefix(s, "`") && strings.HasSuffix(s, "`") {
+		s = s[1 : len(s)-1]
+	}
+	return s
+}
+
+// codeFence returns the fence that opens a fenced code block on line, such as ``` or ~~~~, or "" if there is none.
+func codeFence(line string) string {
+	t := strings.TrimLeft(line, " ")
+	if len(line)-len(t) > 3 || len(t) < 3 || (t[0] != '`' && t[0] != '~') {
+		return ""
+	}
+	n := len(t) - len(strings.TrimLeft(t, t[:1]))
+	// The info string of a backtick fence can't contain backticks, so ```code``` is inline code.
+	if n < 3 || (t[0] == '`' && strings.Contains(t[n:], "`")) {
+		return ""
+	}
+	return t[:n]
+}
+
+// fenceLanguage returns the language in the info string after the fence that opens a code block on line.
+func fenceLanguage(line, fence string) string {
+	info := strings.Fields(strings.TrimLeft(line, " ")[len(fence):])
+	if len(info) == 0 {
+		return ""
+	}
+	// Attributes such as ```{.python} or ```python{title=x} aren't part of the language.
+	language, _, _ := strings.Cut(strings.TrimPrefix(info[0], "{."), "{")
+	return strings.TrimRight(language, "}")
+}
+
+// closesFence returns true if line closes a code block opened by fence,
+// with a fence of the same character that is at least as long.
+func closesFence(line, fence string) bool {
+	f := codeFence(line)
+	return f != "" && f[0] == fence[0] && len(f) >= len(fence) && strings.TrimSpace(strings.TrimLeft(line, " ")[len(f):]) == ""
+}
+
+// indentedCode returns the code of a line of an indented code block, which is indented by four spaces or a tab.
+func indentedCode(line string) (string, bool) {
+	if s, ok := strings.CutPrefix(line, "\t"); ok {
+		return s, true
+	}
+	return strings.CutPrefix(line, "    ")
+}
+
 func (p *parser) parseCodeBlock() error {
-	t := p.currentLine
-	if len(t) < 3 || t[:3] != codeBlockStarter {
-		return nil
+	fence := codeFence(p.currentLine)
+	if fence == "" {
+		return p.parseIndentedCodeBlock()
 	}
-	if len(p.currTask.Script) > 0 {
-		return fmt.Errorf("command block already exists for task %s", p.currTask.Name)
+	// The code blocks of a task are run in order, as one script.
+	previous := len(p.currTask.Script)
+	if language := fenceLanguage(p.currentLine, fence); language != "" {
+		switch {
+		case p.currTask.Language == "":
+			p.currTask.Language = language
+		case !strings.EqualFold(p.currTask.Language, language):
+			return i18n.Errorf("the command blocks of task %s are in different languages", p.currTask.Name)
+		}
 	}
 	var ended bool
+	opening, column := p.currentLineNo, p.column()
 	for p.scan() {
-		if len(p.currentLine) >= 3 && p.currentLine[:3] == codeBlockStarter {
+		if closesFence(p.currentLine, fence) {
 			ended = true
 			break
 		}
 		if strings.TrimSpace(p.currentLine) != "" {
 			p.currTask.Script += p.currentLine + "\n"
+			p.currTask.ScriptLines = append(p.currTask.ScriptLines, p.currentLineNo)
 		}
 	}
 	if !ended {
-		return fmt.Errorf("command block in task %s was not ended", p.currTask.Name)
+		return i18n.Errorf("command block in task %s was not ended", p.currTask.Name)
 	}
-	p.scan()
+	if len(p.currTask.Script) == previous {
+		p.addProblem(opening, column, i18n.Sprintf("the command block of task %s is empty", p.currTask.Name))
+	}
+	if previous > 0 && strings.HasPrefix(p.currTask.Script[previous:], "#!") {
+		return i18n.Errorf("only the first command block of task %s can have a shebang", p.currTask.Name)
+	}
+	p.advance()
 	return nil
 }
 
-func (p *parser) findTaskHeading() (heading string, done bool, err error) {
+// parseIndentedCodeBlock reads the script of the task from an indented code block, if one starts on the current line.
+// An indented code block follows a blank line or a heading, and is only the script of a task without one,
+// otherwise it is part of the description.
+func (p *parser) parseIndentedCodeBlock() error {
+	code, ok := indentedCode(p.currentLine)
+	prev := strings.TrimSpace(p.previousLine)
+	if !ok || strings.TrimSpace(code) == "" || len(p.currTask.Script) > 0 || (prev != "" && !strings.HasPrefix(prev, "#")) {
+		return nil
+	}
 	for {
+		if strings.TrimSpace(code) != "" {
+			p.currTask.Script += code + "\n"
+			p.currTask.ScriptLines = append(p.currTask.ScriptLines, p.currentLineNo)
+		}
+		next, ok := indentedCode(p.nextLine)
+		if p.reachedEnd || (!ok && strings.TrimSpace(p.nextLine) != "") {
+			break
+		}
+		p.scan()
+		code = next
+	}
+	p.advance()
+	return nil
+}
+
+// advance moves past the current line once it has been read.
+func (p *parser) advance() {
+	if !p.scan() {
+		// The current line is the last line, which shouldn't be read again.
+		p.currentLine = ""
+	}
+}
+
+func (p *parser) findTaskHeading() (heading string, line int, done bool, err error) {
+	for {
+		line, column := p.currentLineNo, p.column()
 		tok, level, text := p.parseHeading(true)
-		if !tok || level > p.rootHeadingLevel+1 {
+		if !tok && len(p.tasks) == 0 {
+			p.parseIncludes()
+		}
+		if tok && level > p.rootHeadingLevel+1+len(p.namespaces) {
+			p.addProblem(line, column, i18n.Sprintf("heading %q is not a task, it is nested too deeply", text))
+			// parseHeading has moved past the heading, unless it is the last line.
+			if p.currentLineNo != line {
+				continue
+			}
+		}
+		if !tok || level > p.rootHeadingLevel+1+len(p.namespaces) {
 			if !p.scan() {
-				return "", false, fmt.Errorf("failed to read file: %w", p.scanner.Err())
+				return "", 0, false, i18n.Errorf("failed to read file: %w", p.scanner.Err())
 			}
 			continue
 		}
 		if level <= p.rootHeadingLevel {
-			return "", true, nil
+			return "", 0, true, nil
+		}
+		p.taskHeadingLevel = level
+		depth := level - p.rootHeadingLevel - 1
+		p.namespaces = p.namespaces[:depth]
+		heading = trimTaskName(text)
+		if depth > 0 {
+			heading = p.namespaces[depth-1] + ":" + heading
 		}
-		return strings.Trim(text, trimValues), false, nil
+		return heading, line, false, nil
 	}
 }
 
+// trimTaskName removes the emphasis around a task name, keeping the leading underscore of hidden tasks
+// such as `_setup`, which isn't emphasis because it isn't closed.
+func trimTaskName(s string) string {
+	if t := strings.Trim(s, "*` "); strings.HasPrefix(t, "_") && !strings.HasSuffix(t, "_") {
+		return "_" + strings.Trim(t[1:], trimValues)
+	}
+	return strings.Trim(s, trimValues)
+}
+
 func (p *parser) parseTaskBody() (bool, error) {
 	for {
-		ok, err := p.parseAttribute()
+		ok, err := p.parseAnnotation()
 		if err != nil {
 			return false, err
 		}
-		if p.reachedEnd {
-			// parse attribute again in case it is on the last line
-			_, err = p.parseAttribute()
+		if ok {
+			continue
+		}
+		ok, err = p.parseAttribute()
+		if err != nil {
 			return false, err
 		}
 		if ok {
+			p.docLines = append(p.docLines, "")
 			continue
 		}
+		line := p.currentLineNo
 		err = p.parseCodeBlock()
 		if err != nil {
 			return false, err
 		}
-		tok, level, _ := p.parseHeading(false)
+		if p.currentLineNo != line {
+			// the line after a code block can start another one
+			p.docLines = append(p.docLines, "")
+			continue
+		}
+		tok, level, text := p.parseHeading(false)
 		if tok && level <= p.rootHeadingLevel {
 			return false, nil
 		}
-		if tok && level == p.rootHeadingLevel+1 {
+		if tok && level <= p.taskHeadingLevel {
+			return true, nil
+		}
+		// A heading nested in one without a script or requires is a task in its namespace,
+		// otherwise it is part of the description.
+		if tok && level == p.taskHeadingLevel+1 && len(p.currTask.Script) == 0 && len(p.currTask.DependsOn) == 0 {
+			p.nested = true
 			return true, nil
 		}
 		if strings.TrimSpace(p.currentLine) != "" {
+			p.checkDescriptionLine(tok, text)
 			p.currTask.Description = append(p.currTask.Description, strings.Trim(p.currentLine, trimValues))
 		}
+		p.docLines = append(p.docLines, p.currentLine)
 		if !p.scan() {
 			return false, nil
 		}
@@ -284,27 +889,58 @@ func (p *parser) parseTaskBody() (bool, error) {
 
 func (p *parser) parseTask() (ok bool, err error) {
 	p.currTask = models.Task{}
-	heading, done, err := p.findTaskHeading()
+	heading, line, done, err := p.findTaskHeading()
 	if err != nil || done {
 		return
 	}
 	p.currTask.Name = heading
+	p.currTask.Line = line
+	p.nested = false
+	p.docLines = nil
 	ok, err = p.parseTaskBody()
 	if err != nil {
 		return
 	}
-	if len(p.currTask.Script) < 1 && len(p.currTask.DependsOn) < 1 {
-		err = fmt.Errorf("task %s has no commands or required tasks", p.currTask.Name)
+	p.currTask.Doc = models.ParseDoc(p.docLines)
+	if p.nested {
+		if !reflect.DeepEqual(p.currTask, models.Task{Name: heading, Line: line, Description: p.currTask.Description, Doc: p.currTask.Doc}) {
+			err = i18n.Errorf("%s has nested tasks, so it can only have a description", p.currTask.Name)
+			return
+		}
+		p.namespaces = append(p.namespaces, heading)
+		return
+	}
+	if _, forwarded := p.currTask.Forward(); !forwarded && p.currTask.Extends == "" && len(p.currTask.Script) < 1 && len(p.currTask.DependsOn) < 1 {
+		err = i18n.Errorf("task %s has no commands or required tasks", p.currTask.Name)
 		return
 	}
+	for name := range p.currTask.InputSpecs {
+		if !contains(p.currTask.Inputs, name) {
+			err = i18n.Errorf("task %s has a prompt for %s which is not one of its inputs", p.currTask.Name, name)
+			return
+		}
+	}
 	p.tasks = append(p.tasks, p.currTask)
 	return
 }
 
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 // NewParser will read from r until it finds a valid xc heading block.
 // If no block is found an error is returned.
 func NewParser(r io.Reader, heading string) (p parser, err error) {
 	p.scanner = bufio.NewScanner(r)
+	p.scan()
+	if err = p.parseFrontmatter(); err != nil {
+		return
+	}
 	for p.scan() {
 		ok, level, text := p.parseHeading(true)
 		if !ok || !strings.EqualFold(strings.TrimSpace(text), strings.TrimSpace(heading)) {
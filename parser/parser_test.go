@@ -5,8 +5,10 @@ import (
 	_ "embed"
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/joerdav/xc/models"
 )
@@ -239,6 +241,7 @@ func TestParseAttribute(t *testing.T) {
 		expectDir           string
 		expectDependsOn     string
 		expectInputs        string
+		expectWatch         string
 		expectBehaviour     models.RequiredBehaviour
 		expectDepsBehaviour models.DepsBehaviour
 	}{
@@ -352,6 +355,16 @@ func TestParseAttribute(t *testing.T) {
 			in:                  "runDeps: _*`sync`*_",
 			expectDepsBehaviour: models.DependencyBehaviourSync,
 		},
+		{
+			name:        "given watch, should parse",
+			in:          "Watch: src/**/*.go, !**/*_test.go",
+			expectWatch: "src/**/*.go,!**/*_test.go",
+		},
+		{
+			name:        "given watch with formatting, should parse",
+			in:          "watch: `./web`",
+			expectWatch: "./web",
+		},
 		{
 			name:        "given env with no colon, should not parse",
 			in:          "env _*`my:attribute_*`",
@@ -388,6 +401,9 @@ func TestParseAttribute(t *testing.T) {
 			if tt.expectInputs != "" && p.currTask.Inputs[0] != tt.expectInputs {
 				t.Fatalf("Inputs[0]=%s, want=%s", p.currTask.Inputs[0], tt.expectInputs)
 			}
+			if tt.expectWatch != "" && strings.Join(p.currTask.Watch, ",") != tt.expectWatch {
+				t.Fatalf("Watch=%v, want=%s", p.currTask.Watch, tt.expectWatch)
+			}
 			if tt.expectDir != "" && p.currTask.Dir != tt.expectDir {
 				t.Fatalf("Dir=%s, want=%s", p.currTask.Dir, tt.expectDir)
 			}
@@ -434,3 +450,1083 @@ echo "Hello, world2!"
 		}
 	}
 }
+
+func TestTaskLines(t *testing.T) {
+	p, err := NewParser(strings.NewReader(s), "Tasks")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(s, "\n")
+	for _, task := range result {
+		if task.Line < 1 || task.Line > len(lines) {
+			t.Fatalf("line of %s out of range: %d", task.Name, task.Line)
+		}
+		if !strings.Contains(lines[task.Line-1], task.Name) {
+			t.Fatalf("line %d of %s want heading got %q", task.Line, task.Name, lines[task.Line-1])
+		}
+		script := strings.Split(strings.TrimSuffix(task.Script, "\n"), "\n")
+		if task.Script == "" {
+			script = nil
+		}
+		if len(script) != len(task.ScriptLines) {
+			t.Fatalf("script lines of %s want %d got %v", task.Name, len(script), task.ScriptLines)
+		}
+		for i, l := range task.ScriptLines {
+			if lines[l-1] != script[i] {
+				t.Fatalf("line %d of %s want %q got %q", l, task.Name, script[i], lines[l-1])
+			}
+		}
+	}
+}
+
+func TestParseInputChoices(t *testing.T) {
+	p, _ := NewParser(strings.NewReader("Inputs: ENV[dev|staging|prod], VERSION"), "tasks")
+	if _, err := p.parseAttribute(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(p.currTask.Inputs, ",") != "ENV,VERSION" {
+		t.Fatalf("inputs want=ENV,VERSION got=%v", p.currTask.Inputs)
+	}
+	if c := p.currTask.Input("ENV").Choices; strings.Join(c, ",") != "dev,staging,prod" {
+		t.Fatalf("choices want=dev,staging,prod got=%v", c)
+	}
+	if c := p.currTask.Input("VERSION").Choices; len(c) != 0 {
+		t.Fatalf("expected no choices got %v", c)
+	}
+	p, _ = NewParser(strings.NewReader("Inputs: ENV[dev|prod"), "tasks")
+	if _, err := p.parseAttribute(); err == nil {
+		t.Fatal("expected error got nil")
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	p, _ := NewParser(strings.NewReader("Args: name  version, REPLICAS:int=2"), "tasks")
+	if _, err := p.parseAttribute(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(p.currTask.Inputs, ",") != "name,version,REPLICAS" {
+		t.Fatalf("inputs want=name,version,REPLICAS got=%v", p.currTask.Inputs)
+	}
+	if d := p.currTask.Input("REPLICAS").Default; d == nil || *d != "2" {
+		t.Fatalf("default want=2 got=%v", d)
+	}
+	p, _ = NewParser(strings.NewReader("Args:"), "tasks")
+	if _, err := p.parseAttribute(); err == nil {
+		t.Fatal("expected error got nil")
+	}
+}
+
+func TestParsePrompts(t *testing.T) {
+	t.Run("given a prompt for an input, should parse", func(t *testing.T) {
+		p, _ := NewParser(strings.NewReader(`# Tasks
+## deploy
+Prompt: TOKEN=API token (secret)
+Inputs: ENV[dev|prod], TOKEN
+`+"```"+`
+deploy
+`+"```"), "tasks")
+		tasks, err := p.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		prompt := tasks[0].Input("TOKEN").Prompt
+		if prompt == nil || prompt.Question != "API token" || !prompt.Secret {
+			t.Fatalf("unexpected prompt %+v", prompt)
+		}
+		if c := tasks[0].Input("ENV").Choices; len(c) != 2 {
+			t.Fatalf("choices want=dev,prod got=%v", c)
+		}
+	})
+	t.Run("given a prompt for an unknown input, should error", func(t *testing.T) {
+		p, _ := NewParser(strings.NewReader(`# Tasks
+## deploy
+Prompt: TOKEN=API token
+`+"```"+`
+deploy
+`+"```"), "tasks")
+		if _, err := p.Parse(); err == nil {
+			t.Fatal("expected error got nil")
+		}
+	})
+	t.Run("given an invalid prompt, should error", func(t *testing.T) {
+		p, _ := NewParser(strings.NewReader("Prompt: API token"), "tasks")
+		if _, err := p.parseAttribute(); err == nil {
+			t.Fatal("expected error got nil")
+		}
+	})
+}
+
+func TestParseRedirection(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		currentStdout string
+		expectStdin   string
+		expectStdout  string
+		expectAppend  bool
+		expectError   bool
+	}{
+		{name: "given stdin, should parse", in: "Stdin: input.txt", expectStdin: "input.txt"},
+		{name: "given stdout, should parse", in: "Stdout: `report.html`", expectStdout: "report.html"},
+		{name: "given append stdout, should parse", in: "AppendStdout: log.txt", expectStdout: "log.txt", expectAppend: true},
+		{name: "given stdout and append stdout, should error", in: "AppendStdout: b.txt", currentStdout: "a.txt", expectError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "tasks")
+			p.currTask.Stdout = tt.currentStdout
+			_, err := p.parseAttribute()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if err != nil {
+				return
+			}
+			if p.currTask.Stdin != tt.expectStdin {
+				t.Fatalf("Stdin=%q, want=%q", p.currTask.Stdin, tt.expectStdin)
+			}
+			if p.currTask.Stdout != tt.expectStdout {
+				t.Fatalf("Stdout=%q, want=%q", p.currTask.Stdout, tt.expectStdout)
+			}
+			if p.currTask.AppendStdout != tt.expectAppend {
+				t.Fatalf("AppendStdout=%v, want=%v", p.currTask.AppendStdout, tt.expectAppend)
+			}
+		})
+	}
+}
+
+func TestParseInheritEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    []string
+		expectError bool
+	}{
+		{name: "given names and wildcards, should keep wildcards", in: "InheritEnv: PATH, HOME, GO*", expected: []string{"PATH", "HOME", "GO*"}},
+		{name: "given backticks, should parse", in: "InheritEnv: `PATH`, `XDG_*`", expected: []string{"PATH", "XDG_*"}},
+		{name: "given no names, should inherit nothing", in: "InheritEnv:", expected: []string{}},
+		{name: "given an invalid pattern, should error", in: "InheritEnv: GO[", expectError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "tasks")
+			_, err := p.parseAttribute()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if err == nil && !reflect.DeepEqual(p.currTask.InheritEnv, tt.expected) {
+				t.Fatalf("InheritEnv=%q, want=%q", p.currTask.InheritEnv, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseNotify(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    []string
+		expectError bool
+	}{
+		{name: "given targets, should parse", in: "Notify: desktop, `slack#deploys`", expected: []string{"desktop", "slack#deploys"}},
+		{name: "given an unknown target, should error", in: "Notify: email", expectError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "tasks")
+			_, err := p.parseAttribute()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if err == nil && !reflect.DeepEqual(p.currTask.Notify, tt.expected) {
+				t.Fatalf("Notify=%q, want=%q", p.currTask.Notify, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseResources(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    []string
+		expectError bool
+	}{
+		{name: "given resources, should parse", in: "Resources: docker, `gpu`", expected: []string{"docker", "gpu"}},
+		{name: "given an empty name, should error", in: "Resources: docker,", expectError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "tasks")
+			_, err := p.parseAttribute()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if err == nil && !reflect.DeepEqual(p.currTask.Resources, tt.expected) {
+				t.Fatalf("Resources=%q, want=%q", p.currTask.Resources, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseLock(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    []string
+		expectError bool
+	}{
+		{name: "given a lock, should parse", in: "Lock: database", expected: []string{"database"}},
+		{name: "given locks, should parse", in: "Locks: database, `port-8080`", expected: []string{"database", "port-8080"}},
+		{name: "given an empty name, should error", in: "Lock: ", expectError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "tasks")
+			_, err := p.parseAttribute()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if err == nil && !reflect.DeepEqual(p.currTask.Locks, tt.expected) {
+				t.Fatalf("Locks=%q, want=%q", p.currTask.Locks, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    string
+		expectError bool
+	}{
+		{name: "given a quoted schedule, should parse", in: `Schedule: "*/5 * * * *"`, expected: "*/5 * * * *"},
+		{name: "given a schedule in code, should parse", in: "Schedule: `30 9 * * mon-fri`", expected: "30 9 * * mon-fri"},
+		{name: "given a macro, should parse", in: "Schedule: @daily", expected: "@daily"},
+		{name: "given an invalid schedule, should error", in: "Schedule: every day", expectError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "tasks")
+			_, err := p.parseAttribute()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if err == nil && p.currTask.Schedule != tt.expected {
+				t.Fatalf("Schedule=%q, want=%q", p.currTask.Schedule, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseThrottle(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    time.Duration
+		expectError bool
+	}{
+		{name: "given a duration, should parse", in: "Throttle: `1h30m`", expected: 90 * time.Minute},
+		{name: "given an invalid duration, should error", in: "Throttle: 1 day", expectError: true},
+		{name: "given a zero duration, should error", in: "Throttle: 0s", expectError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "tasks")
+			_, err := p.parseAttribute()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if err == nil && p.currTask.Throttle != tt.expected {
+				t.Fatalf("Throttle=%s, want=%s", p.currTask.Throttle, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseAliases(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    []string
+		expectError bool
+	}{
+		{name: "given aliases, should parse", in: "Aliases: b, `compile`", expected: []string{"b", "compile"}},
+		{name: "given an alias with a space, should error", in: "Aliases: b c", expectError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "tasks")
+			_, err := p.parseAttribute()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if err == nil && !reflect.DeepEqual(p.currTask.Aliases, tt.expected) {
+				t.Fatalf("Aliases=%q, want=%q", p.currTask.Aliases, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseAliasConflicts(t *testing.T) {
+	task := func(name, aliases string) string {
+		return "### " + name + "\nAliases: " + aliases + "\n```\necho " + name + "\n```\n"
+	}
+	tests := []struct {
+		name        string
+		in          string
+		expectError bool
+	}{
+		{name: "given unique aliases, should parse", in: "## Tasks\n" + task("build", "b") + task("test", "t")},
+		{name: "given an alias that is a task name, should error", in: "## Tasks\n" + task("build", "test") + task("test", "t"), expectError: true},
+		{name: "given an alias used by two tasks, should error", in: "## Tasks\n" + task("build", "B") + task("bench", "b"), expectError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewParser(strings.NewReader(tt.in), "Tasks")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := p.Parse(); (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestParseProblems(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    string
+		expectError bool
+	}{
+		{name: "given a builtin matcher, should parse", in: "Problems: go", expected: "go"},
+		{name: "given a regular expression, should keep trailing characters", in: `Problems: ^(?P<message>.+) \*`, expected: `^(?P<message>.+) \*`},
+		{name: "given a regular expression in backticks, should parse", in: "Problems: `^(?P<message>.+)$`", expected: "^(?P<message>.+)$"},
+		{name: "given a regular expression without a message, should error", in: "Problems: `^(?P<file>.+)$`", expectError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "tasks")
+			_, err := p.parseAttribute()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if err == nil && (len(p.currTask.Problems) != 1 || p.currTask.Problems[0] != tt.expected) {
+				t.Fatalf("Problems=%q, want=[%q]", p.currTask.Problems, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseFrontmatter(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		expected      models.Tasks
+		expectedError string
+	}{
+		{
+			name: "given frontmatter, should apply it to tasks that don't override it",
+			in: "---\nshell: bash -e\ninterpreter: python3\ndir: src\nenv:\n  - GOFLAGS=-mod=mod\n  - CGO_ENABLED=0\n---\n# Tasks\n\n" +
+				"## build\nEnv: CGO_ENABLED=1\n```\ngo build\n```\n## docs\nDirectory: docs\n```\nmake\n```\n",
+			expected: models.Tasks{
+				{Name: "build", Script: "go build\n", Dir: "src", Env: []string{"GOFLAGS=-mod=mod", "CGO_ENABLED=0", "CGO_ENABLED=1"}, Interpreter: "python3", Shell: "bash -e"},
+				{Name: "docs", Script: "make\n", Dir: "docs", Env: []string{"GOFLAGS=-mod=mod", "CGO_ENABLED=0"}, Interpreter: "python3", Shell: "bash -e"},
+			},
+		},
+		{
+			name: "given dotenv files, should load them before those of each task",
+			in:   "---\ndotenv: [.env]\n---\n# Tasks\n\n## build\nDotenv: .env.local\n```\ngo build\n```\n",
+			expected: models.Tasks{
+				{Name: "build", Script: "go build\n", Dotenv: []string{".env", ".env.local"}},
+			},
+		},
+		{
+			name: "given a container, should run the tasks that don't override it in it",
+			in:   "---\ncontainer: golang:1.22\n---\n# Tasks\n\n## build\n```\ngo build\n```\n## lint\nContainer: golangci/golangci-lint\n```\ngolangci-lint run\n```\n",
+			expected: models.Tasks{
+				{Name: "build", Script: "go build\n", Container: "golang:1.22"},
+				{Name: "lint", Script: "golangci-lint run\n", Container: "golangci/golangci-lint"},
+			},
+		},
+		{
+			name: "given scriptFile, should run every task from a file",
+			in:   "---\nscriptFile: true\n---\n# Tasks\n\n## build\n```\ngo build\n```\n",
+			expected: models.Tasks{
+				{Name: "build", Script: "go build\n", ScriptFile: true},
+			},
+		},
+		{
+			name:          "given an unknown setting, should error",
+			in:            "---\nshel: bash\n---\n# Tasks\n",
+			expectedError: "failed to parse frontmatter",
+		},
+		{
+			name:          "given frontmatter without an end, should error",
+			in:            "---\nshell: bash\n# Tasks\n",
+			expectedError: "frontmatter was not ended",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewParser(strings.NewReader(tt.in), "Tasks")
+			var tasks models.Tasks
+			if err == nil {
+				tasks, err = p.Parse()
+			}
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error %q got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tasks) != len(tt.expected) {
+				t.Fatalf("want=%+v got=%+v", tt.expected, tasks)
+			}
+			for i, task := range tasks {
+				e := tt.expected[i]
+				if task.Name != e.Name || task.Script != e.Script || task.Dir != e.Dir || task.Interpreter != e.Interpreter ||
+					task.Shell != e.Shell || !reflect.DeepEqual(task.Env, e.Env) || !reflect.DeepEqual(task.Dotenv, e.Dotenv) {
+					t.Fatalf("want=%+v got=%+v", e, task)
+				}
+			}
+		})
+	}
+}
+
+func TestParseInterpreter(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    string
+		expectError bool
+	}{
+		{name: "given an interpreter, should parse", in: "Interpreter: python3", expected: "python3"},
+		{name: "given an interpreter with arguments, should keep them", in: "Interpreter: `deno run --allow-read`", expected: "deno run --allow-read"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "tasks")
+			_, err := p.parseAttribute()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if err == nil && p.currTask.Interpreter != tt.expected {
+				t.Fatalf("Interpreter=%q, want=%q", p.currTask.Interpreter, tt.expected)
+			}
+		})
+	}
+	t.Run("given an interpreter twice, should error", func(t *testing.T) {
+		p, _ := NewParser(strings.NewReader("# Tasks\n## run\nInterpreter: node\nInterpreter: deno\n```\nlog()\n```\n"), "Tasks")
+		if _, err := p.Parse(); err == nil || !strings.Contains(err.Error(), "interpreter appears more than once") {
+			t.Fatalf("expected error got %v", err)
+		}
+	})
+}
+
+func TestParseEnvironmentList(t *testing.T) {
+	in := "# Tasks\n## build\nEnvironment:\n- BIN=$HOME/bin\n* `PATH=$BIN:$PATH`\nEnv: A=1\n```\ngo build\n```\n## last\nRequires: build\nEnv:\n- B=2\n"
+	p, err := NewParser(strings.NewReader(in), "Tasks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"BIN=$HOME/bin", "PATH=$BIN:$PATH", "A=1"}; !reflect.DeepEqual(tasks[0].Env, expected) {
+		t.Fatalf("want=%q got=%q", expected, tasks[0].Env)
+	}
+	if expected := []string{"B=2"}; len(tasks) < 2 || !reflect.DeepEqual(tasks[1].Env, expected) {
+		t.Fatalf("want=%q got=%+v", expected, tasks)
+	}
+}
+
+func TestParseRequiresEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    []string
+		expectError bool
+	}{
+		{name: "given names, should parse", in: "RequiresEnv: AWS_PROFILE, `GITHUB_TOKEN`", expected: []string{"AWS_PROFILE", "GITHUB_TOKEN"}},
+		{name: "given an empty name, should error", in: "RequiresEnv: AWS_PROFILE,", expectError: true},
+		{name: "given an assignment, should error", in: "RequiresEnv: AWS_PROFILE=dev", expectError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "tasks")
+			_, err := p.parseAttribute()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if err == nil && !reflect.DeepEqual(p.currTask.RequiresEnv, tt.expected) {
+				t.Fatalf("RequiresEnv=%q, want=%q", p.currTask.RequiresEnv, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParsePlatform(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    []string
+		expectError bool
+	}{
+		{name: "given platforms, should parse", in: "Platform: linux, `Darwin/arm64`", expected: []string{"linux", "darwin/arm64"}},
+		{name: "given an empty platform, should error", in: "Platform: linux,", expectError: true},
+		{name: "given an invalid platform, should error", in: "Platforms: mac os", expectError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "tasks")
+			_, err := p.parseAttribute()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if err == nil && !reflect.DeepEqual(p.currTask.Platforms, tt.expected) {
+				t.Fatalf("Platforms=%q, want=%q", p.currTask.Platforms, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseIf(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    string
+		expectError bool
+	}{
+		{name: "given a condition, should parse", in: "If: `!fileExists(\"certs/server.pem\")`", expected: `!fileExists("certs/server.pem")`},
+		{name: "given an invalid condition, should error", in: "If: exists(cert)", expectError: true},
+		{name: "given an empty condition, should error", in: "If:", expectError: true},
+		{name: "given a skip condition, should parse", in: "Skip: `env(\"CI\")`", expected: `env("CI")`},
+		{name: "given an invalid skip condition, should error", in: "Skip: CI", expectError: true},
+		{name: "given a matrix exclude condition, should parse", in: "MatrixExclude: env('GOOS') == 'windows'", expected: `env('GOOS') == 'windows'`},
+		{name: "given an invalid matrix exclude condition, should error", in: "MatrixExclude: env(", expectError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "tasks")
+			_, err := p.parseAttribute()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if got := p.currTask.If + p.currTask.Skip + p.currTask.MatrixExclude; err == nil && got != tt.expected {
+				t.Fatalf("condition=%q, want=%q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseSourcesOutputs(t *testing.T) {
+	p, _ := NewParser(strings.NewReader("Sources: `**/*.go`, go.mod"), "tasks")
+	if _, err := p.parseAttribute(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.currTask.Sources, []string{"**/*.go", "go.mod"}) {
+		t.Fatalf("sources want=[**/*.go go.mod] got=%q", p.currTask.Sources)
+	}
+	p, _ = NewParser(strings.NewReader("Outputs: bin/app"), "tasks")
+	if _, err := p.parseAttribute(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.currTask.Outputs, []string{"bin/app"}) {
+		t.Fatalf("outputs want=[bin/app] got=%q", p.currTask.Outputs)
+	}
+	p, _ = NewParser(strings.NewReader("Outputs: bin/app,"), "tasks")
+	if _, err := p.parseAttribute(); err == nil {
+		t.Fatal("expected error got nil")
+	}
+}
+
+func TestParseNamespaces(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    []string
+		expectError bool
+	}{
+		{
+			name:     "given nested headings, should prefix the task names with their namespaces",
+			in:       "# Tasks\n## lint\n```\nlint\n```\n## docker\nDocker images.\n### build\n```\nbuild\n```\n### compose\n#### up\n```\nup\n```\n## test\n```\ntest\n```\n",
+			expected: []string{"lint", "docker:build", "docker:compose:up", "test"},
+		},
+		{
+			name:     "given a nested heading after a script, should keep it in the description",
+			in:       "# Tasks\n## build\n```\nbuild\n```\n### Notes\nSlow.\n## test\n```\ntest\n```\n",
+			expected: []string{"build", "test"},
+		},
+		{
+			name:        "given a namespace with attributes, should error",
+			in:          "# Tasks\n## docker\nDir: docker\n### build\n```\nbuild\n```\n",
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewParser(strings.NewReader(tt.in), "Tasks")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tasks, err := p.Parse()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if err != nil {
+				return
+			}
+			var got []string
+			for _, task := range tasks {
+				got = append(got, task.Name)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseMatrix(t *testing.T) {
+	in := "# Tasks\n## build\nMatrix: `GOOS=[linux,darwin]`\n```\ngo build\n```\n## test\n```\ngo test\n```\n"
+	p, err := NewParser(strings.NewReader(in), "Tasks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, task := range tasks {
+		names = append(names, task.Name)
+	}
+	if expected := []string{"build", "build:linux", "build:darwin", "test"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("want=%q got=%q", expected, names)
+	}
+	p, _ = NewParser(strings.NewReader("# Tasks\n## build\nMatrix: GOOS=[linux,windows]\nMatrixExclude: env(\"GOOS\") == \"windows\"\n```\ngo build\n```\n"), "Tasks")
+	if tasks, err = p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if tasks[0].MatrixExclude != "" || tasks[1].MatrixExclude != `env("GOOS") == "windows"` {
+		t.Fatalf("expected only the variants to have the exclude condition, got %q and %q", tasks[0].MatrixExclude, tasks[1].MatrixExclude)
+	}
+	for _, in := range []string{"Matrix: GOOS=linux", "Matrix: GOOS=[linux]\nMatrix: GOARCH=[amd64]", "MatrixExclude: env(\"GOOS\")"} {
+		p, _ := NewParser(strings.NewReader("# Tasks\n## build\n"+in+"\n```\ngo build\n```\n"), "Tasks")
+		if _, err := p.Parse(); err == nil {
+			t.Fatalf("expected error for %q", in)
+		}
+	}
+}
+
+func TestParseExtends(t *testing.T) {
+	in := "# Tasks\n## test\nEnv: GOFLAGS=-count=1\nDir: src\nRequires: gen\nInputs: PKG\n```\ngo test $PKG\n```\n" +
+		"## test-race\nExtends: test\nEnv: CGO_ENABLED=1\n```\ngo test -race $PKG\n```\n" +
+		"## test-report\nExtends: `test-race` (append)\n```\ngo tool cover\n```\n" +
+		"## test-again\nExtends: test\n" +
+		"## gen\n```\ngo generate\n```\n"
+	p, err := NewParser(strings.NewReader(in), "Tasks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]models.Task{
+		"test-race":   {Env: []string{"GOFLAGS=-count=1", "CGO_ENABLED=1"}, Dir: "src", DependsOn: []string{"gen"}, Inputs: []string{"PKG"}, Script: "go test -race $PKG\n"},
+		"test-report": {Env: []string{"GOFLAGS=-count=1", "CGO_ENABLED=1"}, Dir: "src", DependsOn: []string{"gen"}, Inputs: []string{"PKG"}, Script: "go test -race $PKG\ngo tool cover\n"},
+		"test-again":  {Env: []string{"GOFLAGS=-count=1"}, Dir: "src", DependsOn: []string{"gen"}, Inputs: []string{"PKG"}, Script: "go test $PKG\n"},
+	}
+	for _, task := range tasks {
+		e, ok := expected[task.Name]
+		if !ok {
+			continue
+		}
+		got := models.Task{Env: task.Env, Dir: task.Dir, DependsOn: task.DependsOn, Inputs: task.Inputs, Script: task.Script}
+		if !reflect.DeepEqual(got, e) {
+			t.Fatalf("%s want=%+v got=%+v", task.Name, e, got)
+		}
+	}
+	for in, expected := range map[string]string{
+		"## a\nExtends: missing\n":             "task a extends missing, which is not found",
+		"## a\nExtends: b\n## b\nExtends: a\n": "tasks extend each other in a cycle: a -> b -> a",
+		"## a\nExtends:\n```\necho\n```\n":     "extends should name a task: a",
+	} {
+		p, _ := NewParser(strings.NewReader("# Tasks\n"+in), "Tasks")
+		if _, err := p.Parse(); err == nil || err.Error() != expected {
+			t.Fatalf("expected error %q for %q got %v", expected, in, err)
+		}
+	}
+}
+
+func TestParseCodeBlocks(t *testing.T) {
+	tests := []struct {
+		name                string
+		in                  string
+		expectedScript      string
+		expectedDescription []string
+	}{
+		{name: "given a backtick fence with an info string, should parse", in: "```sh\necho hi\n```\n", expectedScript: "echo hi\n"},
+		{name: "given a tilde fence, should parse", in: "~~~\necho hi\n~~~\n", expectedScript: "echo hi\n"},
+		{name: "given a longer fence, should only close it with a fence as long", in: "````\necho ```\n```\n````\n", expectedScript: "echo ```\n```\n"},
+		{name: "given a tilde fence, should not close it with backticks", in: "~~~\n```\n~~~\n", expectedScript: "```\n"},
+		{name: "given an indented fence, should parse", in: "  ```\n  echo hi\n  ```\n", expectedScript: "  echo hi\n"},
+		{
+			name:           "given an indented code block, should parse until the indentation ends",
+			in:             "Builds it.\n\n    go generate\n\n\tgo build\nDone.\n",
+			expectedScript: "go generate\ngo build\n", expectedDescription: []string{"Builds it.", "Done."},
+		},
+		{
+			name:           "given an indented block after a script, should keep it in the description",
+			in:             "```\necho hi\n```\n\n    note\n\n",
+			expectedScript: "echo hi\n", expectedDescription: []string{"note"},
+		},
+		{
+			name:           "given several code blocks with prose between them, should run them in order",
+			in:             "First generate.\n```\ngo generate\n```\nThen build.\n~~~\ngo build\n~~~\n",
+			expectedScript: "go generate\ngo build\n", expectedDescription: []string{"First generate.", "Then build."},
+		},
+		{
+			name:           "given indented lines in a paragraph, should keep them in the description",
+			in:             "Run it\n    with care.\n```\necho hi\n```\n",
+			expectedScript: "echo hi\n", expectedDescription: []string{"Run it", "with care."},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewParser(strings.NewReader("# Tasks\n## task\n"+tt.in), "Tasks")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tasks, err := p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tasks) != 1 || tasks[0].Script != tt.expectedScript {
+				t.Fatalf("script want=%q got=%+v", tt.expectedScript, tasks)
+			}
+			if !reflect.DeepEqual(tasks[0].Description, tt.expectedDescription) {
+				t.Fatalf("description want=%q got=%q", tt.expectedDescription, tasks[0].Description)
+			}
+		})
+	}
+	t.Run("given a shebang in a later code block, should error", func(t *testing.T) {
+		p, _ := NewParser(strings.NewReader("# Tasks\n## task\n```\necho hi\n```\n```\n#!/usr/bin/env python3\nprint(1)\n```\n"), "Tasks")
+		if _, err := p.Parse(); err == nil || !strings.Contains(err.Error(), "only the first command block") {
+			t.Fatalf("expected error got %v", err)
+		}
+	})
+}
+
+func TestParseLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		expected string
+		err      string
+	}{
+		{name: "given a fence without an info string, should have no language", in: "```\necho hi\n```\n"},
+		{name: "given an info string, should take its first word", in: "```python title=\"x\"\nprint(1)\n```\n", expected: "python"},
+		{name: "given an attribute block, should take the class", in: "~~~ {.ruby}\nputs 1\n~~~\n", expected: "ruby"},
+		{name: "given blocks in the same language, should parse", in: "```js\na()\n```\n```JS\nb()\n```\n", expected: "js"},
+		{name: "given blocks in different languages, should error", in: "```js\na()\n```\n```python\nb()\n```\n", err: "different languages"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewParser(strings.NewReader("# Tasks\n## task\n"+tt.in), "Tasks")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tasks, err := p.Parse()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error %q got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tasks) != 1 || tasks[0].Language != tt.expected {
+				t.Fatalf("language want=%q got=%+v", tt.expected, tasks)
+			}
+		})
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    []string
+		expectError bool
+	}{
+		{name: "given tags, should parse", in: "Tags: ci, `release`", expected: []string{"ci", "release"}},
+		{name: "given an empty tag, should error", in: "Tags: ci,", expectError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "tasks")
+			_, err := p.parseAttribute()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if err == nil && !reflect.DeepEqual(p.currTask.Tags, tt.expected) {
+				t.Fatalf("Tags=%q, want=%q", p.currTask.Tags, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseHidden(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		expected bool
+	}{
+		{name: "given true, should hide the task", in: "Hidden: true", expected: true},
+		{name: "given false, should not hide the task", in: "Hidden: false"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "tasks")
+			if _, err := p.parseAttribute(); err != nil {
+				t.Fatal(err)
+			}
+			if p.currTask.Hidden != tt.expected {
+				t.Fatalf("Hidden=%v, want=%v", p.currTask.Hidden, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseDeprecated(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "given a deprecated task without a script, should parse it as a forward",
+			in:       "# Tasks\n## ship\nDeprecated: use deploy\n## deploy\n```\ndeploy\n```\n",
+			expected: "use deploy",
+		},
+		{
+			name:          "given a notice that doesn't name a task and no script, should error",
+			in:            "# Tasks\n## ship\nDeprecated: ask the platform team\n",
+			expectedError: "has no commands or required tasks",
+		},
+		{
+			name:          "given an empty notice, should error",
+			in:            "# Tasks\n## ship\nDeprecated:\n```\nship\n```\n",
+			expectedError: "deprecated should have a notice",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "Tasks")
+			tasks, err := p.Parse()
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error %q got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tasks[0].Deprecated != tt.expected {
+				t.Fatalf("Deprecated=%q, want=%q", tasks[0].Deprecated, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTrimTaskName(t *testing.T) {
+	tests := map[string]string{
+		"build":       "build",
+		"__build__":   "build",
+		"`build`":     "build",
+		"_setup":      "_setup",
+		"`_setup`":    "_setup",
+		"**_setup**":  "_setup",
+		"_emphasis_":  "emphasis",
+		" spaced out": "spaced out",
+	}
+	for in, expected := range tests {
+		if got := trimTaskName(in); got != expected {
+			t.Errorf("%q: want=%q got=%q", in, expected, got)
+		}
+	}
+}
+
+func TestParseDoc(t *testing.T) {
+	p, _ := NewParser(strings.NewReader(`# Tasks
+## deploy
+Deploys the `+"`api`"+` service.
+
+Steps:
+- build
+- push
+Requires: build
+`+"```"+`
+deploy
+`+"```"+`
+#### Notes
+Needs **docker**.
+## build
+`+"```"+`
+go build
+`+"```"), "tasks")
+	tasks, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Deploys the `api` service.\n\nSteps:\n\n- build\n- push\n\n#### Notes\n\nNeeds **docker**."
+	if got := models.FormatDoc(tasks[0].Doc); got != expected {
+		t.Fatalf("want=%q got=%q", expected, got)
+	}
+	if len(tasks[1].Doc) != 0 {
+		t.Fatalf("expected no doc got %+v", tasks[1].Doc)
+	}
+}
+
+func TestParseAnnotations(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		expected map[string]string
+		err      bool
+	}{
+		{name: "given an annotation, should add its pairs", in: "<!-- xc: ci-only=true owner=platform-team -->", expected: map[string]string{"ci-only": "true", "owner": "platform-team"}},
+		{name: "given quoted values and commas, should split on them", in: `<!--XC: owner="platform team", empty= -->`, expected: map[string]string{"owner": "platform team", "empty": ""}},
+		{name: "given another comment, should not be an annotation", in: "<!-- TODO: owner=me -->"},
+		{name: "given a pair without a value, should error", in: "<!-- xc: ci-only -->", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewParser(strings.NewReader(tt.in), "tasks")
+			ok, err := p.parseAnnotation()
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v got %v", tt.err, err)
+			}
+			if ok != (tt.expected != nil) {
+				t.Fatalf("expected an annotation %v got %v", tt.expected != nil, ok)
+			}
+			if !reflect.DeepEqual(p.currTask.Metadata, tt.expected) {
+				t.Fatalf("want=%v got=%v", tt.expected, p.currTask.Metadata)
+			}
+		})
+	}
+}
+
+func TestParseShell(t *testing.T) {
+	p, _ := NewParser(strings.NewReader("Shell: `pwsh`"), "tasks")
+	if _, err := p.parseAttribute(); err != nil {
+		t.Fatal(err)
+	}
+	if p.currTask.Shell != "pwsh" {
+		t.Fatalf("shell want=pwsh got=%q", p.currTask.Shell)
+	}
+	p, _ = NewParser(strings.NewReader("Shell:"), "tasks")
+	if _, err := p.parseAttribute(); err == nil {
+		t.Fatal("expected error got nil")
+	}
+}
+
+func TestParseFinally(t *testing.T) {
+	p, _ := NewParser(strings.NewReader("Finally: `compose-down`, report ci,"), "tasks")
+	if _, err := p.parseAttribute(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"compose-down", "report ci"}; !reflect.DeepEqual(p.currTask.Finally, expected) {
+		t.Fatalf("finally want=%q got=%q", expected, p.currTask.Finally)
+	}
+}
+
+func TestParseDotenv(t *testing.T) {
+	p, _ := NewParser(strings.NewReader("Dotenv: `.env`, .env.local"), "tasks")
+	if _, err := p.parseAttribute(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{".env", ".env.local"}; !reflect.DeepEqual(p.currTask.Dotenv, expected) {
+		t.Fatalf("dotenv want=%q got=%q", expected, p.currTask.Dotenv)
+	}
+	p, _ = NewParser(strings.NewReader("Dotenv: .env,"), "tasks")
+	if _, err := p.parseAttribute(); err == nil {
+		t.Fatal("expected error got nil")
+	}
+}
+
+func TestParseContainer(t *testing.T) {
+	p, _ := NewParser(strings.NewReader("Container: `golang:1.22`"), "tasks")
+	if _, err := p.parseAttribute(); err != nil {
+		t.Fatal(err)
+	}
+	if p.currTask.Container != "golang:1.22" {
+		t.Fatalf("container want=golang:1.22 got=%q", p.currTask.Container)
+	}
+	p, _ = NewParser(strings.NewReader("Container:"), "tasks")
+	if _, err := p.parseAttribute(); err == nil {
+		t.Fatal("expected error got nil")
+	}
+}
+
+func TestParseScriptFile(t *testing.T) {
+	p, _ := NewParser(strings.NewReader("ScriptFile: true"), "tasks")
+	if _, err := p.parseAttribute(); err != nil {
+		t.Fatal(err)
+	}
+	if !p.currTask.ScriptFile {
+		t.Fatal("expected ScriptFile to be true")
+	}
+}
+
+func TestParseRemote(t *testing.T) {
+	p, _ := NewParser(strings.NewReader("Remote: `deploy@example.com`"), "tasks")
+	if _, err := p.parseAttribute(); err != nil {
+		t.Fatal(err)
+	}
+	if p.currTask.Remote != "deploy@example.com" {
+		t.Fatalf("remote want=deploy@example.com got=%q", p.currTask.Remote)
+	}
+	p, _ = NewParser(strings.NewReader("Remote:"), "tasks")
+	if _, err := p.parseAttribute(); err == nil {
+		t.Fatal("expected error got nil")
+	}
+}
@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/problem"
+)
+
+// StrictError is returned by ParseFileStrict for the mistakes in task files that parsing otherwise tolerates.
+type StrictError struct {
+	Problems []problem.Problem
+}
+
+func (e *StrictError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = p.Location() + ": " + p.Message
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseFileStrict parses the task file at path the same as ParseFile, and returns a *StrictError along with the tasks
+// if the files have unknown attributes, empty code blocks, headings that aren't tasks or duplicate task names.
+// The problems are located in the files relative to the directory of path.
+func ParseFileStrict(path, heading string, discover ...string) (models.Tasks, error) {
+	var problems []problem.Problem
+	tasks, err := parseFiles(path, heading, discover, &problems)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) == 0 {
+		return tasks, nil
+	}
+	for i, p := range problems {
+		if p.File == "" {
+			problems[i].File = path
+		} else {
+			problems[i].File = filepath.Join(filepath.Dir(path), filepath.FromSlash(p.File))
+		}
+	}
+	return tasks, &StrictError{Problems: problems}
+}
+
+// Problems returns the mistakes found while parsing that don't stop the tasks from running, see ParseFileStrict.
+func (p *parser) Problems() []problem.Problem {
+	return p.problems
+}
+
+// addProblem records a problem with the current task at line and column.
+func (p *parser) addProblem(line, column int, message string) {
+	p.problems = append(p.problems, problem.Problem{
+		Task:     p.currTask.Name,
+		Line:     line,
+		Column:   column,
+		Severity: problem.SeverityError,
+		Message:  message,
+	})
+}
+
+// column returns the column of the first non blank character of the current line.
+func (p *parser) column() int {
+	return len(p.currentLine) - len(strings.TrimLeft(p.currentLine, " \t")) + 1
+}
+
+// attributeLikeRe matches a line that is written like an attribute, e.g. `Requries: build`.
+var attributeLikeRe = regexp.MustCompile(`^\s*[*_]*([A-Za-z][A-Za-z0-9_-]*)[*_]*:(\s|$)`)
+
+// checkDescriptionLine records a problem if the line added to the description of the current task
+// looks like an attribute, or is a heading.
+func (p *parser) checkDescriptionLine(heading bool, text string) {
+	if heading {
+		p.addProblem(p.currentLineNo, p.column(), i18n.Sprintf("heading %q is not a task, it is part of the description of %s", text, p.currTask.Name))
+		return
+	}
+	if m := attributeLikeRe.FindStringSubmatch(p.currentLine); m != nil {
+		p.addProblem(p.currentLineNo, p.column(), i18n.Sprintf("unknown attribute %s", m[1]))
+	}
+}
+
+// checkDuplicates records a problem for each task that has the name of an earlier task.
+func (p *parser) checkDuplicates() {
+	lines := map[string]int{}
+	for _, t := range p.tasks {
+		name := models.NormalizeName(t.Name)
+		if line, ok := lines[name]; ok {
+			p.problems = append(p.problems, problem.Problem{
+				Task:     t.Name,
+				Line:     t.Line,
+				Column:   1,
+				Severity: problem.SeverityError,
+				Message:  i18n.Sprintf("task %s is already defined on line %d", t.Name, line),
+			})
+			continue
+		}
+		lines[name] = t.Line
+	}
+}
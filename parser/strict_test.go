@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFileStrict(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected []string
+	}{
+		{
+			name:  "given valid tasks, should not report problems",
+			files: map[string]string{"README.md": "## Tasks\n### build\nBuilds it.\nRequires: lint\n```\ngo build\n```\n### lint\n```\ngo vet\n```\n"},
+		},
+		{
+			name:     "given a misspelt attribute, should locate it",
+			files:    map[string]string{"README.md": "## Tasks\n### build\n  Requries: lint\n```\ngo build\n```\n"},
+			expected: []string{"README.md:3:3: unknown attribute Requries"},
+		},
+		{
+			name:     "given an empty code block, should locate its opening",
+			files:    map[string]string{"README.md": "## Tasks\n### build\nRequires: lint\n```\n\n```\n### lint\n```\ngo vet\n```\n"},
+			expected: []string{"README.md:4:1: the command block of task build is empty"},
+		},
+		{
+			name:  "given headings that aren't tasks, should locate them",
+			files: map[string]string{"README.md": "## Tasks\n#### deep\n### build\n```\ngo build\n```\n#### Notes\nMore.\n"},
+			expected: []string{
+				`README.md:2:1: heading "deep" is not a task, it is nested too deeply`,
+				`README.md:7:1: heading "Notes" is not a task, it is part of the description of build`,
+			},
+		},
+		{
+			name:     "given a duplicate name, should locate the second task",
+			files:    map[string]string{"README.md": "## Tasks\n### build\n```\ngo build\n```\n### Build\n```\ngo build ./...\n```\n"},
+			expected: []string{"README.md:6:1: task Build is already defined on line 2"},
+		},
+		{
+			name: "given a problem in an included file, should locate it in that file",
+			files: map[string]string{
+				"README.md":     "## Tasks\nIncludes: docs/tasks.md\n### build\n```\ngo build\n```\n",
+				"docs/tasks.md": "## Tasks\n### docs\nRequries: build\n```\nmkdocs build\n```\n",
+			},
+			expected: []string{"docs/tasks.md:3:1: unknown attribute Requries"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			_, err := ParseFileStrict(filepath.Join(dir, "README.md"), "Tasks")
+			if len(tt.expected) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				return
+			}
+			var strictErr *StrictError
+			if !errors.As(err, &strictErr) {
+				t.Fatalf("expected a strict error got %v", err)
+			}
+			got := make([]string, len(strictErr.Problems))
+			for i, p := range strictErr.Problems {
+				rel, err := filepath.Rel(dir, p.File)
+				if err != nil {
+					t.Fatal(err)
+				}
+				p.File = filepath.ToSlash(rel)
+				got[i] = p.Location() + ": " + p.Message
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+// Package problem finds errors and warnings, such as compiler errors, in the output of tasks.
+package problem
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/joerdav/xc/i18n"
+)
+
+// Severities of a Problem.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Problem is an error or warning found in the output of a task.
+type Problem struct {
+	Task     string `json:"task"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// builtin matchers, keyed by name.
+var builtin = map[string]string{
+	"go":  `^\s*(?P<file>[^\s:]+\.go):(?P<line>\d+)(?::(?P<column>\d+))?: (?P<message>.+)$`,
+	"gcc": `^(?P<file>[^\s:]+):(?P<line>\d+):(?P<column>\d+): (?:fatal )?(?P<severity>error|warning): (?P<message>.+)$`,
+	"tsc": `^(?P<file>[^\s(]+)\((?P<line>\d+),(?P<column>\d+)\): (?P<severity>error|warning) (?P<message>.+)$`,
+}
+
+// Matcher matches problems in lines of output.
+type Matcher struct {
+	re *regexp.Regexp
+}
+
+// Parse returns the Matcher for s, which is either the name of a builtin matcher (go, gcc, tsc)
+// or a regular expression.
+// The regular expression must have a message group, and can have file, line, column and severity groups,
+// e.g. `^(?P<file>[^:]+):(?P<line>\d+): (?P<message>.+)$`.
+func Parse(s string) (Matcher, error) {
+	if b, ok := builtin[strings.ToLower(s)]; ok {
+		s = b
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return Matcher{}, i18n.Errorf("invalid problem matcher %q: %w", s, err)
+	}
+	if re.SubexpIndex("message") < 0 {
+		return Matcher{}, i18n.Errorf("problem matcher %q has no message group", s)
+	}
+	return Matcher{re: re}, nil
+}
+
+// Match returns the Problem in line, if there is one.
+// Problems without a severity are errors.
+func (m Matcher) Match(line string) (Problem, bool) {
+	match := m.re.FindStringSubmatch(line)
+	if match == nil {
+		return Problem{}, false
+	}
+	group := func(name string) string {
+		if i := m.re.SubexpIndex(name); i >= 0 {
+			return match[i]
+		}
+		return ""
+	}
+	p := Problem{
+		File:     group("file"),
+		Severity: strings.ToLower(group("severity")),
+		Message:  strings.TrimSpace(group("message")),
+	}
+	p.Line, _ = strconv.Atoi(group("line"))
+	p.Column, _ = strconv.Atoi(group("column"))
+	p.Severity = severity(p.Severity)
+	return p, true
+}
+
+// severity normalises common spellings of severities.
+func severity(s string) string {
+	switch s {
+	case "", "err", "fatal":
+		return SeverityError
+	case "warn":
+		return SeverityWarning
+	}
+	return s
+}
+
+// Collector collects the problems found in the output of tasks.
+// It is safe for concurrent use.
+type Collector struct {
+	mu       sync.Mutex
+	problems []Problem
+}
+
+// Problems returns the problems collected so far, in the order they were found.
+func (c *Collector) Problems() []Problem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Problem{}, c.problems...)
+}
+
+func (c *Collector) add(p Problem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.problems = append(c.problems, p)
+}
+
+// Writer returns a writer that adds the problems matched in each line written to it to c.
+// The first matcher to match a line is used.
+// Close matches the final line if it isn't terminated by a newline.
+func (c *Collector) Writer(task string, matchers []Matcher) *Writer {
+	return &Writer{c: c, task: task, matchers: matchers}
+}
+
+// Writer matches problems in output, see Collector.Writer.
+type Writer struct {
+	c        *Collector
+	task     string
+	matchers []Matcher
+	buf      []byte
+	// found holds the problems matched by this writer.
+	found []Problem
+}
+
+// Write implements io.Writer.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.match(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Close matches any remaining output.
+func (w *Writer) Close() error {
+	if len(w.buf) > 0 {
+		w.match(string(w.buf))
+		w.buf = nil
+	}
+	return nil
+}
+
+func (w *Writer) match(line string) {
+	line = strings.TrimSuffix(line, "\r")
+	for _, m := range w.matchers {
+		if p, ok := m.Match(line); ok {
+			p.Task = w.task
+			w.c.add(p)
+			w.found = append(w.found, p)
+			return
+		}
+	}
+}
+
+// Problems returns the problems matched in the output written to w, they are added to its Collector too.
+func (w *Writer) Problems() []Problem {
+	return append([]Problem{}, w.found...)
+}
+
+// Count returns the number of errors and warnings in problems.
+func Count(problems []Problem) (errs, warnings int) {
+	for _, p := range problems {
+		switch p.Severity {
+		case SeverityError:
+			errs++
+		case SeverityWarning:
+			warnings++
+		}
+	}
+	return errs, warnings
+}
+
+// Location returns the location of p in the form file:line:column,
+// omitting the parts that are unknown.
+func (p Problem) Location() string {
+	loc := p.File
+	if p.Line > 0 {
+		loc += ":" + strconv.Itoa(p.Line)
+		if p.Column > 0 {
+			loc += ":" + strconv.Itoa(p.Column)
+		}
+	}
+	return loc
+}
@@ -0,0 +1,124 @@
+package problem
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name, matcher, line string
+		expected            *Problem
+	}{
+		{
+			name:     "given go vet output, should match",
+			matcher:  "go",
+			line:     "./run/run.go:12:3: unreachable code",
+			expected: &Problem{File: "./run/run.go", Line: 12, Column: 3, Severity: SeverityError, Message: "unreachable code"},
+		},
+		{
+			name:     "given go test output, should match",
+			matcher:  "Go",
+			line:     "    run_test.go:40: want=1 got=2",
+			expected: &Problem{File: "run_test.go", Line: 40, Severity: SeverityError, Message: "want=1 got=2"},
+		},
+		{
+			name:     "given a gcc warning, should match",
+			matcher:  "gcc",
+			line:     "main.c:3:10: warning: unused variable 'x'",
+			expected: &Problem{File: "main.c", Line: 3, Column: 10, Severity: SeverityWarning, Message: "unused variable 'x'"},
+		},
+		{
+			name:     "given a tsc error, should match",
+			matcher:  "tsc",
+			line:     "src/app.ts(4,7): error TS2322: Type 'string' is not assignable to type 'number'.",
+			expected: &Problem{File: "src/app.ts", Line: 4, Column: 7, Severity: SeverityError, Message: "TS2322: Type 'string' is not assignable to type 'number'."},
+		},
+		{
+			name:     "given a custom matcher, should match",
+			matcher:  `^(?P<severity>WARN|ERROR) (?P<message>.+)$`,
+			line:     "WARN disk almost full",
+			expected: &Problem{Severity: SeverityWarning, Message: "disk almost full"},
+		},
+		{
+			name:     "given an unknown severity, should keep it",
+			matcher:  `^(?i)(?P<severity>note): (?P<message>.+)$`,
+			line:     "NOTE: see the docs",
+			expected: &Problem{Severity: "note", Message: "see the docs"},
+		},
+		{
+			name:    "given a line that doesn't match, should not match",
+			matcher: "go",
+			line:    "ok  	github.com/joerdav/xc/run	0.008s",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(tt.matcher)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, ok := m.Match(tt.line)
+			if ok != (tt.expected != nil) {
+				t.Fatalf("ok=%v want=%v", ok, tt.expected != nil)
+			}
+			if ok && !reflect.DeepEqual(p, *tt.expected) {
+				t.Fatalf("want=%+v got=%+v", *tt.expected, p)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, s := range []string{"(", "^(?P<file>.+)$"} {
+		if _, err := Parse(s); err == nil {
+			t.Fatalf("%s: expected error got nil", s)
+		}
+	}
+}
+
+func TestCollector(t *testing.T) {
+	m, err := Parse("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c Collector
+	w := c.Writer("test", []Matcher{m})
+	fmt.Fprint(w, "--- FAIL: TestRun\n    run_test.go:1")
+	fmt.Fprint(w, "0: failed\r\nFAIL\nmain.go:3: last")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []Problem{
+		{Task: "test", File: "run_test.go", Line: 10, Severity: SeverityError, Message: "failed"},
+		{Task: "test", File: "main.go", Line: 3, Severity: SeverityError, Message: "last"},
+	}
+	if got := c.Problems(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("want=%+v got=%+v", expected, got)
+	}
+	if got := w.Problems(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("writer want=%+v got=%+v", expected, got)
+	}
+	if errs, warnings := Count(c.Problems()); errs != 2 || warnings != 0 {
+		t.Fatalf("errs=%d warnings=%d", errs, warnings)
+	}
+}
+
+func TestLocation(t *testing.T) {
+	tests := []struct {
+		p        Problem
+		expected string
+	}{
+		{Problem{File: "a.go", Line: 1, Column: 2}, "a.go:1:2"},
+		{Problem{File: "a.go", Line: 1}, "a.go:1"},
+		{Problem{File: "a.go", Column: 2}, "a.go"},
+		{Problem{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.p.Location(); got != tt.expected {
+			t.Fatalf("want=%q got=%q", tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Package report writes the results of a run in formats understood by CI systems.
+package report
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/run"
+)
+
+// Formats are the supported report formats, keyed by name.
+var Formats = map[string]func(w io.Writer, suite string, results []run.Result) error{
+	"junit": JUnit,
+	"trace": Trace,
+}
+
+// Spec is a report to write, parsed from a string such as `junit=report.xml`.
+type Spec struct {
+	Format string
+	Path   string
+}
+
+// ParseSpec parses a report spec of the form `format=path`.
+func ParseSpec(s string) (Spec, error) {
+	format, path, ok := strings.Cut(s, "=")
+	if !ok || path == "" {
+		return Spec{}, i18n.Errorf("invalid report %q, should be format=path", s)
+	}
+	if _, ok := Formats[format]; !ok {
+		return Spec{}, i18n.Errorf("unknown report format %q", format)
+	}
+	return Spec{Format: format, Path: path}, nil
+}
+
+// Write writes the report of results to the file of the Spec.
+func (s Spec) Write(suite string, results []run.Result) (err error) {
+	f, err := os.Create(s.Path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+	return Formats[s.Format](f, suite, results)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Skipped   *junitMessage `xml:"skipped"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit writes results as JUnit XML, with a testcase for each task in a single test suite.
+// The output of failed tasks is included in their failure, and the output of other tasks in system-out.
+func JUnit(w io.Writer, suite string, results []run.Result) error {
+	s := junitTestSuite{Name: suite, Tests: len(results)}
+	var total time.Duration
+	for _, r := range results {
+		c := junitTestCase{Name: r.Task, ClassName: suite, Time: seconds(r.Duration)}
+		switch {
+		case r.Skipped:
+			s.Skipped++
+			c.Skipped = &junitMessage{Message: r.SkipReason}
+			if r.SkipReason == "" {
+				c.Skipped.Message = i18n.T("ran already")
+			}
+		case r.Err != nil:
+			s.Failures++
+			c.Failure = &junitMessage{Message: r.Err.Error(), Text: r.Output}
+		default:
+			c.SystemOut = r.Output
+		}
+		if s.Timestamp == "" && !r.Start.IsZero() {
+			s.Timestamp = r.Start.UTC().Format("2006-01-02T15:04:05")
+		}
+		total += r.Duration
+		s.Cases = append(s.Cases, c)
+	}
+	s.Time = seconds(total)
+	doc := junitTestSuites{
+		Name:     "xc",
+		Tests:    s.Tests,
+		Failures: s.Failures,
+		Skipped:  s.Skipped,
+		Time:     s.Time,
+		Suites:   []junitTestSuite{s},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
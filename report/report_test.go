@@ -0,0 +1,110 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joerdav/xc/run"
+)
+
+func TestJUnit(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	results := []run.Result{
+		{Task: "build", Start: start, Duration: 1500 * time.Millisecond, Output: "ok <done>"},
+		{Task: "build", Start: start, Skipped: true},
+		{Task: "test", Start: start, Duration: 250 * time.Millisecond, Err: errors.New("exit status 1"), Output: "FAIL\n"},
+	}
+	var buf bytes.Buffer
+	if err := JUnit(&buf, "README.md", results); err != nil {
+		t.Fatal(err)
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="xc" tests="3" failures="1" skipped="1" time="1.750">
+  <testsuite name="README.md" tests="3" failures="1" skipped="1" time="1.750" timestamp="2024-01-01T12:00:00">
+    <testcase name="build" classname="README.md" time="1.500">
+      <system-out>ok &lt;done&gt;</system-out>
+    </testcase>
+    <testcase name="build" classname="README.md" time="0.000">
+      <skipped message="ran already"></skipped>
+    </testcase>
+    <testcase name="test" classname="README.md" time="0.250">
+      <failure message="exit status 1">FAIL&#xA;</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+	if got := buf.String(); got != expected {
+		t.Fatalf("want:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected Spec
+		err      bool
+	}{
+		{in: "junit=report.xml", expected: Spec{Format: "junit", Path: "report.xml"}},
+		{in: "junit=out/a=b.xml", expected: Spec{Format: "junit", Path: "out/a=b.xml"}},
+		{in: "junit", err: true},
+		{in: "junit=", err: true},
+		{in: "tap=report.tap", err: true},
+	}
+	for _, tt := range tests {
+		s, err := ParseSpec(tt.in)
+		if (err != nil) != tt.err {
+			t.Fatalf("%s: expected error %v got %v", tt.in, tt.err, err)
+		}
+		if s != tt.expected {
+			t.Fatalf("%s: want=%+v got=%+v", tt.in, tt.expected, s)
+		}
+	}
+}
+
+func TestTrace(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	results := []run.Result{
+		{Task: "gen", Start: start, Duration: time.Second},
+		{Task: "lint", Start: start.Add(time.Second), Duration: 2 * time.Second, Requires: []string{"gen"}},
+		{Task: "test", Start: start.Add(time.Second), Duration: time.Second, Err: errors.New("exit status 1"), Requires: []string{"gen"}},
+		{Task: "gen", Start: start.Add(2 * time.Second), Skipped: true, SkipReason: "ran already"},
+	}
+	var buf bytes.Buffer
+	if err := Trace(&buf, "README.md", results); err != nil {
+		t.Fatal(err)
+	}
+	var f traceFile
+	if err := json.Unmarshal(buf.Bytes(), &f); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range f.TraceEvents {
+		s := fmt.Sprintf("%s %s %d tid=%d", e.Phase, e.Name, e.Time, e.TID)
+		if e.Duration != nil {
+			s += fmt.Sprintf(" dur=%d", *e.Duration)
+		}
+		for k, v := range e.Args {
+			s += " " + k + "=" + v
+		}
+		got = append(got, s)
+	}
+	expected := []string{
+		"M process_name 0 tid=0 name=README.md",
+		"X gen 0 tid=1 dur=1000000",
+		"X lint 1000000 tid=1 dur=2000000",
+		"s requires 1000000 tid=1",
+		"f requires 1000000 tid=1",
+		"X test 1000000 tid=2 dur=1000000 error=exit status 1",
+		"s requires 1000000 tid=1",
+		"f requires 1000000 tid=2",
+		"i gen 2000000 tid=2 skipped=ran already",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("want:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,128 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/joerdav/xc/run"
+)
+
+type traceFile struct {
+	TraceEvents     []traceEvent `json:"traceEvents"`
+	DisplayTimeUnit string       `json:"displayTimeUnit"`
+}
+
+// traceEvent is an event of the Chrome trace event format, with times in microseconds.
+type traceEvent struct {
+	Name     string            `json:"name"`
+	Category string            `json:"cat,omitempty"`
+	Phase    string            `json:"ph"`
+	Time     int64             `json:"ts"`
+	Duration *int64            `json:"dur,omitempty"`
+	PID      int               `json:"pid"`
+	TID      int               `json:"tid"`
+	ID       int               `json:"id,omitempty"`
+	Scope    string            `json:"s,omitempty"`
+	Binding  string            `json:"bp,omitempty"`
+	Args     map[string]string `json:"args,omitempty"`
+}
+
+// Trace writes results as a Chrome trace, which can be opened in chrome://tracing or https://ui.perfetto.dev.
+// Each script is a slice on the first row that is free when it starts, so scripts that ran at the same time are on different rows,
+// and an arrow is drawn from the end of each task to the start of the tasks that require it. Skipped tasks are instant events.
+func Trace(w io.Writer, suite string, results []run.Result) error {
+	var origin time.Time
+	for _, r := range results {
+		if !r.Start.IsZero() && (origin.IsZero() || r.Start.Before(origin)) {
+			origin = r.Start
+		}
+	}
+	micros := func(t time.Time) int64 {
+		return t.Sub(origin).Microseconds()
+	}
+	lanes := traceLanes(results)
+	f := traceFile{
+		DisplayTimeUnit: "ms",
+		TraceEvents: []traceEvent{
+			{Name: "process_name", Phase: "M", PID: 1, Args: map[string]string{"name": suite}},
+		},
+	}
+	var flows int
+	for i, r := range results {
+		e := traceEvent{Name: r.Task, Category: "task", Time: micros(r.Start), PID: 1, TID: lanes[i]}
+		switch {
+		case r.Skipped:
+			e.Phase, e.Scope = "i", "t"
+			e.Args = map[string]string{"skipped": r.SkipReason}
+			f.TraceEvents = append(f.TraceEvents, e)
+			continue
+		case r.Err != nil:
+			e.Args = map[string]string{"error": r.Err.Error()}
+		}
+		dur := r.Duration.Microseconds()
+		e.Phase, e.Duration = "X", &dur
+		f.TraceEvents = append(f.TraceEvents, e)
+		for _, name := range r.Requires {
+			d, ok := lastRun(results, name, r.Start)
+			if !ok {
+				continue
+			}
+			flows++
+			dep := results[d]
+			f.TraceEvents = append(f.TraceEvents,
+				traceEvent{Name: "requires", Category: "requires", Phase: "s", Time: micros(dep.Start.Add(dep.Duration)), PID: 1, TID: lanes[d], ID: flows},
+				traceEvent{Name: "requires", Category: "requires", Phase: "f", Binding: "e", Time: micros(r.Start), PID: 1, TID: lanes[i], ID: flows},
+			)
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(f)
+}
+
+// traceLanes returns the row of each result, the first one that is free when its script starts, counting from 1.
+func traceLanes(results []run.Result) []int {
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return results[order[a]].Start.Before(results[order[b]].Start)
+	})
+	lanes := make([]int, len(results))
+	var ends []time.Time
+	for _, i := range order {
+		r := results[i]
+		lane := len(ends)
+		for l, end := range ends {
+			if !end.After(r.Start) {
+				lane = l
+				break
+			}
+		}
+		if lane == len(ends) {
+			ends = append(ends, time.Time{})
+		}
+		if !r.Skipped {
+			ends[lane] = r.Start.Add(r.Duration)
+		}
+		lanes[i] = lane + 1
+	}
+	return lanes
+}
+
+// lastRun returns the index of the last script of the task name that started before start.
+func lastRun(results []run.Result, name string, start time.Time) (int, bool) {
+	last := -1
+	for i, r := range results {
+		if r.Task != name || r.Skipped || r.Start.After(start) {
+			continue
+		}
+		if last < 0 || r.Start.After(results[last].Start) {
+			last = i
+		}
+	}
+	return last, last >= 0
+}
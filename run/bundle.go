@@ -0,0 +1,254 @@
+package run
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+)
+
+// Bundle writes a shell script to w that runs the named task, and the tasks it requires, without xc.
+// The steps of the Plan of the task are inlined in order, parallel branches run as background jobs.
+// The inputs of the task are read from the arguments of the script or the environment, like `xc <task>`.
+//
+// The directories of tasks are resolved relative to root, a path relative to the location of the script.
+// If root is empty they are resolved relative to the directory the script is run from.
+//
+// Only the attributes that affect how scripts run are bundled:
+// InheritEnv, Dotenv, Platform, If, Sources, Outputs, Problems, Notify, Watch, Finally, Container, Remote, ScriptFile and prompts are not supported.
+func (r *Runner) Bundle(w io.Writer, name, root string) error {
+	task, ok := r.tasks.Get(name)
+	if !ok {
+		return TaskNotFoundError{Name: name}
+	}
+	plan, err := r.Plan(name, nil)
+	if err != nil {
+		return err
+	}
+	b := bundler{runner: r}
+	shell := "/bin/sh"
+	if b.needsBash(plan) {
+		shell = "/usr/bin/env bash"
+	}
+	fmt.Fprintf(&b.out, "#!%s\n", shell)
+	fmt.Fprintf(&b.out, "# Runs the task %s, generated by xc bundle.\n", task.Name)
+	fmt.Fprintf(&b.out, "# Usage: %s\n", strings.Join(append([]string{"$0"}, inputUsage(task)...), " "))
+	b.out.WriteString("set -e\n")
+	if root != "" {
+		fmt.Fprintf(&b.out, "cd \"$(dirname \"$0\")\"/%s\n", shellQuote(filepath.ToSlash(root)))
+	}
+	// The inputs of the task are checked before anything runs, like xc does.
+	for i, n := range task.Inputs {
+		fmt.Fprintf(&b.out, "xc_%s=\"${%d:-${%s:-%s}}\"\n", n, i+1, n, defaultInput(task, n))
+		b.checkInput(task, n, "$xc_"+n)
+	}
+	if err := b.steps(plan, true); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, b.out.String())
+	return err
+}
+
+type bundler struct {
+	runner *Runner
+	out    strings.Builder
+}
+
+// needsBash returns true if any script of the plan declares that it is a bash script.
+func (b *bundler) needsBash(steps []Step) bool {
+	for _, s := range steps {
+		for _, br := range s.Branches {
+			if b.needsBash(br) {
+				return true
+			}
+		}
+		if t, ok := b.runner.tasks.Get(s.Task); ok && strings.HasPrefix(t.Script, "#!") {
+			if line, _, _ := strings.Cut(t.Script, "\n"); shellShebangRe.MatchString(line) && strings.Contains(line, "bash") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// steps writes steps in order, top is true for the steps that run the named task itself as the last step.
+func (b *bundler) steps(steps []Step, top bool) error {
+	for i, s := range steps {
+		if len(s.Branches) > 0 {
+			b.out.WriteString("xc_pids=\n")
+			for _, br := range s.Branches {
+				b.out.WriteString("(\n")
+				if err := b.steps(br, false); err != nil {
+					return err
+				}
+				b.out.WriteString(") &\nxc_pids=\"$xc_pids $!\"\n")
+			}
+			b.out.WriteString("xc_failed=\nfor xc_pid in $xc_pids; do wait \"$xc_pid\" || xc_failed=1; done\n")
+			b.out.WriteString("[ -z \"$xc_failed\" ] || exit 1\n")
+			continue
+		}
+		if err := b.step(s, top && i == len(steps)-1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *bundler) step(s Step, top bool) error {
+	task, ok := b.runner.tasks.Get(s.Task)
+	if !ok {
+		return TaskNotFoundError{Name: s.Task}
+	}
+	if s.Skip != "" {
+		fmt.Fprintf(&b.out, "# %s: %s\n", task.Name, s.Skip)
+		return nil
+	}
+	if task.Script == "" {
+		return nil
+	}
+	fmt.Fprintf(&b.out, "# %s\n(\n", task.Name)
+	for _, e := range task.Env {
+		k, v, _ := strings.Cut(e, "=")
+		fmt.Fprintf(&b.out, "export %s=%s\n", k, QuoteEnvValue(v))
+	}
+	// The directory can refer to the variables of the task, the same as when it runs.
+	if task.Dir != "" {
+		fmt.Fprintf(&b.out, "cd %s\n", QuoteEnvValue(filepath.ToSlash(task.Dir)))
+	}
+	for _, n := range task.RequiresEnv {
+		fmt.Fprintf(&b.out, ": \"${%s:?%s}\"\n", n,
+			doubleQuoteEscape(i18n.Sprintf("task %s requires the environment variable %s", task.Name, n)))
+	}
+	if top {
+		for _, n := range task.Inputs {
+			fmt.Fprintf(&b.out, "export %s=\"$xc_%s\"\n", n, n)
+		}
+	} else {
+		for i, n := range task.Inputs {
+			if i < len(s.Args) {
+				fmt.Fprintf(&b.out, "%s=%s\n", n, shellQuote(s.Args[i]))
+			} else {
+				fmt.Fprintf(&b.out, "%s=\"${%s:-%s}\"\n", n, n, defaultInput(task, n))
+			}
+			b.checkInput(task, n, "$"+n)
+			fmt.Fprintf(&b.out, "export %s\n", n)
+		}
+		set := "set --"
+		for _, a := range s.Args {
+			set += " " + shellQuote(a)
+		}
+		b.out.WriteString(set + "\n")
+	}
+	if task.Stdin != "" {
+		fmt.Fprintf(&b.out, "exec < %s\n", shellQuote(filepath.ToSlash(task.Stdin)))
+	}
+	if task.Stdout != "" {
+		redirect := ">"
+		if task.AppendStdout {
+			redirect = ">>"
+		}
+		fmt.Fprintf(&b.out, "exec %s %s\n", redirect, shellQuote(filepath.ToSlash(task.Stdout)))
+	}
+	b.script(task)
+	b.out.WriteString(")\n")
+	return nil
+}
+
+// script writes the script of a task, scripts for other interpreters are written to a temporary file and run with it.
+func (b *bundler) script(task models.Task) {
+	script := task.Script
+	cmd, args, text, ok := scriptInterpreter(script, b.runner.interpreter(task), task.Shell)
+	if !ok {
+		if line, rest, _ := strings.Cut(script, "\n"); shellShebangRe.MatchString(line) {
+			script = rest
+		}
+		b.out.WriteString("set -x\n" + strings.TrimRight(script, "\n") + "\n")
+		return
+	}
+	delim := "XC_EOF"
+	for strings.Contains(text, delim) {
+		delim += "_"
+	}
+	b.out.WriteString("xc_script=$(mktemp)\ntrap 'rm -f \"$xc_script\"' EXIT\n")
+	fmt.Fprintf(&b.out, "cat > \"$xc_script\" <<'%s'\n%s\n%s\n", delim, strings.TrimRight(text, "\n"), delim)
+	quoted := []string{shellQuote(cmd)}
+	for _, a := range args {
+		quoted = append(quoted, shellQuote(a))
+	}
+	fmt.Fprintf(&b.out, "%s \"$xc_script\" \"$@\"\n", strings.Join(quoted, " "))
+}
+
+// checkInput writes the checks that the input called name, with the given value, is set and allowed.
+func (b *bundler) checkInput(task models.Task, name, value string) {
+	fmt.Fprintf(&b.out, ": \"${%s:?%s}\"\n", strings.TrimPrefix(value, "$"),
+		doubleQuoteEscape(i18n.Sprintf("task %s requires the input %s", task.Name, name)))
+	choices := task.Input(name).Choices
+	if len(choices) == 0 {
+		return
+	}
+	quoted := make([]string, len(choices))
+	for i, c := range choices {
+		quoted[i] = shellQuote(c)
+	}
+	msg := i18n.Sprintf("invalid value for input %s of task %s, should be one of (%s)", name, task.Name, strings.Join(choices, ", "))
+	fmt.Fprintf(&b.out, "case \"%s\" in %s) ;; *) echo %s >&2; exit 1 ;; esac\n", value, strings.Join(quoted, "|"), shellQuote(msg))
+}
+
+// defaultInput returns the default value of an input, or the default of its prompt,
+// escaped for use in a double quoted parameter expansion.
+func defaultInput(task models.Task, name string) string {
+	if d := task.Input(name).Default; d != nil {
+		return doubleQuoteEscape(*d)
+	}
+	if p := task.Input(name).Prompt; p != nil {
+		return doubleQuoteEscape(p.Default)
+	}
+	return ""
+}
+
+// inputUsage returns the usage of the arguments of the bundled task.
+func inputUsage(task models.Task) []string {
+	var usage []string
+	for _, n := range task.Inputs {
+		usage = append(usage, "<"+inputPlaceholder(task, n)+">")
+	}
+	return usage
+}
+
+// shellQuote quotes s as a single word for a POSIX shell.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:,+@%") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// QuoteEnvValue quotes the value of a task environment variable for a POSIX shell,
+// leaving its $NAME and ${NAME} references for the shell to expand like ExpandEnv does.
+func QuoteEnvValue(v string) string {
+	if !strings.Contains(v, "$") {
+		return shellQuote(v)
+	}
+	// The references are marked with NUL bytes, which can't be part of an environment variable.
+	parts := strings.Split(os.Expand(v, func(name string) string { return "\x00" + name + "\x00" }), "\x00")
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, p := range parts {
+		if i%2 == 1 {
+			b.WriteString("${" + p + "}")
+		} else {
+			b.WriteString(doubleQuoteEscape(p))
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// doubleQuoteEscape escapes s for use within double quotes in a POSIX shell.
+func doubleQuoteEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`", "}", `\}`).Replace(s)
+}
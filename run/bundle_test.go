@@ -0,0 +1,183 @@
+package run
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/joerdav/xc/models"
+)
+
+func bundleTasks() models.Tasks {
+	deploy := models.Task{
+		Name:      "deploy",
+		Script:    "echo \"deploy $ENV\" >> out.txt\n",
+		Inputs:    []string{"ENV"},
+		DependsOn: []string{"gen", "greet world"},
+		Dir:       "sub",
+	}
+	deploy.SetInput("ENV", models.InputSpec{Choices: []string{"dev", "prod"}})
+	greet := models.Task{Name: "greet", Script: "echo \"hello $NAME\" >> ../out.txt\n", Inputs: []string{"NAME"}, Dir: "sub"}
+	return models.Tasks{
+		deploy,
+		greet,
+		{Name: "gen", Script: "echo \"gen $GREETING\" > out.txt\n", Env: []string{"GREETING=it's"}, RequiredBehaviour: models.RequiredBehaviourOnce},
+		{Name: "all", DependsOn: []string{"gen", "deploy prod"}},
+	}
+}
+
+func TestBundle(t *testing.T) {
+	runner, err := NewRunner(bundleTasks(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := runner.Bundle(&buf, "deploy", ".."); err != nil {
+		t.Fatal(err)
+	}
+	expected := `#!/bin/sh
+# Runs the task deploy, generated by xc bundle.
+# Usage: $0 <dev|prod>
+set -e
+cd "$(dirname "$0")"/..
+xc_ENV="${1:-${ENV:-}}"
+: "${xc_ENV:?task deploy requires the input ENV}"
+case "$xc_ENV" in dev|prod) ;; *) echo 'invalid value for input ENV of task deploy, should be one of (dev, prod)' >&2; exit 1 ;; esac
+# gen
+(
+export GREETING='it'\''s'
+set --
+set -x
+echo "gen $GREETING" > out.txt
+)
+# greet
+(
+cd sub
+NAME=world
+: "${NAME:?task greet requires the input NAME}"
+export NAME
+set -- world
+set -x
+echo "hello $NAME" >> ../out.txt
+)
+# deploy
+(
+cd sub
+export ENV="$xc_ENV"
+set -x
+echo "deploy $ENV" >> out.txt
+)
+`
+	if got := buf.String(); got != expected {
+		t.Fatalf("want:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestQuoteEnvValue(t *testing.T) {
+	tests := []struct {
+		in, expected string
+	}{
+		{in: "plain", expected: "plain"},
+		{in: "it's", expected: `'it'\''s'`},
+		{in: "$HOME/bin", expected: `"${HOME}/bin"`},
+		{in: `${BIN}:"$PATH" costs $`, expected: `"${BIN}:\"${PATH}\" costs \$"`},
+	}
+	for _, tt := range tests {
+		if got := QuoteEnvValue(tt.in); got != tt.expected {
+			t.Errorf("QuoteEnvValue(%q) want=%s got=%s", tt.in, tt.expected, got)
+		}
+	}
+}
+
+func TestBundleRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("bundles are POSIX shell scripts")
+	}
+	shebang := models.Task{Name: "count", Script: "#!/usr/bin/env awk -f\nBEGIN { print \"count \" ARGV[1] >> \"out.txt\" }\n", Inputs: []string{"N"}}
+	async := models.Task{Name: "async", DependsOn: []string{"count 1", "count 2"}, DepsBehaviour: models.DependencyBehaviourAsync}
+	runner, err := NewRunner(append(bundleTasks(), shebang, async), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		task     string
+		args     []string
+		expected []string
+		err      bool
+	}{
+		{
+			name:     "given a task with deps, should run them in order and only run once tasks once",
+			task:     "all",
+			expected: []string{"gen it's", "hello world", "sub: deploy prod"},
+		},
+		{
+			name:     "given an input argument, should pass it to the task",
+			task:     "deploy",
+			args:     []string{"dev"},
+			expected: []string{"gen it's", "hello world", "sub: deploy dev"},
+		},
+		{
+			name: "given a missing input, should fail before running anything",
+			task: "deploy",
+			err:  true,
+		},
+		{
+			name: "given an invalid input, should fail",
+			task: "deploy",
+			args: []string{"staging"},
+			err:  true,
+		},
+		{
+			name:     "given async deps and shebang scripts, should run each one",
+			task:     "async",
+			expected: []string{"count 1", "count 2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			var script bytes.Buffer
+			if err := runner.Bundle(&script, tt.task, ""); err != nil {
+				t.Fatal(err)
+			}
+			file := filepath.Join(dir, "run.sh")
+			if err := os.WriteFile(file, script.Bytes(), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			cmd := exec.Command(file, tt.args...)
+			cmd.Dir = dir
+			cmd.Env = []string{"PATH=" + os.Getenv("PATH")}
+			out, err := cmd.CombinedOutput()
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v got %v: %s", tt.err, err, out)
+			}
+			var got []string
+			for _, f := range []string{"out.txt", "sub/out.txt"} {
+				b, _ := os.ReadFile(filepath.Join(dir, f))
+				for _, l := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+					if l == "" {
+						continue
+					}
+					if f != "out.txt" {
+						l = "sub: " + l
+					}
+					got = append(got, l)
+				}
+			}
+			// Parallel branches finish in any order.
+			sort.Strings(got)
+			if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package run
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joerdav/xc/cache"
+	"github.com/joerdav/xc/glob"
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+)
+
+// cacheVersion is part of every cache key, it changes when the way keys are calculated changes.
+const cacheVersion = "xc-cache-1"
+
+// WithCache skips the scripts of tasks with Sources when their inputs haven't changed since they last succeeded,
+// restoring their Outputs from c instead.
+// The inputs of a task are its script and how it is run, its environment variables, its inputs and the contents of its Sources.
+func WithCache(c cache.Shared) Option {
+	return func(r *Runner) {
+		r.cache = &c
+	}
+}
+
+// cached returns the cache key of task, and whether its result is cached, in which case its Outputs have been restored.
+// The key is empty if the result of task isn't cached. WithForce the cache isn't read, but the result is still stored.
+// Failing to read the cache isn't an error, the task runs instead.
+func (r *Runner) cached(ctx context.Context, task models.Task, env, inputs []string) (key string, ok bool, err error) {
+	if r.cache == nil || len(task.Sources) == 0 {
+		return "", false, nil
+	}
+	key, err = r.cacheKey(task, env, inputs)
+	if err != nil {
+		return "", false, i18n.Errorf("failed to hash the sources of %s: %w", task.Name, err)
+	}
+	if r.force {
+		return key, false, nil
+	}
+	e, ok, err := r.cache.Get(ctx, key)
+	if err == nil && ok {
+		err = r.cache.Local.Restore(e, r.getExecutionPath(task), task.Outputs)
+	}
+	if err != nil {
+		i18n.Printf("task %q couldn't be read from the cache: %v\n", task.Name, err)
+		return key, false, nil
+	}
+	if ok {
+		_ = r.cache.Local.Touch(key, time.Now())
+	}
+	return key, ok, nil
+}
+
+// saveCache stores the result of task with key, along with its Outputs.
+// Failing to store the result isn't an error, as the task has succeeded.
+func (r *Runner) saveCache(ctx context.Context, task models.Task, key string) {
+	dir := r.getExecutionPath(task)
+	files, err := glob.Files(dir, task.Outputs)
+	if err == nil {
+		err = r.cache.Save(ctx, cache.Entry{Key: key, Task: task.Name, Created: time.Now(), Files: files}, dir, task.Outputs)
+	}
+	if err != nil {
+		i18n.Printf("task %q couldn't be cached: %v\n", task.Name, err)
+	}
+}
+
+// cacheKey returns a hash of the inputs of task, see WithCache.
+// env is the environment of the script, only the variables set by the task, its dotenv files and its inputs are part of the key,
+// so that unrelated changes to the environment don't invalidate the cache.
+func (r *Runner) cacheKey(task models.Task, env, inputs []string) (string, error) {
+	h := sha256.New()
+	args := scriptArgs(task, inputs)
+	fmt.Fprintf(h, "%q\n%q\n", cacheVersion, task.Name)
+	fmt.Fprintf(h, "%q\n%q\n%q\n%q\n", task.Script, r.interpreter(task), task.Shell, task.Dir)
+	if task.Container != "" {
+		fmt.Fprintf(h, "container %q\n", task.Container)
+	}
+	if dir, ok := r.dirs[task.Name]; ok {
+		fmt.Fprintf(h, "dir %q\n", dir)
+	}
+	if task.ScriptFile {
+		fmt.Fprintln(h, "script file")
+	}
+	if remote := r.remote(task); remote != "" {
+		fmt.Fprintf(h, "remote %q\n", remote)
+	}
+	values := map[string]string{}
+	for _, e := range env {
+		k, v, _ := strings.Cut(e, "=")
+		values[k] = v
+	}
+	var names []string
+	for _, e := range task.Env {
+		k, _, _ := strings.Cut(e, "=")
+		names = append(names, k)
+	}
+	for _, in := range task.Inputs {
+		names = append(names, in)
+	}
+	dotenv, err := r.dotenv(task)
+	if err != nil {
+		return "", err
+	}
+	for _, e := range dotenv {
+		k, _, _ := strings.Cut(e, "=")
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		fmt.Fprintf(h, "env %q=%q\n", k, values[k])
+	}
+	for _, a := range args {
+		fmt.Fprintf(h, "arg %q\n", a)
+	}
+	dir := r.getExecutionPath(task)
+	sources, err := glob.Files(dir, task.Sources)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range sources {
+		sum, err := fileHash(filepath.Join(dir, filepath.FromSlash(s)))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "source %q %s\n", s, sum)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,85 @@
+package run
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/joerdav/xc/cache"
+	"github.com/joerdav/xc/models"
+)
+
+// outputScriptRunner writes the script to the file out in the directory of the execution.
+type outputScriptRunner struct{}
+
+func (outputScriptRunner) Execute(ctx context.Context, e Execution) error {
+	return os.WriteFile(filepath.Join(e.Dir, "out"), []byte(e.Script), 0o644)
+}
+
+func TestRunCache(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", "package main")
+	// The output is removed before each run, so that the tasks are never up to date.
+	removeOutput := func() {
+		_ = os.Remove(filepath.Join(dir, "out"))
+	}
+	c := cache.Shared{Local: cache.New(t.TempDir())}
+	tasks := models.Tasks{
+		{Name: "build", Script: "build", Sources: []string{"*.go"}, Outputs: []string{"out"}, Env: []string{"MODE=release"}},
+		{Name: "debug", Script: "build", Sources: []string{"*.go"}, Outputs: []string{"out"}, Env: []string{"MODE=debug"}},
+		{Name: "lint", Script: "lint"},
+	}
+	tests := []struct {
+		name    string
+		task    string
+		change  func()
+		force   bool
+		skipped bool
+	}{
+		{name: "given no cached result, should run the task", task: "build"},
+		{name: "given unchanged sources, should restore the outputs", task: "build", skipped: true},
+		{name: "given changed sources, should run the task", task: "build", change: func() {
+			write("main.go", "package main // changed")
+		}},
+		{name: "given unchanged sources after a change, should restore the outputs", task: "build", skipped: true},
+		{name: "given a different environment, should run the task", task: "debug"},
+		{name: "given force, should run the task", task: "debug", force: true},
+		{name: "given no sources, should run the task", task: "lint"},
+		{name: "given no sources, should run the task again", task: "lint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			removeOutput()
+			if tt.change != nil {
+				tt.change()
+			}
+			opts := []Option{WithCache(c)}
+			if tt.force {
+				opts = append(opts, WithForce())
+			}
+			runner, err := NewRunner(tasks, dir, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner.scriptRunner = outputScriptRunner{}
+			if err := runner.Run(context.Background(), tt.task, nil); err != nil {
+				t.Fatal(err)
+			}
+			results := runner.Results()
+			if len(results) != 1 || results[0].Skipped != tt.skipped {
+				t.Fatalf("skipped want=%v got=%+v", tt.skipped, results)
+			}
+			task, _ := tasks.Get(tt.task)
+			if b, err := os.ReadFile(filepath.Join(dir, "out")); err != nil || string(b) != task.Script {
+				t.Fatalf("want output %q got %q err=%v", task.Script, b, err)
+			}
+		})
+	}
+}
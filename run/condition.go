@@ -0,0 +1,72 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/joerdav/xc/expr"
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+)
+
+// skipCondition returns why task is skipped with env, as a message and the reason recorded in its Result:
+// its If condition is false, its Skip condition is true, or it is a variant that its MatrixExclude excludes.
+// The reason is empty if the task runs. Relative paths in the conditions are relative to the directory of the task.
+func (r *Runner) skipCondition(task models.Task, env []string) (message, reason string, err error) {
+	e := r.conditionEnv(task, env)
+	holds := func(condition string) (bool, error) {
+		c, err := expr.Parse(condition)
+		if err != nil {
+			return false, i18n.Errorf("invalid condition of %s: %w", task.Name, err)
+		}
+		return c.Eval(e), nil
+	}
+	if task.If != "" {
+		ok, err := holds(task.If)
+		if err != nil {
+			return "", "", err
+		}
+		if !ok {
+			return i18n.Sprintf("task %q runs if %s, which is false: skipping\n", task.Name, task.If), i18n.T("condition is false"), nil
+		}
+	}
+	if task.Skip != "" {
+		ok, err := holds(task.Skip)
+		if err != nil {
+			return "", "", err
+		}
+		if ok {
+			return i18n.Sprintf("task %q is skipped if %s, which is true: skipping\n", task.Name, task.Skip), i18n.T("skip condition is true"), nil
+		}
+	}
+	if task.MatrixExclude != "" {
+		ok, err := holds(task.MatrixExclude)
+		if err != nil {
+			return "", "", err
+		}
+		if ok {
+			return i18n.Sprintf("task %q is excluded from its matrix by %s: skipping\n", task.Name, task.MatrixExclude), i18n.T("excluded from the matrix"), nil
+		}
+	}
+	return "", "", nil
+}
+
+// conditionEnv returns what the functions of the conditions of task look at, with the environment variables in env.
+func (r *Runner) conditionEnv(task models.Task, env []string) expr.Env {
+	dir := r.getExecutionPath(task)
+	return expr.Env{
+		Getenv: func(name string) string {
+			return environmentValue(env, name)
+		},
+		FileExists: func(path string) bool {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, filepath.FromSlash(path))
+			}
+			_, err := os.Stat(path)
+			return err == nil
+		},
+		OS:   runtime.GOOS,
+		Arch: runtime.GOARCH,
+	}
+}
@@ -0,0 +1,141 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/joerdav/xc/i18n"
+	"golang.org/x/term"
+)
+
+// ContainerRuntimeVar is the environment variable that names the command that runs the scripts of tasks
+// with a Container, e.g. podman. The first of docker and podman that is installed is used if it isn't set.
+const ContainerRuntimeVar = "XC_CONTAINER_RUNTIME"
+
+// containerScriptDir is where the script of a task is mounted in its container.
+const containerScriptDir = "/xc"
+
+// containerRuntime returns the command that runs containers, see ContainerRuntimeVar.
+func containerRuntime(env []string) (string, error) {
+	if runtime := environmentValue(env, ContainerRuntimeVar); runtime != "" {
+		return runtime, nil
+	}
+	for _, runtime := range []string{"docker", "podman"} {
+		if _, err := exec.LookPath(runtime); err == nil {
+			return runtime, nil
+		}
+	}
+	return "", i18n.Errorf("no container runtime was found, install docker or podman, or set %s", ContainerRuntimeVar)
+}
+
+// executeContainer runs the script of e in the image e.Container, with e.Mount mounted at the same path,
+// see containerPath, and e.Dir as the working directory. The script runs like a ScriptFile, see fileInterpreter,
+// as the shell built into xc only runs on the host.
+//
+//nolint:gosec // accept that command is being executed here from outside of xc
+func (i interpreter) executeContainer(ctx context.Context, e Execution) error {
+	runtime, err := containerRuntime(e.Env)
+	if err != nil {
+		return err
+	}
+	interpreterCmd, interpreterArgs, text := fileInterpreter(e)
+	f, err := os.CreateTemp("", tempFilePattern(i.tempFilePrefix, interpreterCmd))
+	if err != nil {
+		return errors.New(i18n.T("failed to create execution file"))
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.WriteString(text); err != nil {
+		return errors.New(i18n.T("failed to write execution file"))
+	}
+	if err = f.Close(); err != nil {
+		return errors.New(i18n.T("failed to write execution file"))
+	}
+	// The file is created readable only by its owner, who may not be the user of the image.
+	if err = os.Chmod(f.Name(), 0o644); err != nil {
+		return errors.New(i18n.T("failed to write execution file"))
+	}
+	args, err := containerArgs(e, f.Name())
+	if err != nil {
+		return err
+	}
+	script := containerScriptDir + "/" + filepath.Base(f.Name())
+	args = append(append(append(args, interpreterCmd), interpreterArgs...), script)
+	cmd := exec.CommandContext(ctx, runtime, append(args, e.Args...)...)
+	cmd.Dir = e.Dir
+	cmd.Env = e.Env
+	stdin, stdout, stderr := i.stdFiles(e)
+	cmd.Stdin = stdinFile(stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cancelCmd(ctx, cmd, i.gracePeriod)
+	return i.shebangRunner(cmd)
+}
+
+// containerArgs returns the arguments of the container runtime that run the image of e, up to the image,
+// with the script at path mounted in containerScriptDir.
+// The variables that xc sets are passed to the container by name, so their values are read from the environment of the runtime.
+func containerArgs(e Execution, path string) ([]string, error) {
+	mount, err := filepath.Abs(e.Mount)
+	if err != nil {
+		return nil, i18n.Errorf("failed to find the directory to mount: %w", err)
+	}
+	dir, err := filepath.Abs(e.Dir)
+	if err != nil {
+		return nil, i18n.Errorf("failed to find the directory to mount: %w", err)
+	}
+	args := []string{"run", "--rm", "-i"}
+	if allocateTerminal(e) {
+		args = append(args, "-t")
+	}
+	args = append(args,
+		"-v", mount+":"+containerPath(mount),
+		"-v", path+":"+containerScriptDir+"/"+filepath.Base(path)+":ro",
+		"-w", containerPath(dir),
+	)
+	for _, kv := range setEnv(e.Env) {
+		if name, _, _ := strings.Cut(kv, "="); name != ContainerRuntimeVar {
+			args = append(args, "-e", name)
+		}
+	}
+	return append(args, e.Container), nil
+}
+
+// containerPath returns the path in a container of a directory mounted from path on the host.
+// Windows paths are translated to the paths Docker Desktop uses, so C:\src\app is /c/src/app.
+func containerPath(path string) string {
+	if len(path) >= 2 && path[1] == ':' && unicode.IsLetter(rune(path[0])) {
+		return "/" + strings.ToLower(path[:1]) + strings.ReplaceAll(path[2:], `\`, "/")
+	}
+	return filepath.ToSlash(path)
+}
+
+// allocateTerminal returns true if the script of e is given a terminal where it runs, in a container or on a remote host.
+// Only interactive tasks, which have no log prefix, are given one, if xc is run in a terminal.
+func allocateTerminal(e Execution) bool {
+	return e.LogPrefix == "" && e.Output == nil && e.Stdin == nil && stdinIsTerminal() && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// setEnv returns the variables of env that xc sets, rather than those it inherits, with the last value of each.
+func setEnv(env []string) []string {
+	var names []string
+	values := map[string]string{}
+	for _, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+		values[name] = value
+	}
+	var set []string
+	for _, name := range names {
+		if inherited, ok := os.LookupEnv(name); !ok || inherited != values[name] {
+			set = append(set, name+"="+values[name])
+		}
+	}
+	return set
+}
@@ -0,0 +1,99 @@
+package run
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecuteContainer(t *testing.T) {
+	defer func(f func() bool) { stdinIsTerminal = f }(stdinIsTerminal)
+	stdinIsTerminal = func() bool { return false }
+	dir := t.TempDir()
+	tests := []struct {
+		name           string
+		e              Execution
+		expectedArgs   string
+		expectedScript string
+	}{
+		{
+			name:           "given a shell script, should run it with sh",
+			e:              Execution{Script: "go build", Container: "golang:1.22", Args: []string{"-v"}},
+			expectedArgs:   "podman run --rm -i -v {dir}:{dir} -v {script}:/xc/{name}:ro -w {dir} golang:1.22 sh -e -x /xc/{name} -v",
+			expectedScript: "go build",
+		},
+		{
+			name:           "given a shell shebang, should run the script with its shell",
+			e:              Execution{Script: "#!/usr/bin/env bash\ngo build", Container: "golang:1.22"},
+			expectedArgs:   "podman run --rm -i -v {dir}:{dir} -v {script}:/xc/{name}:ro -w {dir} golang:1.22 bash /xc/{name}",
+			expectedScript: "go build",
+		},
+		{
+			name:           "given an interpreter, should run the script with it",
+			e:              Execution{Script: "print(1)", Interpreter: "python3 -u", Container: "python:3"},
+			expectedArgs:   "podman run --rm -i -v {dir}:{dir} -v {script}:/xc/{name}:ro -w {dir} python:3 python3 -u /xc/{name}",
+			expectedScript: "print(1)",
+		},
+		{
+			name:           "given variables set by xc, should pass them by name",
+			e:              Execution{Script: "make", Container: "alpine", Env: append(os.Environ(), "STAGE=prod", "STAGE=dev")},
+			expectedArgs:   "podman run --rm -i -v {dir}:{dir} -v {script}:/xc/{name}:ro -w {dir} -e STAGE alpine sh -e -x /xc/{name}",
+			expectedScript: "make",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []string
+			var script string
+			i := newInterpreter(nil)
+			i.shebangRunner = func(cmd *exec.Cmd) error {
+				args = cmd.Args
+				b, err := os.ReadFile(scriptMount(args))
+				script = string(b)
+				return err
+			}
+			tt.e.Dir, tt.e.Mount = dir, dir
+			tt.e.Env = append(tt.e.Env, ContainerRuntimeVar+"=podman")
+			if err := i.Execute(context.Background(), tt.e); err != nil {
+				t.Fatal(err)
+			}
+			path := scriptMount(args)
+			expected := strings.NewReplacer("{dir}", filepath.ToSlash(dir), "{script}", path, "{name}", filepath.Base(path)).Replace(tt.expectedArgs)
+			if got := strings.Join(args, " "); got != expected {
+				t.Fatalf("args\nwant=%s\n got=%s", expected, got)
+			}
+			if script != tt.expectedScript {
+				t.Fatalf("script want=%q got=%q", tt.expectedScript, script)
+			}
+		})
+	}
+}
+
+// scriptMount returns the path of the script that args mount in the container.
+func scriptMount(args []string) string {
+	for i, a := range args {
+		if a == "-v" && strings.HasSuffix(args[i+1], ":ro") {
+			return args[i+1][:strings.LastIndex(args[i+1], ":/xc/")]
+		}
+	}
+	return ""
+}
+
+func TestContainerPath(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected string
+	}{
+		{in: "/home/joe/app", expected: "/home/joe/app"},
+		{in: `C:\src\app`, expected: "/c/src/app"},
+		{in: `d:\`, expected: "/d/"},
+	}
+	for _, tt := range tests {
+		if got := containerPath(tt.in); got != tt.expected {
+			t.Errorf("containerPath(%q) want=%q got=%q", tt.in, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package run
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/joerdav/xc/dotenv"
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+)
+
+// dotenv returns the variables of the Dotenv files of task, which are relative to its directory,
+// in the order of the files so that later files override earlier ones.
+// Files that don't exist are skipped, so that local overrides such as .env.local can be left out.
+func (r *Runner) dotenv(task models.Task) ([]string, error) {
+	dir := r.getExecutionPath(task)
+	var env []string
+	for _, f := range task.Dotenv {
+		b, err := os.ReadFile(resolvePath(dir, f))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, i18n.Errorf("failed to read dotenv file %s of %s: %w", f, task.Name, err)
+		}
+		vars, err := dotenv.Parse(bytes.NewReader(b))
+		if err != nil {
+			return nil, i18n.Errorf("invalid dotenv file %s of %s: %w", f, task.Name, err)
+		}
+		env = append(env, vars...)
+	}
+	return env, nil
+}
@@ -0,0 +1,56 @@
+package run
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/joerdav/xc/models"
+)
+
+func TestRunDotenv(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{".env": "A=env\nB=env\nC=env\n", ".env.local": "B=local\nC=local\n", "bad.env": "oops\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name     string
+		task     models.Task
+		expected map[string]string
+		err      bool
+	}{
+		{
+			name:     "given dotenv files, should let later files and the task env override earlier ones",
+			task:     models.Task{Name: "test", Script: "test", Dotenv: []string{".env", ".env.local"}, Env: []string{"C=task"}},
+			expected: map[string]string{"A": "env", "B": "local", "C": "task"},
+		},
+		{
+			name:     "given a missing file, should skip it",
+			task:     models.Task{Name: "test", Script: "test", Dotenv: []string{".env", ".env.missing"}},
+			expected: map[string]string{"A": "env", "B": "env", "C": "env"},
+		},
+		{name: "given an invalid file, should error", task: models.Task{Name: "test", Script: "test", Dotenv: []string{"bad.env"}}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := NewRunner(models.Tasks{tt.task}, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			scriptRunner := &executionScriptRunner{}
+			runner.scriptRunner = scriptRunner
+			err = runner.Run(context.Background(), "test", nil)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v got %v", tt.err, err)
+			}
+			for k, v := range tt.expected {
+				if got := environmentValue(scriptRunner.last.Env, k); got != v {
+					t.Fatalf("%s want=%q got=%q", k, v, got)
+				}
+			}
+		})
+	}
+}
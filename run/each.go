@@ -0,0 +1,90 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/joerdav/xc/glob"
+	"github.com/joerdav/xc/i18n"
+)
+
+// EachVar is the environment variable that holds the current item of RunEach.
+const EachVar = "ITEM"
+
+// RunEach runs the dependencies of the named task, then its script once for each file that matches pattern
+// with the path of the file in the ITEM environment variable.
+// The pattern and the paths are relative to the directory of the task.
+// Up to jobs scripts run at the same time, no more scripts are started once one fails.
+func (r *Runner) RunEach(ctx context.Context, name string, inputs []string, pattern string, jobs int) error {
+	r.failed.Store(false)
+	task, ok := r.tasks.Get(name)
+	if !ok {
+		return TaskNotFoundError{Name: name}
+	}
+	items, err := glob.Files(r.getExecutionPath(task), []string{pattern})
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return i18n.Errorf("no files match %s", pattern)
+	}
+	padding, err := r.getLogPadding(name)
+	if err != nil {
+		return err
+	}
+	labels := make([]string, len(items))
+	for i, item := range items {
+		labels[i] = task.Name + "[" + item + "]"
+		if n := utf8.RuneCountInString(labels[i]); n > padding {
+			padding = n
+		}
+	}
+	padding = r.hookPadding(padding)
+	return r.runHooked(ctx, name, padding, func(ctx context.Context) error {
+		return r.runEach(ctx, name, inputs, items, labels, padding, jobs)
+	})
+}
+
+// runEach runs the dependencies of the named task, then its script for each of items, labelled by labels.
+func (r *Runner) runEach(ctx context.Context, name string, inputs, items, labels []string, padding, jobs int) error {
+	task, env, done, err := r.prepare(ctx, name, inputs, padding)
+	if err != nil || done == nil {
+		return err
+	}
+	if jobs < 1 {
+		jobs = 1
+	}
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed bool
+	)
+	sem := make(chan struct{}, jobs)
+	errs := make([]error, len(items))
+	for i, item := range items {
+		sem <- struct{}{}
+		mu.Lock()
+		stop := failed
+		mu.Unlock()
+		if stop {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(i int, item string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			itemEnv := append(append([]string{}, env...), EachVar+"="+item)
+			errs[i] = r.execute(ctx, task, labels[i], itemEnv, inputs, padding)
+			if errs[i] != nil {
+				mu.Lock()
+				failed = true
+				mu.Unlock()
+			}
+		}(i, item)
+	}
+	wg.Wait()
+	return done(errors.Join(errs...))
+}
@@ -0,0 +1,132 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joerdav/xc/models"
+)
+
+// itemScriptRunner records the ITEM of each execution, and the most executions that ran at the same time.
+type itemScriptRunner struct {
+	mu             sync.Mutex
+	items          []string
+	running, most  int
+	fail           string
+	executionDelay time.Duration
+}
+
+func (r *itemScriptRunner) Execute(ctx context.Context, e Execution) error {
+	r.mu.Lock()
+	item := environmentValue(e.Env, EachVar)
+	r.items = append(r.items, item)
+	r.running++
+	if r.running > r.most {
+		r.most = r.running
+	}
+	r.mu.Unlock()
+	time.Sleep(r.executionDelay)
+	r.mu.Lock()
+	r.running--
+	r.mu.Unlock()
+	if item != "" && item == r.fail {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func TestRunEach(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"proto/a.proto", "proto/b.proto", "proto/nested/c.proto", "proto/README.md"} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(f)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tasks := models.Tasks{
+		{Name: "setup", Script: "setup"},
+		{Name: "gen", Script: "protoc $ITEM", DependsOn: []string{"setup"}},
+		{Name: "gen-sub", Script: "protoc $ITEM", Dir: "proto"},
+	}
+	tests := []struct {
+		name         string
+		task         string
+		pattern      string
+		jobs         int
+		fail         string
+		expected     []string
+		expectedMost int
+		err          bool
+	}{
+		{
+			name:         "given a pattern, should run the deps once and the script for each file in order",
+			task:         "gen",
+			pattern:      "**/*.proto",
+			jobs:         1,
+			expected:     []string{"", "proto/a.proto", "proto/b.proto", "proto/nested/c.proto"},
+			expectedMost: 1,
+		},
+		{
+			name:     "given a task directory, should match files relative to it",
+			task:     "gen-sub",
+			pattern:  "*.proto",
+			expected: []string{"a.proto", "b.proto"},
+		},
+		{
+			name:         "given jobs, should run scripts in parallel up to the limit",
+			task:         "gen-sub",
+			pattern:      "**/*.proto",
+			jobs:         2,
+			expected:     []string{"a.proto", "b.proto", "nested/c.proto"},
+			expectedMost: 2,
+		},
+		{
+			name:     "given a failing item, should stop starting more and return the error",
+			task:     "gen-sub",
+			pattern:  "**/*.proto",
+			jobs:     1,
+			fail:     "a.proto",
+			expected: []string{"a.proto"},
+			err:      true,
+		},
+		{
+			name:    "given no matching files, should error",
+			task:    "gen",
+			pattern: "*.go",
+			err:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := NewRunner(tasks, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			scriptRunner := &itemScriptRunner{fail: tt.fail, executionDelay: 10 * time.Millisecond}
+			runner.scriptRunner = scriptRunner
+			err = runner.RunEach(context.Background(), tt.task, nil, tt.pattern, tt.jobs)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v got %v", tt.err, err)
+			}
+			got := scriptRunner.items
+			if tt.expectedMost > 1 {
+				sort.Strings(got)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Fatalf("items want=%q got=%q", tt.expected, got)
+			}
+			if tt.expectedMost > 0 && scriptRunner.most != tt.expectedMost {
+				t.Fatalf("parallel want=%d got=%d", tt.expectedMost, scriptRunner.most)
+			}
+		})
+	}
+}
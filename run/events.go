@@ -0,0 +1,137 @@
+package run
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/joerdav/xc/problem"
+)
+
+// The types of Event.
+const (
+	// EventStart is sent when the script of a task starts.
+	EventStart = "start"
+	// EventOutput is sent for each line of output of a script.
+	EventOutput = "output"
+	// EventFinish is sent when the script of a task finishes.
+	EventFinish = "finish"
+	// EventSkip is sent when a task is skipped, see Result.
+	EventSkip = "skip"
+	// EventProblem is sent for each problem found in the output of a script by its Problems, before its finish event.
+	EventProblem = "problem"
+)
+
+// Event is something that happened while running tasks, it is sent to the handler of WithEvents.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	// Task is the name of the task, with the file in brackets when it runs for each file, e.g. lint[main.go].
+	Task string `json:"task"`
+	// Stream and Line are set for output events, the stream is stdout or stderr.
+	Stream string `json:"stream,omitempty"`
+	Line   string `json:"line,omitempty"`
+	// ExitCode, DurationMS and Error are set for finish events, see ExitCode.
+	ExitCode   *int   `json:"exitCode,omitempty"`
+	DurationMS *int64 `json:"durationMs,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// Reason is set for skip events.
+	Reason string `json:"reason,omitempty"`
+	// Problem is set for problem events, with its file, line, column, severity and message.
+	Problem *problem.Problem `json:"problem,omitempty"`
+}
+
+// EventHandler receives the events of a run, it is called from every script that runs in parallel.
+type EventHandler func(Event)
+
+// WithEvents sends the events of every task to h.
+// The output of scripts is sent as output events instead of being written to the terminal,
+// except for interactive tasks, which need the terminal.
+func WithEvents(h EventHandler) Option {
+	return func(r *Runner) {
+		r.events = h
+	}
+}
+
+// eventResult sends the finish or skip event of a result.
+func (r *Runner) eventResult(res Result) {
+	if r.events == nil {
+		return
+	}
+	if res.Skipped {
+		r.events(Event{Type: EventSkip, Time: res.Start, Task: res.Task, Reason: res.SkipReason})
+		return
+	}
+	code, ms := ExitCode(res.Err), res.Duration.Milliseconds()
+	e := Event{Type: EventFinish, Time: res.Start.Add(res.Duration), Task: res.Task, ExitCode: &code, DurationMS: &ms}
+	if res.Err != nil {
+		e.Error = res.Err.Error()
+	}
+	r.events(e)
+}
+
+// eventProblems closes the problem writers of a script and sends a problem event for each problem they found.
+func (r *Runner) eventProblems(task string, writers []*problem.Writer) {
+	if r.events == nil {
+		return
+	}
+	for _, w := range writers {
+		w.Close()
+		for _, p := range w.Problems() {
+			p := p
+			r.events(Event{Type: EventProblem, Time: time.Now(), Task: task, Problem: &p})
+		}
+	}
+}
+
+// eventWriter sends each line written to it as an output event.
+type eventWriter struct {
+	mu           sync.Mutex
+	events       EventHandler
+	task, stream string
+	buf          bytes.Buffer
+}
+
+func (w *eventWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(p)
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := string(bytes.TrimSuffix(w.buf.Next(i + 1)[:i], []byte{'\r'}))
+		w.send(line)
+	}
+}
+
+// Close sends the last line, if it doesn't end with a newline.
+func (w *eventWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf.Len() > 0 {
+		w.send(w.buf.String())
+		w.buf.Reset()
+	}
+	return nil
+}
+
+func (w *eventWriter) send(line string) {
+	w.events(Event{Type: EventOutput, Time: time.Now(), Task: w.task, Stream: w.stream, Line: line})
+}
+
+// eventOutput sends the output of e as output events of task, instead of writing it to the terminal.
+// The returned func sends the last lines of output.
+func (r *Runner) eventOutput(e *Execution, task string) func() {
+	stdout := &eventWriter{events: r.events, task: task, stream: "stdout"}
+	stderr := &eventWriter{events: r.events, task: task, stream: "stderr"}
+	e.LogPrefix = ""
+	e.Output = io.Discard
+	tee(e, stdout, stderr)
+	return func() {
+		stdout.Close()
+		stderr.Close()
+	}
+}
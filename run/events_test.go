@@ -0,0 +1,92 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/joerdav/xc/models"
+)
+
+// teeScriptRunner writes the script to stdout, with no trailing newline, and fails if the script is "fail".
+type teeScriptRunner struct{}
+
+func (teeScriptRunner) Execute(ctx context.Context, e Execution) error {
+	_, _ = e.StdoutTee.Write([]byte(e.Script))
+	if e.Script == "fail" {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func TestRunEvents(t *testing.T) {
+	tasks := models.Tasks{
+		{Name: "build", Script: "one\ntwo"},
+		{Name: "docs", Script: "docs", If: "false"},
+		{Name: "lint", Script: "fail"},
+		{Name: "all", DependsOn: []string{"build", "docs"}},
+		{Name: "vet", Script: "main.go:3:9: unused", Problems: []string{"go"}},
+	}
+	tests := []struct {
+		name     string
+		task     string
+		expected []string
+	}{
+		{
+			name:     "given a task, should send its output lines and finish",
+			task:     "build",
+			expected: []string{"start build", "output build stdout one", "output build stdout two", "finish build 0"},
+		},
+		{
+			name:     "given a skipped dependency, should send a skip event",
+			task:     "all",
+			expected: []string{"start build", "output build stdout one", "output build stdout two", "finish build 0", "skip docs condition is false"},
+		},
+		{
+			name:     "given a failing task, should send its exit code and error",
+			task:     "lint",
+			expected: []string{"start lint", "output lint stdout fail", "finish lint 1 failed"},
+		},
+		{
+			name:     "given problems in the output, should send them before the finish",
+			task:     "vet",
+			expected: []string{"start vet", "output vet stdout main.go:3:9: unused", "problem vet main.go:3:9 error unused", "finish vet 0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var got []string
+			runner, err := NewRunner(tasks, "", WithEvents(func(e Event) {
+				mu.Lock()
+				defer mu.Unlock()
+				s := e.Type + " " + e.Task
+				switch e.Type {
+				case EventOutput:
+					s += " " + e.Stream + " " + e.Line
+				case EventFinish:
+					s += fmt.Sprintf(" %d", *e.ExitCode)
+					if e.Error != "" {
+						s += " " + e.Error
+					}
+				case EventSkip:
+					s += " " + e.Reason
+				case EventProblem:
+					s += " " + e.Problem.Location() + " " + e.Problem.Severity + " " + e.Problem.Message
+				}
+				got = append(got, s)
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner.scriptRunner = teeScriptRunner{}
+			_ = runner.Run(context.Background(), tt.task, nil)
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+}
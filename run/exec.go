@@ -0,0 +1,70 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// execHandler runs the commands of shell scripts like interp.DefaultExecHandler,
+// except that a cancelled command is sent the signal that interrupted xc, see cancelCmd.
+func execHandler(gracePeriod time.Duration) interp.ExecHandlerFunc {
+	return func(ctx context.Context, args []string) error {
+		hc := interp.HandlerCtx(ctx)
+		path, err := interp.LookPathDir(hc.Dir, hc.Env, args[0])
+		if err != nil {
+			fmt.Fprintln(hc.Stderr, err)
+			return interp.NewExitStatus(127)
+		}
+		//nolint:gosec // accept that command is being executed here from outside of xc
+		cmd := exec.CommandContext(ctx, path)
+		cmd.Args = args
+		cmd.Env = execEnv(hc.Env)
+		cmd.Dir = hc.Dir
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = stdinFile(hc.Stdin), hc.Stdout, hc.Stderr
+		cancelCmd(ctx, cmd, gracePeriod)
+		if err := cmd.Start(); err != nil {
+			fmt.Fprintln(hc.Stderr, err)
+			return interp.NewExitStatus(127)
+		}
+		err = cmd.Wait()
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return err
+		}
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return interp.NewExitStatus(uint8(128 + status.Signal()))
+		}
+		return interp.NewExitStatus(uint8(exitErr.ExitCode()))
+	}
+}
+
+// execEnv returns the exported variables of env, as the environment of a command.
+func execEnv(env expand.Environ) []string {
+	var list []string
+	env.Each(func(name string, vr expand.Variable) bool {
+		// A variable that is unset by the script is removed, in case it was set in the parent environment.
+		if !vr.IsSet() {
+			for i, kv := range list {
+				if strings.HasPrefix(kv, name+"=") {
+					list[i] = ""
+				}
+			}
+		}
+		if vr.Exported && vr.Kind == expand.String {
+			list = append(list, name+"="+vr.String())
+		}
+		return true
+	})
+	return list
+}
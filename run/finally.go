@@ -0,0 +1,38 @@
+package run
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/shlex"
+	"github.com/joerdav/xc/models"
+)
+
+// finallyKey marks the context of Finally tasks, which start even if another script failed, see acquireJob.
+type finallyKey struct{}
+
+// runFinally runs the Finally tasks of task in order after it ran with the result err,
+// and returns err joined with the errors of those that failed.
+// They run even if the task failed or was cancelled, and the failure of one doesn't stop the others.
+func (r *Runner) runFinally(task models.Task, padding int, err error) error {
+	if len(task.Finally) == 0 {
+		return err
+	}
+	// The Finally tasks must run even if the failure was caused by cancellation, like the --on-failure tasks.
+	ctx := context.WithValue(context.Background(), finallyKey{}, true)
+	errs := []error{err}
+	for _, t := range task.Finally {
+		ta, splitErr := shlex.Split(t)
+		if splitErr != nil {
+			errs = append(errs, splitErr)
+			continue
+		}
+		if finallyErr := r.runWithPadding(ctx, ta[0], ta[1:], padding); finallyErr != nil {
+			errs = append(errs, finallyErr)
+		}
+	}
+	if len(errs) == 1 {
+		return err
+	}
+	return errors.Join(errs...)
+}
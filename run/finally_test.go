@@ -0,0 +1,91 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/joerdav/xc/models"
+)
+
+// finallyScriptRunner fails the script fail, and cancels the run and waits for it to stop in the script cancel.
+type finallyScriptRunner struct {
+	cancel context.CancelFunc
+}
+
+func (r finallyScriptRunner) Execute(ctx context.Context, e Execution) error {
+	switch e.Script {
+	case "fail":
+		return errors.New("failed")
+	case "cancel":
+		r.cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func TestRunFinally(t *testing.T) {
+	tasks := models.Tasks{
+		{Name: "up", Script: "up"},
+		{Name: "down", Script: "down"},
+		{Name: "broken", Script: "fail"},
+		{Name: "test", Script: "test", DependsOn: []string{"up"}, Finally: []string{"down"}},
+		{Name: "failing", Script: "fail", Finally: []string{"down"}},
+		{Name: "failing-dep", Script: "test", DependsOn: []string{"broken"}, Finally: []string{"down"}},
+		{Name: "failing-finally", Script: "test", Finally: []string{"broken", "down"}},
+		{Name: "cancelled", Script: "cancel", Finally: []string{"down"}},
+		{Name: "skipped", Script: "test", If: "false", Finally: []string{"down"}},
+	}
+	tests := []struct {
+		name     string
+		task     string
+		jobs     int
+		expected []string
+		err      bool
+	}{
+		{name: "given a task that succeeds, should run finally after it", task: "test", expected: []string{"up", "test", "down"}},
+		{name: "given a task that fails, should run finally", task: "failing", expected: []string{"failing failed", "down"}, err: true},
+		{name: "given a task that fails in parallel, should still start finally", task: "failing", jobs: 2, expected: []string{"failing failed", "down"}, err: true},
+		{name: "given a dependency that fails, should run finally", task: "failing-dep", expected: []string{"broken failed", "down"}, err: true},
+		{name: "given a finally task that fails, should run the rest", task: "failing-finally", expected: []string{"failing-finally", "broken failed", "down"}, err: true},
+		{name: "given a cancelled task, should run finally", task: "cancelled", expected: []string{"cancelled failed", "down"}, err: true},
+		{name: "given a skipped task, should not run finally", task: "skipped"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.jobs > 0 {
+				opts = append(opts, WithJobs(tt.jobs))
+			}
+			runner, err := NewRunner(tasks, "", opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			runner.scriptRunner = finallyScriptRunner{cancel: cancel}
+			err = runner.Run(ctx, tt.task, nil)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v got %v", tt.err, err)
+			}
+			var got []string
+			for _, r := range runner.Results() {
+				switch {
+				case r.Skipped:
+				case r.Err != nil:
+					got = append(got, r.Task+" failed")
+				default:
+					got = append(got, r.Task)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+	if _, err := NewRunner(models.Tasks{{Name: "test", Script: "test", Finally: []string{"missing"}}}, ""); err == nil {
+		t.Fatal("expected a missing finally task to error")
+	}
+}
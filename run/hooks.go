@@ -0,0 +1,62 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/joerdav/xc/models"
+)
+
+// The variables set for the hook tasks, see runHooked.
+const (
+	// HookTaskVar holds the name of the task that was invoked.
+	HookTaskVar = "XC_TASK"
+	// HookExitCodeVar holds the exit code of the task that was invoked, it is only set for the AfterHook, see ExitCode.
+	HookExitCodeVar = "XC_EXIT_CODE"
+)
+
+// hookEnvKey is the key of the variables that are set for the hook tasks and their dependencies, see prepare.
+type hookEnvKey struct{}
+
+// runHooked runs the models.BeforeHook task, run, and the models.AfterHook task, if the tasks have them.
+// The task isn't run if the before hook fails, the after hook runs even if the task failed or was cancelled,
+// and the errors of the task and the after hook are joined.
+func (r *Runner) runHooked(ctx context.Context, name string, padding int, run func(context.Context) error) error {
+	task, ok := r.tasks.Get(name)
+	if !ok || task.IsHook() {
+		return run(ctx)
+	}
+	_, before := r.tasks.Get(models.BeforeHook)
+	_, after := r.tasks.Get(models.AfterHook)
+	env := []string{HookTaskVar + "=" + task.Name}
+	if before {
+		if err := r.runWithPadding(context.WithValue(ctx, hookEnvKey{}, env), models.BeforeHook, nil, padding); err != nil {
+			return err
+		}
+	}
+	err := run(ctx)
+	if !after {
+		return err
+	}
+	// The after hook must run even if the failure was caused by cancellation, like the Finally tasks.
+	env = append(env, HookExitCodeVar+"="+strconv.Itoa(ExitCode(err)))
+	hookCtx := context.WithValue(context.WithValue(context.Background(), finallyKey{}, true), hookEnvKey{}, env)
+	if hookErr := r.runWithPadding(hookCtx, models.AfterHook, nil, padding); hookErr != nil {
+		return errors.Join(err, hookErr)
+	}
+	return err
+}
+
+// hookPadding returns the widest of padding and the log padding of the hook tasks.
+func (r *Runner) hookPadding(padding int) int {
+	for _, h := range []string{models.BeforeHook, models.AfterHook} {
+		if _, ok := r.tasks.Get(h); !ok {
+			continue
+		}
+		if p, err := r.getLogPadding(h); err == nil && p > padding {
+			padding = p
+		}
+	}
+	return padding
+}
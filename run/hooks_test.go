@@ -0,0 +1,99 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/joerdav/xc/models"
+)
+
+// hookScriptRunner records each script with the variables set for hooks, and fails the script fail.
+type hookScriptRunner struct {
+	mu      sync.Mutex
+	scripts []string
+}
+
+func (r *hookScriptRunner) Execute(ctx context.Context, e Execution) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := e.Script
+	for _, v := range []string{HookTaskVar, HookExitCodeVar} {
+		if environmentContainsInput(e.Env, v) {
+			s += " " + environmentValue(e.Env, v)
+		}
+	}
+	r.scripts = append(r.scripts, s)
+	if e.Script == "fail" {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func TestRunHooks(t *testing.T) {
+	hooks := models.Tasks{
+		{Name: models.BeforeHook, Script: "before"},
+		{Name: models.AfterHook, Script: "after"},
+	}
+	tasks := models.Tasks{
+		{Name: "build", Script: "build"},
+		{Name: "failing", Script: "fail"},
+		{Name: "test", Script: "test", DependsOn: []string{"build"}},
+	}
+	tests := []struct {
+		name     string
+		tasks    models.Tasks
+		run      []string
+		jobs     int
+		expected []string
+		err      bool
+	}{
+		{name: "given no hooks, should only run the task", tasks: tasks, run: []string{"build"}, expected: []string{"build"}},
+		{
+			name: "given hooks, should run them around the invoked task", tasks: append(hooks, tasks...), run: []string{"test"},
+			expected: []string{"before test", "build", "test", "after test 0"},
+		},
+		{
+			name: "given a failing task, should run the after hook with its exit code", tasks: append(hooks, tasks...), run: []string{"failing"},
+			expected: []string{"before failing", "fail", "after failing 1"}, err: true,
+		},
+		{
+			name: "given a failing before hook, should not run the task", tasks: append(models.Tasks{{Name: models.BeforeHook, Script: "fail"}}, tasks...), run: []string{"build"},
+			expected: []string{"fail build"}, err: true,
+		},
+		{
+			name: "given a hook that is invoked, should not run the hooks", tasks: append(hooks, tasks...), run: []string{models.AfterHook},
+			expected: []string{"after"},
+		},
+		{
+			name: "given tasks invoked in parallel mode, should run the hooks for each", tasks: append(hooks, tasks...), run: []string{"build", "test"}, jobs: 2,
+			expected: []string{"before build", "build", "after build 0", "before test", "test", "after test 0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.jobs > 0 {
+				opts = append(opts, WithJobs(tt.jobs))
+			}
+			runner, err := NewRunner(tt.tasks, "", opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			scriptRunner := &hookScriptRunner{}
+			runner.scriptRunner = scriptRunner
+			var errs []error
+			for _, name := range tt.run {
+				errs = append(errs, runner.Run(context.Background(), name, nil))
+			}
+			if err := errors.Join(errs...); (err != nil) != tt.err {
+				t.Fatalf("expected error %v got %v", tt.err, err)
+			}
+			if strings.Join(scriptRunner.scripts, ",") != strings.Join(tt.expected, ",") {
+				t.Fatalf("want=%q got=%q", tt.expected, scriptRunner.scripts)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package run
+
+import (
+	"os"
+	"strings"
+
+	"github.com/google/shlex"
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+)
+
+// WithStrictEnv makes a reference to a variable that isn't defined, in the Dir, Requires or Env of a task, an error.
+// Otherwise it is replaced with an empty string, the same as in a shell.
+func WithStrictEnv() Option {
+	return func(r *Runner) {
+		r.strictEnv = true
+	}
+}
+
+// expander replaces $NAME and ${NAME} with the values of the variables in env,
+// and records the names of the variables that aren't defined.
+type expander struct {
+	env       []string
+	undefined []string
+}
+
+// expand returns s with its variables replaced.
+func (x *expander) expand(s string) string {
+	return os.Expand(s, func(name string) string {
+		v, ok := lookupEnv(x.env, name)
+		if !ok {
+			x.undefine(name)
+		}
+		return v
+	})
+}
+
+// undefine records that name isn't defined, once.
+func (x *expander) undefine(name string) {
+	for _, n := range x.undefined {
+		if n == name {
+			return
+		}
+	}
+	x.undefined = append(x.undefined, name)
+}
+
+// add appends vars to the environment, with the variables in their values replaced.
+func (x *expander) add(vars []string) {
+	for _, e := range vars {
+		if k, v, ok := strings.Cut(e, "="); ok {
+			e = k + "=" + x.expand(v)
+		}
+		x.env = append(x.env, e)
+	}
+}
+
+// interpolate returns env followed by the Env of task, like ExpandEnv, and replaces $NAME and ${NAME}
+// in the Dir of task and in the arguments of its Requires with the values of the variables in that environment.
+// A reference to a variable that isn't defined is an error WithStrictEnv.
+func (r *Runner) interpolate(task *models.Task, env []string) ([]string, error) {
+	x := &expander{env: append([]string{}, env...)}
+	x.add(task.Env)
+	task.Dir = x.expand(task.Dir)
+	if len(task.DependsOn) > 0 {
+		deps := make([]string, len(task.DependsOn))
+		for i, d := range task.DependsOn {
+			deps[i] = expandDependency(d, x.expand)
+		}
+		task.DependsOn = deps
+	}
+	if r.strictEnv && len(x.undefined) > 0 {
+		return nil, i18n.Errorf("task %s refers to undefined variables: %s", task.Name, strings.Join(x.undefined, ", "))
+	}
+	return x.env, nil
+}
+
+// expandDependency replaces the variables in the arguments of a dependency, which are quoted so a value
+// containing spaces stays one argument. The name of the task is left as it is.
+func expandDependency(dep string, expand func(string) string) string {
+	if !strings.Contains(dep, "$") {
+		return dep
+	}
+	args, err := shlex.Split(dep)
+	if err != nil || len(args) < 2 {
+		// The error is returned when the dependency runs.
+		return dep
+	}
+	for i := 1; i < len(args); i++ {
+		args[i] = shellQuote(expand(args[i]))
+	}
+	return strings.Join(args, " ")
+}
+
+// lookupEnv returns the value of the last definition of name in env, and whether it is defined.
+func lookupEnv(env []string, name string) (string, bool) {
+	var value string
+	var ok bool
+	for _, en := range env {
+		if k, v, _ := strings.Cut(en, "="); k == name {
+			value, ok = v, true
+		}
+	}
+	return value, ok
+}
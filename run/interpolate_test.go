@@ -0,0 +1,80 @@
+package run
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/joerdav/xc/models"
+)
+
+func TestInterpolate(t *testing.T) {
+	env := []string{"SERVICE=api", "TARGET=linux amd64", "EMPTY="}
+	tests := []struct {
+		name         string
+		task         models.Task
+		strict       bool
+		expectedDir  string
+		expectedDeps []string
+		expectedEnv  []string
+		expectedErr  string
+	}{
+		{
+			name:        "given a directory with a variable, should replace it",
+			task:        models.Task{Name: "test", Dir: "services/${SERVICE}"},
+			expectedDir: "services/api",
+		},
+		{
+			name:        "given a directory with a variable of the task, should replace it",
+			task:        models.Task{Name: "test", Dir: "$OUT/$EMPTY", Env: []string{"OUT=dist/${SERVICE}"}},
+			expectedDir: "dist/api/",
+			expectedEnv: []string{"OUT=dist/api"},
+		},
+		{
+			name:         "given requires with variables, should replace them in the arguments and keep each one argument",
+			task:         models.Task{Name: "test", DependsOn: []string{"build ${TARGET}", "lint", "deploy $SERVICE"}},
+			expectedDeps: []string{"build 'linux amd64'", "lint", "deploy api"},
+		},
+		{
+			name:        "given an undefined variable, should replace it with nothing",
+			task:        models.Task{Name: "test", Dir: "services/${MISSING}"},
+			expectedDir: "services/",
+		},
+		{
+			name:        "given undefined variables in strict mode, should name them",
+			task:        models.Task{Name: "test", Dir: "${MISSING}", DependsOn: []string{"build $OTHER"}, Env: []string{"A=${MISSING}"}},
+			strict:      true,
+			expectedErr: "task test refers to undefined variables: MISSING, OTHER",
+		},
+		{
+			name:        "given an empty variable in strict mode, should not fail",
+			task:        models.Task{Name: "test", Dir: "a${EMPTY}"},
+			strict:      true,
+			expectedDir: "a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Runner{strictEnv: tt.strict}
+			task := tt.task
+			got, err := r.interpolate(&task, env)
+			if tt.expectedErr != "" {
+				if err == nil || err.Error() != tt.expectedErr {
+					t.Fatalf("want err=%q got=%v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if task.Dir != tt.expectedDir {
+				t.Fatalf("want dir=%q got=%q", tt.expectedDir, task.Dir)
+			}
+			if tt.expectedDeps != nil && !reflect.DeepEqual(task.DependsOn, tt.expectedDeps) {
+				t.Fatalf("want deps=%q got=%q", tt.expectedDeps, task.DependsOn)
+			}
+			if expected := append(append([]string{}, env...), tt.expectedEnv...); !reflect.DeepEqual(got, expected) {
+				t.Fatalf("want env=%q got=%q", expected, got)
+			}
+		})
+	}
+}
@@ -3,13 +3,15 @@ package run
 import (
 	"bytes"
 	"context"
-	"fmt"
+	"errors"
 	"io"
 	"os"
 	"os/exec"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/joerdav/xc/i18n"
 	"golang.org/x/term"
 	"mvdan.cc/sh/v3/expand"
 	"mvdan.cc/sh/v3/interp"
@@ -21,10 +23,16 @@ var (
 	otherSupportedShebangRe = regexp.MustCompile(`^#!(.+)`)
 )
 
+// killTimeout is how long a cancelled script is given to exit after it is interrupted, before it is killed,
+// unless the Runner sets a grace period. It matches the default of the shell interpreter.
+const killTimeout = 2 * time.Second
+
 type interpreter struct {
 	shellRunner    func(context.Context, *interp.Runner, *syntax.File) error
 	shebangRunner  func(*exec.Cmd) error
 	tempFilePrefix string
+	echoStyle      func(string) string
+	gracePeriod    time.Duration
 }
 
 func interpShellRunner(ctx context.Context, runner *interp.Runner, file *syntax.File) error {
@@ -35,22 +43,33 @@ func cmdShebangRunner(cmd *exec.Cmd) error {
 	return cmd.Run()
 }
 
-func newInterpreter() interpreter {
+func newInterpreter(echoStyle func(string) string) interpreter {
 	return interpreter{
 		shellRunner:    interpShellRunner,
 		shebangRunner:  cmdShebangRunner,
 		tempFilePrefix: "xc_",
+		echoStyle:      echoStyle,
+		gracePeriod:    killTimeout,
 	}
 }
 
-func (i interpreter) Execute(
-	ctx context.Context, script string, env, args []string, dir, logPrefix string,
-) error {
-	interpreterCmd, interpreterArgs, text, ok := parseShebang(script)
+func (i interpreter) Execute(ctx context.Context, e Execution) error {
+	switch {
+	case e.ScriptFile && e.Container == "" && e.Remote == "":
+		interpreterCmd, interpreterArgs, text := fileInterpreter(e)
+		return i.executeShebang(ctx, interpreterCmd, interpreterArgs, text, e)
+	case e.Container != "" && e.Remote != "":
+		return i18n.Errorf("a script can't run both in the container %s and on the host %s", e.Container, e.Remote)
+	case e.Container != "":
+		return i.executeContainer(ctx, e)
+	case e.Remote != "":
+		return i.executeRemote(ctx, e)
+	}
+	interpreterCmd, interpreterArgs, text, ok := scriptInterpreter(e.Script, e.Interpreter, e.Shell)
 	if !ok {
-		return i.executeShell(ctx, script, env, args, dir, logPrefix)
+		return i.executeShell(ctx, e)
 	}
-	return i.executeShebang(ctx, interpreterCmd, interpreterArgs, text, env, args, dir, logPrefix)
+	return i.executeShebang(ctx, interpreterCmd, interpreterArgs, text, e)
 }
 
 //nolint:gosec // accept that command is being executed here from outside of xc
@@ -59,62 +78,101 @@ func (i interpreter) executeShebang(
 	interpreterCmd string,
 	interpreterArgs []string,
 	text string,
-	env []string,
-	args []string,
-	dir string,
-	logPrefix string,
+	e Execution,
 ) error {
-	f, err := os.CreateTemp("", i.tempFilePrefix)
+	f, err := os.CreateTemp("", tempFilePattern(i.tempFilePrefix, interpreterCmd))
 	if err != nil {
-		return fmt.Errorf("failed to create execution file")
+		return errors.New(i18n.T("failed to create execution file"))
 	}
 	defer os.Remove(f.Name())
 	if _, err = f.WriteString(text); err != nil {
-		return fmt.Errorf("failed to write execution file")
+		return errors.New(i18n.T("failed to write execution file"))
+	}
+	if err = f.Close(); err != nil {
+		return errors.New(i18n.T("failed to write execution file"))
 	}
 	interpreterArgs = append(interpreterArgs, f.Name())
-	cmd := exec.CommandContext(ctx, interpreterCmd, append(interpreterArgs, args...)...)
-	cmd.Dir = dir
-	cmd.Env = env
-	stdin, stdout, stderr := stdFiles(logPrefix)
-	cmd.Stdin = stdin
+	cmd := exec.CommandContext(ctx, installedCommand(interpreterCmd), append(interpreterArgs, e.Args...)...)
+	cmd.Dir = e.Dir
+	cmd.Env = e.Env
+	stdin, stdout, stderr := i.stdFiles(e)
+	cmd.Stdin = stdinFile(stdin)
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
+	cancelCmd(ctx, cmd, i.gracePeriod)
 	return i.shebangRunner(cmd)
 }
 
-func (i interpreter) executeShell(
-	ctx context.Context, text string, env, args []string, dir, logPrefix string,
-) error {
+func (i interpreter) executeShell(ctx context.Context, e Execution) error {
+	text := e.Script
+	env := e.Env
 	if shellShebangRe.MatchString(text) {
 		text = strings.Join(strings.Split(text, "\n")[1:], "\n")
 	}
 	var script bytes.Buffer
 	if _, err := script.Write([]byte(scriptHeader)); err != nil {
-		return fmt.Errorf("failed to write script header: %w", err)
+		return i18n.Errorf("failed to write script header: %w", err)
 	}
 	if _, err := script.Write([]byte(text)); err != nil {
-		return fmt.Errorf("failed to write script: %w", err)
+		return i18n.Errorf("failed to write script: %w", err)
 	}
 	file, err := syntax.NewParser().Parse(&script, "")
 	if err != nil {
-		return fmt.Errorf("failed to parse task: %w", err)
+		return i18n.Errorf("failed to parse task: %w", err)
 	}
 	if os.Getenv("NO_COLOR") != "1" && term.IsTerminal(int(os.Stdout.Fd())) {
 		env = append(env, "CLICOLOR_FORCE=1", "FORCE_COLOR=1")
 	}
 	runner, err := interp.New(
 		interp.Env(expand.ListEnviron(env...)),
-		interp.StdIO(stdFiles(logPrefix)),
-		interp.Dir(dir),
-		interp.Params(args...),
+		interp.StdIO(i.stdFiles(e)),
+		interp.Dir(e.Dir),
+		// The arguments are preceded by "--", so that those starting with "-" aren't taken as shell options.
+		interp.Params(append([]string{"--"}, e.Args...)...),
+		interp.ExecHandler(execHandler(i.gracePeriod)),
 	)
 	if err != nil {
-		return fmt.Errorf("failed to compose script: %w", err)
+		return i18n.Errorf("failed to compose script: %w", err)
 	}
 	return i.shellRunner(ctx, runner, file)
 }
 
+// commandFallbacks are the commands that run scripts in place of commands that aren't installed,
+// such as Windows PowerShell, which comes with Windows, in place of PowerShell.
+var commandFallbacks = map[string]string{"pwsh": "powershell"}
+
+// lookPath is exec.LookPath, it is replaced in tests.
+var lookPath = exec.LookPath
+
+// installedCommand returns cmd, or its fallback if cmd isn't installed and the fallback is, see commandFallbacks.
+func installedCommand(cmd string) string {
+	fallback, ok := commandFallbacks[strings.ToLower(cmd)]
+	if !ok {
+		return cmd
+	}
+	if _, err := lookPath(cmd); err == nil {
+		return cmd
+	}
+	if _, err := lookPath(fallback); err == nil {
+		return fallback
+	}
+	return cmd
+}
+
+// tempFilePattern returns the pattern for the execution file of an interpreter.
+// Some Windows interpreters refuse to run files without the expected extension.
+func tempFilePattern(prefix, interpreterCmd string) string {
+	name := strings.ToLower(interpreterCmd[strings.LastIndexAny(interpreterCmd, `/\`)+1:])
+	name = strings.TrimSuffix(name, ".exe")
+	switch name {
+	case "pwsh", "powershell":
+		return prefix + "*.ps1"
+	case "cmd":
+		return prefix + "*.cmd"
+	}
+	return prefix
+}
+
 func parseShebang(script string) (interpreterCmd string, interpreterArgs []string, text string, ok bool) {
 	if script == "" {
 		return "", nil, "", false
@@ -134,9 +192,83 @@ func parseShebang(script string) (interpreterCmd string, interpreterArgs []strin
 	return interpreterCmd, interpreterArgs, strings.Join(lines[1:], "\n"), true
 }
 
-func stdFiles(prefix string) (io.Reader, io.Writer, io.Writer) {
-	if prefix == "" {
-		return os.Stdin, os.Stdout, os.Stderr
+// fileInterpreter returns the command that runs the script of e from a file, and the text of the file.
+// Unlike scriptInterpreter, a shell shebang such as #!/bin/bash runs its shell, rather than the shell built into xc,
+// and scripts without a shebang, Interpreter or Shell run with sh -e -x, which echoes commands like the built-in shell.
+func fileInterpreter(e Execution) (interpreterCmd string, interpreterArgs []string, text string) {
+	if shebang, rest, _ := strings.Cut(strings.TrimSpace(e.Script), "\n"); strings.HasPrefix(shebang, "#!") {
+		fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(shebang, "#!")), "/usr/bin/env "))
+		if len(fields) > 0 {
+			return fields[0], fields[1:], rest
+		}
+	}
+	if interpreterCmd, interpreterArgs, text, ok := scriptInterpreter(e.Script, e.Interpreter, e.Shell); ok {
+		return interpreterCmd, interpreterArgs, text
+	}
+	return "sh", []string{"-e", "-x"}, e.Script
+}
+
+// scriptInterpreter returns the command that runs script, and ok is false if it runs with the built-in shell.
+// A shebang takes precedence, then interpreter for scripts without a shebang, then shell for shell scripts.
+func scriptInterpreter(script, interpreter, shell string) (interpreterCmd string, interpreterArgs []string, text string, ok bool) {
+	if interpreterCmd, interpreterArgs, text, ok = parseShebang(script); ok {
+		return interpreterCmd, interpreterArgs, text, ok
+	}
+	hasShebang := strings.HasPrefix(strings.TrimSpace(script), "#!")
+	if fields := strings.Fields(interpreter); len(fields) > 0 && !hasShebang {
+		return fields[0], fields[1:], script, true
+	}
+	if fields := strings.Fields(shell); len(fields) > 0 && script != "" {
+		text = script
+		if hasShebang {
+			_, text, _ = strings.Cut(strings.TrimSpace(script), "\n")
+		}
+		if len(fields) == 1 {
+			return fields[0], shellArgs(fields[0]), text, true
+		}
+		return fields[0], fields[1:], text, true
+	}
+	return "", nil, "", false
+}
+
+// shellArgs returns the arguments that a shell named without any is run with,
+// so that scripts stop at the first failing command, and aren't affected by the profile of the user, where the shell allows it.
+func shellArgs(shell string) []string {
+	name := strings.ToLower(shell[strings.LastIndexAny(shell, `/\`)+1:])
+	switch strings.TrimSuffix(name, ".exe") {
+	case "sh", "bash", "dash", "ksh", "mksh", "zsh":
+		return []string{"-e"}
+	case "pwsh", "powershell":
+		return []string{"-NoProfile", "-NonInteractive", "-File"}
+	case "fish":
+		return []string{"--no-config"}
+	case "cmd":
+		return []string{"/c"}
+	}
+	return nil
+}
+
+func (i interpreter) stdFiles(e Execution) (stdin io.Reader, stdout, stderr io.Writer) {
+	stdin, stdout, stderr = os.Stdin, os.Stdout, os.Stderr
+	if e.Output != nil {
+		stdout, stderr = e.Output, e.Output
+	}
+	if e.LogPrefix != "" {
+		l := newPrefixLogger(stderr, e.LogPrefix)
+		l.echoStyle = i.echoStyle
+		stdout, stderr = newPrefixLogger(stdout, e.LogPrefix), l
+	}
+	if e.Stdin != nil {
+		stdin = e.Stdin
+	}
+	if e.Stdout != nil {
+		stdout = e.Stdout
+	}
+	if e.StdoutTee != nil {
+		stdout = io.MultiWriter(stdout, e.StdoutTee)
+	}
+	if e.StderrTee != nil {
+		stderr = io.MultiWriter(stderr, e.StderrTee)
 	}
-	return os.Stdin, newPrefixLogger(os.Stdout, prefix), newPrefixLogger(os.Stderr, prefix)
+	return stdin, stdout, stderr
 }
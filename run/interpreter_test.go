@@ -1,9 +1,17 @@
 package run
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"mvdan.cc/sh/v3/interp"
 	"mvdan.cc/sh/v3/syntax"
@@ -33,7 +41,7 @@ func newTestInterpreter() *testInterpreter {
 func TestIsShell(t *testing.T) {
 	t.Run("empty assume shell", func(t *testing.T) {
 		ti := newTestInterpreter()
-		if err := ti.Execute(context.Background(), "", nil, nil, "", ""); err != nil {
+		if err := ti.Execute(context.Background(), Execution{Script: ""}); err != nil {
 			t.Fatal(err)
 		}
 		if !ti.shellRunnerCalled {
@@ -45,7 +53,7 @@ func TestIsShell(t *testing.T) {
 	})
 	t.Run("no shebang assume shell", func(t *testing.T) {
 		ti := newTestInterpreter()
-		if err := ti.Execute(context.Background(), "echo", nil, nil, "", ""); err != nil {
+		if err := ti.Execute(context.Background(), Execution{Script: "echo"}); err != nil {
 			t.Fatal(err)
 		}
 		if !ti.shellRunnerCalled {
@@ -66,7 +74,7 @@ func TestIsShell(t *testing.T) {
 		for _, s := range shells {
 			she := "#!/usr/bin/env " + s + " "
 			ti := newTestInterpreter()
-			if err := ti.Execute(context.Background(), she, nil, nil, "", ""); err != nil {
+			if err := ti.Execute(context.Background(), Execution{Script: she}); err != nil {
 				t.Fatal(err)
 			}
 			if !ti.shellRunnerCalled {
@@ -85,7 +93,7 @@ func TestIsShell(t *testing.T) {
 		for _, s := range shells {
 			she := "#!/usr/bin/env " + s + " "
 			ti := newTestInterpreter()
-			if err := ti.Execute(context.Background(), she, nil, nil, "", ""); err != nil {
+			if err := ti.Execute(context.Background(), Execution{Script: she}); err != nil {
 				t.Fatal(err)
 			}
 			if ti.shellRunnerCalled {
@@ -103,7 +111,7 @@ func TestIsShell(t *testing.T) {
 			print("hang on this isn't shell")
 		}`
 		ti := newTestInterpreter()
-		if err := ti.Execute(context.Background(), she, nil, nil, "", ""); err == nil {
+		if err := ti.Execute(context.Background(), Execution{Script: she}); err == nil {
 			t.Fatal("expected an error")
 		}
 		if ti.shellRunnerCalled {
@@ -117,7 +125,7 @@ func TestIsShell(t *testing.T) {
 		she := "#!/usr/bin/env python "
 		ti := newTestInterpreter()
 		ti.tempFilePrefix = "invalid/prefix"
-		if err := ti.Execute(context.Background(), she, nil, nil, "", ""); err == nil {
+		if err := ti.Execute(context.Background(), Execution{Script: she}); err == nil {
 			t.Fatal("expected an error")
 		}
 		if ti.shellRunnerCalled {
@@ -128,3 +136,172 @@ func TestIsShell(t *testing.T) {
 		}
 	})
 }
+
+func TestScriptInterpreter(t *testing.T) {
+	tests := []struct {
+		name                   string
+		script, interp, shell  string
+		expectedCmd            string
+		expectedArgs, expected string
+		expectedShell          bool
+	}{
+		{name: "given no interpreter or shell, should use the built-in shell", script: "echo hi\n", expectedShell: true},
+		{name: "given a shebang, should take precedence", script: "#!/usr/bin/env node\nlog()\n", interp: "python3", shell: "bash", expectedCmd: "node", expected: "log()"},
+		{name: "given an interpreter, should run the script with it", script: "print(1)\n", interp: "deno run", expectedCmd: "deno", expectedArgs: "run", expected: "print(1)\n"},
+		{name: "given a shell shebang and an interpreter, should not use the interpreter", script: "#!/bin/sh\necho hi\n", interp: "python3", expectedShell: true},
+		{name: "given a shell, should run shell scripts with it", script: "#!/bin/sh\necho hi\n", interp: "python3", shell: "bash -e", expectedCmd: "bash", expectedArgs: "-e", expected: "echo hi"},
+		{name: "given a shell and an empty script, should use the built-in shell", shell: "bash", expectedShell: true},
+		{name: "given a known shell without arguments, should add its flags", script: "Write-Output hi\n", shell: "pwsh", expectedCmd: "pwsh", expectedArgs: "-NoProfile -NonInteractive -File", expected: "Write-Output hi\n"},
+		{name: "given fish, should skip its config", script: "echo hi\n", shell: "/usr/bin/fish", expectedCmd: "/usr/bin/fish", expectedArgs: "--no-config", expected: "echo hi\n"},
+		{name: "given bash, should stop at the first failure", script: "echo hi\n", shell: "bash", expectedCmd: "bash", expectedArgs: "-e", expected: "echo hi\n"},
+		{name: "given an unknown shell, should run it without flags", script: "echo hi\n", shell: "nu", expectedCmd: "nu", expected: "echo hi\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, args, text, ok := scriptInterpreter(tt.script, tt.interp, tt.shell)
+			if ok == tt.expectedShell {
+				t.Fatalf("expected built-in shell %v got %v", tt.expectedShell, !ok)
+			}
+			if cmd != tt.expectedCmd || strings.Join(args, " ") != tt.expectedArgs || text != tt.expected {
+				t.Fatalf("want=%q %q %q got=%q %q %q", tt.expectedCmd, tt.expectedArgs, tt.expected, cmd, args, text)
+			}
+		})
+	}
+}
+
+func TestTempFilePattern(t *testing.T) {
+	tests := map[string]string{
+		"python":                  "xc_",
+		"/usr/bin/node":           "xc_",
+		"pwsh":                    "xc_*.ps1",
+		"powershell.exe":          "xc_*.ps1",
+		`C:\Windows\System32\cmd`: "xc_*.cmd",
+	}
+	for interpreter, expected := range tests {
+		if got := tempFilePattern("xc_", interpreter); got != expected {
+			t.Errorf("%s: want=%q got=%q", interpreter, expected, got)
+		}
+	}
+}
+
+func TestShebangInterrupt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Go can't send an interrupt on Windows")
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+	// A shell that isn't recognised by name is run through its shebang.
+	shell := filepath.Join(t.TempDir(), "testshell")
+	if err := os.Symlink(sh, shell); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!" + shell + "\ntrap 'echo interrupted; exit 0' INT\necho started\nsleep 5 >/dev/null 2>&1 &\nwait\n"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r, w := io.Pipe()
+	var out bytes.Buffer
+	read := make(chan struct{})
+	go func() {
+		defer close(read)
+		s := bufio.NewScanner(r)
+		for s.Scan() {
+			out.WriteString(s.Text() + "\n")
+			if s.Text() == "started" {
+				cancel()
+			}
+		}
+	}()
+	start := time.Now()
+	newInterpreter(nil).Execute(ctx, Execution{Script: script, Stdout: w})
+	w.Close()
+	<-read
+	if !strings.Contains(out.String(), "interrupted") {
+		t.Fatalf("expected the script to be interrupted got output %q", out.String())
+	}
+	if time.Since(start) >= killTimeout {
+		t.Fatalf("expected the script to exit when interrupted, took %v", time.Since(start))
+	}
+}
+
+func TestExecuteOutput(t *testing.T) {
+	out := &lockedBuffer{}
+	if err := newInterpreter(nil).Execute(context.Background(), Execution{Script: "echo out\necho err >&2\n", LogPrefix: "build", Output: out}); err != nil {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	out.writeTo(&got)
+	for _, expected := range []string{"build｜ out\n", "build｜ err\n"} {
+		if !strings.Contains(got.String(), expected) {
+			t.Fatalf("expected output to contain %q got %q", expected, got.String())
+		}
+	}
+}
+
+func TestExecuteArgs(t *testing.T) {
+	var out bytes.Buffer
+	if err := newInterpreter(nil).Execute(context.Background(), Execution{Script: `echo "$#:$1:$2"`, Args: []string{"-run", "-v"}, Stdout: &out}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "2:-run:-v\n"; out.String() != expected {
+		t.Fatalf("want=%q got=%q", expected, out.String())
+	}
+}
+
+func TestInstalledCommand(t *testing.T) {
+	defer func(f func(string) (string, error)) { lookPath = f }(lookPath)
+	tests := []struct {
+		name      string
+		cmd       string
+		installed []string
+		expected  string
+	}{
+		{name: "given an installed command, should use it", cmd: "pwsh", installed: []string{"pwsh", "powershell"}, expected: "pwsh"},
+		{name: "given a missing command, should use its fallback", cmd: "pwsh", installed: []string{"powershell"}, expected: "powershell"},
+		{name: "given a missing fallback, should use the command", cmd: "pwsh", expected: "pwsh"},
+		{name: "given a command without a fallback, should use it", cmd: "python3", expected: "python3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookPath = func(file string) (string, error) {
+				for _, i := range tt.installed {
+					if i == file {
+						return file, nil
+					}
+				}
+				return "", exec.ErrNotFound
+			}
+			if got := installedCommand(tt.cmd); got != tt.expected {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExecuteScriptFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the scripts run with sh and bash")
+	}
+	tests := []struct {
+		name     string
+		script   string
+		expected string
+	}{
+		{name: "given no shebang, should run the script with sh", script: "cat <<EOF\nhello $1\nEOF", expected: "hello joe\n"},
+		{name: "given a shell shebang, should run the script with its shell", script: "#!/usr/bin/env bash\necho \"${BASH_VERSION:+bash} $1\"", expected: "bash joe\n"},
+		{name: "given a function, should define it", script: "greet() {\n  echo \"hi $1\"\n}\ngreet \"$1\"", expected: "hi joe\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			e := Execution{Script: tt.script, Args: []string{"joe"}, ScriptFile: true, Env: os.Environ(), Stdout: &out, Output: io.Discard}
+			if err := newInterpreter(nil).Execute(context.Background(), e); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.expected {
+				t.Fatalf("want=%q got=%q", tt.expected, out.String())
+			}
+		})
+	}
+}
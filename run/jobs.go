@@ -0,0 +1,103 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+)
+
+// WithJobs runs up to jobs scripts at the same time: the tasks given to RunAll start together,
+// as do the dependencies of every task that sets `RunDeps: async`, and a task required with the same inputs
+// by more than one task runs once. The dependencies of other tasks still run in order.
+// Once a script fails no more scripts are started, the scripts that are running are left to finish.
+// A jobs of 1 runs the dependency graph in order, and the dependencies of tasks that set `RunDeps: async` one at a time,
+// so that no more than jobs scripts ever run at the same time. Less than 1 is the same as without WithJobs.
+func WithJobs(jobs int) Option {
+	return func(r *Runner) {
+		if jobs > 0 {
+			r.jobs = make(chan struct{}, jobs)
+		}
+		r.parallel = jobs > 1
+	}
+}
+
+// WithForceDeps runs the tasks that are required more than once with the same inputs each time they are required,
+// as if they had Run: always, rather than once per run. Tasks with Run: once still run once.
+func WithForceDeps() Option {
+	return func(r *Runner) {
+		r.forceDeps = true
+	}
+}
+
+// WithKeepGoing keeps running the tasks that don't depend on a task that failed, the same as make -k.
+// Every dependency of a task runs even if one of them fails, including when they run one at a time,
+// and when running WithJobs scripts keep starting after a script fails.
+// The task itself still doesn't run, and the errors of all the tasks that failed are returned together.
+func WithKeepGoing() Option {
+	return func(r *Runner) {
+		r.keepGoing = true
+	}
+}
+
+// ErrNotStarted is returned for the scripts, and the tasks of RunAll, that weren't started because another script failed.
+var ErrNotStarted = errors.New(i18n.T("not started because another task failed"))
+
+// parallelDeps returns true if the dependencies of task run at the same time.
+func (r *Runner) parallelDeps(task models.Task) bool {
+	return task.DepsBehaviour == models.DependencyBehaviourAsync
+}
+
+// runKey returns the key of a run of task in alreadyRan, and whether the task only runs once for that key.
+// A task that is required with the same inputs by more than one task runs once, except for the hook tasks,
+// which run for every task that is invoked. Tasks with Run: always, and every task WithForceDeps, run each time
+// they are required, except that the tasks given to RunAll share them.
+func (r *Runner) runKey(task models.Task, inputs []string) (key string, once bool) {
+	if task.RequiredBehaviour == models.RequiredBehaviourOnce {
+		return task.Name, true
+	}
+	key = task.Name + "\x00" + strings.Join(inputs, "\x00")
+	if task.IsHook() {
+		return key, false
+	}
+	if task.RequiredBehaviour == models.RequiredBehaviourAlways || r.forceDeps {
+		return key, r.shareDeps
+	}
+	return key, true
+}
+
+// acquireJob waits for one of the jobs to be free WithJobs, and holds it until release is called.
+// It returns ErrNotStarted if a script has failed, unless ctx is the context of Finally tasks.
+func (r *Runner) acquireJob(ctx context.Context) (release func(), err error) {
+	if r.jobs == nil {
+		return func() {}, nil
+	}
+	select {
+	case r.jobs <- struct{}{}:
+	case <-ctx.Done():
+		return func() {}, ctx.Err()
+	}
+	release = func() { <-r.jobs }
+	if r.failed.Load() && ctx.Value(finallyKey{}) == nil {
+		release()
+		return func() {}, ErrNotStarted
+	}
+	return release, nil
+}
+
+// started joins the errors of dependencies that ran in parallel,
+// leaving out the dependencies that weren't started if another one failed.
+func started(errs []error) error {
+	var failed []error
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, ErrNotStarted) {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) == 0 {
+		return errors.Join(errs...)
+	}
+	return errors.Join(failed...)
+}
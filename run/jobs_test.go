@@ -0,0 +1,123 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joerdav/xc/models"
+)
+
+// concurrencyScriptRunner records the scripts that ran and the most that ran at the same time.
+// The script fail waits for up to 100ms for another script to be running, then fails.
+type concurrencyScriptRunner struct {
+	mu           sync.Mutex
+	running, max int
+	ran          []string
+}
+
+func (r *concurrencyScriptRunner) Execute(ctx context.Context, e Execution) error {
+	r.mu.Lock()
+	r.ran = append(r.ran, e.Script)
+	if r.running++; r.running > r.max {
+		r.max = r.running
+	}
+	r.mu.Unlock()
+	if e.Script != "fail" {
+		time.Sleep(20 * time.Millisecond)
+	} else {
+		r.waitForOthers(100 * time.Millisecond)
+	}
+	r.mu.Lock()
+	r.running--
+	r.mu.Unlock()
+	if e.Script == "fail" {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func (r *concurrencyScriptRunner) waitForOthers(timeout time.Duration) {
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		r.mu.Lock()
+		running := r.running
+		r.mu.Unlock()
+		if running > 1 {
+			return
+		}
+	}
+}
+
+func TestRunJobs(t *testing.T) {
+	tasks := models.Tasks{
+		{Name: "gen", Script: "gen"},
+		{Name: "lint", Script: "lint"},
+		{Name: "test", Script: "test", DependsOn: []string{"gen"}},
+		{Name: "build", Script: "build", DependsOn: []string{"gen", "lint"}},
+		{Name: "all", DependsOn: []string{"lint", "test", "build"}, DepsBehaviour: models.DependencyBehaviourAsync},
+		{Name: "fail", Script: "fail"},
+		{Name: "slow", Script: "slow"},
+		{Name: "later", Script: "later", DependsOn: []string{"slow"}},
+		{Name: "broken", DependsOn: []string{"fail", "later"}, DepsBehaviour: models.DependencyBehaviourAsync},
+		{Name: "blocked", Script: "blocked", DependsOn: []string{"fail", "lint"}},
+		{Name: "async", DependsOn: []string{"gen", "lint"}, DepsBehaviour: models.DependencyBehaviourAsync},
+	}
+	tests := []struct {
+		name        string
+		task        string
+		jobs        int
+		keepGoing   bool
+		forceDeps   bool
+		expectedRan []string
+		expectedMax int
+		expectedErr string
+	}{
+		{name: "given one job, should run one script at a time and shared dependencies once", task: "all", jobs: 1, expectedRan: []string{"build", "gen", "lint", "test"}, expectedMax: 1},
+		{name: "given one job and force deps, should run shared dependencies each time", task: "all", jobs: 1, forceDeps: true, expectedRan: []string{"build", "gen", "gen", "lint", "lint", "test"}, expectedMax: 1},
+		{name: "given jobs and force deps, should run shared dependencies each time", task: "all", jobs: 2, forceDeps: true, expectedRan: []string{"build", "gen", "gen", "lint", "lint", "test"}, expectedMax: 2},
+		{name: "given jobs, should run shared dependencies once and branches in parallel", task: "all", jobs: 2, expectedRan: []string{"build", "gen", "lint", "test"}, expectedMax: 2},
+		{name: "given more jobs than branches, should run every branch at once", task: "all", jobs: 8, expectedRan: []string{"build", "gen", "lint", "test"}, expectedMax: 2},
+		{name: "given a failure, should not start the tasks that haven't started", task: "broken", jobs: 2, expectedRan: []string{"fail", "slow"}, expectedMax: 2, expectedErr: "failed"},
+		{name: "given keep going, should start the tasks that don't depend on the failure", task: "broken", jobs: 2, keepGoing: true, expectedRan: []string{"fail", "later", "slow"}, expectedMax: 2, expectedErr: "failed"},
+		{name: "given keep going and one job, should run the other dependencies but not the task", task: "blocked", jobs: 1, keepGoing: true, expectedRan: []string{"fail", "lint"}, expectedMax: 1, expectedErr: "failed"},
+		{name: "given one job, should stop at the first failure", task: "blocked", jobs: 1, expectedRan: []string{"fail"}, expectedMax: 1, expectedErr: "failed"},
+		{name: "given one job and async dependencies, should run one script at a time", task: "async", jobs: 1, expectedRan: []string{"gen", "lint"}, expectedMax: 1},
+		{name: "given jobs and async dependencies, should run them in parallel", task: "async", jobs: 2, expectedRan: []string{"gen", "lint"}, expectedMax: 2},
+		{name: "given jobs and runDeps omitted, should run the dependencies in order", task: "build", jobs: 2, expectedRan: []string{"build", "gen", "lint"}, expectedMax: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []Option{WithJobs(tt.jobs)}
+			if tt.keepGoing {
+				opts = append(opts, WithKeepGoing())
+			}
+			if tt.forceDeps {
+				opts = append(opts, WithForceDeps())
+			}
+			runner, err := NewRunner(tasks, "", opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			scriptRunner := &concurrencyScriptRunner{}
+			runner.scriptRunner = scriptRunner
+			err = runner.Run(context.Background(), tt.task, nil)
+			if tt.expectedErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.expectedErr != "" && (err == nil || err.Error() != tt.expectedErr) {
+				t.Fatalf("expected error %q got %v", tt.expectedErr, err)
+			}
+			sort.Strings(scriptRunner.ran)
+			if strings.Join(scriptRunner.ran, ",") != strings.Join(tt.expectedRan, ",") {
+				t.Fatalf("ran want=%q got=%q", tt.expectedRan, scriptRunner.ran)
+			}
+			if scriptRunner.max != tt.expectedMax {
+				t.Fatalf("running at the same time want=%d got=%d", tt.expectedMax, scriptRunner.max)
+			}
+		})
+	}
+}
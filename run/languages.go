@@ -0,0 +1,78 @@
+package run
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+)
+
+// DefaultLanguages maps the languages of code blocks to the interpreters that run their scripts,
+// for tasks without an Interpreter attribute.
+var DefaultLanguages = map[string]string{
+	"python":     "python3",
+	"python3":    "python3",
+	"py":         "python3",
+	"javascript": "node",
+	"js":         "node",
+	"node":       "node",
+	"typescript": "deno run",
+	"ts":         "deno run",
+	"ruby":       "ruby",
+	"rb":         "ruby",
+	"perl":       "perl",
+	"php":        "php",
+	"lua":        "lua",
+	"r":          "Rscript",
+	"powershell": "pwsh",
+	"pwsh":       "pwsh",
+	"ps1":        "pwsh",
+	"cmd":        "cmd /c",
+	"bat":        "cmd /c",
+	"batch":      "cmd /c",
+	"zsh":        "zsh",
+	"fish":       "fish",
+}
+
+// shellLanguages are the languages of code blocks that run with the shell.
+var shellLanguages = map[string]bool{"": true, "sh": true, "shell": true, "bash": true, "console": true}
+
+// IsShellLanguage returns true if a code block in language runs with the shell, rather than an interpreter.
+func IsShellLanguage(language string) bool {
+	return shellLanguages[strings.ToLower(language)]
+}
+
+// WithLanguages maps the languages of code blocks to the interpreters that run them,
+// in addition to, or replacing, DefaultLanguages.
+func WithLanguages(languages map[string]string) Option {
+	return func(r *Runner) {
+		r.languages = make(map[string]string, len(languages))
+		for language, cmd := range languages {
+			r.languages[strings.ToLower(language)] = cmd
+		}
+	}
+}
+
+// warnedLanguages holds the tasks that were warned about the unknown language of their script.
+var warnedLanguages sync.Map
+
+// interpreter returns the Interpreter of task,
+// or the interpreter of the language of its code blocks if it doesn't have one.
+// Scripts in languages without an interpreter run with the shell, with a warning.
+func (r *Runner) interpreter(task models.Task) string {
+	if task.Interpreter != "" || IsShellLanguage(task.Language) {
+		return task.Interpreter
+	}
+	language := strings.ToLower(task.Language)
+	if cmd, ok := r.languages[language]; ok {
+		return cmd
+	}
+	if cmd, ok := DefaultLanguages[language]; ok {
+		return cmd
+	}
+	if _, warned := warnedLanguages.LoadOrStore(task.Name, true); !warned {
+		i18n.Printf("task %q is written in %s, which has no interpreter: running it with the shell\n", task.Name, task.Language)
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+package run
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joerdav/xc/models"
+)
+
+func TestRunLanguages(t *testing.T) {
+	tasks := models.Tasks{
+		{Name: "shell", Script: "echo hi", Language: "sh"},
+		{Name: "python", Script: "print(1)", Language: "Python"},
+		{Name: "ruby", Script: "puts 1", Language: "ruby"},
+		{Name: "pinned", Script: "print(1)", Language: "python", Interpreter: "python3.11"},
+		{Name: "unknown", Script: "echo hi", Language: "text"},
+		{Name: "batch", Script: "echo hi", Language: "bat"},
+	}
+	tests := []struct {
+		name      string
+		task      string
+		languages map[string]string
+		expected  string
+	}{
+		{name: "given a shell language, should run with the shell", task: "shell"},
+		{name: "given a known language, should run with its interpreter", task: "python", expected: "python3"},
+		{name: "given a configured language, should override the default", task: "ruby", languages: map[string]string{"ruby": "jruby"}, expected: "jruby"},
+		{name: "given an interpreter attribute, should take precedence", task: "pinned", languages: map[string]string{"python": "pypy"}, expected: "python3.11"},
+		{name: "given an unknown language, should run with the shell", task: "unknown"},
+		{name: "given a batch file, should run with cmd", task: "batch", expected: "cmd /c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := NewRunner(tasks, "", WithLanguages(tt.languages))
+			if err != nil {
+				t.Fatal(err)
+			}
+			scriptRunner := &executionScriptRunner{}
+			runner.scriptRunner = scriptRunner
+			if err := runner.Run(context.Background(), tt.task, nil); err != nil {
+				t.Fatal(err)
+			}
+			if scriptRunner.last.Interpreter != tt.expected {
+				t.Fatalf("interpreter want=%q got=%q", tt.expected, scriptRunner.last.Interpreter)
+			}
+		})
+	}
+}
@@ -12,8 +12,12 @@ type prefixLogger struct {
 	w      io.Writer
 	buf    *bytes.Buffer
 	prefix []byte
+	// echoStyle formats lines that echo commands, which start with "+ ".
+	echoStyle func(string) string
 }
 
+var echoMarker = []byte("+ ")
+
 func newPrefixLogger(w io.Writer, prefix string) *prefixLogger {
 	streamer := &prefixLogger{
 		w:   w,
@@ -88,6 +92,10 @@ func (l *prefixLogger) out(p []byte) error {
 		return nil
 	}
 
+	if l.echoStyle != nil && bytes.HasPrefix(p, echoMarker) {
+		line := bytes.TrimSuffix(p, []byte{newLine})
+		p = append([]byte(l.echoStyle(string(line))), p[len(line):]...)
+	}
 	_, err := l.w.Write(append(l.prefix, p...))
 	return err
 }
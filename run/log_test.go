@@ -56,3 +56,17 @@ func TestPrefixLogger_Write(t *testing.T) {
 		})
 	}
 }
+
+func TestPrefixLogger_EchoStyle(t *testing.T) {
+	w := bytes.NewBuffer(nil)
+	l := newPrefixLogger(w, "task")
+	l.echoStyle = func(s string) string { return "<" + s + ">" }
+	defer l.Close()
+
+	l.Write([]byte("+ go test ./...\nok\n"))
+
+	expect := "task｜ <+ go test ./...>\ntask｜ ok\n"
+	if w.String() != expect {
+		t.Errorf("got %q, want %q", w.String(), expect)
+	}
+}
@@ -0,0 +1,77 @@
+package run
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// WithGroupedOutput holds back the output of each script until it finishes, then writes it all at once,
+// so that the output of scripts that run in parallel isn't interleaved.
+// Both the standard output and error of a script are written to the standard output, still prefixed with its name.
+// Interactive tasks are never grouped, as they need the terminal.
+func WithGroupedOutput() Option {
+	return func(r *Runner) {
+		r.groupOutput = true
+	}
+}
+
+// lockedBuffer is a buffer that is safe for concurrent use, as the standard output and error of a script are written separately.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// writeTo writes the contents of the buffer to w in a single write, so that it isn't interleaved with other output.
+func (b *lockedBuffer) writeTo(w io.Writer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, _ = w.Write(b.buf.Bytes())
+}
+
+// WithLogDir also writes the combined output of each script to a file in dir,
+// named after the time it started in a directory named after its task, e.g. build/20240101-120000.000.log.
+func WithLogDir(dir string) Option {
+	return func(r *Runner) {
+		r.logDir = dir
+	}
+}
+
+// logFile tees the output of e to a new log file for label, if a log directory is set.
+// If the file can't be created the script runs without one.
+func (r *Runner) logFile(e *Execution, label string) (close func()) {
+	if r.logDir == "" {
+		return func() {}
+	}
+	dir := filepath.Join(r.logDir, logName(label))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return func() {}
+	}
+	f, err := os.Create(filepath.Join(dir, time.Now().Format("20060102-150405.000")+".log"))
+	if err != nil {
+		return func() {}
+	}
+	tee(e, f, f)
+	return func() { _ = f.Close() }
+}
+
+// logName replaces the characters of label that aren't safe in a file name, such as the slashes in the files of -each.
+func logName(label string) string {
+	return strings.Map(func(c rune) rune {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) || c == '-' || c == '_' || c == '.' {
+			return c
+		}
+		return '_'
+	}, strings.TrimSpace(label))
+}
@@ -0,0 +1,58 @@
+package run
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/joerdav/xc/models"
+)
+
+func TestWithLogDir(t *testing.T) {
+	dir := t.TempDir()
+	tasks := models.Tasks{
+		{Name: "gen", Script: "generated"},
+		{Name: "build", Script: "fail", DependsOn: []string{"gen"}},
+	}
+	runner, err := NewRunner(tasks, "", WithLogDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.scriptRunner = teeScriptRunner{}
+	if err := runner.Run(context.Background(), "build", nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	for task, expected := range map[string]string{"gen": "generated", "build": "fail"} {
+		files, err := filepath.Glob(filepath.Join(dir, task, "*.log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 1 {
+			t.Fatalf("%s: expected 1 log file got %v", task, files)
+		}
+		b, err := os.ReadFile(files[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != expected {
+			t.Fatalf("%s: want=%q got=%q", task, expected, b)
+		}
+	}
+}
+
+func TestLogName(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected string
+	}{
+		{in: "build", expected: "build"},
+		{in: "gen-proto proto/api/v1.proto", expected: "gen-proto_proto_api_v1.proto"},
+		{in: "  test:unit ", expected: "test_unit"},
+	}
+	for _, tt := range tests {
+		if got := logName(tt.in); got != tt.expected {
+			t.Fatalf("%s: want=%q got=%q", tt.in, tt.expected, got)
+		}
+	}
+}
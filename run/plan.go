@@ -0,0 +1,150 @@
+package run
+
+import (
+	"os"
+	"strings"
+
+	"github.com/google/shlex"
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+)
+
+// Step is a single step of a Plan.
+// It is either a task, or a group of branches that run in parallel.
+type Step struct {
+	Task string
+	Args []string
+	// Skip is the reason the task would be skipped, it is empty if the task would run.
+	Skip string
+	// Branches is set for steps that run in parallel, the steps of each branch run in order.
+	Branches [][]Step
+	// Finally holds the steps of the Finally tasks of the task, which run after it even if it fails.
+	Finally []Step
+	// Dir, Env, Interpreter, Shell, Container, Remote and Script describe how the script of the task would run,
+	// they are only set for tasks with a script that would run.
+	// Env holds the variables set by the task and by its inputs, Interpreter and Shell are empty for the shell built into xc,
+	// and Container and Remote are empty for scripts that run on this machine.
+	Dir         string
+	Env         []string
+	Interpreter string
+	Shell       string
+	Container   string
+	Remote      string
+	// DeclaredDir is the directory of the Dir attribute of the task if it is replaced for the run, see WithDir.
+	DeclaredDir string
+	Script      string
+}
+
+// Plan is the ordered list of steps that would be executed to run a task.
+type Plan []Step
+
+// Plan returns the steps that Run would execute for the named task, without running anything.
+func (r *Runner) Plan(name string, inputs []string) (Plan, error) {
+	seen := map[string]bool{}
+	return r.plan(name, inputs, seen)
+}
+
+func (r *Runner) plan(name string, inputs []string, seen map[string]bool) ([]Step, error) {
+	task, ok := r.tasks.Get(name)
+	if !ok {
+		return nil, TaskNotFoundError{Name: name}
+	}
+	if to, ok := task.Forward(); ok {
+		return r.plan(to, inputs, seen)
+	}
+	if len(inputs) == 0 {
+		inputs = nil
+	}
+	key, once := r.runKey(task, inputs)
+	if once && seen[key] {
+		return []Step{{Task: task.Name, Args: inputs, Skip: i18n.T("ran already")}}, nil
+	}
+	seen[key] = true
+	env, err := r.taskEnv(task, os.Environ())
+	if err != nil {
+		return nil, err
+	}
+	env, err = r.interpolate(&task, env)
+	if err != nil {
+		return nil, err
+	}
+	_, reason, err := r.skipCondition(task, env)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		return []Step{{Task: task.Name, Args: inputs, Skip: reason}}, nil
+	}
+	var steps []Step
+	var branches [][]Step
+	for _, d := range task.DependsOn {
+		da, err := shlex.Split(d)
+		if err != nil {
+			return nil, err
+		}
+		ds, err := r.plan(da[0], da[1:], seen)
+		if err != nil {
+			return nil, err
+		}
+		if r.parallelDeps(task) {
+			branches = append(branches, ds)
+			continue
+		}
+		steps = append(steps, ds...)
+	}
+	if len(branches) > 0 {
+		steps = append(steps, Step{Branches: branches})
+	}
+	step := Step{Task: task.Name, Args: inputs}
+	if len(task.Script) > 0 {
+		step.Dir = r.getExecutionPath(task)
+		if _, ok := r.dirs[task.Name]; ok {
+			step.DeclaredDir = resolvePath(r.dir, task.Dir)
+		}
+		step.Env = planEnv(task, inputs, env)
+		step.Interpreter = r.interpreter(task)
+		step.Shell = task.Shell
+		step.Container = task.Container
+		step.Remote = r.remote(task)
+		step.Script = task.Script
+	}
+	for _, f := range task.Finally {
+		fa, err := shlex.Split(f)
+		if err != nil {
+			return nil, err
+		}
+		fs, err := r.plan(fa[0], fa[1:], seen)
+		if err != nil {
+			return nil, err
+		}
+		step.Finally = append(step.Finally, fs...)
+	}
+	return append(steps, step), nil
+}
+
+// planEnv returns the variables that the Env attribute of task and its inputs would set, without prompting for inputs.
+// Inputs that would be prompted for are left out.
+func planEnv(task models.Task, inputs, env []string) []string {
+	var result []string
+	for _, e := range task.Env {
+		k, _, _ := strings.Cut(e, "=")
+		result = append(result, k+"="+environmentValue(env, k))
+	}
+	named, positional := namedInputs(task, inputs)
+	for _, n := range task.Inputs {
+		v, ok := named[n]
+		if !ok && len(positional) > 0 {
+			v, positional, ok = positional[0], positional[1:], true
+		}
+		if !ok && environmentContainsInput(env, n) {
+			v, ok = environmentValue(env, n), true
+		}
+		if d := task.Input(n).Default; !ok && d != nil && task.Input(n).Prompt == nil {
+			v, ok = *d, true
+		}
+		if ok {
+			result = append(result, n+"="+v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,220 @@
+package run
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/joerdav/xc/models"
+)
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		tasks    models.Tasks
+		taskName string
+		jobs     int
+		expected Plan
+	}{
+		{
+			name:     "given a task without deps, should plan the task",
+			tasks:    models.Tasks{{Name: "build", Script: "go build"}},
+			taskName: "build",
+			expected: Plan{{Task: "build"}},
+		},
+		{
+			name: "given sync deps, should plan deps in order before the task, running shared deps once",
+			tasks: models.Tasks{
+				{Name: "lint", Script: "lint"},
+				{Name: "build", Script: "go build", DependsOn: []string{"lint"}},
+				{Name: "all", DependsOn: []string{"lint", "build arg"}},
+			},
+			taskName: "all",
+			expected: Plan{
+				{Task: "lint"},
+				{Task: "lint", Skip: "ran already"},
+				{Task: "build", Args: []string{"arg"}},
+				{Task: "all"},
+			},
+		},
+		{
+			name: "given an always task required twice, should plan it twice",
+			tasks: models.Tasks{
+				{Name: "lint", Script: "lint", RequiredBehaviour: models.RequiredBehaviourAlways},
+				{Name: "build", Script: "go build", DependsOn: []string{"lint"}},
+				{Name: "all", DependsOn: []string{"lint", "build"}},
+			},
+			taskName: "all",
+			expected: Plan{
+				{Task: "lint"},
+				{Task: "lint"},
+				{Task: "build"},
+				{Task: "all"},
+			},
+		},
+		{
+			name: "given a once task required twice, should skip the second",
+			tasks: models.Tasks{
+				{Name: "lint", Script: "lint", RequiredBehaviour: models.RequiredBehaviourOnce},
+				{Name: "build", Script: "go build", DependsOn: []string{"lint"}},
+				{Name: "all", DependsOn: []string{"lint", "build"}},
+			},
+			taskName: "all",
+			expected: Plan{
+				{Task: "lint"},
+				{Task: "lint", Skip: "ran already"},
+				{Task: "build"},
+				{Task: "all"},
+			},
+		},
+		{
+			name: "given async deps, should plan parallel branches",
+			tasks: models.Tasks{
+				{Name: "a", Script: "a"},
+				{Name: "b", Script: "b", DependsOn: []string{"a"}},
+				{Name: "all", DependsOn: []string{"a", "b"}, DepsBehaviour: models.DependencyBehaviourAsync},
+			},
+			taskName: "all",
+			expected: Plan{
+				{Branches: [][]Step{
+					{{Task: "a"}},
+					{{Task: "a", Skip: "ran already"}, {Task: "b"}},
+				}},
+				{Task: "all"},
+			},
+		},
+		{
+			name: "given jobs, should plan only the deps of tasks with runDeps async as branches, running shared deps once",
+			tasks: models.Tasks{
+				{Name: "a", Script: "a"},
+				{Name: "b", Script: "b", DependsOn: []string{"a", "c"}},
+				{Name: "c", Script: "c"},
+				{Name: "all", DependsOn: []string{"a", "b"}, DepsBehaviour: models.DependencyBehaviourAsync},
+			},
+			taskName: "all",
+			jobs:     4,
+			expected: Plan{
+				{Branches: [][]Step{
+					{{Task: "a"}},
+					{{Task: "a", Skip: "ran already"}, {Task: "c"}, {Task: "b"}},
+				}},
+				{Task: "all"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := NewRunner(tt.tasks, "", WithJobs(tt.jobs))
+			if err != nil {
+				t.Fatal(err)
+			}
+			plan, err := runner.Plan(tt.taskName, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Plan(stepOrder(plan)); !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("want=%+v got=%+v", tt.expected, got)
+			}
+		})
+	}
+	t.Run("given an unknown task, should error", func(t *testing.T) {
+		runner, err := NewRunner(models.Tasks{}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := runner.Plan("missing", nil); err == nil {
+			t.Fatal("expected error got nil")
+		}
+	})
+}
+
+// stepOrder returns steps with only the tasks, their arguments and how they are ordered.
+func stepOrder(steps []Step) []Step {
+	var result []Step
+	for _, s := range steps {
+		o := Step{Task: s.Task, Args: s.Args, Skip: s.Skip}
+		for _, b := range s.Branches {
+			o.Branches = append(o.Branches, stepOrder(b))
+		}
+		result = append(result, o)
+	}
+	return result
+}
+
+func TestPlanExecution(t *testing.T) {
+	t.Setenv("XC_PLAN_TARGET", "prod")
+	def := "us-east-1"
+	tasks := models.Tasks{
+		{
+			Name:       "deploy",
+			Script:     "./deploy.sh\n",
+			Dir:        "infra",
+			Env:        []string{"TARGET=$XC_PLAN_TARGET"},
+			Inputs:     []string{"VERSION", "REGION"},
+			InputSpecs: map[string]models.InputSpec{"REGION": {Default: &def}},
+			Shell:      "bash",
+		},
+		{Name: "report", Script: "print(1)", Language: "python"},
+	}
+	tests := []struct {
+		name     string
+		task     string
+		inputs   []string
+		opts     []Option
+		expected Step
+	}{
+		{
+			name:   "given an environment and inputs, should plan the variables they set",
+			task:   "deploy",
+			inputs: []string{"v1.2.0"},
+			expected: Step{
+				Task: "deploy", Args: []string{"v1.2.0"},
+				Dir:    filepath.Join("/repo", "infra"),
+				Env:    []string{"TARGET=prod", "VERSION=v1.2.0", "REGION=us-east-1"},
+				Shell:  "bash",
+				Script: "./deploy.sh\n",
+			},
+		},
+		{
+			name:     "given a language, should plan its interpreter",
+			task:     "report",
+			expected: Step{Task: "report", Dir: "/repo", Interpreter: "python3", Script: "print(1)"},
+		},
+		{
+			name:   "given another directory, should plan it with the declared directory",
+			task:   "deploy",
+			inputs: []string{"v1.2.0"},
+			opts:   []Option{WithDir("deploy", "services")},
+			expected: Step{
+				Task: "deploy", Args: []string{"v1.2.0"},
+				Dir:         filepath.Join("/repo", "services"),
+				DeclaredDir: filepath.Join("/repo", "infra"),
+				Env:         []string{"TARGET=prod", "VERSION=v1.2.0", "REGION=us-east-1"},
+				Shell:       "bash",
+				Script:      "./deploy.sh\n",
+			},
+		},
+		{
+			name:     "given another directory for another task, should plan the directory of the task",
+			task:     "report",
+			opts:     []Option{WithDir("deploy", "services")},
+			expected: Step{Task: "report", Dir: "/repo", Interpreter: "python3", Script: "print(1)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := NewRunner(tasks, "/repo", tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			plan, err := runner.Plan(tt.task, tt.inputs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(plan) != 1 || !reflect.DeepEqual(plan[0], tt.expected) {
+				t.Fatalf("want=%+v got=%+v", tt.expected, plan)
+			}
+		})
+	}
+}
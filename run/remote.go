@@ -0,0 +1,83 @@
+package run
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/joerdav/xc/models"
+)
+
+// SSHCommandVar is the environment variable that names the command that runs the scripts of tasks
+// with a Remote host, e.g. `ssh -i deploy.pem`. It is ssh if it isn't set.
+const SSHCommandVar = "XC_SSH_COMMAND"
+
+// WithHost runs the scripts of every task on host over ssh, e.g. deploy@example.com,
+// instead of on the host of their Remote attribute or locally.
+func WithHost(host string) Option {
+	return func(r *Runner) {
+		r.host = host
+	}
+}
+
+// remote returns the host that runs the script of task, see WithHost.
+func (r *Runner) remote(task models.Task) string {
+	if r.host != "" {
+		return r.host
+	}
+	return task.Remote
+}
+
+// executeRemote runs the script of e on the host e.Remote over ssh, with the output streamed back
+// and the exit code of the script returned by ssh.
+// The script is written to a temporary file on the host, which is run like a ScriptFile, see fileInterpreter,
+// in the home directory of the user.
+//
+//nolint:gosec // accept that command is being executed here from outside of xc
+func (i interpreter) executeRemote(ctx context.Context, e Execution) error {
+	ssh := strings.Fields(environmentValue(e.Env, SSHCommandVar))
+	if len(ssh) == 0 {
+		ssh = []string{"ssh"}
+	}
+	args := ssh[1:]
+	if allocateTerminal(e) {
+		args = append(args, "-t")
+	}
+	args = append(args, e.Remote, "--", remoteCommand(e))
+	cmd := exec.CommandContext(ctx, ssh[0], args...)
+	cmd.Dir = e.Dir
+	cmd.Env = e.Env
+	stdin, stdout, stderr := i.stdFiles(e)
+	cmd.Stdin = stdinFile(stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cancelCmd(ctx, cmd, i.gracePeriod)
+	return i.shebangRunner(cmd)
+}
+
+// remoteCommand returns the command that runs the script of e on a remote host.
+// It is run by sh, whatever the login shell of the user is,
+// with the variables that xc sets and the arguments of the script.
+func remoteCommand(e Execution) string {
+	interpreterCmd, interpreterArgs, text := fileInterpreter(e)
+	var run []string
+	if env := setEnv(e.Env); len(env) > 0 {
+		run = append(run, "env")
+		for _, kv := range env {
+			if name, _, _ := strings.Cut(kv, "="); name != SSHCommandVar {
+				run = append(run, shellQuote(kv))
+			}
+		}
+	}
+	run = append(run, shellQuote(interpreterCmd))
+	for _, a := range interpreterArgs {
+		run = append(run, shellQuote(a))
+	}
+	run = append(run, `"$f"`)
+	for _, a := range e.Args {
+		run = append(run, shellQuote(a))
+	}
+	script := `f=$(mktemp) && printf '%s' ` + shellQuote(text) + ` > "$f" && ` + strings.Join(run, " ") +
+		`; s=$?; rm -f "$f"; exit $s`
+	return "sh -c " + shellQuote(script)
+}
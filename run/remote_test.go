@@ -0,0 +1,108 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/joerdav/xc/models"
+)
+
+func TestExecuteRemote(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the remote command is run with sh")
+	}
+	defer func(f func() bool) { stdinIsTerminal = f }(stdinIsTerminal)
+	stdinIsTerminal = func() bool { return false }
+	tests := []struct {
+		name           string
+		e              Execution
+		expectedOutput string
+		expectedCode   int
+	}{
+		{
+			name:           "given a shell script, should run it with its arguments",
+			e:              Execution{Script: "#!/bin/bash\necho \"$1 and '$2'\"", Args: []string{"one", "it's two"}},
+			expectedOutput: "one and 'it's two'\n",
+		},
+		{
+			name:           "given variables set by xc, should set them",
+			e:              Execution{Script: "echo \"$STAGE\"", Env: append(os.Environ(), "STAGE=prod '1'")},
+			expectedOutput: "prod '1'\n",
+		},
+		{
+			name:           "given an interpreter, should run the script with it",
+			e:              Execution{Script: "print('hello')", Interpreter: "cat -u"},
+			expectedOutput: "print('hello')",
+		},
+		{
+			name:         "given a failing script, should return its exit code",
+			e:            Execution{Script: "exit 3"},
+			expectedCode: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []string
+			var out bytes.Buffer
+			i := newInterpreter(nil)
+			i.shebangRunner = func(cmd *exec.Cmd) error {
+				args = cmd.Args
+				// Run the command that ssh would run on the host.
+				sh := exec.Command("sh", "-c", cmd.Args[len(cmd.Args)-1])
+				sh.Stdout = &out
+				return sh.Run()
+			}
+			tt.e.Remote, tt.e.Stdout = "deploy@example.com", &out
+			tt.e.Env = append(tt.e.Env, SSHCommandVar+"=ssh -p 2222")
+			err := i.Execute(context.Background(), tt.e)
+			if code := ExitCode(err); code != tt.expectedCode {
+				t.Fatalf("exit code want=%d got=%d: %v", tt.expectedCode, code, err)
+			}
+			if expected := "ssh -p 2222 deploy@example.com --"; strings.Join(args[:5], " ") != expected {
+				t.Fatalf("args want=%q got=%q", expected, args[:5])
+			}
+			if out.String() != tt.expectedOutput {
+				t.Fatalf("output want=%q got=%q", tt.expectedOutput, out.String())
+			}
+		})
+	}
+}
+
+func TestWithHost(t *testing.T) {
+	tasks := models.Tasks{
+		{Name: "deploy", Script: "./deploy.sh", Remote: "deploy@prod"},
+		{Name: "build", Script: "go build"},
+	}
+	tests := []struct {
+		name     string
+		task     string
+		host     string
+		expected string
+	}{
+		{name: "given a remote, should run the script on it", task: "deploy", expected: "deploy@prod"},
+		{name: "given no remote, should run the script locally", task: "build"},
+		{name: "given a host, should override the remote", task: "deploy", host: "deploy@staging", expected: "deploy@staging"},
+		{name: "given a host, should run every script on it", task: "build", host: "deploy@staging", expected: "deploy@staging"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := NewRunner(tasks, "", WithHost(tt.host))
+			if err != nil {
+				t.Fatal(err)
+			}
+			scriptRunner := &executionScriptRunner{}
+			runner.scriptRunner = scriptRunner
+			if err := runner.Run(context.Background(), tt.task, nil); err != nil {
+				t.Fatal(err)
+			}
+			if scriptRunner.last.Remote != tt.expected {
+				t.Fatalf("want=%q got=%q", tt.expected, scriptRunner.last.Remote)
+			}
+		})
+	}
+}
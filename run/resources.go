@@ -0,0 +1,69 @@
+package run
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// WithResources sets the capacities of the resources named by the Resources attribute of tasks,
+// which is the number of scripts that can hold the resource at the same time.
+// Resources that have no capacity can be held by one script at a time.
+func WithResources(capacities map[string]int) Option {
+	return func(r *Runner) {
+		r.resources.capacities = capacities
+	}
+}
+
+// resources limits how many scripts hold the same resource at the same time.
+// The locks of tasks are resources without capacities, so they are held by one script at a time.
+type resources struct {
+	mu         sync.Mutex
+	capacities map[string]int
+	slots      map[string]chan struct{}
+}
+
+func (p *resources) slot(name string) chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.slots == nil {
+		p.slots = map[string]chan struct{}{}
+	}
+	s, ok := p.slots[name]
+	if !ok {
+		capacity := p.capacities[name]
+		if capacity < 1 {
+			capacity = 1
+		}
+		s = make(chan struct{}, capacity)
+		p.slots[name] = s
+	}
+	return s
+}
+
+// acquire waits until each of the named resources is available and holds it until release is called.
+// Resources are acquired in order of name, so that two scripts never wait for each other.
+func (p *resources) acquire(ctx context.Context, names []string) (release func(), err error) {
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+	var held []chan struct{}
+	release = func() {
+		for _, s := range held {
+			<-s
+		}
+	}
+	for i, name := range sorted {
+		if i > 0 && name == sorted[i-1] {
+			continue
+		}
+		s := p.slot(name)
+		select {
+		case s <- struct{}{}:
+			held = append(held, s)
+		case <-ctx.Done():
+			release()
+			return func() {}, ctx.Err()
+		}
+	}
+	return release, nil
+}
@@ -0,0 +1,108 @@
+package run
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joerdav/xc/models"
+)
+
+func TestResources(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"a.bin", "b.bin", "c.bin", "d.bin"} {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tasks := models.Tasks{
+		{Name: "migrate", Script: "migrate", Resources: []string{"db"}},
+		{Name: "seed", Script: "seed", Resources: []string{"db"}},
+		{Name: "lint", Script: "lint"},
+		{Name: "all", DependsOn: []string{"migrate", "seed"}, DepsBehaviour: models.DependencyBehaviourAsync},
+		{Name: "train", Script: "train $ITEM", Resources: []string{"gpu", "gpu"}},
+		{Name: "compile", Script: "compile $ITEM"},
+		{Name: "reset", Script: "reset", Locks: []string{"database"}},
+		{Name: "load", Script: "load", Locks: []string{"database"}},
+		{Name: "fixtures", DependsOn: []string{"reset", "load"}, DepsBehaviour: models.DependencyBehaviourAsync},
+	}
+	tests := []struct {
+		name         string
+		task         string
+		each         string
+		capacities   map[string]int
+		expectedMost int
+	}{
+		{
+			name:         "given async deps that hold the same resource, should run them one at a time",
+			task:         "all",
+			expectedMost: 1,
+		},
+		{
+			name:         "given a capacity, should run up to the capacity at a time",
+			task:         "train",
+			each:         "*.bin",
+			capacities:   map[string]int{"gpu": 2},
+			expectedMost: 2,
+		},
+		{
+			name:         "given a resource without a capacity, should run one at a time",
+			task:         "train",
+			each:         "*.bin",
+			expectedMost: 1,
+		},
+		{
+			name:         "given async deps that hold the same lock, should run them one at a time",
+			task:         "fixtures",
+			capacities:   map[string]int{"database": 2},
+			expectedMost: 1,
+		},
+		{
+			name:         "given no resources, should only be limited by jobs",
+			task:         "compile",
+			each:         "*.bin",
+			expectedMost: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := NewRunner(tasks, dir, WithResources(tt.capacities))
+			if err != nil {
+				t.Fatal(err)
+			}
+			scriptRunner := &itemScriptRunner{executionDelay: 20 * time.Millisecond}
+			runner.scriptRunner = scriptRunner
+			if tt.each != "" {
+				err = runner.RunEach(context.Background(), tt.task, nil, tt.each, 4)
+			} else {
+				err = runner.Run(context.Background(), tt.task, nil)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if scriptRunner.most != tt.expectedMost {
+				t.Fatalf("parallel want=%d got=%d", tt.expectedMost, scriptRunner.most)
+			}
+		})
+	}
+}
+
+func TestResourcesCancel(t *testing.T) {
+	p := &resources{}
+	release, err := p.acquire(context.Background(), []string{"db"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.acquire(ctx, []string{"cache", "db"}); err == nil {
+		t.Fatal("expected an error while db is held")
+	}
+	// The cache must have been released when acquiring db failed.
+	if _, err := p.acquire(context.Background(), []string{"cache"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,187 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// Result is the outcome of running the script of a task.
+type Result struct {
+	Task string
+	// Start is when the script started, and Duration is how long it ran for.
+	Start    time.Time
+	Duration time.Duration
+	// Err is the error returned by the script, it is nil if the script succeeded.
+	Err error
+	// Skipped is true if the task wasn't run, because it is only run once and ran already,
+	// because it succeeded within its Throttle, because its If condition is false,
+	// because its Outputs are up to date with its Sources, or because its result is cached.
+	Skipped bool
+	// SkipReason is why the task was skipped, e.g. ran already.
+	SkipReason string
+	// Output holds the end of the combined standard output and error of the script,
+	// it is only captured when the Runner is created WithOutputCapture.
+	Output string
+	// Requires are the names of the tasks that the task requires, which ran before it.
+	Requires []string
+}
+
+// The exit codes that xc reserves for its own failures, so that CI scripts can tell them apart from a script that failed.
+// A script can still exit with one of them, they are only used when no script failed.
+const (
+	// ExitCodeParse is the exit code when the task file or the config can't be parsed.
+	ExitCodeParse = 65
+	// ExitCodeNotFound is the exit code when a task isn't found.
+	ExitCodeNotFound = 66
+	// ExitCodeTimeout is the exit code when a run is stopped because it took too long, the same as timeout(1).
+	ExitCodeTimeout = 124
+)
+
+// TaskNotFoundError is returned when a task isn't found, xc exits with ExitCodeNotFound after it.
+type TaskNotFoundError struct {
+	Name string
+}
+
+func (e TaskNotFoundError) Error() string {
+	return i18n.Sprintf("task %s not found", e.Name)
+}
+
+// CycleError is returned when the dependencies of tasks form a cycle, xc exits with ExitCodeParse after it.
+// Tasks holds the tasks of the cycle in the order they require each other, starting and ending with the same task.
+type CycleError struct {
+	Tasks []models.Task
+}
+
+// Error returns the cycle, such as build -> generate -> build, followed by the line of the heading of each task.
+func (e CycleError) Error() string {
+	names := make([]string, len(e.Tasks))
+	for i, t := range e.Tasks {
+		names[i] = t.Name
+	}
+	lines := []string{i18n.Sprintf("dependency cycle: %s", strings.Join(names, " -> "))}
+	for _, t := range e.Tasks[:len(e.Tasks)-1] {
+		switch {
+		case t.Line == 0:
+		case t.File != "":
+			lines = append(lines, i18n.Sprintf("  %s is defined at %s:%d", t.Name, t.File, t.Line))
+		default:
+			lines = append(lines, i18n.Sprintf("  %s is defined on line %d", t.Name, t.Line))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ExitCode returns the exit code xc should exit with after err: 0 if it is nil, ExitCodeTimeout if a deadline passed,
+// 128 plus the signal if xc was Interrupted, like a shell, otherwise the highest exit status of the scripts that failed,
+// or if no script failed ExitCodeNotFound if a task wasn't found, ExitCodeParse for a CycleError, or 1.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ExitCodeTimeout
+	}
+	var interrupted Interrupted
+	if errors.As(err, &interrupted) {
+		if s, ok := interrupted.Signal.(syscall.Signal); ok {
+			return 128 + int(s)
+		}
+	}
+	if code := exitStatus(err); code > 0 {
+		return code
+	}
+	if errors.As(err, &TaskNotFoundError{}) {
+		return ExitCodeNotFound
+	}
+	if errors.As(err, &CycleError{}) {
+		return ExitCodeParse
+	}
+	return 1
+}
+
+// exitStatus returns the highest exit status of the scripts that failed in err, which can join several errors.
+func exitStatus(err error) int {
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		var code int
+		for _, err := range e.Unwrap() {
+			if c := exitStatus(err); c > code {
+				code = c
+			}
+		}
+		return code
+	case interface{ Unwrap() error }:
+		return exitStatus(e.Unwrap())
+	case *exec.ExitError:
+		return e.ExitCode()
+	}
+	if s, ok := interp.IsExitStatus(err); ok {
+		return int(s)
+	}
+	return 0
+}
+
+// WithOutputCapture captures the last max bytes of the output of each task in its Result.
+func WithOutputCapture(max int) Option {
+	return func(r *Runner) {
+		r.captureOutput = max
+	}
+}
+
+// results holds the results of a Runner, it is shared between copies of the Runner.
+type results struct {
+	mu      sync.Mutex
+	results []Result
+}
+
+func (rs *results) add(r Result) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.results = append(rs.results, r)
+}
+
+// addResult records the result of a task, and sends its event.
+func (r *Runner) addResult(res Result) {
+	r.results.add(res)
+	r.eventResult(res)
+}
+
+// Results returns the results of the tasks that have run, in the order they finished.
+// Tasks without a script have no result.
+func (r *Runner) Results() []Result {
+	r.results.mu.Lock()
+	defer r.results.mu.Unlock()
+	return append([]Result{}, r.results.results...)
+}
+
+// tailBuffer keeps the last max bytes written to it, it is safe for concurrent use.
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = append(b.buf[:0], b.buf[len(b.buf)-b.max:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
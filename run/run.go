@@ -4,19 +4,109 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/shlex"
+	"github.com/joerdav/xc/cache"
+	"github.com/joerdav/xc/i18n"
 	"github.com/joerdav/xc/models"
+	"github.com/joerdav/xc/problem"
 )
 
 const maxDeps = 50
 
+// Execution is a single execution of a task script.
+type Execution struct {
+	Script    string
+	Env, Args []string
+	Dir       string
+	// LogPrefix prefixes each line of output, output is passed through unchanged if it is empty.
+	LogPrefix string
+	// Stdin and Stdout replace the standard input and output of the script if they are set.
+	Stdin  io.Reader
+	Stdout io.Writer
+	// StdoutTee and StderrTee receive a copy of the standard output and error of the script if they are set.
+	StdoutTee, StderrTee io.Writer
+	// Output receives both the standard output and error of the script instead of the terminal if it is set,
+	// the Stdout attribute still takes precedence for the standard output.
+	Output io.Writer
+	// Interpreter and Shell are the commands that run the script instead of the built-in shell if they are set,
+	// see models.Task.
+	Interpreter, Shell string
+	// Container is the image that runs the script if it is set, with Mount, the directory of the task file,
+	// mounted at the same path, see models.Task.
+	Container, Mount string
+	// Remote is the host that runs the script over ssh if it is set, see models.Task.
+	Remote string
+	// ScriptFile runs the script from a temporary file with its shebang, see models.Task.
+	ScriptFile bool
+}
+
 type ScriptRunner interface {
-	Execute(ctx context.Context, text string, env, args []string, dir, logPrefix string) error
+	Execute(ctx context.Context, e Execution) error
+}
+
+// Style formats text for display, for example by adding colour.
+type Style func(string) string
+
+// Styles configures how the output of tasks is formatted.
+// A nil Style leaves the text unchanged.
+type Styles struct {
+	// Prefix formats the task name that prefixes each line of output.
+	Prefix Style
+	// Echo formats lines that echo a command before it is run, e.g. `+ go test ./...`.
+	Echo Style
+}
+
+// Option configures a Runner.
+type Option func(*Runner)
+
+// WithStyles sets the Styles used to format the output of tasks.
+func WithStyles(s Styles) Option {
+	return func(r *Runner) {
+		r.styles = s
+	}
+}
+
+// Prompter asks for the value of an input that wasn't provided.
+type Prompter func(task models.Task, input string, p models.Prompt) (string, error)
+
+// WithPrompter sets the Prompter used for inputs that declare a Prompt.
+// Without a Prompter, missing inputs are an error.
+func WithPrompter(p Prompter) Option {
+	return func(r *Runner) {
+		r.prompter = p
+	}
+}
+
+// Notifier is called when a task that has Notify targets finishes.
+type Notifier func(task models.Task, r Result)
+
+// WithNotifier sets the Notifier used for tasks with Notify targets.
+func WithNotifier(n Notifier) Option {
+	return func(r *Runner) {
+		r.notifier = n
+	}
+}
+
+// Throttler returns when a task last succeeded, ok is false if it never has.
+type Throttler func(task models.Task) (last time.Time, ok bool)
+
+// WithThrottler sets the Throttler used to skip tasks with a Throttle that succeeded recently.
+// Without a Throttler, tasks are never throttled.
+func WithThrottler(t Throttler) Option {
+	return func(r *Runner) {
+		r.throttler = t
+	}
 }
 
 // Runner is responsible for running Tasks.
@@ -24,25 +114,80 @@ type Runner struct {
 	scriptRunner ScriptRunner
 	tasks        models.Tasks
 	dir          string
-	alreadyRan   map[string]bool
-	alreadRanMu  sync.Mutex
+	styles       Styles
+	prompter     Prompter
+	notifier     Notifier
+	throttler    Throttler
+	// promptMu stops dependencies that run in parallel from prompting at the same time,
+	// or while an interactive task has the terminal.
+	promptMu sync.Mutex
+	problems *problem.Collector
+	results  *results
+	// captureOutput is the number of bytes of output of each task that is kept in its Result.
+	captureOutput int
+	resources     *resources
+	locks         *resources
+	alreadyRan    map[string]*taskRun
+	alreadRanMu   sync.Mutex
+	// languages maps the languages of code blocks to interpreters, see WithLanguages.
+	languages map[string]string
+	// force runs tasks even if they are up to date, see WithForce.
+	force bool
+	// jobs holds a value for each script that is running WithJobs, it is nil otherwise.
+	jobs chan struct{}
+	// parallel is true when the dependency graph runs in parallel, WithJobs of more than 1.
+	parallel bool
+	// failed is true once a script has failed in the current run, so no more scripts are started in parallel.
+	// It stays false WithKeepGoing.
+	failed *atomic.Bool
+	// keepGoing runs the remaining dependencies of a task after one fails, see WithKeepGoing.
+	keepGoing bool
+	// groupOutput writes the output of each script at once when it finishes, see WithGroupedOutput.
+	groupOutput bool
+	// logDir is where the output of each script is written to a file, see WithLogDir. It is empty if no log files are written.
+	logDir string
+	// events receives the events of the run, see WithEvents. It is nil if events aren't sent.
+	events EventHandler
+	// gracePeriod is how long a cancelled script is given to exit before it is killed, see WithGracePeriod.
+	gracePeriod time.Duration
+	// cache holds the results of tasks with Sources, see WithCache. It is nil if results aren't cached.
+	cache *cache.Shared
+	// host runs the scripts of every task over ssh if it is set, see WithHost.
+	host string
+	// dirs maps the names of tasks to the directories that replace their Dir, see WithDir.
+	dirs map[string]string
+	// shareDeps runs a task that is required with the same inputs by more than one task once, see RunAll.
+	shareDeps bool
+	// strictEnv makes references to undefined variables an error, see WithStrictEnv.
+	strictEnv bool
+	// forceDeps runs shared dependencies each time they are required, see WithForceDeps.
+	forceDeps bool
 }
 
 // NewRunner takes Tasks and returns a Runner.
-// If the OS is windows commands will be run using `cmd \C`
-// and separated by `&&`.
-// Otherwise, commands will be run using `bash -c`
-// and separated by `;`.
+// Shell scripts are run by the shell built into xc on every OS, including Windows, without a POSIX sh,
+// unless the task has a Shell, Interpreter or shebang.
 //
 // NewRunner will return an error in the case that Dependent tasks are cyclical,
 // invalid or at a larger depth than 50.
-func NewRunner(ts models.Tasks, dir string) (runner Runner, err error) {
+func NewRunner(ts models.Tasks, dir string, opts ...Option) (runner Runner, err error) {
 	runner = Runner{
-		scriptRunner: newInterpreter(),
-		tasks:        ts,
-		dir:          dir,
-		alreadyRan:   map[string]bool{},
+		tasks:       ts,
+		dir:         dir,
+		alreadyRan:  map[string]*taskRun{},
+		problems:    &problem.Collector{},
+		results:     &results{},
+		resources:   &resources{},
+		locks:       &resources{},
+		failed:      &atomic.Bool{},
+		gracePeriod: killTimeout,
 	}
+	for _, o := range opts {
+		o(&runner)
+	}
+	i := newInterpreter(runner.styles.Echo)
+	i.gracePeriod = runner.gracePeriod
+	runner.scriptRunner = i
 	for _, t := range ts {
 		err = runner.ValidateDependencies(t.Name, []string{})
 		if err != nil {
@@ -58,16 +203,24 @@ const scriptHeader = ` #!/bin/bash
 `
 
 func taskUsage(task models.Task) string {
-	argUsage := fmt.Sprintf("xc %s", task.Name)
+	argUsage := fmt.Sprintf("xc %s", task.Command())
 	for _, n := range task.Inputs {
-		argUsage += fmt.Sprintf(" <%s>", strings.ToLower(n))
+		argUsage += fmt.Sprintf(" <%s>", inputPlaceholder(task, n))
 	}
 	envUsage := ""
 	for _, n := range task.Inputs {
-		envUsage += fmt.Sprintf("%s=<%s> ", n, strings.ToLower(n))
+		envUsage += fmt.Sprintf("%s=<%s> ", n, inputPlaceholder(task, n))
+	}
+	envUsage += fmt.Sprintf("xc %s", task.Command())
+	return i18n.Sprintf("Task has required inputs:\n\t%s\n\t%s", argUsage, envUsage)
+}
+
+// inputPlaceholder returns the choices of an input if it has any, or its name.
+func inputPlaceholder(task models.Task, name string) string {
+	if c := task.Input(name).Choices; len(c) > 0 {
+		return strings.Join(c, "|")
 	}
-	envUsage += fmt.Sprintf("xc %s", task.Name)
-	return fmt.Sprintf("Task has required inputs:\n\t%s\n\t%s", argUsage, envUsage)
+	return strings.ToLower(name)
 }
 
 func environmentContainsInput(env []string, input string) bool {
@@ -79,83 +232,569 @@ func environmentContainsInput(env []string, input string) bool {
 	return false
 }
 
-func getInputs(task models.Task, inputs, env []string) ([]string, error) {
+// taskEnv returns the variables of the host environment that are passed to task, followed by those of its Dotenv files.
+// The Env of the task is not added, so that the caller can add variables that it overrides.
+func (r *Runner) taskEnv(task models.Task, env []string) ([]string, error) {
+	env = inheritedEnv(task, env)
+	// The dotenv files are in the directory of the task, which can only refer to the inherited variables to find them.
+	task.Dir = (&expander{env: env}).expand(task.Dir)
+	dotenv, err := r.dotenv(task)
+	if err != nil {
+		return nil, err
+	}
+	return append(env, dotenv...), nil
+}
+
+// inheritedEnv returns the variables of the host environment that are passed to task.
+// Inputs and required variables of the task are always passed, so they can still be provided as environment variables.
+func inheritedEnv(task models.Task, env []string) []string {
+	if task.InheritEnv == nil {
+		return env
+	}
+	var result []string
+	for _, e := range env {
+		name, _, _ := strings.Cut(e, "=")
+		if inherits(task, name) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func inherits(task models.Task, name string) bool {
+	fold := func(s string) string { return s }
+	if runtime.GOOS == "windows" {
+		// Environment variables are case insensitive on Windows.
+		fold = strings.ToUpper
+	}
+	for _, in := range task.Inputs {
+		if fold(in) == fold(name) {
+			return true
+		}
+	}
+	for _, n := range task.RequiresEnv {
+		if fold(n) == fold(name) {
+			return true
+		}
+	}
+	for _, p := range task.InheritEnv {
+		if ok, _ := path.Match(fold(p), fold(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// missingEnv returns the RequiresEnv variables of task that are not set, or empty, in env.
+func missingEnv(task models.Task, env []string) []string {
+	var missing []string
+	for _, n := range task.RequiresEnv {
+		if environmentValue(env, n) == "" {
+			missing = append(missing, n)
+		}
+	}
+	return missing
+}
+
+// ExpandEnv returns env followed by vars, where $NAME and ${NAME} in the value of each variable in vars
+// are replaced with the value of NAME in env or an earlier variable in vars.
+func ExpandEnv(env, vars []string) []string {
+	x := &expander{env: append([]string{}, env...)}
+	x.add(vars)
+	return x.env
+}
+
+// environmentValue returns the value of the last definition of name in env.
+func environmentValue(env []string, name string) string {
+	var value string
+	for _, en := range env {
+		if k, v, _ := strings.Cut(en, "="); k == name {
+			value = v
+		}
+	}
+	return value
+}
+
+// namedInputs splits the arguments of task into the inputs given by name as NAME=value, keyed by input name,
+// and the positional arguments, leaving out the arguments after "--". Input names are matched case insensitively.
+func namedInputs(task models.Task, args []string) (named map[string]string, positional []string) {
+	args, _ = splitPassThrough(args)
+	named = map[string]string{}
+	for _, a := range args {
+		if k, v, ok := strings.Cut(a, "="); ok {
+			if n, ok := inputName(task, k); ok {
+				named[n] = v
+				continue
+			}
+		}
+		positional = append(positional, a)
+	}
+	return named, positional
+}
+
+// splitPassThrough splits args at the first "--", the arguments after it are passed to the script as they are,
+// rather than being inputs.
+func splitPassThrough(args []string) (inputs, passThrough []string) {
+	for i, a := range args {
+		if a == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
+
+// scriptArgs returns the positional parameters of the script of task,
+// its positional arguments followed by the arguments after "--".
+func scriptArgs(task models.Task, args []string) []string {
+	_, positional := namedInputs(task, args)
+	_, passThrough := splitPassThrough(args)
+	return append(positional, passThrough...)
+}
+
+func inputName(task models.Task, name string) (string, bool) {
+	for _, n := range task.Inputs {
+		if strings.EqualFold(n, name) {
+			return n, true
+		}
+	}
+	return "", false
+}
+
+// Inputs returns the inputs of task as NAME=value, from its arguments given by name or position,
+// the answers to its prompts or its defaults. Inputs that are set in env are left out, as the script inherits them.
+func (r *Runner) Inputs(task models.Task, inputs, env []string) ([]string, error) {
 	result := []string{}
-	for i, n := range task.Inputs {
+	named, positional := namedInputs(task, inputs)
+	for _, n := range task.Inputs {
 		// Do the command args contain the input?
-		if len(inputs) > i {
-			result = append(result, fmt.Sprintf("%v=%v", n, inputs[i]))
+		v, ok := named[n]
+		if !ok && len(positional) > 0 {
+			v, positional, ok = positional[0], positional[1:], true
+		}
+		if ok {
+			if err := validateInput(task, n, v); err != nil {
+				return nil, err
+			}
+			result = append(result, fmt.Sprintf("%v=%v", n, v))
 			continue
 		}
 		// Does the task environment contain the input?
 		if environmentContainsInput(env, n) {
+			if err := validateInput(task, n, environmentValue(env, n)); err != nil {
+				return nil, err
+			}
 			continue
 		}
-		return nil, fmt.Errorf(taskUsage(task))
+		// Can the input be prompted for?
+		if p := task.Input(n).Prompt; p != nil && r.prompter != nil {
+			v, err := r.prompt(task, n, *p)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, fmt.Sprintf("%v=%v", n, v))
+			continue
+		}
+		// Does the input have a default?
+		if d := task.Input(n).Default; d != nil {
+			result = append(result, fmt.Sprintf("%v=%v", n, *d))
+			continue
+		}
+		return nil, errors.New(taskUsage(task))
 	}
 	return result, nil
 }
 
+func (r *Runner) prompt(task models.Task, name string, p models.Prompt) (string, error) {
+	r.promptMu.Lock()
+	defer r.promptMu.Unlock()
+	v, err := r.prompter(task, name, p)
+	if err != nil {
+		return "", err
+	}
+	if v == "" {
+		v = task.Input(name).Fallback()
+	}
+	return v, validateInput(task, name, v)
+}
+
+func validateInput(task models.Task, name, value string) error {
+	spec := task.Input(name)
+	if spec.Allows(value) {
+		return nil
+	}
+	switch {
+	case spec.Type == models.InputTypeInt && len(spec.Choices) == 0:
+		return i18n.Errorf("invalid value %q for input %s of task %s, should be an integer", value, name, task.Name)
+	case spec.Type == models.InputTypeBool && len(spec.Choices) == 0:
+		return i18n.Errorf("invalid value %q for input %s of task %s, should be true or false", value, name, task.Name)
+	}
+	return i18n.Errorf("invalid value %q for input %s of task %s, should be one of (%s)",
+		value, name, task.Name, strings.Join(spec.Choices, ", "))
+}
+
 // Run runs a task given a string name.
 // Task dependencies will be run first, an error will return if any fail.
 // Task commands are run next, in case of a non zero result an error will return.
+// The hook tasks run before and after the task, see runHooked.
 func (r *Runner) Run(ctx context.Context, name string, inputs []string) error {
+	r.failed.Store(false)
 	padding, err := r.getLogPadding(name)
 	if err != nil {
 		return err
 	}
-	return r.runWithPadding(ctx, name, inputs, padding)
+	padding = r.hookPadding(padding)
+	return r.runHooked(ctx, name, padding, func(ctx context.Context) error {
+		return r.runWithPadding(ctx, name, inputs, padding)
+	})
 }
 
 func (r *Runner) runWithPadding(ctx context.Context, name string, inputs []string, padding int) error {
+	task, env, done, err := r.prepare(ctx, name, inputs, padding)
+	if err != nil || done == nil {
+		return err
+	}
+	return done(r.execute(ctx, task, task.Name, env, inputs, padding))
+}
+
+// taskRun is a run of a task, which the tasks that require it once wait for when they run in parallel.
+type taskRun struct {
+	finished chan struct{}
+	err      error
+}
+
+func (t *taskRun) done(err error) {
+	t.err = err
+	close(t.finished)
+}
+
+// prepare runs the dependencies of the named task and returns the environment of its script.
+// done is nil if the script shouldn't run, because the task has no script or ran already,
+// otherwise it should be called with the result of the script, and returns it joined with the errors of the Finally tasks.
+func (r *Runner) prepare(ctx context.Context, name string, inputs []string, padding int) (task models.Task, env []string, done func(error) error, err error) {
 	task, ok := r.tasks.Get(name)
 	if !ok {
-		return fmt.Errorf("task %s not found", name)
+		return task, nil, nil, TaskNotFoundError{Name: name}
+	}
+	if task.Deprecated != "" {
+		i18n.Printf("task %q is deprecated: %s\n", task.Name, task.Deprecated)
+	}
+	if to, ok := task.Forward(); ok {
+		return r.prepare(ctx, to, inputs, padding)
 	}
+	if !task.Supports(runtime.GOOS, runtime.GOARCH) {
+		return task, nil, nil, i18n.Errorf("task %s only runs on %s, not on %s/%s",
+			task.Name, strings.Join(task.Platforms, ", "), runtime.GOOS, runtime.GOARCH)
+	}
+	key, once := r.runKey(task, inputs)
 	r.alreadRanMu.Lock()
-	if task.RequiredBehaviour == models.RequiredBehaviourOnce && r.alreadyRan[task.Name] {
+	if previous, ok := r.alreadyRan[key]; ok && once {
 		r.alreadRanMu.Unlock()
-		fmt.Printf("task %q ran already: skipping\n", task.Name)
-		return nil
+		// A task that is required by dependencies running in parallel might still be running,
+		// the tasks that require it can only continue once it is finished.
+		select {
+		case <-previous.finished:
+		case <-ctx.Done():
+			return task, nil, nil, ctx.Err()
+		}
+		if previous.err != nil {
+			return task, nil, nil, i18n.Errorf("required task %s failed", task.Name)
+		}
+		i18n.Printf("task %q ran already: skipping\n", task.Name)
+		if len(task.Script) > 0 {
+			r.addResult(Result{Task: task.Name, Start: time.Now(), Skipped: true, SkipReason: i18n.T("ran already")})
+		}
+		return task, nil, nil, nil
 	}
-	r.alreadyRan[task.Name] = true
+	run := &taskRun{finished: make(chan struct{})}
+	r.alreadyRan[key] = run
 	r.alreadRanMu.Unlock()
-	env := os.Environ()
-	env = append(env, task.Env...)
-	inp, err := getInputs(task, inputs, env)
+	defer func() {
+		if done == nil {
+			run.done(err)
+		}
+	}()
+	if ago, ok := r.throttled(task); ok {
+		i18n.Printf("task %q succeeded %s ago, within its throttle of %s: skipping\n", task.Name, ago, task.Throttle)
+		if len(task.Script) > 0 {
+			r.addResult(Result{Task: task.Name, Start: time.Now(), Skipped: true, SkipReason: i18n.T("within its throttle")})
+		}
+		return task, nil, nil, nil
+	}
+	env, err = r.taskEnv(task, os.Environ())
 	if err != nil {
-		return err
+		return task, nil, nil, err
+	}
+	if hookEnv, ok := ctx.Value(hookEnvKey{}).([]string); ok {
+		env = append(env, hookEnv...)
 	}
+	env, err = r.interpolate(&task, env)
+	if err != nil {
+		return task, nil, nil, err
+	}
+	message, reason, err := r.skipCondition(task, env)
+	if err != nil {
+		return task, nil, nil, err
+	}
+	if reason != "" {
+		fmt.Print(message)
+		if len(task.Script) > 0 {
+			r.addResult(Result{Task: task.Name, Start: time.Now(), Skipped: true, SkipReason: reason})
+		}
+		return task, nil, nil, nil
+	}
+	inp, err := r.Inputs(task, inputs, env)
+	if err != nil {
+		return task, nil, nil, err
+	}
+	if missing := missingEnv(task, append(env, inp...)); len(missing) > 0 {
+		return task, nil, nil, i18n.Errorf("task %s requires the environment variables %s, which are not set", task.Name, strings.Join(missing, ", "))
+	}
+	// From here on the task runs, so its Finally tasks run whether or not the dependencies and script succeed.
+	defer func() {
+		if done == nil {
+			err = r.runFinally(task, padding, err)
+		}
+	}()
 	runFunc := r.runDepsSync
-	if task.DepsBehaviour == models.DependencyBehaviourAsync {
+	if r.parallelDeps(task) {
 		runFunc = r.runDepsAsync
 	}
 	if err := runFunc(ctx, padding, task.DependsOn...); err != nil {
-		return err
+		return task, nil, nil, err
 	}
 	if len(task.Script) == 0 {
-		return nil
+		return task, nil, nil, nil
+	}
+	upToDate, err := r.upToDate(task)
+	if err != nil {
+		return task, nil, nil, err
+	}
+	if upToDate {
+		i18n.Printf("task %q is up to date: skipping\n", task.Name)
+		r.addResult(Result{Task: task.Name, Start: time.Now(), Skipped: true, SkipReason: i18n.T("up to date")})
+		return task, nil, nil, nil
 	}
 	env = append(env, inp...)
+	key, cached, err := r.cached(ctx, task, env, inputs)
+	if err != nil {
+		return task, nil, nil, err
+	}
+	if cached {
+		i18n.Printf("task %q is cached: skipping\n", task.Name)
+		r.addResult(Result{Task: task.Name, Start: time.Now(), Skipped: true, SkipReason: i18n.T("cached")})
+		return task, nil, nil, nil
+	}
+	return task, env, func(err error) error {
+		err = r.runFinally(task, padding, err)
+		if err == nil && key != "" {
+			r.saveCache(ctx, task, key)
+		}
+		run.done(err)
+		return err
+	}, nil
+}
 
+// throttled returns how long ago task last succeeded, if that is within its Throttle.
+func (r *Runner) throttled(task models.Task) (ago time.Duration, ok bool) {
+	if task.Throttle <= 0 || r.throttler == nil {
+		return 0, false
+	}
+	last, ok := r.throttler(task)
+	if !ok {
+		return 0, false
+	}
+	ago = time.Since(last).Round(time.Second)
+	return ago, ago < task.Throttle
+}
+
+// execute runs the script of task with env, label names the execution in its log prefix and Result.
+func (r *Runner) execute(ctx context.Context, task models.Task, label string, env, inputs []string, padding int) error {
 	var prefix string
 	if !task.Interactive {
-		prefix = fmt.Sprintf("%*s", padding, strings.TrimSpace(task.Name))
+		prefix = fmt.Sprintf("%*s", padding, strings.TrimSpace(label))
+		if r.styles.Prefix != nil {
+			prefix = r.styles.Prefix(prefix)
+		}
+	}
+	args := scriptArgs(task, inputs)
+	e := Execution{
+		Script:      task.Script,
+		Env:         env,
+		Args:        args,
+		Dir:         r.getExecutionPath(task),
+		LogPrefix:   prefix,
+		Interpreter: r.interpreter(task),
+		Shell:       task.Shell,
+		Container:   task.Container,
+		Mount:       r.dir,
+		Remote:      r.remote(task),
+		ScriptFile:  task.ScriptFile,
+	}
+	closeFiles, err := redirect(task, &e)
+	if err != nil {
+		return err
+	}
+	defer closeFiles()
+	watchStdin(task, &e)
+	var problemWriters []*problem.Writer
+	if len(task.Problems) > 0 {
+		matchers, err := problemMatchers(task)
+		if err != nil {
+			return err
+		}
+		stdout, stderr := r.problems.Writer(task.Name, matchers), r.problems.Writer(task.Name, matchers)
+		defer stdout.Close()
+		defer stderr.Close()
+		tee(&e, stdout, stderr)
+		problemWriters = []*problem.Writer{stdout, stderr}
+	}
+	closeLog := r.logFile(&e, label)
+	defer closeLog()
+	var output *tailBuffer
+	if r.captureOutput > 0 {
+		output = &tailBuffer{max: r.captureOutput}
+		tee(&e, output, output)
+	}
+	release, err := r.resources.acquire(ctx, task.Resources)
+	if err != nil {
+		return err
+	}
+	defer release()
+	// Locks are acquired after resources, in the same order for every script, so that two scripts never wait for each other.
+	unlock, err := r.locks.acquire(ctx, task.Locks)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	releaseJob, err := r.acquireJob(ctx)
+	if err != nil {
+		return err
+	}
+	defer releaseJob()
+	flushEvents := func() {}
+	switch {
+	case task.Interactive:
+		r.promptMu.Lock()
+		defer r.promptMu.Unlock()
+	case r.events != nil:
+		flushEvents = r.eventOutput(&e, label)
+	case r.groupOutput:
+		group := &lockedBuffer{}
+		e.Output = group
+		defer group.writeTo(os.Stdout)
+	}
+	start := time.Now()
+	if r.events != nil {
+		r.events(Event{Type: EventStart, Time: start, Task: label})
 	}
-	return r.scriptRunner.Execute(ctx, task.Script, env, inputs, r.getExecutionPath(task), prefix)
+	err = r.scriptRunner.Execute(ctx, e)
+	flushEvents()
+	r.eventProblems(label, problemWriters)
+	if err != nil && !r.keepGoing {
+		r.failed.Store(true)
+	}
+	result := Result{Task: label, Start: start, Duration: time.Since(start), Err: err, Requires: r.requires(task)}
+	if output != nil {
+		result.Output = output.String()
+	}
+	r.addResult(result)
+	if r.notifier != nil && len(task.Notify) > 0 {
+		r.notifier(task, result)
+	}
+	return err
+}
+
+// tee adds writers that receive a copy of the standard output and error of e.
+func tee(e *Execution, stdout, stderr io.Writer) {
+	if e.StdoutTee != nil {
+		stdout = io.MultiWriter(e.StdoutTee, stdout)
+	}
+	if e.StderrTee != nil {
+		stderr = io.MultiWriter(e.StderrTee, stderr)
+	}
+	e.StdoutTee, e.StderrTee = stdout, stderr
+}
+
+// Problems returns the problems found in the output of the tasks that have run, see the Problems attribute.
+func (r *Runner) Problems() []problem.Problem {
+	return r.problems.Problems()
+}
+
+func problemMatchers(task models.Task) ([]problem.Matcher, error) {
+	matchers := make([]problem.Matcher, len(task.Problems))
+	for i, s := range task.Problems {
+		m, err := problem.Parse(s)
+		if err != nil {
+			return nil, i18n.Errorf("invalid problems for %s: %w", task.Name, err)
+		}
+		matchers[i] = m
+	}
+	return matchers, nil
+}
+
+// redirect opens the files of the Stdin and Stdout attributes of a task, relative to its directory.
+// The returned func closes the files.
+func redirect(task models.Task, e *Execution) (func(), error) {
+	var files []*os.File
+	closeFiles := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+	if task.Stdin != "" {
+		f, err := os.Open(resolvePath(e.Dir, task.Stdin))
+		if err != nil {
+			return closeFiles, i18n.Errorf("failed to open stdin of task %s: %w", task.Name, err)
+		}
+		files = append(files, f)
+		e.Stdin = f
+	}
+	if task.Stdout != "" {
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if task.AppendStdout {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		f, err := os.OpenFile(resolvePath(e.Dir, task.Stdout), flags, 0o644)
+		if err != nil {
+			closeFiles()
+			return func() {}, i18n.Errorf("failed to open stdout of task %s: %w", task.Name, err)
+		}
+		files = append(files, f)
+		e.Stdout = f
+	}
+	return closeFiles, nil
+}
+
+// requires returns the names of the tasks that task requires, without their inputs.
+func (r *Runner) requires(task models.Task) []string {
+	var names []string
+	for _, d := range task.DependsOn {
+		ta, err := shlex.Split(d)
+		if err != nil || len(ta) == 0 {
+			continue
+		}
+		if t, ok := r.tasks.Get(ta[0]); ok {
+			names = append(names, t.Name)
+		}
+	}
+	return names
 }
 
 func (r *Runner) runDepsSync(ctx context.Context, padding int, dependencies ...string) error {
+	var errs []error
 	for _, t := range dependencies {
 		ta, err := shlex.Split(t)
 		if err != nil {
 			return err
 		}
 		if err := r.runWithPadding(ctx, ta[0], ta[1:], padding); err != nil {
-			return err
+			if !r.keepGoing || ctx.Err() != nil {
+				return err
+			}
+			errs = append(errs, err)
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func (r *Runner) runDepsAsync(ctx context.Context, padding int, dependencies ...string) error {
@@ -176,17 +815,22 @@ func (r *Runner) runDepsAsync(ctx context.Context, padding int, dependencies ...
 	}
 
 	wg.Wait()
-	return errors.Join(errs...)
+	return started(errs)
 }
 
 func (r *Runner) getLogPadding(name string) (int, error) {
 	task, ok := r.tasks.Get(name)
 	if !ok {
-		return 0, fmt.Errorf("task %s not found", name)
+		return 0, TaskNotFoundError{Name: name}
 	}
 
-	maxLen := len(task.Name)
-	for _, depName := range task.DependsOn {
+	if to, ok := task.Forward(); ok {
+		return r.getLogPadding(to)
+	}
+	// The prefix is padded with fmt, which counts runes rather than bytes.
+	maxLen := utf8.RuneCountInString(task.Name)
+	for _, dep := range append(append([]string{}, task.DependsOn...), task.Finally...) {
+		depName, _, _ := strings.Cut(dep, " ")
 		depLen, err := r.getLogPadding(depName)
 		if err != nil {
 			return maxLen, err
@@ -199,46 +843,97 @@ func (r *Runner) getLogPadding(name string) (int, error) {
 }
 
 func (r *Runner) getExecutionPath(task models.Task) string {
-	if task.Dir == "" {
-		return r.dir
+	if dir, ok := r.dirs[task.Name]; ok {
+		return resolvePath(r.dir, dir)
+	}
+	return resolvePath(r.dir, task.Dir)
+}
+
+// WithDir runs the script of the named task in dir instead of the directory of its Dir attribute,
+// a relative dir is resolved like the Dir attribute. The tasks it requires still run in their own directories.
+func WithDir(name, dir string) Option {
+	return func(r *Runner) {
+		if r.dirs == nil {
+			r.dirs = map[string]string{}
+		}
+		r.dirs[name] = dir
+	}
+}
+
+// resolvePath resolves p relative to base.
+// Forward slashes are translated to the OS separator so that directories written
+// in Unix-centric markdown work on Windows, p is also allowed to be absolute,
+// including Windows drive letters and UNC paths.
+func resolvePath(base, p string) string {
+	if p == "" {
+		return base
 	}
-	if filepath.IsAbs(task.Dir) {
-		return task.Dir
+	p = filepath.FromSlash(p)
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
 	}
-	return filepath.Join(r.dir, task.Dir)
+	// On Windows `\dir` is rooted but not absolute, it refers to the root of the current drive.
+	if filepath.VolumeName(p) == "" && strings.HasPrefix(p, string(filepath.Separator)) {
+		return filepath.Join(filepath.VolumeName(base), p)
+	}
+	return filepath.Join(base, p)
 }
 
 // ValidateDependencies checks that task dependencies follow these rules:
 // - No deeper dependency trees than maxDeps.
 // - Dependencies must exist as tasks.
-// - No cyclical dependencies.
+// - No cyclical dependencies, a CycleError is returned with the whole cycle.
+//
+// prevTasks holds the tasks that require task, the closest first.
 func (r *Runner) ValidateDependencies(task string, prevTasks []string) error {
-	if len(prevTasks) >= maxDeps {
-		return fmt.Errorf("max dependency depth of %d reached", maxDeps)
+	path := make([]string, 0, len(prevTasks)+1)
+	for i := len(prevTasks) - 1; i >= 0; i-- {
+		path = append(path, prevTasks[i])
+	}
+	return r.validateDependencies(task, append(path, task))
+}
+
+// validateDependencies validates the dependencies of task, path holds the tasks that require it in order, ending with task.
+func (r *Runner) validateDependencies(task string, path []string) error {
+	if len(path) > maxDeps {
+		return i18n.Errorf("max dependency depth of %d reached", maxDeps)
 	}
 	// Check exists
 	t, ok := r.tasks.Get(task)
 	if !ok {
-		return fmt.Errorf("task %s not found", task)
+		return TaskNotFoundError{Name: task}
 	}
 	if t.ParsingError != "" {
-		return fmt.Errorf("task %s has a parsing error: %s", task, t.ParsingError)
+		return i18n.Errorf("task %s has a parsing error: %s", task, t.ParsingError)
+	}
+	deps := append(append([]string{}, t.DependsOn...), t.Finally...)
+	if to, ok := t.Forward(); ok {
+		deps = []string{to}
 	}
-	for _, t := range t.DependsOn {
+	for _, t := range deps {
 		t, _, _ := strings.Cut(t, " ")
 		st, ok := r.tasks.Get(t)
 		if !ok {
-			return fmt.Errorf("task %s not found", t)
+			return TaskNotFoundError{Name: t}
 		}
-		for _, pt := range prevTasks {
+		for i, pt := range path {
 			if pt == st.Name {
-				return fmt.Errorf("task %s contains a circular dependency", t)
+				return r.cycleError(append(append([]string{}, path[i:]...), st.Name))
 			}
 		}
-		err := r.ValidateDependencies(st.Name, append([]string{st.Name}, prevTasks...))
+		err := r.validateDependencies(st.Name, append(append([]string{}, path...), st.Name))
 		if err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// cycleError returns a CycleError with the tasks of names.
+func (r *Runner) cycleError(names []string) CycleError {
+	e := CycleError{Tasks: make([]models.Task, len(names))}
+	for i, name := range names {
+		e.Tasks[i], _ = r.tasks.Get(name)
+	}
+	return e
+}
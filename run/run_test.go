@@ -3,11 +3,20 @@ package run
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
 	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/joerdav/xc/models"
+	"mvdan.cc/sh/v3/interp"
 )
 
 type mockScriptRunner struct {
@@ -16,9 +25,7 @@ type mockScriptRunner struct {
 	runnerMutex sync.Mutex
 }
 
-func (r *mockScriptRunner) Execute(
-	ctx context.Context, text string, env, args []string, dir, logPrefix string,
-) error {
+func (r *mockScriptRunner) Execute(ctx context.Context, e Execution) error {
 	r.runnerMutex.Lock()
 	defer r.runnerMutex.Unlock()
 	r.calls++
@@ -199,6 +206,67 @@ func TestRunAsync(t *testing.T) {
 		})
 	}
 }
+
+// orderScriptRunner records when each script starts and ends, the setup script is slow and fails if fail is set.
+type orderScriptRunner struct {
+	mu     sync.Mutex
+	events []string
+	fail   bool
+}
+
+func (r *orderScriptRunner) Execute(ctx context.Context, e Execution) error {
+	r.mu.Lock()
+	r.events = append(r.events, "start "+e.Script)
+	r.mu.Unlock()
+	if e.Script == "setup" {
+		time.Sleep(20 * time.Millisecond)
+	}
+	r.mu.Lock()
+	r.events = append(r.events, "end "+e.Script)
+	r.mu.Unlock()
+	if e.Script == "setup" && r.fail {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func TestRunAsyncSharedDependency(t *testing.T) {
+	tasks := models.Tasks{
+		{Name: "setup", Script: "setup", RequiredBehaviour: models.RequiredBehaviourOnce},
+		{Name: "a", Script: "a", DependsOn: []string{"setup"}},
+		{Name: "b", Script: "b", DependsOn: []string{"setup"}},
+		{Name: "all", DependsOn: []string{"a", "b"}, DepsBehaviour: models.DependencyBehaviourAsync},
+	}
+	t.Run("given a dependency shared by parallel tasks, should run it once before both", func(t *testing.T) {
+		runner, err := NewRunner(tasks, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		scriptRunner := &orderScriptRunner{}
+		runner.scriptRunner = scriptRunner
+		if err := runner.Run(context.Background(), "all", nil); err != nil {
+			t.Fatal(err)
+		}
+		if len(scriptRunner.events) != 6 || strings.Join(scriptRunner.events[:2], ",") != "start setup,end setup" {
+			t.Fatalf("expected setup to finish first got %q", scriptRunner.events)
+		}
+	})
+	t.Run("given a failing shared dependency, should not run the tasks that require it", func(t *testing.T) {
+		runner, err := NewRunner(tasks, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		scriptRunner := &orderScriptRunner{fail: true}
+		runner.scriptRunner = scriptRunner
+		if err := runner.Run(context.Background(), "all", nil); err == nil {
+			t.Fatal("expected error got nil")
+		}
+		if strings.Join(scriptRunner.events, ",") != "start setup,end setup" {
+			t.Fatalf("expected only setup to run got %q", scriptRunner.events)
+		}
+	})
+}
+
 func TestRun(t *testing.T) {
 	for _, tt := range testCases() {
 		tt := tt
@@ -284,4 +352,753 @@ func TestRunWithInputs(t *testing.T) {
 			t.Fatal("task was not run")
 		}
 	})
+	choiceTasks := models.Tasks{
+		{
+			Name:       "task",
+			Script:     "somecmd",
+			Inputs:     []string{"ENV"},
+			InputSpecs: map[string]models.InputSpec{"ENV": {Choices: []string{"dev", "prod"}}},
+		},
+	}
+	t.Run("given an input with choices is provided a choice, run the task", func(t *testing.T) {
+		runner, err := NewRunner(choiceTasks, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		scriptRunner := &mockScriptRunner{}
+		runner.scriptRunner = scriptRunner
+		err = runner.Run(context.Background(), "task", []string{"prod"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if scriptRunner.calls != 1 {
+			t.Fatal("task was not run")
+		}
+	})
+	t.Run("given an input with choices is provided another value, return an error", func(t *testing.T) {
+		runner, err := NewRunner(choiceTasks, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		scriptRunner := &mockScriptRunner{}
+		runner.scriptRunner = scriptRunner
+		err = runner.Run(context.Background(), "task", []string{"staging"})
+		if err == nil {
+			t.Fatal("expected an error got none")
+		}
+		if scriptRunner.calls != 0 {
+			t.Fatal("task was run")
+		}
+	})
+	t.Run("given an input with choices is provided another value as an environment variable, return an error", func(t *testing.T) {
+		runner, err := NewRunner(choiceTasks, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.Setenv("ENV", "staging")
+		defer os.Unsetenv("ENV")
+		err = runner.Run(context.Background(), "task", nil)
+		if err == nil {
+			t.Fatal("expected an error got none")
+		}
+	})
+}
+
+func TestRunPassThrough(t *testing.T) {
+	pkg := "./..."
+	task := models.Task{Name: "test", Script: "go test", Inputs: []string{"PKG"}}
+	task.SetInput("PKG", models.InputSpec{Default: &pkg})
+	tests := []struct {
+		name         string
+		args         []string
+		expectedPKG  string
+		expectedArgs []string
+	}{
+		{name: "given no arguments, should use the default", expectedPKG: "./..."},
+		{name: "given arguments after --, should pass them to the script", args: []string{"--", "-run", "TestFoo", "-v"}, expectedPKG: "./...", expectedArgs: []string{"-run", "TestFoo", "-v"}},
+		{name: "given inputs before --, should use them", args: []string{"./run", "--", "-v"}, expectedPKG: "./run", expectedArgs: []string{"./run", "-v"}},
+		{name: "given NAME=value after --, should not be an input", args: []string{"--", "PKG=./cmd"}, expectedPKG: "./...", expectedArgs: []string{"PKG=./cmd"}},
+		{name: "given -- after --, should pass it to the script", args: []string{"--", "a", "--", "b"}, expectedPKG: "./...", expectedArgs: []string{"a", "--", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := NewRunner(models.Tasks{task}, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			scriptRunner := &executionScriptRunner{}
+			runner.scriptRunner = scriptRunner
+			if err := runner.Run(context.Background(), "test", tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if got := environmentValue(scriptRunner.last.Env, "PKG"); got != tt.expectedPKG {
+				t.Fatalf("PKG want=%q got=%q", tt.expectedPKG, got)
+			}
+			if strings.Join(scriptRunner.last.Args, " ") != strings.Join(tt.expectedArgs, " ") {
+				t.Fatalf("args want=%q got=%q", tt.expectedArgs, scriptRunner.last.Args)
+			}
+		})
+	}
+}
+
+// executionScriptRunner records the last execution.
+type executionScriptRunner struct {
+	last Execution
+}
+
+func (r *executionScriptRunner) Execute(ctx context.Context, e Execution) error {
+	r.last = e
+	return nil
+}
+
+func TestRunWithTypedInputs(t *testing.T) {
+	replicas, dryRun := "2", "false"
+	task := models.Task{Name: "deploy", Script: "deploy", Inputs: []string{"ENV", "REPLICAS", "DRY_RUN"}}
+	task.SetInput("ENV", models.InputSpec{Choices: []string{"dev", "prod"}})
+	task.SetInput("REPLICAS", models.InputSpec{Type: models.InputTypeInt, Default: &replicas})
+	task.SetInput("DRY_RUN", models.InputSpec{Type: models.InputTypeBool, Default: &dryRun})
+	tests := []struct {
+		name         string
+		args         []string
+		expectedEnv  []string
+		expectedArgs []string
+		expectedErr  string
+	}{
+		{
+			name:         "given positional inputs, should use the defaults for the rest",
+			args:         []string{"dev"},
+			expectedEnv:  []string{"ENV=dev", "REPLICAS=2", "DRY_RUN=false"},
+			expectedArgs: []string{"dev"},
+		},
+		{
+			name:        "given named inputs in any case, should set them and fill the rest by position",
+			args:        []string{"dry_run=true", "prod", "replicas=3"},
+			expectedEnv: []string{"ENV=prod", "REPLICAS=3", "DRY_RUN=true"},
+			// Named inputs aren't passed as positional arguments.
+			expectedArgs: []string{"prod"},
+		},
+		{
+			name:        "given a value that isn't an integer, should error before running",
+			args:        []string{"env=dev", "REPLICAS=many"},
+			expectedErr: `invalid value "many" for input REPLICAS of task deploy, should be an integer`,
+		},
+		{
+			name:        "given a value that isn't a bool, should error before running",
+			args:        []string{"dev", "1", "maybe"},
+			expectedErr: `invalid value "maybe" for input DRY_RUN of task deploy, should be true or false`,
+		},
+		{
+			name:        "given a value that isn't a choice, should error before running",
+			args:        []string{"env=qa"},
+			expectedErr: `invalid value "qa" for input ENV of task deploy, should be one of (dev, prod)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := NewRunner(models.Tasks{task}, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			scriptRunner := &executionScriptRunner{}
+			runner.scriptRunner = scriptRunner
+			err = runner.Run(context.Background(), "deploy", tt.args)
+			if tt.expectedErr != "" {
+				if err == nil || err.Error() != tt.expectedErr {
+					t.Fatalf("expected error %q got %v", tt.expectedErr, err)
+				}
+				if scriptRunner.last.Script != "" {
+					t.Fatal("task was run")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			env := scriptRunner.last.Env
+			if got := env[len(env)-len(tt.expectedEnv):]; !reflect.DeepEqual(got, tt.expectedEnv) {
+				t.Fatalf("env want=%q got=%q", tt.expectedEnv, got)
+			}
+			if !reflect.DeepEqual(scriptRunner.last.Args, tt.expectedArgs) {
+				t.Fatalf("args want=%q got=%q", tt.expectedArgs, scriptRunner.last.Args)
+			}
+		})
+	}
+}
+
+func TestRunWithPrompts(t *testing.T) {
+	tasks := models.Tasks{
+		{
+			Name:   "task",
+			Script: "somecmd",
+			Inputs: []string{"ENV"},
+			InputSpecs: map[string]models.InputSpec{"ENV": {
+				Choices: []string{"dev", "prod"},
+				Prompt:  &models.Prompt{Question: "Which environment?", Default: "dev"},
+			}},
+		},
+	}
+	tests := []struct {
+		name             string
+		answer           string
+		promptErr        error
+		expectedRunError bool
+	}{
+		{name: "given an answer, run the task", answer: "prod"},
+		{name: "given no answer, use the default", answer: ""},
+		{name: "given an invalid answer, return an error", answer: "staging", expectedRunError: true},
+		{name: "given the prompt fails, return an error", promptErr: errors.New("no terminal"), expectedRunError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var asked string
+			runner, err := NewRunner(tasks, "", WithPrompter(func(task models.Task, input string, p models.Prompt) (string, error) {
+				asked = p.Question
+				return tt.answer, tt.promptErr
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			scriptRunner := &mockScriptRunner{}
+			runner.scriptRunner = scriptRunner
+			err = runner.Run(context.Background(), "task", nil)
+			if (err != nil) != tt.expectedRunError {
+				t.Fatalf("expected error %v, got %v", tt.expectedRunError, err)
+			}
+			if asked != "Which environment?" {
+				t.Fatalf("question want=%q got=%q", "Which environment?", asked)
+			}
+			if !tt.expectedRunError && scriptRunner.calls != 1 {
+				t.Fatal("task was not run")
+			}
+		})
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	base := filepath.Join("project", "root")
+	abs, err := filepath.Abs("somewhere")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, dir, expected string
+	}{
+		{name: "given no dir, should use base", dir: "", expected: base},
+		{name: "given a relative dir, should join with base", dir: "sub", expected: filepath.Join(base, "sub")},
+		{name: "given a forward slash dir, should translate separators", dir: "a/b/c", expected: filepath.Join(base, "a", "b", "c")},
+		{name: "given a dot dir, should clean", dir: "./a/../b", expected: filepath.Join(base, "b")},
+		{name: "given an absolute dir, should use dir", dir: abs, expected: abs},
+		{name: "given an absolute forward slash dir, should use dir", dir: filepath.ToSlash(abs), expected: abs},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolvePath(base, tt.dir); got != tt.expected {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "in.txt"), []byte("input"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "log.txt"), []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		task     models.Task
+		file     string
+		expected string
+		err      bool
+	}{
+		{name: "given stdout, should truncate", task: models.Task{Stdout: "log.txt"}, file: "log.txt", expected: "second\n"},
+		{name: "given append stdout, should append", task: models.Task{Stdout: "log.txt", AppendStdout: true}, file: "log.txt", expected: "second\nsecond\n"},
+		{name: "given a new stdout, should create", task: models.Task{Stdout: "new.txt"}, file: "new.txt", expected: "second\n"},
+		{name: "given a missing stdin, should error", task: models.Task{Stdin: "missing.txt"}, err: true},
+	}
+	for _, tt := range tests {
+		e := Execution{Dir: dir}
+		closeFiles, err := redirect(tt.task, &e)
+		if (err != nil) != tt.err {
+			t.Fatalf("%s: expected error %v, got %v", tt.name, tt.err, err)
+		}
+		if err != nil {
+			closeFiles()
+			continue
+		}
+		if _, err := e.Stdout.Write([]byte("second\n")); err != nil {
+			t.Fatal(err)
+		}
+		closeFiles()
+		b, err := os.ReadFile(filepath.Join(dir, tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != tt.expected {
+			t.Fatalf("%s: want=%q got=%q", tt.name, tt.expected, string(b))
+		}
+	}
+	e := Execution{Dir: dir}
+	closeFiles, err := redirect(models.Task{Stdin: "in.txt"}, &e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeFiles()
+	b, err := io.ReadAll(e.Stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "input" {
+		t.Fatalf("want=%q got=%q", "input", string(b))
+	}
+}
+
+func TestInheritedEnv(t *testing.T) {
+	env := []string{"PATH=/bin", "HOME=/root", "GOPATH=/go", "GOFLAGS=-mod=mod", "SECRET=shh", "VERSION=1.2"}
+	tests := []struct {
+		name     string
+		task     models.Task
+		expected []string
+	}{
+		{name: "given no InheritEnv, should inherit everything", task: models.Task{}, expected: env},
+		{name: "given an empty InheritEnv, should inherit nothing", task: models.Task{InheritEnv: []string{}}},
+		{
+			name:     "given names and wildcards, should inherit matches",
+			task:     models.Task{InheritEnv: []string{"PATH", "GO*"}},
+			expected: []string{"PATH=/bin", "GOPATH=/go", "GOFLAGS=-mod=mod"},
+		},
+		{
+			name:     "given inputs, should inherit inputs",
+			task:     models.Task{InheritEnv: []string{"HOME"}, Inputs: []string{"VERSION"}},
+			expected: []string{"HOME=/root", "VERSION=1.2"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inheritedEnv(tt.task, env); !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("want=%v got=%v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	env := []string{"HOME=/root", "PATH=/bin"}
+	tests := []struct {
+		name     string
+		vars     []string
+		expected []string
+	}{
+		{name: "given literal values, should append them", vars: []string{"A=1"}, expected: []string{"HOME=/root", "PATH=/bin", "A=1"}},
+		{
+			name:     "given references to the environment, should expand them",
+			vars:     []string{"BIN=$HOME/bin", "DIR=${HOME}/dir"},
+			expected: []string{"HOME=/root", "PATH=/bin", "BIN=/root/bin", "DIR=/root/dir"},
+		},
+		{
+			name:     "given references to earlier variables, should expand them",
+			vars:     []string{"BIN=$HOME/bin", "PATH=$BIN:$PATH"},
+			expected: []string{"HOME=/root", "PATH=/bin", "BIN=/root/bin", "PATH=/root/bin:/bin"},
+		},
+		{name: "given an unset variable, should expand it to nothing", vars: []string{"A=x$MISSING"}, expected: []string{"HOME=/root", "PATH=/bin", "A=x"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandEnv(env, tt.vars); !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("want=%v got=%v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// echoScriptRunner writes the script to the output of the execution, and fails if the script is "fail".
+type echoScriptRunner struct{}
+
+func (echoScriptRunner) Execute(ctx context.Context, e Execution) error {
+	if e.StdoutTee != nil {
+		if _, err := io.WriteString(e.StdoutTee, e.Script); err != nil {
+			return err
+		}
+	}
+	if e.Script == "fail" {
+		return errors.New("exit status 1")
+	}
+	return nil
+}
+
+func TestResults(t *testing.T) {
+	tasks := models.Tasks{
+		{Name: "setup", Script: "setup", RequiredBehaviour: models.RequiredBehaviourOnce},
+		{Name: "build", Script: "build output", DependsOn: []string{"setup"}},
+		{Name: "test", Script: "fail", DependsOn: []string{"setup", "build"}},
+		{Name: "all", DependsOn: []string{"test"}},
+	}
+	runner, err := NewRunner(tasks, "", WithOutputCapture(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.scriptRunner = echoScriptRunner{}
+	if err := runner.Run(context.Background(), "all", nil); err == nil {
+		t.Fatal("expected error got nil")
+	}
+	results := runner.Results()
+	expected := []struct {
+		task, output    string
+		failed, skipped bool
+	}{
+		{task: "setup", output: "setup"},
+		{task: "setup", skipped: true},
+		{task: "build", output: "output"},
+		{task: "test", output: "fail", failed: true},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results got %+v", len(expected), results)
+	}
+	for i, e := range expected {
+		r := results[i]
+		if r.Task != e.task || r.Output != e.output || (r.Err != nil) != e.failed || r.Skipped != e.skipped {
+			t.Fatalf("result %d: want=%+v got=%+v", i, e, r)
+		}
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	tasks := models.Tasks{
+		{Name: "build", Script: "build"},
+		{Name: "deploy", Script: "fail", Notify: []string{"desktop"}, DependsOn: []string{"build"}},
+	}
+	var notified []Result
+	runner, err := NewRunner(tasks, "", WithNotifier(func(task models.Task, r Result) {
+		notified = append(notified, r)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.scriptRunner = echoScriptRunner{}
+	if err := runner.Run(context.Background(), "deploy", nil); err == nil {
+		t.Fatal("expected error got nil")
+	}
+	if len(notified) != 1 || notified[0].Task != "deploy" || notified[0].Err == nil {
+		t.Fatalf("expected the failure of deploy to be notified got %+v", notified)
+	}
+}
+
+func TestThrottler(t *testing.T) {
+	tasks := models.Tasks{
+		{Name: "fixtures", Script: "download", Throttle: time.Hour, DependsOn: []string{"setup"}},
+		{Name: "setup", Script: "setup"},
+		{Name: "deps", Script: "update", Throttle: time.Hour},
+		{Name: "test", Script: "test", DependsOn: []string{"fixtures", "deps"}},
+	}
+	succeeded := map[string]time.Time{
+		"fixtures": time.Now().Add(-10 * time.Minute),
+		"deps":     time.Now().Add(-2 * time.Hour),
+	}
+	runner, err := NewRunner(tasks, "", WithThrottler(func(task models.Task) (time.Time, bool) {
+		last, ok := succeeded[task.Name]
+		return last, ok
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.scriptRunner = echoScriptRunner{}
+	if err := runner.Run(context.Background(), "test", nil); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range runner.Results() {
+		if r.Skipped {
+			got = append(got, "skipped "+r.Task)
+			continue
+		}
+		got = append(got, r.Task)
+	}
+	// fixtures and its dependencies are skipped, deps succeeded too long ago.
+	expected := []string{"skipped fixtures", "deps", "test"}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Fatalf("want=%q got=%q", expected, got)
+	}
+}
+
+func TestRunDeprecated(t *testing.T) {
+	tasks := models.Tasks{
+		{Name: "deploy", Script: "deploy $ENV", Inputs: []string{"ENV"}},
+		{Name: "ship", Deprecated: "use deploy"},
+		{Name: "push", Script: "push", Deprecated: "use deploy"},
+	}
+	tests := []struct {
+		name     string
+		task     string
+		expected []string
+	}{
+		{name: "given a deprecated task without a script, should run its replacement with the inputs", task: "ship", expected: []string{"deploy"}},
+		{name: "given a deprecated task with a script, should run the script", task: "push", expected: []string{"push"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := NewRunner(tasks, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner.scriptRunner = echoScriptRunner{}
+			if err := runner.Run(context.Background(), tt.task, []string{"prod"}); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range runner.Results() {
+				got = append(got, r.Task)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+	t.Run("given a replacement that doesn't exist, should error", func(t *testing.T) {
+		if _, err := NewRunner(models.Tasks{{Name: "ship", Deprecated: "use missing"}}, ""); err == nil {
+			t.Fatal("expected error got nil")
+		}
+	})
+}
+
+func TestRunRequiresEnv(t *testing.T) {
+	t.Setenv("XC_TEST_SET", "1")
+	t.Setenv("XC_TEST_EMPTY", "")
+	tasks := models.Tasks{
+		{Name: "dep", Script: "dep"},
+		{Name: "set", Script: "set", RequiresEnv: []string{"XC_TEST_SET"}, InheritEnv: []string{}},
+		{Name: "env", Script: "env", RequiresEnv: []string{"XC_TEST_NEW"}, Env: []string{"XC_TEST_NEW=1"}},
+		{Name: "missing", Script: "missing", DependsOn: []string{"dep"}, RequiresEnv: []string{"XC_TEST_SET", "XC_TEST_EMPTY", "XC_TEST_UNSET"}},
+	}
+	tests := []struct {
+		name     string
+		task     string
+		expected []string
+		err      string
+	}{
+		{name: "given a set variable, should run even if it isn't inherited otherwise", task: "set", expected: []string{"set"}},
+		{name: "given a variable set by the task, should run", task: "env", expected: []string{"env"}},
+		{
+			name: "given missing variables, should list them and not run the deps",
+			task: "missing",
+			err:  "task missing requires the environment variables XC_TEST_EMPTY, XC_TEST_UNSET, which are not set",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := NewRunner(tasks, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner.scriptRunner = echoScriptRunner{}
+			err = runner.Run(context.Background(), tt.task, nil)
+			if (err != nil) != (tt.err != "") || (err != nil && err.Error() != tt.err) {
+				t.Fatalf("expected error %q got %v", tt.err, err)
+			}
+			var got []string
+			for _, r := range runner.Results() {
+				got = append(got, r.Task)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRunPlatform(t *testing.T) {
+	tasks := models.Tasks{
+		{Name: "native", Script: "native", Platforms: []string{"plan9", runtime.GOOS + "/" + runtime.GOARCH}},
+		{Name: "other", Script: "other", Platforms: []string{"plan9/arm"}},
+		{Name: "all", DependsOn: []string{"native", "other"}},
+	}
+	tests := []struct {
+		name     string
+		task     string
+		expected []string
+		err      bool
+	}{
+		{name: "given a supported platform, should run", task: "native", expected: []string{"native"}},
+		{name: "given an unsupported platform, should error", task: "other", err: true},
+		{name: "given an unsupported dependency, should error", task: "all", expected: []string{"native"}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := NewRunner(tasks, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner.scriptRunner = echoScriptRunner{}
+			err = runner.Run(context.Background(), tt.task, nil)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v got %v", tt.err, err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "only runs on plan9/arm") {
+				t.Fatalf("unexpected error %v", err)
+			}
+			var got []string
+			for _, r := range runner.Results() {
+				got = append(got, r.Task)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRunIf(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cert.pem"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tasks := models.Tasks{
+		{Name: "certs", Script: "certs", If: `!fileExists("cert.pem")`},
+		{Name: "keys", Script: "keys", If: `!fileExists("key.pem")`},
+		{Name: "deploy", Script: "deploy", If: `env("STAGE") == "prod"`, Env: []string{"STAGE=dev"}, DependsOn: []string{"keys"}},
+		{Name: "all", DependsOn: []string{"certs", "keys"}},
+		{Name: "lint", Script: "lint", Skip: `env("CI")`, Env: []string{"CI=true"}},
+		{Name: "docs", Script: "docs", Skip: `os() == "plan9"`},
+		{Name: "build", DependsOn: []string{"build:linux-arm64", "build:windows-arm64"}},
+		{Name: "build:linux-arm64", Script: "build", Env: []string{"GOOS=linux", "GOARCH=arm64"}, MatrixExclude: `env("GOOS") == "windows" && env("GOARCH") == "arm64"`},
+		{Name: "build:windows-arm64", Script: "build", Env: []string{"GOOS=windows", "GOARCH=arm64"}, MatrixExclude: `env("GOOS") == "windows" && env("GOARCH") == "arm64"`},
+	}
+	tests := []struct {
+		name     string
+		task     string
+		expected []string
+		skipped  []string
+		reason   string
+	}{
+		{name: "given a false condition, should skip the task", task: "certs", skipped: []string{"certs"}},
+		{name: "given a true condition, should run the task", task: "keys", expected: []string{"keys"}},
+		{name: "given a false condition, should skip the task and its dependencies", task: "deploy", skipped: []string{"deploy"}},
+		{name: "given conditional dependencies, should only run those that hold", task: "all", expected: []string{"keys"}, skipped: []string{"certs"}},
+		{name: "given a true skip condition, should skip the task", task: "lint", skipped: []string{"lint"}, reason: "skip condition is true"},
+		{name: "given a false skip condition, should run the task", task: "docs", expected: []string{"docs"}},
+		{name: "given an excluded variant of a matrix, should skip it", task: "build", expected: []string{"build:linux-arm64"}, skipped: []string{"build:windows-arm64"}, reason: "excluded from the matrix"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := NewRunner(tasks, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner.scriptRunner = echoScriptRunner{}
+			if err := runner.Run(context.Background(), tt.task, nil); err != nil {
+				t.Fatal(err)
+			}
+			var ran, skipped []string
+			for _, r := range runner.Results() {
+				if r.Skipped {
+					skipped = append(skipped, r.Task)
+					reason := tt.reason
+					if reason == "" {
+						reason = "condition is false"
+					}
+					if r.SkipReason != reason {
+						t.Fatalf("skip reason want=%q got=%q", reason, r.SkipReason)
+					}
+					continue
+				}
+				ran = append(ran, r.Task)
+			}
+			if strings.Join(ran, ",") != strings.Join(tt.expected, ",") || strings.Join(skipped, ",") != strings.Join(tt.skipped, ",") {
+				t.Fatalf("ran want=%q got=%q, skipped want=%q got=%q", tt.expected, ran, tt.skipped, skipped)
+			}
+		})
+	}
+}
+
+func TestValidateDependencies(t *testing.T) {
+	tests := []struct {
+		name        string
+		tasks       models.Tasks
+		taskName    string
+		expectedErr string
+	}{
+		{
+			name: "given tasks without a cycle, should not fail",
+			tasks: models.Tasks{
+				{Name: "build", DependsOn: []string{"generate", "lint"}},
+				{Name: "generate", DependsOn: []string{"lint"}},
+				{Name: "lint"},
+			},
+			taskName: "build",
+		},
+		{
+			name: "given a task that requires itself, should report the cycle",
+			tasks: models.Tasks{
+				{Name: "build", DependsOn: []string{"build"}, Line: 3},
+			},
+			taskName:    "build",
+			expectedErr: "dependency cycle: build -> build\n  build is defined on line 3",
+		},
+		{
+			name: "given a cycle, should report the whole path and where each task is defined",
+			tasks: models.Tasks{
+				{Name: "build", DependsOn: []string{"generate"}, Line: 3},
+				{Name: "generate", DependsOn: []string{"codegen ./..."}, Line: 8},
+				{Name: "codegen", DependsOn: []string{"build"}, File: "tools/README.md", Line: 12},
+			},
+			taskName:    "build",
+			expectedErr: "dependency cycle: build -> generate -> codegen -> build\n  build is defined on line 3\n  generate is defined on line 8\n  codegen is defined at tools/README.md:12",
+		},
+		{
+			name: "given a cycle below the task, should report only the cycle",
+			tasks: models.Tasks{
+				{Name: "release", DependsOn: []string{"build"}, Line: 1},
+				{Name: "build", Finally: []string{"clean"}, Line: 5},
+				{Name: "clean", DependsOn: []string{"build"}, Line: 9},
+			},
+			taskName:    "release",
+			expectedErr: "dependency cycle: build -> clean -> build\n  build is defined on line 5\n  clean is defined on line 9",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Runner{tasks: tt.tasks}
+			err := r.ValidateDependencies(tt.taskName, []string{})
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if !errors.As(err, &CycleError{}) || err.Error() != tt.expectedErr {
+				t.Fatalf("want err=%q got=%v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "given no error, should be 0", expected: 0},
+		{name: "given an error without an exit status, should be 1", err: errors.New("failed"), expected: 1},
+		{name: "given a task that isn't found, should be the not found code", err: fmt.Errorf("xc: %w", TaskNotFoundError{Name: "build"}), expected: ExitCodeNotFound},
+		{name: "given a dependency cycle, should be the parse code", err: CycleError{Tasks: []models.Task{{Name: "build"}, {Name: "build"}}}, expected: ExitCodeParse},
+		{name: "given a failed script and a task that isn't found, should be the status", err: errors.Join(TaskNotFoundError{Name: "build"}, interp.NewExitStatus(2)), expected: 2},
+		{name: "given an interrupt, should be 128 plus the signal", err: fmt.Errorf("xc: %w", errors.Join(Interrupted{Signal: syscall.SIGINT}, interp.NewExitStatus(1))), expected: 130},
+		{name: "given a wrapped exit status, should be the status", err: fmt.Errorf("xc: %w", interp.NewExitStatus(3)), expected: 3},
+		{name: "given a deadline that passed, should be the timeout code", err: fmt.Errorf("xc: %w", errors.Join(interp.NewExitStatus(1), context.DeadlineExceeded)), expected: ExitCodeTimeout},
+		{name: "given joined errors, should be the highest status", err: fmt.Errorf("xc: %w", errors.Join(interp.NewExitStatus(1), errors.New("other"), interp.NewExitStatus(4))), expected: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.expected {
+				t.Fatalf("want=%d got=%d", tt.expected, got)
+			}
+		})
+	}
 }
@@ -0,0 +1,51 @@
+package run
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RunAll runs the named tasks without inputs, in order, or at the same time when the dependency graph runs in parallel,
+// see WithJobs. From then on a task that is required with the same inputs by more than one task runs once,
+// as it does when the dependency graph runs in parallel.
+// Once a task fails the named tasks that haven't started aren't run, unless WithKeepGoing.
+//
+// It returns the Result of each named task in the order of names, which took the time of the task and its dependencies.
+// The Err of the tasks that weren't run is ErrNotStarted.
+func (r *Runner) RunAll(ctx context.Context, names []string) ([]Result, error) {
+	r.shareDeps = true
+	results := make([]Result, len(names))
+	runOne := func(i int) {
+		results[i] = Result{Task: names[i], Start: time.Now()}
+		if r.failed.Load() {
+			results[i].Err = ErrNotStarted
+			return
+		}
+		results[i].Err = r.Run(ctx, names[i], nil)
+		results[i].Duration = time.Since(results[i].Start)
+	}
+	if r.parallel {
+		var wg sync.WaitGroup
+		for i := range names {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				runOne(i)
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := range names {
+			runOne(i)
+			if results[i].Err != nil && !r.keepGoing {
+				r.failed.Store(true)
+			}
+		}
+	}
+	errs := make([]error, len(results))
+	for i, res := range results {
+		errs[i] = res.Err
+	}
+	return results, started(errs)
+}
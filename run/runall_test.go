@@ -0,0 +1,90 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/joerdav/xc/models"
+)
+
+func TestRunAll(t *testing.T) {
+	tasks := models.Tasks{
+		{Name: "gen", Script: "gen"},
+		{Name: "lint", Script: "lint", DependsOn: []string{"gen"}},
+		{Name: "test", Script: "fail", DependsOn: []string{"gen"}},
+		{Name: "build", Script: "build", DependsOn: []string{"gen"}},
+	}
+	tests := []struct {
+		name            string
+		names           []string
+		opts            []Option
+		expectedScripts []string
+		expectedResults []string
+		sortScripts     bool
+	}{
+		{
+			name:            "given tasks with a common dependency, should run it once",
+			names:           []string{"lint", "build"},
+			expectedScripts: []string{"gen", "lint", "build"},
+			expectedResults: []string{"lint ok", "build ok"},
+		},
+		{
+			name:            "given a failing task, should not start the next tasks",
+			names:           []string{"lint", "test", "build"},
+			expectedScripts: []string{"gen", "lint", "fail"},
+			expectedResults: []string{"lint ok", "test failed", "build not started"},
+		},
+		{
+			name:            "given keep going, should run the tasks after a failing task",
+			names:           []string{"lint", "test", "build"},
+			opts:            []Option{WithKeepGoing()},
+			expectedScripts: []string{"gen", "lint", "fail", "build"},
+			expectedResults: []string{"lint ok", "test failed", "build ok"},
+		},
+		{
+			name:            "given jobs, should run the tasks at the same time with the dependency once",
+			names:           []string{"lint", "build"},
+			opts:            []Option{WithJobs(4)},
+			expectedScripts: []string{"build", "gen", "lint"},
+			expectedResults: []string{"lint ok", "build ok"},
+			sortScripts:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := NewRunner(tasks, "", tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			scriptRunner := &hookScriptRunner{}
+			runner.scriptRunner = scriptRunner
+			results, err := runner.RunAll(context.Background(), tt.names)
+			if expectErr := strings.Contains(strings.Join(tt.expectedResults, ","), "failed"); (err != nil) != expectErr {
+				t.Fatalf("expected error %v got %v", expectErr, err)
+			}
+			if tt.sortScripts {
+				sort.Strings(scriptRunner.scripts)
+			}
+			if strings.Join(scriptRunner.scripts, ",") != strings.Join(tt.expectedScripts, ",") {
+				t.Fatalf("scripts want=%q got=%q", tt.expectedScripts, scriptRunner.scripts)
+			}
+			var got []string
+			for _, r := range results {
+				switch {
+				case errors.Is(r.Err, ErrNotStarted):
+					got = append(got, r.Task+" not started")
+				case r.Err != nil:
+					got = append(got, r.Task+" failed")
+				default:
+					got = append(got, r.Task+" ok")
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expectedResults, ",") {
+				t.Fatalf("results want=%q got=%q", tt.expectedResults, got)
+			}
+		})
+	}
+}
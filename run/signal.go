@@ -0,0 +1,64 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/joerdav/xc/i18n"
+)
+
+// Interrupted is the cause of a context that was cancelled because xc received a signal, see context.WithCancelCause.
+// The scripts that are running are sent the same signal.
+type Interrupted struct {
+	Signal os.Signal
+}
+
+func (i Interrupted) Error() string {
+	return i18n.Sprintf("%s signal received", i.Signal)
+}
+
+// WithGracePeriod sets how long a script is given to exit after it is interrupted, before it is killed.
+// The default is 2 seconds.
+func WithGracePeriod(d time.Duration) Option {
+	return func(r *Runner) {
+		r.gracePeriod = d
+	}
+}
+
+// cancelSignal returns the signal to send to the scripts of a cancelled context,
+// the signal of Interrupted or an interrupt.
+func cancelSignal(ctx context.Context) os.Signal {
+	var i Interrupted
+	if errors.As(context.Cause(ctx), &i) {
+		return i.Signal
+	}
+	return os.Interrupt
+}
+
+// cancelCmd makes cmd send the signal of cancelSignal when its context is cancelled,
+// and kills it if it hasn't exited after gracePeriod. It is called once the standard input of cmd is set.
+//
+// cmd is started in its own process group, and the signal is sent to the whole group,
+// so that the commands it starts, such as the test binaries of go test, are stopped too.
+// That is unless cmd is given the terminal: only the foreground process group can read it,
+// and the terminal sends control+c to every process of that group already.
+func cancelCmd(ctx context.Context, cmd *exec.Cmd, gracePeriod time.Duration) {
+	if cmd.Stdin != os.Stdin || !stdinIsTerminal() {
+		setProcessGroup(cmd)
+	}
+	cmd.Cancel = func() error {
+		if gracePeriod <= 0 {
+			return signalCmd(cmd, os.Kill)
+		}
+		err := signalCmd(cmd, cancelSignal(ctx))
+		// WaitDelay kills cmd, but not the rest of its process group.
+		time.AfterFunc(gracePeriod, func() {
+			_ = signalCmd(cmd, os.Kill)
+		})
+		return err
+	}
+	cmd.WaitDelay = gracePeriod
+}
@@ -0,0 +1,76 @@
+package run
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSignalForwarding(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Go can't send signals on Windows")
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+	// A shell that isn't recognised by name is run through its shebang.
+	shell := filepath.Join(t.TempDir(), "testshell")
+	if err := os.Symlink(sh, shell); err != nil {
+		t.Fatal(err)
+	}
+	trap := `trap 'echo terminated; exit 0' TERM; echo started; sleep 5 >/dev/null 2>&1 & wait`
+	ignore := `trap 'echo ignored' TERM; echo started; sleep 5 >/dev/null 2>&1 & wait; sleep 5 >/dev/null 2>&1`
+	grandchild := `sh -c "trap 'echo grandchild terminated; exit 0' TERM; sleep 5 >/dev/null 2>&1 & wait" & sleep 0.1; echo started; wait`
+	tests := []struct {
+		name     string
+		script   string
+		expected string
+	}{
+		{name: "given a command of a shell script, should forward the signal", script: "sh -c \"" + trap + "\"", expected: "terminated"},
+		{name: "given a shebang script, should forward the signal", script: "#!" + shell + "\n" + trap, expected: "terminated"},
+		{name: "given a command that ignores the signal, should kill it after the grace period", script: "sh -c \"" + ignore + "\"", expected: "ignored"},
+		{name: "given a command started by a shebang script, should signal its process group", script: "#!" + shell + "\n" + grandchild, expected: "grandchild terminated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+			r, w := io.Pipe()
+			var out bytes.Buffer
+			read := make(chan struct{})
+			go func() {
+				defer close(read)
+				s := bufio.NewScanner(r)
+				for s.Scan() {
+					out.WriteString(s.Text() + "\n")
+					if s.Text() == "started" {
+						cancel(Interrupted{Signal: syscall.SIGTERM})
+					}
+				}
+			}()
+			i := newInterpreter(nil)
+			i.gracePeriod = 100 * time.Millisecond
+			start := time.Now()
+			// The scripts aren't given the terminal, which would keep them in the process group of the test.
+			_ = i.Execute(ctx, Execution{Script: tt.script, Env: os.Environ(), Stdin: strings.NewReader(""), Stdout: w})
+			w.Close()
+			<-read
+			if !strings.Contains(out.String(), tt.expected) {
+				t.Fatalf("expected output to contain %q got %q", tt.expected, out.String())
+			}
+			if time.Since(start) >= 5*time.Second {
+				t.Fatalf("expected the script to exit when signalled, took %v", time.Since(start))
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package run
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing, as cancelled commands are killed on Windows.
+func setProcessGroup(*exec.Cmd) {}
+
+// signalCmd kills cmd, Go can't send an interrupt on Windows.
+func signalCmd(cmd *exec.Cmd, _ os.Signal) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,52 @@
+package run
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/joerdav/xc/i18n"
+	"github.com/joerdav/xc/models"
+	"golang.org/x/term"
+)
+
+// stdinIsTerminal reports whether the standard input of xc is a terminal.
+var stdinIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// terminalStdin is the standard input of a script that isn't interactive, when it is a terminal.
+// The first time the script reads from it warn is called, as the output of the script is prefixed,
+// which gets in the way of prompts, and other scripts may be running at the same time.
+type terminalStdin struct {
+	*os.File
+	once sync.Once
+	warn func()
+}
+
+func (s *terminalStdin) Read(p []byte) (int, error) {
+	s.once.Do(s.warn)
+	return s.File.Read(p)
+}
+
+// stdinFile returns the terminal of r if it is a terminalStdin.
+// Commands are given the terminal itself, because any other reader is copied to them by a goroutine
+// that would keep waiting for the terminal after they exit.
+func stdinFile(r io.Reader) io.Reader {
+	if s, ok := r.(*terminalStdin); ok {
+		return s.File
+	}
+	return r
+}
+
+// watchStdin sets the standard input of e to a terminalStdin if the script of task would read the terminal
+// without being interactive.
+func watchStdin(task models.Task, e *Execution) {
+	if task.Interactive || e.Stdin != nil || !stdinIsTerminal() {
+		return
+	}
+	e.Stdin = &terminalStdin{File: os.Stdin, warn: func() {
+		fmt.Fprint(os.Stderr, i18n.Sprintf("xc: task %s is reading from the terminal, set Interactive: true if it needs it\n", task.Name))
+	}}
+}
@@ -0,0 +1,63 @@
+package run
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joerdav/xc/models"
+)
+
+func TestWatchStdin(t *testing.T) {
+	defer func(f func() bool) { stdinIsTerminal = f }(stdinIsTerminal)
+	tests := []struct {
+		name     string
+		task     models.Task
+		stdin    io.Reader
+		terminal bool
+		expected bool
+	}{
+		{name: "given a terminal, should watch the stdin", task: models.Task{Name: "test"}, terminal: true, expected: true},
+		{name: "given no terminal, should not watch the stdin", task: models.Task{Name: "test"}},
+		{name: "given an interactive task, should give it the terminal", task: models.Task{Name: "test", Interactive: true}, terminal: true},
+		{name: "given a redirected stdin, should not watch the stdin", task: models.Task{Name: "test"}, stdin: strings.NewReader(""), terminal: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdinIsTerminal = func() bool { return tt.terminal }
+			e := Execution{Stdin: tt.stdin}
+			watchStdin(tt.task, &e)
+			if _, ok := e.Stdin.(*terminalStdin); ok != tt.expected {
+				t.Fatalf("want=%v got=%v", tt.expected, ok)
+			}
+			if stdinFile(e.Stdin) != e.Stdin && stdinFile(e.Stdin) != os.Stdin {
+				t.Fatal("expected the terminal to be given to commands")
+			}
+		})
+	}
+}
+
+func TestTerminalStdinWarnsOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "in.txt")
+	if err := os.WriteFile(path, []byte("one\ntwo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var warnings int
+	s := &terminalStdin{File: f, warn: func() { warnings++ }}
+	buf := make([]byte, 4)
+	for i := 0; i < 2; i++ {
+		if _, err := s.Read(buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if warnings != 1 {
+		t.Fatalf("expected 1 warning, got %d", warnings)
+	}
+}
@@ -0,0 +1,54 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/joerdav/xc/glob"
+	"github.com/joerdav/xc/models"
+)
+
+// WithForce runs tasks even if their Outputs are up to date with their Sources.
+func WithForce() Option {
+	return func(r *Runner) {
+		r.force = true
+	}
+}
+
+// upToDate returns true if task has Sources and Outputs, and every output is at least as new as every source,
+// the same as make. Tasks are never up to date if none of their outputs exist.
+func (r *Runner) upToDate(task models.Task) (bool, error) {
+	if r.force || len(task.Sources) == 0 || len(task.Outputs) == 0 {
+		return false, nil
+	}
+	dir := r.getExecutionPath(task)
+	outputs, err := glob.Files(dir, task.Outputs)
+	if err != nil || len(outputs) == 0 {
+		return false, err
+	}
+	sources, err := glob.Files(dir, task.Sources)
+	if err != nil {
+		return false, err
+	}
+	var newestSource, oldestOutput time.Time
+	for _, s := range sources {
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(s)))
+		if err != nil {
+			return false, err
+		}
+		if info.ModTime().After(newestSource) {
+			newestSource = info.ModTime()
+		}
+	}
+	for i, o := range outputs {
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(o)))
+		if err != nil {
+			return false, err
+		}
+		if i == 0 || info.ModTime().Before(oldestOutput) {
+			oldestOutput = info.ModTime()
+		}
+	}
+	return !newestSource.After(oldestOutput), nil
+}
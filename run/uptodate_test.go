@@ -0,0 +1,70 @@
+package run
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joerdav/xc/models"
+)
+
+func TestRunUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	old, now := time.Now().Add(-time.Hour), time.Now()
+	for f, mtime := range map[string]time.Time{"main.go": old, "go.mod": old, "bin/app": now, "docs/index.md": now, "site/index.html": old} {
+		path := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tasks := models.Tasks{
+		{Name: "build", Script: "build", Sources: []string{"*.go", "go.mod"}, Outputs: []string{"bin/app"}},
+		{Name: "site", Script: "site", Sources: []string{"docs/**"}, Outputs: []string{"site/**"}},
+		{Name: "dist", Script: "dist", Sources: []string{"*.go"}, Outputs: []string{"dist/*"}},
+		{Name: "all", DependsOn: []string{"build", "site", "dist"}},
+	}
+	tests := []struct {
+		name     string
+		force    bool
+		expected []string
+	}{
+		{name: "given outputs newer than sources, should skip the task", expected: []string{"skipped build", "site", "dist"}},
+		{name: "given force, should run every task", force: true, expected: []string{"build", "site", "dist"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.force {
+				opts = append(opts, WithForce())
+			}
+			runner, err := NewRunner(tasks, dir, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner.scriptRunner = echoScriptRunner{}
+			if err := runner.Run(context.Background(), "all", nil); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range runner.Results() {
+				if r.Skipped {
+					got = append(got, "skipped "+r.Task)
+					continue
+				}
+				got = append(got, r.Task)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Fatalf("want=%q got=%q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Package schedule parses cron schedules and finds the times they fire.
+package schedule
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joerdav/xc/i18n"
+)
+
+// Schedule is a parsed cron expression of five fields: minute, hour, day of month, month and day of week,
+// such as `*/5 * * * *` or `30 9 * * mon-fri`.
+//
+// Each field is *, a value, a range such as 1-5, or a list of them separated by commas,
+// and *, or a range, can be followed by a step such as /15.
+// Months and days of the week can be given by the first three letters of their names, and Sunday is 0 or 7.
+// Like cron, when both the day of month and the day of week are restricted, a day matching either fires.
+// The macros @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly can be used instead of the fields.
+type Schedule struct {
+	minute, hour, day, month, weekday uint64
+	// anyDay and anyWeekday are true if the day of month or the day of week field starts with *.
+	anyDay, anyWeekday bool
+}
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// Parse parses a cron expression, see Schedule.
+func Parse(s string) (Schedule, error) {
+	s = strings.TrimSpace(s)
+	if m, ok := macros[strings.ToLower(s)]; ok {
+		s = m
+	}
+	parts := strings.Fields(s)
+	if len(parts) != len(fields) {
+		return Schedule{}, i18n.Errorf("schedule %q should have 5 fields, not %d", s, len(parts))
+	}
+	var bits [5]uint64
+	for i, p := range parts {
+		b, err := fields[i].parse(p)
+		if err != nil {
+			return Schedule{}, i18n.Errorf("invalid %s in schedule %q: %w", fields[i].name, s, err)
+		}
+		bits[i] = b
+	}
+	// Sunday is both 0 and 7.
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return Schedule{
+		minute:     bits[0],
+		hour:       bits[1],
+		day:        bits[2],
+		month:      bits[3],
+		weekday:    bits[4],
+		anyDay:     strings.HasPrefix(parts[2], "*"),
+		anyWeekday: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// parse returns the values of the field in s, as bits.
+func (f field) parse(s string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step < 1 {
+				return 0, i18n.Errorf("invalid step %s", stepText)
+			}
+		}
+		lo, hi := f.min, f.max
+		switch from, to, isRange := strings.Cut(rng, "-"); {
+		case rng == "*":
+		case isRange:
+			var err error
+			if lo, err = f.value(from); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(to); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, i18n.Errorf("invalid range %s", rng)
+			}
+		case hasStep:
+			return 0, i18n.Errorf("a step should follow * or a range, not %s", rng)
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value parses a single value of the field, a number or a name.
+func (f field) value(s string) (int, error) {
+	for i, n := range f.names {
+		if strings.EqualFold(s, n) {
+			return i + f.min, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, i18n.Errorf("%s should be between %d and %d", s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// maxYears is how far ahead Next looks for a time that matches, a schedule like `0 0 30 2 *` never does.
+const maxYears = 5
+
+// Next returns the first time after t that the schedule fires, to the minute, in the location of t.
+// It returns the zero time if the schedule doesn't fire in the next 5 years.
+func (s Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(maxYears, 0, 0)
+	for t.Before(end) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Truncate(time.Minute).Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches returns true if the day of t matches the day of month and day of week fields.
+func (s Schedule) dayMatches(t time.Time) bool {
+	day := s.day&(1<<uint(t.Day())) != 0
+	weekday := s.weekday&(1<<uint(t.Weekday())) != 0
+	if s.anyDay || s.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
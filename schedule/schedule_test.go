@@ -0,0 +1,48 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// A Monday.
+	from := time.Date(2024, 1, 1, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		name     string
+		in       string
+		expected time.Time
+	}{
+		{name: "given every minute, should be the next minute", in: "* * * * *", expected: time.Date(2024, 1, 1, 10, 8, 0, 0, time.UTC)},
+		{name: "given a step, should be the next multiple", in: "*/5 * * * *", expected: time.Date(2024, 1, 1, 10, 10, 0, 0, time.UTC)},
+		{name: "given an hour that passed, should be the next day", in: "30 9 * * *", expected: time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)},
+		{name: "given a list, should be the next value", in: "0 8,12,18 * * *", expected: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{name: "given a range with a step, should be the next value", in: "0 9-17/4 * * *", expected: time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC)},
+		{name: "given day names, should be the next matching day", in: "0 9 * * sat,sun", expected: time.Date(2024, 1, 6, 9, 0, 0, 0, time.UTC)},
+		{name: "given 7 for sunday, should be sunday", in: "0 9 * * 7", expected: time.Date(2024, 1, 7, 9, 0, 0, 0, time.UTC)},
+		{name: "given a month name, should be in that month", in: "0 0 1 mar *", expected: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "given a day of month and a day of week, should match either", in: "0 0 15 * fri", expected: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{name: "given the 29th of february, should be in a leap year", in: "0 0 29 2 *", expected: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "given a macro, should expand it", in: "@daily", expected: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "given a day that doesn't exist, should never fire", in: "0 0 30 2 *"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Next(from); !got.Equal(tt.expected) {
+				t.Fatalf("want=%s got=%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5/10 * * * *", "10-5 * * * *", "* * * foo *", "@often"} {
+		if _, err := Parse(in); err == nil {
+			t.Fatalf("%q: expected an error", in)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Package watch detects changes to files by polling them.
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/joerdav/xc/glob"
+)
+
+// DefaultInterval is the interval between polls used by xc.
+const DefaultInterval = 500 * time.Millisecond
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Snapshot is the state of the watched files at a point in time.
+type Snapshot map[string]fileState
+
+// Changed returns the files that were added, removed or modified in s since prev, sorted by name.
+func (s Snapshot) Changed(prev Snapshot) []string {
+	var changed []string
+	for name, st := range s {
+		if p, ok := prev[name]; !ok || !p.modTime.Equal(st.modTime) || p.size != st.size {
+			changed = append(changed, name)
+		}
+	}
+	for name := range prev {
+		if _, ok := s[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Watcher watches the files below a directory that match a list of glob patterns.
+type Watcher struct {
+	dir      string
+	patterns []string
+	interval time.Duration
+}
+
+// New returns a Watcher of the files below dir that match patterns, see glob.NewMatcher.
+func New(dir string, patterns []string, interval time.Duration) Watcher {
+	return Watcher{dir: dir, patterns: patterns, interval: interval}
+}
+
+// Snapshot returns the current state of the watched files.
+func (w Watcher) Snapshot() (Snapshot, error) {
+	files, err := glob.Files(w.dir, w.patterns)
+	if err != nil {
+		return nil, err
+	}
+	s := Snapshot{}
+	for _, f := range files {
+		info, err := os.Stat(filepath.Join(w.dir, filepath.FromSlash(f)))
+		if err != nil {
+			// The file was removed since it was listed.
+			continue
+		}
+		s[f] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+	return s, nil
+}
+
+// Watch polls the watched files until ctx is done, sending the names of the files that changed.
+// Changes are sent once a poll finds no further changes, so that a burst of writes,
+// such as a git checkout or an editor saving several files, is sent once.
+// Polls that fail, for example because a file is removed while it is listed, are retried on the next poll.
+func (w Watcher) Watch(ctx context.Context) (<-chan []string, error) {
+	prev, err := w.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	changes := make(chan []string)
+	go func() {
+		defer close(changes)
+		t := time.NewTicker(w.interval)
+		defer t.Stop()
+		var pending []string
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			s, err := w.Snapshot()
+			if err != nil {
+				continue
+			}
+			changed := s.Changed(prev)
+			prev = s
+			if len(changed) > 0 {
+				pending = merge(pending, changed)
+				continue
+			}
+			if len(pending) == 0 {
+				continue
+			}
+			select {
+			case changes <- pending:
+				pending = nil
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// merge returns the sorted names in either a or b.
+func merge(a, b []string) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, name := range append(a, b...) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,55 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotChanged(t *testing.T) {
+	now := time.Now()
+	prev := Snapshot{
+		"same.go":     {modTime: now, size: 1},
+		"modified.go": {modTime: now, size: 1},
+		"removed.go":  {modTime: now, size: 1},
+	}
+	s := Snapshot{
+		"same.go":     {modTime: now, size: 1},
+		"modified.go": {modTime: now.Add(time.Second), size: 1},
+		"added.go":    {modTime: now, size: 1},
+	}
+	expected := "added.go,modified.go,removed.go"
+	if got := strings.Join(s.Changed(prev), ","); got != expected {
+		t.Fatalf("want=%s got=%s", expected, got)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	changes, err := New(dir, []string{"*.go"}, 10*time.Millisecond).Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write("README.md")
+	write("other.go")
+	select {
+	case changed := <-changes:
+		if strings.Join(changed, ",") != "other.go" {
+			t.Fatalf("want=other.go got=%v", changed)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for a change")
+	}
+}